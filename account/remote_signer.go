@@ -0,0 +1,480 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology-crypto/vrf"
+	"github.com/ontio/ontology/common/log"
+)
+
+// The remote signer protocol runs over a unix socket or tcp connection, every message is a json object in a line.
+// Both ends share a secret, and the mac is the HMAC-SHA256 of the secret over the label, the nonces of both ends and
+// the fields of message:
+//   signer -> node: {"Nonce"}                              signer nonce of 32 bytes in hex
+//   node -> signer: {"Nonce", "Mac"}                       node nonce, mac of "node"
+//   signer -> node: {"PubKey", "Scheme", "Mac", "Error"}   mac of "signer" over the public key and scheme
+//   node -> signer: {"Id", "Method", "Data", "Mac"}        method "sign" or "vrf", mac of "request" over the id,
+//                                                          method and data, the id increases in a connection
+//   signer -> node: {"Id", "Result", "Proof", "Error"}     the signature, or the vrf value and proof
+// The responses are verified with the public key, so they are not authenticated by mac.
+const (
+	SIGNER_METHOD_SIGN = "sign"
+	SIGNER_METHOD_VRF  = "vrf"
+
+	signerNonceLen       = 32
+	remoteSignerTimeout  = 10 * time.Second
+	remoteSignerMaxBytes = 1024 * 1024
+)
+
+type signerHello struct {
+	Nonce string
+}
+
+type signerAuth struct {
+	Nonce string
+	Mac   string
+}
+
+type signerAuthResp struct {
+	PubKey string
+	Scheme string
+	Mac    string
+	Error  string
+}
+
+type signerRequest struct {
+	Id     uint64
+	Method string
+	Data   string
+	Mac    string
+}
+
+type signerResponse struct {
+	Id     uint64
+	Result string
+	Proof  string
+	Error  string
+}
+
+func signerMac(secret []byte, label string, signerNonce, nodeNonce []byte, fields ...[]byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	mac.Write(signerNonce)
+	mac.Write(nodeNonce)
+	for _, field := range fields {
+		var l [4]byte
+		binary.BigEndian.PutUint32(l[:], uint32(len(field)))
+		mac.Write(l[:])
+		mac.Write(field)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func checkSignerMac(mac, expected string) error {
+	if !hmac.Equal([]byte(mac), []byte(expected)) {
+		return fmt.Errorf("invalid mac")
+	}
+	return nil
+}
+
+func newSignerNonce() ([]byte, error) {
+	nonce := make([]byte, signerNonceLen)
+	_, err := rand.Read(nonce)
+	return nonce, err
+}
+
+func decodeSignerNonce(nonce string) ([]byte, error) {
+	buf, err := hex.DecodeString(nonce)
+	if err != nil || len(buf) != signerNonceLen {
+		return nil, fmt.Errorf("invalid nonce %s", nonce)
+	}
+	return buf, nil
+}
+
+func signerUint64(n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return buf[:]
+}
+
+func parseSignerAddress(address string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		return "unix", strings.TrimPrefix(address, "unix://"), nil
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://"), nil
+	}
+	return "", "", fmt.Errorf("invalid remote signer address %s, it should be unix://<path> or tcp://<host:port>", address)
+}
+
+// signerConn reads and writes the json lines of protocol
+type signerConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newSignerConn(conn net.Conn) *signerConn {
+	return &signerConn{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (self *signerConn) write(msg interface{}) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = self.conn.Write(append(buf, '\n'))
+	return err
+}
+
+func (self *signerConn) read(msg interface{}) error {
+	var line []byte
+	for {
+		buf, isPrefix, err := self.reader.ReadLine()
+		if err != nil {
+			return err
+		}
+		line = append(line, buf...)
+		if len(line) > remoteSignerMaxBytes {
+			return fmt.Errorf("message is too large")
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	return json.Unmarshal(line, msg)
+}
+
+//RemoteSigner signs with the key kept by a remote signer, the connection is dialed again if it's broken. The
+//signatures and vrf proofs returned are verified with the public key before they are used.
+type RemoteSigner struct {
+	address string
+	secret  []byte
+	pubKey  keypair.PublicKey
+	scheme  s.SignatureScheme
+
+	lock      sync.Mutex
+	conn      *signerConn
+	nonces    [2][]byte //signer and node nonce of conn
+	requestId uint64
+}
+
+//NewRemoteSigner connect to the remote signer at address, unix://<path> or tcp://<host:port>, with the shared secret
+func NewRemoteSigner(address string, secret []byte) (*RemoteSigner, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret of remote signer is empty")
+	}
+	signer := &RemoteSigner{address: address, secret: secret}
+	if _, _, err := parseSignerAddress(address); err != nil {
+		return nil, err
+	}
+	if err := signer.connect(); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+func (self *RemoteSigner) connect() error {
+	network, addr, _ := parseSignerAddress(self.address)
+	conn, err := net.DialTimeout(network, addr, remoteSignerTimeout)
+	if err != nil {
+		return fmt.Errorf("connect remote signer %s error: %s", self.address, err)
+	}
+	conn.SetDeadline(time.Now().Add(remoteSignerTimeout))
+	sconn := newSignerConn(conn)
+	pubKey, scheme, nonces, err := self.handshake(sconn)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("handshake with remote signer %s error: %s", self.address, err)
+	}
+	if self.pubKey == nil {
+		self.pubKey, self.scheme = pubKey, scheme
+	} else if !bytes.Equal(keypair.SerializePublicKey(pubKey), keypair.SerializePublicKey(self.pubKey)) ||
+		scheme != self.scheme {
+		conn.Close()
+		return fmt.Errorf("key of remote signer %s is changed", self.address)
+	}
+	self.conn, self.nonces, self.requestId = sconn, nonces, 0
+	return nil
+}
+
+func (self *RemoteSigner) handshake(conn *signerConn) (keypair.PublicKey, s.SignatureScheme, [2][]byte, error) {
+	var nonces [2][]byte
+	hello := &signerHello{}
+	if err := conn.read(hello); err != nil {
+		return nil, 0, nonces, err
+	}
+	signerNonce, err := decodeSignerNonce(hello.Nonce)
+	if err != nil {
+		return nil, 0, nonces, err
+	}
+	nodeNonce, err := newSignerNonce()
+	if err != nil {
+		return nil, 0, nonces, err
+	}
+	err = conn.write(&signerAuth{
+		Nonce: hex.EncodeToString(nodeNonce),
+		Mac:   signerMac(self.secret, "node", signerNonce, nodeNonce),
+	})
+	if err != nil {
+		return nil, 0, nonces, err
+	}
+	resp := &signerAuthResp{}
+	if err := conn.read(resp); err != nil {
+		return nil, 0, nonces, err
+	}
+	if resp.Error != "" {
+		return nil, 0, nonces, fmt.Errorf("%s", resp.Error)
+	}
+	rawKey, err := hex.DecodeString(resp.PubKey)
+	if err != nil {
+		return nil, 0, nonces, fmt.Errorf("invalid public key %s", resp.PubKey)
+	}
+	expected := signerMac(self.secret, "signer", signerNonce, nodeNonce, rawKey, []byte(resp.Scheme))
+	if err := checkSignerMac(resp.Mac, expected); err != nil {
+		return nil, 0, nonces, err
+	}
+	pubKey, err := keypair.DeserializePublicKey(rawKey)
+	if err != nil {
+		return nil, 0, nonces, fmt.Errorf("invalid public key %s: %s", resp.PubKey, err)
+	}
+	scheme, err := s.GetScheme(resp.Scheme)
+	if err != nil {
+		return nil, 0, nonces, err
+	}
+	nonces[0], nonces[1] = signerNonce, nodeNonce
+	return pubKey, scheme, nonces, nil
+}
+
+func (self *RemoteSigner) PubKey() keypair.PublicKey {
+	return self.pubKey
+}
+
+func (self *RemoteSigner) Scheme() s.SignatureScheme {
+	return self.scheme
+}
+
+//Close the connection to remote signer
+func (self *RemoteSigner) Close() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.conn == nil {
+		return nil
+	}
+	err := self.conn.conn.Close()
+	self.conn = nil
+	return err
+}
+
+// call send the request to remote signer, the connection is dialed again once if it's broken
+func (self *RemoteSigner) call(method string, data []byte) (*signerResponse, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	var err error
+	for retry := 0; retry < 2; retry++ {
+		if self.conn == nil {
+			if err = self.connect(); err != nil {
+				continue
+			}
+		}
+		var resp *signerResponse
+		resp, err = self.request(method, data)
+		if err == nil {
+			if resp.Error != "" {
+				return nil, fmt.Errorf("remote signer error: %s", resp.Error)
+			}
+			return resp, nil
+		}
+		log.Warnf("[RemoteSigner] request remote signer %s error: %s", self.address, err)
+		self.conn.conn.Close()
+		self.conn = nil
+	}
+	return nil, err
+}
+
+func (self *RemoteSigner) request(method string, data []byte) (*signerResponse, error) {
+	self.requestId++
+	id := self.requestId
+	self.conn.conn.SetDeadline(time.Now().Add(remoteSignerTimeout))
+	err := self.conn.write(&signerRequest{
+		Id:     id,
+		Method: method,
+		Data:   hex.EncodeToString(data),
+		Mac:    signerMac(self.secret, "request", self.nonces[0], self.nonces[1], signerUint64(id), []byte(method), data),
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &signerResponse{}
+	if err := self.conn.read(resp); err != nil {
+		return nil, err
+	}
+	if resp.Id != id {
+		return nil, fmt.Errorf("response id %d is not %d", resp.Id, id)
+	}
+	return resp, nil
+}
+
+func (self *RemoteSigner) Sign(data []byte) ([]byte, error) {
+	resp, err := self.call(SIGNER_METHOD_SIGN, data)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(resp.Result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %s", resp.Result)
+	}
+	sigObj, err := s.Deserialize(sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %s: %s", resp.Result, err)
+	}
+	if !s.Verify(self.pubKey, data, sigObj) {
+		return nil, fmt.Errorf("signature of remote signer is not verified")
+	}
+	return sig, nil
+}
+
+func (self *RemoteSigner) Vrf(data []byte) ([]byte, []byte, error) {
+	resp, err := self.call(SIGNER_METHOD_VRF, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := hex.DecodeString(resp.Result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vrf value %s", resp.Result)
+	}
+	proof, err := hex.DecodeString(resp.Proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vrf proof %s", resp.Proof)
+	}
+	if ok, err := vrf.Verify(self.pubKey, data, value, proof); err != nil || !ok {
+		return nil, nil, fmt.Errorf("vrf of remote signer is not verified")
+	}
+	return value, proof, nil
+}
+
+//ListenSigner listen on the remote signer address, unix://<path> or tcp://<host:port>
+func ListenSigner(address string) (net.Listener, error) {
+	network, addr, err := parseSignerAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return net.Listen(network, addr)
+}
+
+//ServeSigner serve the remote signer protocol with signer on listener until it's closed. It's the stand-in of a KMS
+//or HSM, which implements the same protocol.
+func ServeSigner(listener net.Listener, signer Signer, secret []byte) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := serveSignerConn(newSignerConn(conn), signer, secret); err != nil {
+				log.Warnf("[ServeSigner] connection from %s closed: %s", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+func serveSignerConn(conn *signerConn, signer Signer, secret []byte) error {
+	signerNonce, err := newSignerNonce()
+	if err != nil {
+		return err
+	}
+	if err := conn.write(&signerHello{Nonce: hex.EncodeToString(signerNonce)}); err != nil {
+		return err
+	}
+	auth := &signerAuth{}
+	if err := conn.read(auth); err != nil {
+		return err
+	}
+	nodeNonce, err := decodeSignerNonce(auth.Nonce)
+	if err != nil {
+		return err
+	}
+	if err := checkSignerMac(auth.Mac, signerMac(secret, "node", signerNonce, nodeNonce)); err != nil {
+		conn.write(&signerAuthResp{Error: "authentication failed"})
+		return err
+	}
+	rawKey := keypair.SerializePublicKey(signer.PubKey())
+	scheme := signer.Scheme().Name()
+	err = conn.write(&signerAuthResp{
+		PubKey: hex.EncodeToString(rawKey),
+		Scheme: scheme,
+		Mac:    signerMac(secret, "signer", signerNonce, nodeNonce, rawKey, []byte(scheme)),
+	})
+	if err != nil {
+		return err
+	}
+
+	var lastId uint64
+	for {
+		req := &signerRequest{}
+		if err := conn.read(req); err != nil {
+			return err
+		}
+		data, err := hex.DecodeString(req.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data of request %d", req.Id)
+		}
+		expected := signerMac(secret, "request", signerNonce, nodeNonce, signerUint64(req.Id), []byte(req.Method), data)
+		if err := checkSignerMac(req.Mac, expected); err != nil || req.Id <= lastId {
+			return fmt.Errorf("invalid request %d", req.Id)
+		}
+		lastId = req.Id
+		resp := &signerResponse{Id: req.Id}
+		switch req.Method {
+		case SIGNER_METHOD_SIGN:
+			sig, err := signer.Sign(data)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			resp.Result = hex.EncodeToString(sig)
+		case SIGNER_METHOD_VRF:
+			value, proof, err := signer.Vrf(data)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			resp.Result, resp.Proof = hex.EncodeToString(value), hex.EncodeToString(proof)
+		default:
+			resp.Error = fmt.Sprintf("unknown method %s", req.Method)
+		}
+		if err := conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology-crypto/vrf"
+	"github.com/stretchr/testify/assert"
+)
+
+func startTestSigner(t *testing.T, address string, signer Signer, secret string) (string, net.Listener) {
+	listener, err := ListenSigner(address)
+	assert.Nil(t, err)
+	go ServeSigner(listener, signer, []byte(secret))
+	if listener.Addr().Network() == "tcp" {
+		return "tcp://" + listener.Addr().String(), listener
+	}
+	return address, listener
+}
+
+func TestRemoteSigner(t *testing.T) {
+	acc := NewAccount("")
+	address, listener := startTestSigner(t, "tcp://127.0.0.1:0", acc, "secret")
+	defer listener.Close()
+
+	_, err := NewRemoteSigner(address, []byte("wrong"))
+	assert.NotNil(t, err)
+	_, err = NewRemoteSigner("127.0.0.1:1", []byte("secret"))
+	assert.NotNil(t, err)
+
+	signer, err := NewRemoteSigner(address, []byte("secret"))
+	assert.Nil(t, err)
+	defer signer.Close()
+	assert.Equal(t, keypair.SerializePublicKey(acc.PublicKey), keypair.SerializePublicKey(signer.PubKey()))
+	assert.Equal(t, acc.Scheme(), signer.Scheme())
+
+	data := []byte("block hash")
+	sig, err := signer.Sign(data)
+	assert.Nil(t, err)
+	sigObj, err := s.Deserialize(sig)
+	assert.Nil(t, err)
+	assert.True(t, s.Verify(acc.PublicKey, data, sigObj))
+
+	value, proof, err := signer.Vrf(data)
+	assert.Nil(t, err)
+	ok, err := vrf.Verify(acc.PublicKey, data, value, proof)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// the broken connection is dialed again
+	signer.conn.conn.Close()
+	_, err = signer.Sign(data)
+	assert.Nil(t, err)
+
+	// the key of remote signer is changed
+	signer.Close()
+	address, other := startTestSigner(t, "tcp://127.0.0.1:0", NewAccount(""), "secret")
+	defer other.Close()
+	signer.address = address
+	_, err = signer.Sign(data)
+	assert.NotNil(t, err)
+}
+
+func TestRemoteSignerUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	acc := NewAccount("SM3withSM2")
+	address, listener := startTestSigner(t, "unix://"+filepath.Join(dir, "signer.sock"), acc, "secret")
+	defer listener.Close()
+	signer, err := NewRemoteSigner(address, []byte("secret"))
+	assert.Nil(t, err)
+	defer signer.Close()
+
+	data := []byte("block hash")
+	sig, err := signer.Sign(data)
+	assert.Nil(t, err)
+	sigObj, err := s.Deserialize(sig)
+	assert.Nil(t, err)
+	assert.True(t, s.Verify(acc.PublicKey, data, sigObj))
+
+	_, err = NewRemoteSigner("udp://127.0.0.1:20000", []byte("secret"))
+	assert.NotNil(t, err)
+}
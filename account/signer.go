@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology-crypto/vrf"
+)
+
+//Signer signs the consensus and p2p messages with the key of node. The Account loaded from wallet file signs with
+//the private key in memory, and RemoteSigner asks a remote signer which keeps the private key, e.g. a KMS or HSM.
+type Signer interface {
+	PubKey() keypair.PublicKey
+	Scheme() s.SignatureScheme
+	//Sign return the serialized signature of data
+	Sign(data []byte) ([]byte, error)
+	//Vrf return the vrf value and proof of data
+	Vrf(data []byte) ([]byte, []byte, error)
+}
+
+func (this *Account) Sign(data []byte) ([]byte, error) {
+	sig, err := s.Sign(this.SigScheme, this.PrivateKey, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.Serialize(sig)
+}
+
+func (this *Account) Vrf(data []byte) ([]byte, []byte, error) {
+	return vrf.Vrf(this.PrivateKey, data)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/core/types"
+)
+
+//GetIdentityMulti return the identity of wallet by ONT ID, label or index. Index start from 1
+func GetIdentityMulti(wallet account.Client, id string) (*account.Identity, error) {
+	identities := wallet.GetWalletData().Identities
+	for i := range identities {
+		if identities[i].ID == id {
+			return &identities[i], nil
+		}
+	}
+	for i := range identities {
+		if id != "" && identities[i].Label == id {
+			return &identities[i], nil
+		}
+	}
+	index, err := strconv.ParseInt(id, 10, 32)
+	if err == nil && index > 0 && int(index) <= len(identities) {
+		return &identities[index-1], nil
+	}
+	return nil, fmt.Errorf("cannot get identity by ONT ID: %s", id)
+}
+
+//GetIdentityKeyIndex return the key index of identity controller, the id of controller
+//may be "1", "keys-1" or "did:ont:xxx#keys-1"
+func GetIdentityKeyIndex(ctrl *account.Controller) (uint32, error) {
+	id := ctrl.ID
+	if pos := strings.LastIndex(id, "keys-"); pos >= 0 {
+		id = id[pos+len("keys-"):]
+	}
+	index, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || index == 0 {
+		return 0, fmt.Errorf("invalid controller id: %s", ctrl.ID)
+	}
+	return uint32(index), nil
+}
+
+//GetIdentityAccount decrypt the key of identity with the key index, the signature scheme
+//is the default scheme of key type
+func GetIdentityAccount(wallet account.Client, identity *account.Identity, index uint32, passwd []byte) (*account.Account, error) {
+	for i := range identity.Control {
+		ctrl := &identity.Control[i]
+		keyIndex, err := GetIdentityKeyIndex(ctrl)
+		if err != nil || keyIndex != index {
+			continue
+		}
+		privateKey, err := keypair.DecryptWithCustomScrypt(&ctrl.ProtectedKey, passwd, wallet.GetWalletData().Scrypt)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %s#keys-%d error: %s", identity.ID, index, err)
+		}
+		publicKey := privateKey.Public()
+		var scheme s.SignatureScheme
+		switch keypair.GetKeyType(publicKey) {
+		case keypair.PK_SM2:
+			scheme = s.SM3withSM2
+		case keypair.PK_EDDSA:
+			scheme = s.SHA512withEDDSA
+		default:
+			scheme = s.SHA256withECDSA
+		}
+		return &account.Account{
+			PrivateKey: privateKey,
+			PublicKey:  publicKey,
+			Address:    types.AddressFromPubKey(publicKey),
+			SigScheme:  scheme,
+		}, nil
+	}
+	return nil, fmt.Errorf("cannot find key %s#keys-%d in wallet", identity.ID, index)
+}
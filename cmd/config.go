@@ -20,11 +20,13 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ontio/ontology/cmd/utils"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/kvstore"
 	"github.com/ontio/ontology/smartcontract/service/native/governance"
 	"github.com/urfave/cli"
 )
@@ -73,6 +75,23 @@ func SetOntologyConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 		cfg.Common.WasmVerifyMethod = config.JitVerifyMethod
 	}
 
+	execMode := ctx.GlobalString(utils.GetFlagName(utils.TxExecModeFlag))
+	switch execMode {
+	case "sequential":
+		cfg.Common.TxExecMode = config.SequentialExecMode
+	case "parallel":
+		log.Infof("Enable parallel transaction execution")
+		cfg.Common.TxExecMode = config.ParallelExecMode
+	case "compare":
+		log.Infof("Enable transaction execution mode comparison")
+		cfg.Common.TxExecMode = config.CompareExecMode
+	default:
+		return nil, fmt.Errorf("invalid tx exec mode: %s", execMode)
+	}
+	if _, err := kvstore.Get(cfg.Common.DBBackend); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -91,6 +110,36 @@ func setGenesis(ctx *cli.Context, cfg *config.OntologyConfig) error {
 		if cfg.Genesis.SOLO.GenBlockTime <= 1 {
 			cfg.Genesis.SOLO.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
 		}
+		cfg.Genesis.SOLO.InstantSeal = ctx.Bool(utils.GetFlagName(utils.TestModeInstantSealFlag))
+		if ctx.IsSet(utils.GetFlagName(utils.TestModePrefundFileFlag)) {
+			prefundFile := ctx.String(utils.GetFlagName(utils.TestModePrefundFileFlag))
+			var prefunded []*config.PrefundedAccount
+			err := utils.GetJsonObjectFromFile(prefundFile, &prefunded)
+			if err != nil {
+				return fmt.Errorf("load prefund file %s error: %s", prefundFile, err)
+			}
+			cfg.Genesis.SOLO.Prefunded = prefunded
+			if _, _, err = cfg.GetPrefunded(); err != nil {
+				return err
+			}
+			log.Infof("Load prefunded accounts:%s", prefundFile)
+		}
+		cfg.Genesis.SOLO.ForkRpc = ctx.String(utils.GetFlagName(utils.TestModeForkRpcFlag))
+		if ctx.IsSet(utils.GetFlagName(utils.TestModeImpersonateFlag)) {
+			addrs := strings.Split(ctx.String(utils.GetFlagName(utils.TestModeImpersonateFlag)), ",")
+			impersonated := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				addr = strings.TrimSpace(addr)
+				if addr == "" {
+					continue
+				}
+				if _, err := common.AddressFromBase58(addr); err != nil {
+					return fmt.Errorf("invalid impersonated address %s: %s", addr, err)
+				}
+				impersonated = append(impersonated, addr)
+			}
+			cfg.Genesis.SOLO.Impersonated = impersonated
+		}
 		return nil
 	}
 
@@ -140,6 +189,7 @@ func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
 	cfg.MinGasLimit = ctx.Uint64(utils.GetFlagName(utils.GasLimitFlag))
 	cfg.GasPrice = ctx.Uint64(utils.GetFlagName(utils.GasPriceFlag))
 	cfg.DataDir = ctx.String(utils.GetFlagName(utils.DataDirFlag))
+	cfg.DBBackend = ctx.String(utils.GetFlagName(utils.DbBackendFlag))
 	//add new flag for ethgaslimit
 	cfg.ETHTxGasLimit = ctx.Uint64(utils.GetFlagName(utils.ETHTxGasLimitFlag))
 }
@@ -112,6 +112,28 @@ var (
 					utils.AccountAddressFlag,
 				},
 			},
+			{
+				Action:    authRoles,
+				Name:      "authroles",
+				Usage:     "Display the roles of contract in auth contract",
+				ArgsUsage: "<contract>",
+				Description: `Display the admin ONT ID of contract and the functions assigned to each role by the admin in auth
+contract.`,
+				Flags: []cli.Flag{
+					utils.RPCPortFlag,
+				},
+			},
+			{
+				Action:    authTokens,
+				Name:      "authtokens",
+				Usage:     "Display the ONT IDs holding roles of contract in auth contract",
+				ArgsUsage: "<contract>",
+				Description: `Display the roles held by each ONT ID of contract in auth contract, with the expire time and the
+delegation level. The ONT ID with level 2 could delegate the role to others.`,
+				Flags: []cli.Flag{
+					utils.RPCPortFlag,
+				},
+			},
 		},
 	}
 )
@@ -380,3 +402,33 @@ func invokeContract(ctx *cli.Context) error {
 	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
 	return nil
 }
+
+func authRoles(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing contract argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	data, err := utils.GetRoleFuncs(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("GetRoleFuncs error:%s", err)
+	}
+	PrintJsonData(data)
+	return nil
+}
+
+func authTokens(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing contract argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	data, err := utils.GetOntIDTokens(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("GetOntIDTokens error:%s", err)
+	}
+	PrintJsonData(data)
+	return nil
+}
@@ -0,0 +1,411 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ethcom "github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/consensus/vbft"
+	"github.com/ontio/ontology/core/store/kvstore"
+	"github.com/ontio/ontology/core/store/ledgerstore"
+)
+
+const DEFAULT_MIGRATE_BATCH_SIZE = 10000
+
+var DbCommand = cli.Command{
+	Action:    cli.ShowSubcommandHelp,
+	Name:      "db",
+	Usage:     "Maintain the ledger DB of a stopped node",
+	ArgsUsage: "[arguments...]",
+	Description: `DB management commands can be used to verify, repair, migrate and inspect the ledger DB, and to move the vbft sign records.
+You can use ./Ontology db --help command to view help information of DB management command.`,
+	Subcommands: []cli.Command{
+		{
+			Action:    verifyLedger,
+			Name:      "verify",
+			Usage:     "Verify the ledger DB",
+			ArgsUsage: "[sub-command options]",
+			Flags: []cli.Flag{
+				utils.DbVerifyStartHeightFlag,
+				utils.DbVerifyEndHeightFlag,
+				utils.DbRepairFlag,
+				utils.DataDirFlag,
+				utils.DbBackendFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.EnableTestModeFlag,
+			},
+			Description: ` Verify the header chain, block and state merkle trees, and the consistency of block, event and cross chain stores.
+   The blocks in the range of --start-height and --end-height are executed again to compare the write set hashes and cross chain roots,
+   it requires the reverse write sets of the blocks from --start-height to the current height, saved by --save-reverse-write-sets.
+   With --repair, the ledger is rolled back to the last good height if any bad block is found.`,
+		},
+		{
+			Action:    migrateLedger,
+			Name:      "migrate",
+			Usage:     "Convert the ledger DB to another key-value backend",
+			ArgsUsage: "[sub-command options]",
+			Flags: []cli.Flag{
+				utils.DbMigrateToFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.EnableTestModeFlag,
+			},
+			Description: ` Convert the block, state, event and cross chain stores to the backend of --to, stores created by the backend already are skipped.
+   The original store is kept in the dir with suffix of its backend and ".bak", remove it once the node runs well with --db-backend.`,
+		},
+		{
+			Action:    inspectLedger,
+			Name:      "inspect",
+			Usage:     "Inspect the raw keys of the ledger DB",
+			ArgsUsage: "[sub-command options]",
+			Flags: []cli.Flag{
+				utils.DbInspectStoreFlag,
+				utils.DbInspectPrefixFlag,
+				utils.DbInspectLimitFlag,
+				utils.DbInspectStatsFlag,
+				utils.DbInspectContractFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.EnableTestModeFlag,
+			},
+			Description: ` Open the stores read-only and decode the keys and values by their data entry prefix.
+   Scan the keys of --store with --prefix by default, show the key statistics of stores with --stats,
+   or dump the storage of a contract in the state store with --contract.`,
+		},
+		{
+			Action:    exportSignRecords,
+			Name:      "exportsigns",
+			Usage:     "Export the vbft sign records to a file",
+			ArgsUsage: "[sub-command options]",
+			Flags: []cli.Flag{
+				utils.DbSignRecordFileFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.EnableTestModeFlag,
+			},
+			Description: ` Export the height, round, message type and block hash of the consensus messages signed by the node,
+   so the bookkeeper key can be moved to another machine with the records, and the node there does not sign conflicting messages.`,
+		},
+		{
+			Action:    importSignRecords,
+			Name:      "importsigns",
+			Usage:     "Import the vbft sign records from a file",
+			ArgsUsage: "[sub-command options]",
+			Flags: []cli.Flag{
+				utils.DbSignRecordFileFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.EnableTestModeFlag,
+			},
+			Description: ` Import the sign records exported by a node of the same bookkeeper key, the existing records are kept.
+   The records of another bookkeeper key are refused.`,
+		},
+	},
+}
+
+func openLedgerStore(ctx *cli.Context) (*ledgerstore.LedgerStoreImp, error) {
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledgerStore, err := ledgerstore.NewLedgerStore(dbDir, stateHashHeight)
+	if err != nil {
+		return nil, fmt.Errorf("NewLedgerStore error:%s", err)
+	}
+	return ledgerStore, nil
+}
+
+func verifyLedger(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	ledgerStore, err := openLedgerStore(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledgerStore.Close()
+
+	startHeight := uint32(ctx.Uint(utils.GetFlagName(utils.DbVerifyStartHeightFlag)))
+	endHeight := uint32(ctx.Uint(utils.GetFlagName(utils.DbVerifyEndHeightFlag)))
+	PrintInfoMsg("Start verify ledger.")
+	report, err := ledgerStore.Verify(startHeight, endHeight)
+	if err != nil {
+		return fmt.Errorf("verify error:%s", err)
+	}
+	PrintInfoMsg("Block height:%d, state height:%d, blocks are checked from height:%d.", report.CurrentHeight,
+		report.StateHeight, report.CheckedHeight)
+	if report.ExecStartHeight != 0 {
+		PrintInfoMsg("Blocks are executed again from height:%d to %d.", report.ExecStartHeight, report.ExecEndHeight)
+	}
+	for _, warning := range report.Warnings {
+		PrintWarnMsg("%s", warning)
+	}
+	if report.Good() {
+		PrintInfoMsg("Ledger is good.")
+		return nil
+	}
+	PrintErrorMsg("First bad height:%d, %s.", report.BadHeight, report.Reason)
+	if !ctx.Bool(utils.GetFlagName(utils.DbRepairFlag)) {
+		return nil
+	}
+	if report.BadHeight == 0 {
+		return fmt.Errorf("genesis block is bad, the ledger can not be repaired")
+	}
+	err = ledgerStore.Rollback(report.BadHeight - 1)
+	if err != nil {
+		return fmt.Errorf("rollback error:%s", err)
+	}
+	PrintInfoMsg("Ledger is repaired, current block height:%d.", ledgerStore.GetCurrentBlockHeight())
+	return nil
+}
+
+func migrateLedger(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	to := ctx.String(utils.GetFlagName(utils.DbMigrateToFlag))
+	if to == "" {
+		PrintErrorMsg("Missing %s argument.", utils.DbMigrateToFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	if _, err := kvstore.Get(to); err != nil {
+		return err
+	}
+	_, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	for _, name := range []string{ledgerstore.DBDirBlock, ledgerstore.DBDirState, ledgerstore.DBDirEvent,
+		ledgerstore.DBDirCrossChain} {
+		dir := filepath.Join(dbDir, name)
+		from := kvstore.Detect(dir)
+		if from == "" {
+			PrintWarnMsg("No store is found in %s, skipped.", dir)
+			continue
+		}
+		if from == to {
+			PrintInfoMsg("Store %s is %s already.", dir, to)
+			continue
+		}
+		backupDir := fmt.Sprintf("%s.%s.bak", dir, from)
+		PrintInfoMsg("Start migrate store %s from %s to %s.", dir, from, to)
+		_, count, err := kvstore.Migrate(dir, backupDir, to, DEFAULT_MIGRATE_BATCH_SIZE)
+		if err != nil {
+			return fmt.Errorf("migrate %s error:%s", dir, err)
+		}
+		PrintInfoMsg("Migrate store %s completed, %d keys copied, the original store is kept in %s.", dir, count,
+			backupDir)
+	}
+	PrintInfoMsg("Please start the node with --%s %s.", utils.GetFlagName(utils.DbBackendFlag), to)
+	return nil
+}
+
+func inspectLedger(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	_, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	storeName := ctx.String(utils.GetFlagName(utils.DbInspectStoreFlag))
+	switch {
+	case ctx.Bool(utils.GetFlagName(utils.DbInspectStatsFlag)):
+		names := []string{ledgerstore.DBDirBlock, ledgerstore.DBDirState, ledgerstore.DBDirEvent,
+			ledgerstore.DBDirCrossChain}
+		if ctx.IsSet(utils.GetFlagName(utils.DbInspectStoreFlag)) {
+			names = []string{storeName}
+		}
+		for _, name := range names {
+			if err := showStoreStats(filepath.Join(dbDir, name)); err != nil {
+				return err
+			}
+		}
+		merklePath := filepath.Join(dbDir, ledgerstore.MerkleTreeStorePath)
+		if info, err := os.Stat(merklePath); err == nil {
+			PrintInfoMsg("Merkle tree %s: %d hashes, %d bytes.", merklePath, info.Size()/common.UINT256_SIZE,
+				info.Size())
+		}
+		return nil
+	case ctx.IsSet(utils.GetFlagName(utils.DbInspectContractFlag)):
+		contract, err := parseContractAddress(ctx.String(utils.GetFlagName(utils.DbInspectContractFlag)))
+		if err != nil {
+			return err
+		}
+		store, err := kvstore.OpenReadOnly(filepath.Join(dbDir, ledgerstore.DBDirState))
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		dump, err := ledgerstore.DumpContractStorage(store, contract)
+		if err != nil {
+			return fmt.Errorf("dump storage error:%s", err)
+		}
+		PrintJsonObject(dump)
+		return nil
+	}
+
+	prefix, err := hex.DecodeString(ctx.String(utils.GetFlagName(utils.DbInspectPrefixFlag)))
+	if err != nil {
+		return fmt.Errorf("invalid prefix:%s", err)
+	}
+	limit := ctx.Uint(utils.GetFlagName(utils.DbInspectLimitFlag))
+	store, err := kvstore.OpenReadOnly(filepath.Join(dbDir, storeName))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	iter := store.NewIterator(prefix)
+	defer iter.Release()
+	count := uint(0)
+	for iter.Next() {
+		if limit != 0 && count == limit {
+			PrintInfoMsg("Limit of %d keys is reached.", limit)
+			return nil
+		}
+		fmt.Println(ledgerstore.DecodeStoreEntry(iter.Key(), iter.Value()))
+		count++
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	PrintInfoMsg("%d keys are found.", count)
+	return nil
+}
+
+func showStoreStats(dir string) error {
+	store, err := kvstore.OpenReadOnly(dir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	stats, err := ledgerstore.StorePrefixStats(store)
+	if err != nil {
+		return fmt.Errorf("stats %s error:%s", dir, err)
+	}
+	PrintInfoMsg("Store %s:", dir)
+	PrintInfoMsg("%-36s %12s %14s %14s", "Prefix", "Keys", "Key Bytes", "Value Bytes")
+	var total ledgerstore.PrefixStat
+	for _, stat := range stats {
+		PrintInfoMsg("%-36s %12d %14d %14d", fmt.Sprintf("%s(0x%02x)", stat.Prefix, byte(stat.Prefix)), stat.Count,
+			stat.KeySize, stat.ValueSize)
+		total.Count += stat.Count
+		total.KeySize += stat.KeySize
+		total.ValueSize += stat.ValueSize
+	}
+	PrintInfoMsg("%-36s %12d %14d %14d", "Total", total.Count, total.KeySize, total.ValueSize)
+	return nil
+}
+
+//parseContractAddress parse the contract address in hex, base58 or ethereum address format
+func parseContractAddress(address string) (common.Address, error) {
+	if strings.HasPrefix(address, "0x") {
+		if !ethcom.IsHexAddress(address) {
+			return common.ADDRESS_EMPTY, fmt.Errorf("invalid contract address:%s", address)
+		}
+		return common.Address(ethcom.HexToAddress(address)), nil
+	}
+	if addr, err := common.AddressFromHexString(address); err == nil {
+		return addr, nil
+	}
+	addr, err := common.AddressFromBase58(address)
+	if err != nil {
+		return common.ADDRESS_EMPTY, fmt.Errorf("invalid contract address:%s", address)
+	}
+	return addr, nil
+}
+
+func openSignRecordDB(ctx *cli.Context, create bool) (*vbft.SignRecordDB, error) {
+	_, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	dir := filepath.Join(dbDir, vbft.SignRecordDir)
+	if _, err := os.Stat(dir); !create && os.IsNotExist(err) {
+		return nil, fmt.Errorf("no sign record is found in %s", dir)
+	}
+	return vbft.OpenSignRecordDB(dir)
+}
+
+func exportSignRecords(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	db, err := openSignRecordDB(ctx, false)
+	if err != nil {
+		return fmt.Errorf("open sign record db error:%s", err)
+	}
+	defer db.Close()
+	fileName := ctx.String(utils.GetFlagName(utils.DbSignRecordFileFlag))
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("create file %s error:%s", fileName, err)
+	}
+	defer file.Close()
+	count, err := db.Export(file)
+	if err != nil {
+		return fmt.Errorf("export sign records error:%s", err)
+	}
+	PrintInfoMsg("%d sign records are exported to %s.", count, fileName)
+	return nil
+}
+
+func importSignRecords(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	fileName := ctx.String(utils.GetFlagName(utils.DbSignRecordFileFlag))
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("open file %s error:%s", fileName, err)
+	}
+	defer file.Close()
+	db, err := openSignRecordDB(ctx, true)
+	if err != nil {
+		return fmt.Errorf("open sign record db error:%s", err)
+	}
+	defer db.Close()
+	imported, conflicts, err := db.Import(file)
+	if err != nil {
+		return fmt.Errorf("import sign records error:%s", err)
+	}
+	PrintInfoMsg("%d sign records are imported from %s.", imported, fileName)
+	if conflicts != 0 {
+		PrintWarnMsg("%d sign records conflict with the existing ones, the bookkeeper key has signed conflicting messages.",
+			conflicts)
+	}
+	return nil
+}
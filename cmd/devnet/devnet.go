@@ -0,0 +1,426 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package devnet runs a multi-node VBFT network in one process. The nodes are connected by the mock p2p
+//network, so network partitions can be injected besides killing and restarting nodes.
+package devnet
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/consensus/vbft"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	p2pcom "github.com/ontio/ontology/p2pserver/common"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/mock"
+	"github.com/ontio/ontology/p2pserver/net/netserver"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
+)
+
+const (
+	DEVNET_NETWORK_ID      = 1024
+	DEVNET_WALLET_PASSWORD = "devnet"
+	DEVNET_GENESIS_FILE    = "genesis.json"
+	DEVNET_MIN_NODES       = 7
+	DEVNET_INIT_POS        = 10000
+	DEVNET_STOP_TIMEOUT    = 10 * time.Second
+	DEVNET_CONNECT_PERIOD  = time.Second
+	// vrf seed of the genesis block, same as docs/specifications/config-vbft.json
+	DEVNET_VRF_VALUE = "1c9810aa9822e511d5804a9c4db9dd08497c31087b0daafa34d768a3253441fa20515e2f30f81741102af0ca3cefc4818fef16adb825fbaa8cad78647f3afb590e"
+	DEVNET_VRF_PROOF = "c57741f934042cb8d8b087b44b161db56fc3ffd4ffb675d36cd09f83935be853d8729f3f5298d12d6fd28d45dde515a4b9d7f67682d182ba5118abf451ff1988"
+)
+
+//consensusProtocol delivers the consensus messages received from peers to the vbft server of the node
+type consensusProtocol struct {
+	pid *actor.PID
+}
+
+func (self *consensusProtocol) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage) {}
+
+func (self *consensusProtocol) HandlePeerMessage(ctx *p2p.Context, msg msgTypes.Message) {
+	consensus, ok := msg.(*msgTypes.Consensus)
+	if !ok {
+		return
+	}
+	if err := consensus.Cons.Verify(); err != nil {
+		log.Warn(err)
+		return
+	}
+	consensus.Cons.PeerId = ctx.Sender().GetID()
+	self.pid.Tell(&consensus.Cons)
+}
+
+type Node struct {
+	Index   uint32
+	Address string
+	dir     string
+	acct    *account.Account
+	keyId   *p2pcom.PeerKeyId
+	// address listened in mock network
+	listenAddr string
+	group      uint32
+	ledger     *ledger.Ledger
+	net        *netserver.NetServer
+	server     *vbft.Server
+}
+
+func (self *Node) chainDir() string {
+	return filepath.Join(self.dir, "Chain")
+}
+
+func (self *Node) running() bool {
+	return self.server != nil
+}
+
+type NodeStatus struct {
+	Index   uint32
+	Address string
+	Running bool
+	Group   uint32
+	Height  uint32
+}
+
+type Devnet struct {
+	lock    sync.Mutex
+	network mock.Network
+	genesis *config.GenesisConfig
+	txPool  *actor.PID
+	nodes   []*Node
+	exit    chan struct{}
+	done    chan struct{}
+}
+
+//Open loads the devnet generated in dir and opens the ledger of every node. The ledger of node 1 is used
+//as the process wide ledger, so the tx pool and rpc servers started after Open serve node 1.
+func Open(dir string) (*Devnet, error) {
+	genesisCfg, err := LoadDevnet(dir)
+	if err != nil {
+		return nil, err
+	}
+	if genesisCfg == nil {
+		return nil, fmt.Errorf("devnet not found in %s", dir)
+	}
+	config.DefConfig.Genesis = genesisCfg
+	config.DefConfig.P2PNode.NetworkId = DEVNET_NETWORK_ID
+
+	peers := append([]*config.VBFTPeerStakeInfo{}, genesisCfg.VBFT.Peers...)
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Index < peers[j].Index })
+	this := &Devnet{
+		network: mock.NewNetwork(),
+		genesis: genesisCfg,
+	}
+	for _, p := range peers {
+		node := &Node{
+			Index:      p.Index,
+			Address:    p.Address,
+			dir:        nodeDir(dir, p.Index),
+			listenAddr: fmt.Sprintf("10.0.%d.%d:20338", p.Index/256, p.Index%256),
+		}
+		wallet, err := account.Open(filepath.Join(node.dir, config.DEFAULT_WALLET_FILE_NAME))
+		if err != nil {
+			return nil, err
+		}
+		node.acct, err = wallet.GetDefaultAccount([]byte(DEVNET_WALLET_PASSWORD))
+		if err != nil {
+			return nil, fmt.Errorf("open wallet of node %d error: %s", p.Index, err)
+		}
+		this.nodes = append(this.nodes, node)
+	}
+
+	// the key id is kept after restart, so the peers see the same node
+	var wg sync.WaitGroup
+	for _, node := range this.nodes {
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			node.keyId = p2pcom.RandPeerKeyId()
+		}(node)
+	}
+	wg.Wait()
+	this.updateLinks()
+
+	for _, node := range this.nodes {
+		if err := this.openLedger(node); err != nil {
+			this.closeLedgers()
+			return nil, err
+		}
+	}
+	ledger.DefLedger = this.nodes[0].ledger
+	return this, nil
+}
+
+func (this *Devnet) openLedger(node *Node) error {
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return err
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, this.genesis)
+	if err != nil {
+		return err
+	}
+	node.ledger, err = ledger.InitLedger(node.chainDir(), config.GetStateHashCheckHeight(DEVNET_NETWORK_ID),
+		bookkeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("open ledger of node %d error: %s", node.Index, err)
+	}
+	return nil
+}
+
+func (this *Devnet) closeLedgers() {
+	for _, node := range this.nodes {
+		if node.ledger != nil {
+			node.ledger.Close()
+			node.ledger = nil
+		}
+	}
+}
+
+//Start starts all the nodes, txPool serves the proposals of all of them
+func (this *Devnet) Start(txPool *actor.PID) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.txPool = txPool
+	for _, node := range this.nodes {
+		if err := this.startNode(node); err != nil {
+			this.stopNodes()
+			return err
+		}
+	}
+	this.exit = make(chan struct{})
+	this.done = make(chan struct{})
+	go this.connectLoop()
+	return nil
+}
+
+//Stop stops all the nodes and closes their ledgers
+func (this *Devnet) Stop() {
+	if this.exit != nil {
+		close(this.exit)
+		<-this.done
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.stopNodes()
+	this.closeLedgers()
+}
+
+func (this *Devnet) stopNodes() {
+	for _, node := range this.nodes {
+		if node.running() {
+			this.stopNode(node)
+		}
+	}
+}
+
+func (this *Devnet) startNode(node *Node) error {
+	if node.ledger == nil {
+		if err := this.openLedger(node); err != nil {
+			return err
+		}
+	}
+	info := peer.NewPeerInfo(node.keyId.Id, p2pcom.PROTOCOL_VERSION, p2pcom.SERVICE_NODE, true, 0,
+		0, uint64(node.ledger.GetCurrentBlockHeight()), config.Version, "")
+	logger := p2pcom.LoggerWithContext(p2pcom.NewGlobalLoggerWrapper(), fmt.Sprintf("node %d: ", node.Index))
+	proto := &consensusProtocol{}
+	net := mock.NewNode(node.keyId, node.listenAddr, info, proto, this.network, nil, p2p.AllAddrFilter(), logger)
+	server, err := vbft.NewCustomVbftServer(node.acct, this.txPool, net, node.ledger,
+		filepath.Join(node.chainDir(), vbft.SignRecordDir))
+	if err != nil {
+		net.Stop()
+		return fmt.Errorf("start node %d error: %s", node.Index, err)
+	}
+	proto.pid = server.GetPID()
+	if err = net.Start(); err != nil {
+		net.Stop()
+		this.stopServer(server)
+		return fmt.Errorf("start node %d error: %s", node.Index, err)
+	}
+	if err = server.Start(); err != nil {
+		net.Stop()
+		this.stopServer(server)
+		return fmt.Errorf("start node %d error: %s", node.Index, err)
+	}
+	node.net = net
+	node.server = server
+	return nil
+}
+
+func (this *Devnet) stopNode(node *Node) {
+	this.stopServer(node.server)
+	node.net.Stop()
+	node.server = nil
+	node.net = nil
+}
+
+//stopServer waits the vbft server to stop, so that its ledger can be closed
+func (this *Devnet) stopServer(server *vbft.Server) {
+	future := server.GetPID().RequestFuture(&actorTypes.StopConsensus{}, DEVNET_STOP_TIMEOUT)
+	if _, err := future.Result(); err != nil {
+		log.Warnf("stop vbft server error: %s", err)
+	}
+	server.GetPID().Stop()
+}
+
+//connectLoop keeps connecting the running nodes which are allowed to connect, the node with less index dials
+func (this *Devnet) connectLoop() {
+	defer close(this.done)
+	ticker := time.NewTicker(DEVNET_CONNECT_PERIOD)
+	defer ticker.Stop()
+	for {
+		this.lock.Lock()
+		for i, node := range this.nodes {
+			if !node.running() {
+				continue
+			}
+			for _, remote := range this.nodes[i+1:] {
+				if remote.running() && remote.group == node.group {
+					node.net.Connect(remote.listenAddr)
+				}
+			}
+		}
+		this.lock.Unlock()
+
+		select {
+		case <-this.exit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//updateLinks allows the nodes in the same group to connect and disconnects the others
+func (this *Devnet) updateLinks() {
+	for i, node := range this.nodes {
+		for _, remote := range this.nodes[i+1:] {
+			if node.group == remote.group {
+				this.network.AllowConnect(node.keyId.Id, remote.keyId.Id)
+			} else {
+				this.network.DisallowConnect(node.keyId.Id, remote.keyId.Id)
+			}
+		}
+	}
+}
+
+func (this *Devnet) getNode(index uint32) (*Node, error) {
+	for _, node := range this.nodes {
+		if node.Index == index {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("node %d not found", index)
+}
+
+//KillNode stops the node and closes its ledger. Node 1 can not be killed since its ledger serves the
+//tx pool and rpc servers.
+func (this *Devnet) KillNode(index uint32) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	node, err := this.getNode(index)
+	if err != nil {
+		return err
+	}
+	if node == this.nodes[0] {
+		return fmt.Errorf("node %d serves the tx pool and rpc, can not be killed", index)
+	}
+	if !node.running() {
+		return fmt.Errorf("node %d is not running", index)
+	}
+	this.stopNode(node)
+	node.ledger.Close()
+	node.ledger = nil
+	return nil
+}
+
+//StartNode restarts a killed node
+func (this *Devnet) StartNode(index uint32) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	node, err := this.getNode(index)
+	if err != nil {
+		return err
+	}
+	if node.running() {
+		return fmt.Errorf("node %d is already running", index)
+	}
+	return this.startNode(node)
+}
+
+//Partition moves the nodes to a new group, the nodes can only connect with the nodes in the same group
+func (this *Devnet) Partition(indexes []uint32) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	var nodes []*Node
+	for _, index := range indexes {
+		node, err := this.getNode(index)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+	group := uint32(0)
+	for _, node := range this.nodes {
+		if node.group > group {
+			group = node.group
+		}
+	}
+	for _, node := range nodes {
+		node.group = group + 1
+	}
+	this.updateLinks()
+	return nil
+}
+
+//Heal moves all the nodes back to one group
+func (this *Devnet) Heal() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, node := range this.nodes {
+		node.group = 0
+	}
+	this.updateLinks()
+}
+
+func (this *Devnet) Status() []*NodeStatus {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	var status []*NodeStatus
+	for _, node := range this.nodes {
+		s := &NodeStatus{
+			Index:   node.Index,
+			Address: node.Address,
+			Running: node.running(),
+			Group:   node.group,
+		}
+		if node.ledger != nil {
+			s.Height = node.ledger.GetCurrentBlockHeight()
+		}
+		status = append(status, s)
+	}
+	return status
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	p2pcom "github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+	txpool "github.com/ontio/ontology/txnpool/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	p2pcom.Difficulty = 1
+}
+
+//startEmptyTxPool spawns a tx pool actor which has no transactions, so the nodes propose empty blocks
+func startEmptyTxPool() *actor.PID {
+	return actor.Spawn(actor.FromFunc(func(context actor.Context) {
+		switch context.Message().(type) {
+		case *txpool.GetTxnPoolReq:
+			context.Respond(&txpool.GetTxnPoolRsp{})
+		case *txpool.VerifyBlockReq:
+			context.Respond(&txpool.VerifyBlockRsp{})
+		}
+	}))
+}
+
+func TestGenDevnet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "devnet")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = GenDevnet(dir, DEVNET_MIN_NODES-1)
+	assert.NotNil(t, err)
+
+	genesisCfg, err := GenDevnet(dir, 7)
+	require.Nil(t, err)
+	assert.Equal(t, config.CONSENSUS_TYPE_VBFT, genesisCfg.ConsensusType)
+	assert.Equal(t, uint32(7), genesisCfg.VBFT.N)
+	assert.Equal(t, uint32(2), genesisCfg.VBFT.C)
+	assert.Len(t, genesisCfg.VBFT.Peers, 7)
+	assert.Nil(t, governance.CheckVBFTConfig(genesisCfg.VBFT))
+	for i, peer := range genesisCfg.VBFT.Peers {
+		assert.Equal(t, uint32(i+1), peer.Index)
+		wallet, err := account.Open(filepath.Join(nodeDir(dir, peer.Index), config.DEFAULT_WALLET_FILE_NAME))
+		require.Nil(t, err)
+		acct, err := wallet.GetDefaultAccount([]byte(DEVNET_WALLET_PASSWORD))
+		require.Nil(t, err)
+		assert.Equal(t, peer.Address, acct.Address.ToBase58())
+	}
+	assert.Equal(t, "did:ont:"+genesisCfg.VBFT.Peers[0].Address, genesisCfg.VBFT.AdminOntID)
+
+	loaded, err := LoadDevnet(dir)
+	require.Nil(t, err)
+	assert.Equal(t, genesisCfg.VBFT.Peers, loaded.VBFT.Peers)
+
+	_, err = GenDevnet(dir, 7)
+	assert.NotNil(t, err, "dir is not empty")
+}
+
+func waitHeight(dev *Devnet, indexes []uint32, height uint32, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		reached := true
+		for _, status := range dev.Status() {
+			for _, index := range indexes {
+				if status.Index == index && status.Height < height {
+					reached = false
+				}
+			}
+		}
+		if reached {
+			return true
+		}
+		time.Sleep(time.Second)
+	}
+	return false
+}
+
+func TestDevnet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "devnet")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	_, err = GenDevnet(dir, 7)
+	require.Nil(t, err)
+
+	dev, err := Open(dir)
+	require.Nil(t, err)
+	require.Nil(t, dev.Start(startEmptyTxPool()))
+	defer dev.Stop()
+
+	all := []uint32{1, 2, 3, 4, 5, 6, 7}
+	require.True(t, waitHeight(dev, all, 1, 2*time.Minute), "devnet should reach committed height 1")
+
+	// the majority keeps committing when two nodes are partitioned
+	require.Nil(t, dev.Partition([]uint32{6, 7}))
+	var height uint32
+	for _, status := range dev.Status() {
+		if status.Height > height {
+			height = status.Height
+		}
+	}
+	require.True(t, waitHeight(dev, []uint32{1, 2, 3, 4, 5}, height+1, 2*time.Minute),
+		"majority should commit blocks in partition")
+
+	// the partitioned nodes catch up after healed
+	dev.Heal()
+	require.True(t, waitHeight(dev, all, height+2, 2*time.Minute), "all nodes should catch up after healed")
+
+	// a killed node keeps its chain and catches up after restarted
+	assert.NotNil(t, dev.KillNode(1), "node 1 can not be killed")
+	require.Nil(t, dev.KillNode(7))
+	require.Nil(t, dev.StartNode(7))
+	assert.True(t, waitHeight(dev, all, height+3, 2*time.Minute), "restarted node should catch up")
+}
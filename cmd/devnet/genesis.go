@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology/account"
+	cmdutils "github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+)
+
+//GenDevnet creates the wallet of every node and the genesis config, then saves them to dir
+func GenDevnet(dir string, num uint32) (*config.GenesisConfig, error) {
+	if num < DEVNET_MIN_NODES {
+		return nil, fmt.Errorf("devnet needs at least %d nodes", DEVNET_MIN_NODES)
+	}
+	if common.FileExisted(dir) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) != 0 {
+			return nil, fmt.Errorf("dir %s is not empty", dir)
+		}
+	}
+	// the minimum msg delays, an empty block is proposed every 3 block msg delays
+	vbft := &config.VBFTConfig{
+		N:                    num,
+		C:                    (num - 1) / 3,
+		K:                    num,
+		L:                    16 * num,
+		BlockMsgDelay:        5000,
+		HashMsgDelay:         5000,
+		PeerHandshakeTimeout: 10,
+		MaxBlockChangeView:   1000,
+		MinInitStake:         DEVNET_INIT_POS,
+		VrfValue:             DEVNET_VRF_VALUE,
+		VrfProof:             DEVNET_VRF_PROOF,
+	}
+	genesisCfg := config.NewGenesisConfig()
+	genesisCfg.ConsensusType = config.CONSENSUS_TYPE_VBFT
+	genesisCfg.VBFT = vbft
+	for i := uint32(1); i <= num; i++ {
+		nodeDir := nodeDir(dir, i)
+		if err := os.MkdirAll(nodeDir, 0700); err != nil {
+			return nil, err
+		}
+		wallet, err := account.Open(filepath.Join(nodeDir, config.DEFAULT_WALLET_FILE_NAME))
+		if err != nil {
+			return nil, err
+		}
+		acc, err := wallet.NewAccount("", keypair.PK_ECDSA, keypair.P256, signature.SHA256withECDSA, []byte(DEVNET_WALLET_PASSWORD))
+		if err != nil {
+			return nil, fmt.Errorf("create account of node %d error: %s", i, err)
+		}
+		vbft.Peers = append(vbft.Peers, &config.VBFTPeerStakeInfo{
+			Index:      i,
+			PeerPubkey: hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)),
+			Address:    acc.Address.ToBase58(),
+			InitPos:    DEVNET_INIT_POS,
+		})
+	}
+	vbft.AdminOntID = "did:ont:" + vbft.Peers[0].Address
+	if err := governance.CheckVBFTConfig(vbft); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(genesisCfg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, DEVNET_GENESIS_FILE), data, 0600); err != nil {
+		return nil, err
+	}
+	return genesisCfg, nil
+}
+
+//LoadDevnet read the genesis config generated by GenDevnet in dir, return nil if there is none
+func LoadDevnet(dir string) (*config.GenesisConfig, error) {
+	genesisFile := filepath.Join(dir, DEVNET_GENESIS_FILE)
+	if !common.FileExisted(genesisFile) {
+		return nil, nil
+	}
+	genesisCfg := config.NewGenesisConfig()
+	if err := cmdutils.GetJsonObjectFromFile(genesisFile, genesisCfg); err != nil {
+		return nil, fmt.Errorf("load genesis config %s error: %s", genesisFile, err)
+	}
+	if genesisCfg.VBFT == nil || len(genesisCfg.VBFT.Peers) == 0 {
+		return nil, fmt.Errorf("genesis config %s is not a devnet config", genesisFile)
+	}
+	return genesisCfg, nil
+}
+
+func nodeDir(dir string, index uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("node%d", index))
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ontio/ontology/cmd/devnet"
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/events"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	"github.com/ontio/ontology/http/jsonrpc"
+	"github.com/ontio/ontology/http/restful"
+	"github.com/ontio/ontology/txnpool"
+	"github.com/ontio/ontology/txnpool/proc"
+	"github.com/urfave/cli"
+)
+
+var DevnetCommand = cli.Command{
+	Name:      "devnet",
+	Usage:     "Run a local multi-node VBFT network",
+	ArgsUsage: "",
+	Action:    runDevnet,
+	Flags: []cli.Flag{
+		utils.DevnetNodesFlag,
+		utils.DevnetDirFlag,
+		utils.DevnetRpcPortFlag,
+	},
+	Description: `Devnet generates a bookkeeper wallet for every node and a VBFT genesis config in devnet dir,
+then runs all the nodes in the current process. An existing devnet dir is reused. The nodes are connected
+by an in-memory network instead of sockets, so the network can be partitioned. Every node keeps its own
+ledger in devnet dir, the transactions are shared by one tx pool. Only node 1 serves the json rpc server
+on devnet-rpc-port and the restful server on the next port, so node 1 can not be killed.
+
+Faults can be injected by typing commands to stdin:
+  status                   Display node status
+  kill <index>             Kill the node immediately
+  start <index>            Start a killed node
+  partition <index>...     Move the nodes to a new group, nodes of different groups can not connect
+  heal                     Connect all the nodes again
+  exit                     Stop all nodes and exit`,
+}
+
+func runDevnet(ctx *cli.Context) error {
+	dir := ctx.String(utils.GetFlagName(utils.DevnetDirFlag))
+	rpcPort := ctx.Uint(utils.GetFlagName(utils.DevnetRpcPortFlag))
+	if rpcPort == 0 {
+		return fmt.Errorf("devnet rpc port cannot be 0")
+	}
+	num := ctx.Uint(utils.GetFlagName(utils.DevnetNodesFlag))
+	genesisCfg, err := devnet.LoadDevnet(dir)
+	if err != nil {
+		return err
+	}
+	if genesisCfg != nil {
+		if ctx.IsSet(utils.GetFlagName(utils.DevnetNodesFlag)) && num != uint(len(genesisCfg.VBFT.Peers)) {
+			return fmt.Errorf("devnet in %s has %d nodes, cannot change to %d", dir, len(genesisCfg.VBFT.Peers), num)
+		}
+		PrintInfoMsg("Reuse devnet in %s", dir)
+	} else {
+		if _, err = devnet.GenDevnet(dir, uint32(num)); err != nil {
+			return fmt.Errorf("generate devnet error: %s", err)
+		}
+		PrintInfoMsg("Generate devnet of %d nodes in %s", num, dir)
+	}
+
+	logDir := filepath.Join(dir, "Log") + string(os.PathSeparator)
+	log.InitLog(ctx.GlobalInt(utils.GetFlagName(utils.LogLevelFlag)), logDir)
+	events.Init()
+	dev, err := devnet.Open(dir)
+	if err != nil {
+		return err
+	}
+	txPoolServer, err := txnpool.StartTxnPoolServer(false, true)
+	if err != nil {
+		dev.Stop()
+		return fmt.Errorf("init txpool error: %s", err)
+	}
+	bactor.SetTxnPoolPid(txPoolServer.GetPID())
+	bactor.SetTxPoolService(proc.NewTxPoolService(txPoolServer))
+	if err = dev.Start(txPoolServer.GetPID()); err != nil {
+		dev.Stop()
+		return err
+	}
+	defer dev.Stop()
+
+	config.DefConfig.Rpc.HttpJsonPort = rpcPort
+	config.DefConfig.Restful.HttpRestPort = rpcPort + 1
+	go func() {
+		if err := jsonrpc.StartRPCServer(); err != nil {
+			log.Errorf("start json rpc server error: %s", err)
+		}
+	}()
+	go restful.StartServer()
+	PrintInfoMsg("Devnet started, json rpc port %d, restful port %d, log in %s", rpcPort, rpcPort+1, logDir)
+	printDevnetStatus(dev)
+
+	exit := make(chan struct{})
+	go devnetConsole(dev, exit)
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	select {
+	case sig := <-sc:
+		PrintInfoMsg("Devnet received exit signal: %v.", sig.String())
+	case <-exit:
+	}
+	return nil
+}
+
+func printDevnetStatus(dev *devnet.Devnet) {
+	PrintInfoMsg("Index  Status   Group  Height    Address")
+	for _, node := range dev.Status() {
+		status := "stopped"
+		if node.Running {
+			status = "running"
+		}
+		PrintInfoMsg("%-6d %-8s %-6d %-9d %s", node.Index, status, node.Group, node.Height, node.Address)
+	}
+}
+
+func parseNodeIndexes(args []string) ([]uint32, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("need node index")
+	}
+	var indexes []uint32
+	for _, arg := range args {
+		index, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node index %s", arg)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+//devnetConsole reads fault injection commands from stdin until exit command is received
+func devnetConsole(dev *devnet.Devnet, exit chan struct{}) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "exit" {
+			close(exit)
+			return
+		}
+		start := time.Now()
+		if err := executeDevnetCommand(dev, fields[0], fields[1:]); err != nil {
+			PrintErrorMsg("%s error: %s", fields[0], err)
+			continue
+		}
+		if fields[0] != "status" {
+			PrintInfoMsg("%s done in %v", fields[0], time.Since(start))
+		}
+	}
+}
+
+func executeDevnetCommand(dev *devnet.Devnet, command string, args []string) error {
+	switch command {
+	case "status":
+		printDevnetStatus(dev)
+		return nil
+	case "heal":
+		dev.Heal()
+		return nil
+	}
+	indexes, err := parseNodeIndexes(args)
+	if err != nil {
+		return err
+	}
+	switch command {
+	case "kill", "start":
+		if len(indexes) != 1 {
+			return fmt.Errorf("need one node index")
+		}
+		if command == "kill" {
+			return dev.KillNode(indexes[0])
+		}
+		return dev.StartNode(indexes[0])
+	case "partition":
+		return dev.Partition(indexes)
+	default:
+		return fmt.Errorf("unknown command")
+	}
+}
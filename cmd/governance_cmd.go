@@ -0,0 +1,544 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	cmdcom "github.com/ontio/ontology/cmd/common"
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+	"github.com/urfave/cli"
+)
+
+var governanceTxFlags = []cli.Flag{
+	utils.RPCPortFlag,
+	utils.WalletFileFlag,
+	utils.AccountAddressFlag,
+	utils.TransactionGasPriceFlag,
+	utils.TransactionGasLimitFlag,
+}
+
+func governanceFlags(flags ...cli.Flag) []cli.Flag {
+	return append(flags, governanceTxFlags...)
+}
+
+var GovernanceCommand = cli.Command{
+	Name:  "governance",
+	Usage: "Stake and authorize in governance contract",
+	Description: "Governance commands register candidate peers, authorize ONT to peers, withdraw ONT and ONG, " +
+		"change the peer settings and query the governance states. The transaction is signed by the account " +
+		"specified by --account, and the default account of wallet is used if not specific.",
+	Subcommands: []cli.Command{
+		{
+			Action:      registerCandidate,
+			Name:        "registercandidate",
+			Usage:       "Register a candidate peer",
+			ArgsUsage:   " ",
+			Description: "Register a candidate peer with initial pos of ONT. The ONT and the ONG fee of registration are paid by the account.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag),
+		},
+		{
+			Action:      unRegisterCandidate,
+			Name:        "unregistercandidate",
+			Usage:       "Cancel the registration of candidate peer",
+			ArgsUsage:   " ",
+			Description: "Cancel the registration of candidate peer which has not been approved.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag),
+		},
+		{
+			Action:      quitNode,
+			Name:        "quitnode",
+			Usage:       "Quit the peer",
+			ArgsUsage:   " ",
+			Description: "Quit the peer, the initial pos and the authorized pos are unfrozen in the following views.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag),
+		},
+		{
+			Action:      authorizeForPeer,
+			Name:        "authorize",
+			Usage:       "Authorize ONT to peers",
+			ArgsUsage:   " ",
+			Description: "Authorize ONT to one or several peers, the pos takes effect in the next view.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag),
+		},
+		{
+			Action:      unAuthorizeForPeer,
+			Name:        "unauthorize",
+			Usage:       "Cancel the authorization of ONT to peers",
+			ArgsUsage:   " ",
+			Description: "Cancel the authorization of ONT to one or several peers, the pos can be withdrawn after it is unfrozen.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag),
+		},
+		{
+			Action:      withdraw,
+			Name:        "withdraw",
+			Usage:       "Withdraw the unfrozen ONT from peers",
+			ArgsUsage:   " ",
+			Description: "Withdraw the unfrozen ONT from one or several peers.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag),
+		},
+		{
+			Action:      withdrawGovernanceOng,
+			Name:        "withdrawong",
+			Usage:       "Withdraw the unbound ONG of ONT staked in governance contract",
+			ArgsUsage:   " ",
+			Description: "Withdraw the unbound ONG of ONT staked in governance contract.",
+			Flags:       governanceTxFlags,
+		},
+		{
+			Action:      withdrawFee,
+			Name:        "withdrawfee",
+			Usage:       "Withdraw the ONG split from governance fee",
+			ArgsUsage:   " ",
+			Description: "Withdraw the ONG split from governance fee, use 'splitfee' command to query the amount.",
+			Flags:       governanceTxFlags,
+		},
+		{
+			Action:      changeMaxAuthorization,
+			Name:        "changemaxauthorization",
+			Usage:       "Change the max authorization of peer",
+			ArgsUsage:   " ",
+			Description: "Change the max ONT the peer accepts from authorization.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernanceMaxAuthorizeFlag),
+		},
+		{
+			Action:      setPeerCost,
+			Name:        "setpeercost",
+			Usage:       "Set the peer cost of peer",
+			ArgsUsage:   " ",
+			Description: "Set the percentage of the peer fee kept by peer owner.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePeerCostFlag),
+		},
+		{
+			Action:      setFeePercentage,
+			Name:        "setfeepercentage",
+			Usage:       "Set the peer cost and stake cost of peer",
+			ArgsUsage:   " ",
+			Description: "Set the percentage of the peer fee and the stake fee kept by peer owner.",
+			Flags: governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePeerCostFlag,
+				utils.GovernanceStakeCostFlag),
+		},
+		{
+			Action:      addInitPos,
+			Name:        "addinitpos",
+			Usage:       "Add the initial pos of peer",
+			ArgsUsage:   " ",
+			Description: "Add ONT to the initial pos of peer.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag),
+		},
+		{
+			Action:      reduceInitPos,
+			Name:        "reduceinitpos",
+			Usage:       "Reduce the initial pos of peer",
+			ArgsUsage:   " ",
+			Description: "Reduce ONT from the initial pos of peer, the initial pos can not be less than the promised pos.",
+			Flags:       governanceFlags(utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag),
+		},
+		{
+			Action:      showPeerPool,
+			Name:        "peerpool",
+			Usage:       "Show the peer pool",
+			ArgsUsage:   " ",
+			Description: "Show the peers of governance view sorted by index.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.GovernanceViewFlag,
+			},
+		},
+		{
+			Action:      showAuthorizeInfo,
+			Name:        "authorizeinfo",
+			Usage:       "Show the pos of account authorized to peer",
+			ArgsUsage:   "<address|label|index>",
+			Description: "Show the pos of account authorized to peer.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.WalletFileFlag,
+				utils.GovernancePeerPubkeyFlag,
+			},
+		},
+		{
+			Action:      showSplitFee,
+			Name:        "splitfee",
+			Usage:       "Show the ONG split from governance fee of account",
+			ArgsUsage:   "<address|label|index>",
+			Description: "Show the ONG split from governance fee of account which can be withdrawn.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.WalletFileFlag,
+			},
+		},
+		{
+			Action:      showTotalStake,
+			Name:        "totalstake",
+			Usage:       "Show the total ONT staked by account",
+			ArgsUsage:   "<address|label|index>",
+			Description: "Show the total ONT staked by account in governance contract.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.WalletFileFlag,
+			},
+		},
+	},
+}
+
+//sendGovernanceTx sign the invocation of governance method with the account, the param is built with the
+//address of account
+func sendGovernanceTx(ctx *cli.Context, method string, buildParam func(address common.Address) interface{}) error {
+	SetRpcPort(ctx)
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return err
+	}
+	gasPrice := ctx.Uint64(utils.TransactionGasPriceFlag.Name)
+	gasLimit := ctx.Uint64(utils.TransactionGasLimitFlag.Name)
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+	tx, err := utils.GovernanceTx(gasPrice, gasLimit, method, buildParam(signer.Address))
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.InvokeSmartContract(signer, tx)
+	if err != nil {
+		return fmt.Errorf("%s error:%s", method, err)
+	}
+	PrintInfoMsg("Invoke governance %s:", method)
+	PrintInfoMsg("  Account:%s", signer.Address.ToBase58())
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+func requireFlags(ctx *cli.Context, flags ...cli.Flag) bool {
+	for _, flag := range flags {
+		if !ctx.IsSet(utils.GetFlagName(flag)) {
+			PrintErrorMsg("Missing %s argument.", flag.GetName())
+			cli.ShowSubcommandHelp(ctx)
+			return false
+		}
+	}
+	return true
+}
+
+func getPeerPubkey(ctx *cli.Context) (string, error) {
+	pubkeys, err := utils.ParsePeerPubkeyList(ctx.String(utils.GetFlagName(utils.GovernancePeerPubkeyFlag)))
+	if err != nil {
+		return "", err
+	}
+	if len(pubkeys) != 1 {
+		return "", fmt.Errorf("only one peer pubkey is allowed")
+	}
+	return pubkeys[0], nil
+}
+
+func getPos(ctx *cli.Context) (uint32, error) {
+	posList, err := utils.ParseUint32List(ctx.String(utils.GetFlagName(utils.GovernancePosFlag)))
+	if err != nil {
+		return 0, err
+	}
+	if len(posList) != 1 || posList[0] == 0 {
+		return 0, fmt.Errorf("pos should be one positive number")
+	}
+	return posList[0], nil
+}
+
+//getPeerPosList return the peers and the pos of each peer
+func getPeerPosList(ctx *cli.Context) ([]string, []uint32, error) {
+	pubkeys, err := utils.ParsePeerPubkeyList(ctx.String(utils.GetFlagName(utils.GovernancePeerPubkeyFlag)))
+	if err != nil {
+		return nil, nil, err
+	}
+	posList, err := utils.ParseUint32List(ctx.String(utils.GetFlagName(utils.GovernancePosFlag)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pubkeys) == 0 || len(pubkeys) != len(posList) {
+		return nil, nil, fmt.Errorf("the number of pos should be equal to the number of peers")
+	}
+	return pubkeys, posList, nil
+}
+
+func registerCandidate(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	pos, err := getPos(ctx)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, governance.REGISTER_CANDIDATE, func(address common.Address) interface{} {
+		return &governance.RegisterCandidateParam{PeerPubkey: peerPubkey, Address: address, InitPos: pos}
+	})
+}
+
+func unRegisterCandidate(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, governance.UNREGISTER_CANDIDATE, func(address common.Address) interface{} {
+		return &governance.UnRegisterCandidateParam{PeerPubkey: peerPubkey, Address: address}
+	})
+}
+
+func quitNode(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, governance.QUIT_NODE, func(address common.Address) interface{} {
+		return &governance.QuitNodeParam{PeerPubkey: peerPubkey, Address: address}
+	})
+}
+
+func authorizeForPeer(ctx *cli.Context) error {
+	return sendAuthorizeTx(ctx, governance.AUTHORIZE_FOR_PEER)
+}
+
+func unAuthorizeForPeer(ctx *cli.Context) error {
+	return sendAuthorizeTx(ctx, governance.UNAUTHORIZE_FOR_PEER)
+}
+
+func sendAuthorizeTx(ctx *cli.Context, method string) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag) {
+		return nil
+	}
+	pubkeys, posList, err := getPeerPosList(ctx)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, method, func(address common.Address) interface{} {
+		return &governance.AuthorizeForPeerParam{Address: address, PeerPubkeyList: pubkeys, PosList: posList}
+	})
+}
+
+func withdraw(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag) {
+		return nil
+	}
+	pubkeys, posList, err := getPeerPosList(ctx)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, governance.WITHDRAW, func(address common.Address) interface{} {
+		return &governance.WithdrawParam{Address: address, PeerPubkeyList: pubkeys, WithdrawList: posList}
+	})
+}
+
+func withdrawGovernanceOng(ctx *cli.Context) error {
+	return sendGovernanceTx(ctx, governance.WITHDRAW_ONG, func(address common.Address) interface{} {
+		return &governance.WithdrawOngParam{Address: address}
+	})
+}
+
+func withdrawFee(ctx *cli.Context) error {
+	return sendGovernanceTx(ctx, governance.WITHDRAW_FEE, func(address common.Address) interface{} {
+		return &governance.WithdrawFeeParam{Address: address}
+	})
+}
+
+func changeMaxAuthorization(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernanceMaxAuthorizeFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	maxAuthorize := uint32(ctx.Uint(utils.GetFlagName(utils.GovernanceMaxAuthorizeFlag)))
+	return sendGovernanceTx(ctx, governance.CHANGE_MAX_AUTHORIZATION, func(address common.Address) interface{} {
+		return &governance.ChangeMaxAuthorizationParam{PeerPubkey: peerPubkey, Address: address, MaxAuthorize: maxAuthorize}
+	})
+}
+
+func getCost(ctx *cli.Context, flag cli.Flag) (uint32, error) {
+	cost := ctx.Uint(utils.GetFlagName(flag))
+	if cost > 100 {
+		return 0, fmt.Errorf("%s should be from 0 to 100", flag.GetName())
+	}
+	return uint32(cost), nil
+}
+
+func setPeerCost(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernancePeerCostFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	peerCost, err := getCost(ctx, utils.GovernancePeerCostFlag)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, governance.SET_PEER_COST, func(address common.Address) interface{} {
+		return &governance.SetPeerCostParam{PeerPubkey: peerPubkey, Address: address, PeerCost: peerCost}
+	})
+}
+
+func setFeePercentage(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernancePeerCostFlag,
+		utils.GovernanceStakeCostFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	peerCost, err := getCost(ctx, utils.GovernancePeerCostFlag)
+	if err != nil {
+		return err
+	}
+	stakeCost, err := getCost(ctx, utils.GovernanceStakeCostFlag)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, governance.SET_FEE_PERCENTAGE, func(address common.Address) interface{} {
+		return &governance.SetFeePercentageParam{PeerPubkey: peerPubkey, Address: address, PeerCost: peerCost,
+			StakeCost: stakeCost}
+	})
+}
+
+func addInitPos(ctx *cli.Context) error {
+	return sendChangeInitPosTx(ctx, governance.ADD_INIT_POS)
+}
+
+func reduceInitPos(ctx *cli.Context) error {
+	return sendChangeInitPosTx(ctx, governance.REDUCE_INIT_POS)
+}
+
+func sendChangeInitPosTx(ctx *cli.Context, method string) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag, utils.GovernancePosFlag) {
+		return nil
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	pos, err := getPos(ctx)
+	if err != nil {
+		return err
+	}
+	return sendGovernanceTx(ctx, method, func(address common.Address) interface{} {
+		return &governance.ChangeInitPosParam{PeerPubkey: peerPubkey, Address: address, Pos: pos}
+	})
+}
+
+func showPeerPool(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	view := uint32(ctx.Uint(utils.GetFlagName(utils.GovernanceViewFlag)))
+	if view == 0 {
+		governanceView, err := utils.GetGovernanceView()
+		if err != nil {
+			return err
+		}
+		view = governanceView.View
+	}
+	peers, err := utils.GetPeerPoolMap(view)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Peer pool of view %d:", view)
+	PrintJsonObject(peers)
+	return nil
+}
+
+//getQueryAddress return the address of account argument
+func getQueryAddress(ctx *cli.Context) (common.Address, bool, error) {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing account argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return common.ADDRESS_EMPTY, false, nil
+	}
+	addr, err := cmdcom.ParseAddress(ctx.Args().First(), ctx)
+	if err != nil {
+		return common.ADDRESS_EMPTY, false, err
+	}
+	address, err := common.AddressFromBase58(addr)
+	if err != nil {
+		return common.ADDRESS_EMPTY, false, err
+	}
+	return address, true, nil
+}
+
+func showAuthorizeInfo(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.GovernancePeerPubkeyFlag) {
+		return nil
+	}
+	address, ok, err := getQueryAddress(ctx)
+	if !ok {
+		return err
+	}
+	peerPubkey, err := getPeerPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	info, err := utils.GetAuthorizeInfo(peerPubkey, address)
+	if err != nil {
+		return err
+	}
+	PrintJsonObject(info)
+	return nil
+}
+
+func showSplitFee(ctx *cli.Context) error {
+	address, ok, err := getQueryAddress(ctx)
+	if !ok {
+		return err
+	}
+	splitFee, err := utils.GetSplitFeeAddress(address)
+	if err != nil {
+		return err
+	}
+	PrintJsonObject(splitFee)
+	return nil
+}
+
+func showTotalStake(ctx *cli.Context) error {
+	address, ok, err := getQueryAddress(ctx)
+	if !ok {
+		return err
+	}
+	totalStake, err := utils.GetTotalStake(address)
+	if err != nil {
+		return err
+	}
+	PrintJsonObject(totalStake)
+	return nil
+}
@@ -46,6 +46,7 @@ var ImportCommand = cli.Command{
 		utils.ImportFileFlag,
 		utils.ImportEndHeightFlag,
 		utils.DataDirFlag,
+		utils.DbBackendFlag,
 		utils.ConfigFlag,
 		utils.NetworkIdFlag,
 		utils.DisableEventLogFlag,
@@ -73,6 +73,30 @@ var InfoCommand = cli.Command{
 				utils.RPCPortFlag,
 			},
 		},
+		{
+			Action:    storageDiff,
+			Name:      "storagediff",
+			Usage:     "Display the storage changes of contract between two heights",
+			ArgsUsage: "<contract> <fromHeight> <toHeight>",
+			Description: `Display the storage keys of contract changed by the blocks after fromHeight up to toHeight, with the
+old and new values. The keys and values of ONT, ONG and governance contract are decoded. The node should be
+started with --save-write-sets before the blocks are saved.`,
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+			},
+		},
+		{
+			Action:    storageProof,
+			Name:      "storageproof",
+			Usage:     "Display the proof of contract storage key at height",
+			ArgsUsage: "<contract> <key> <height>",
+			Description: `Display the value of contract storage key in hex at height, with the root of storage trie after the
+block and the trie nodes proving the value against the root. The node should be started with --storage-proof
+before the block is saved.`,
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+			},
+		},
 	},
 	Description: `Query information command can query information such as blocks, transactions, and transaction executions. 
 You can use the ./Ontology info block --help command to view help information.`,
@@ -166,6 +190,48 @@ func curBlockHeight(ctx *cli.Context) error {
 	return nil
 }
 
+func storageDiff(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 3 {
+		PrintErrorMsg("Missing argument. Contract, fromHeight and toHeight expected.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	fromHeight, err := strconv.ParseUint(ctx.Args().Get(1), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid fromHeight:%s", ctx.Args().Get(1))
+	}
+	toHeight, err := strconv.ParseUint(ctx.Args().Get(2), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid toHeight:%s", ctx.Args().Get(2))
+	}
+	data, err := utils.GetStorageDiff(ctx.Args().First(), uint32(fromHeight), uint32(toHeight))
+	if err != nil {
+		return fmt.Errorf("GetStorageDiff error:%s", err)
+	}
+	PrintJsonData(data)
+	return nil
+}
+
+func storageProof(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 3 {
+		PrintErrorMsg("Missing argument. Contract, key and height expected.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	height, err := strconv.ParseUint(ctx.Args().Get(2), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid height:%s", ctx.Args().Get(2))
+	}
+	data, err := utils.GetStorageProof(ctx.Args().First(), ctx.Args().Get(1), uint32(height))
+	if err != nil {
+		return fmt.Errorf("GetStorageProof error:%s", err)
+	}
+	PrintJsonData(data)
+	return nil
+}
+
 func showTx(ctx *cli.Context) error {
 	SetRpcPort(ctx)
 	if ctx.NArg() < 1 {
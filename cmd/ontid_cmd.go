@@ -0,0 +1,628 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	cmdcom "github.com/ontio/ontology/cmd/common"
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract/service/native/ontid"
+	"github.com/urfave/cli"
+)
+
+var ontIDTxFlags = []cli.Flag{
+	utils.RPCPortFlag,
+	utils.WalletFileFlag,
+	utils.AccountAddressFlag,
+	utils.TransactionGasPriceFlag,
+	utils.TransactionGasLimitFlag,
+	utils.OntIDFlag,
+	utils.OntIDKeyIndexFlag,
+}
+
+func ontIDFlags(flags ...cli.Flag) []cli.Flag {
+	return append(flags, ontIDTxFlags...)
+}
+
+var OntIDCommand = cli.Command{
+	Name:  "ontid",
+	Usage: "Register and manage ONT ID",
+	Description: "ONT ID commands register ONT ID, manage the keys, attributes, recovery, service and controller " +
+		"of ONT ID and query the DID document. The identity specified by --ontid is read from wallet, and the " +
+		"transaction is signed by the identity key of --key-index. If --signers is specified, the transaction " +
+		"is signed by the keys of controllers in wallet instead. The transaction fee is paid by the account " +
+		"specified by --account, and the default account of wallet is used if not specific.",
+	Subcommands: []cli.Command{
+		{
+			Action:      listIdentity,
+			Name:        "list",
+			Usage:       "List the identities in wallet",
+			ArgsUsage:   " ",
+			Description: "List the ONT ID and the keys of identities in wallet.",
+			Flags: []cli.Flag{
+				utils.WalletFileFlag,
+			},
+		},
+		{
+			Action:      regIDWithPublicKey,
+			Name:        "regid",
+			Usage:       "Register ONT ID with public key",
+			ArgsUsage:   " ",
+			Description: "Register the ONT ID of identity with the identity key, use 'account add --ontid' to create identity.",
+			Flags:       ontIDTxFlags,
+		},
+		{
+			Action:    regIDWithController,
+			Name:      "regwithcontroller",
+			Usage:     "Register ONT ID with controller",
+			ArgsUsage: " ",
+			Description: "Register ONT ID controlled by an ONT ID or a group of ONT IDs, the transaction is signed by the keys " +
+				"of --signers. The controller of ONT ID can only be set at registration.",
+			Flags: ontIDFlags(utils.OntIDControllerFlag, utils.OntIDSignersFlag),
+		},
+		{
+			Action:      removeOntIDController,
+			Name:        "removecontroller",
+			Usage:       "Remove the controller of ONT ID",
+			ArgsUsage:   " ",
+			Description: "Remove the controller of ONT ID, the transaction is signed by the identity key.",
+			Flags:       ontIDTxFlags,
+		},
+		{
+			Action:      addOntIDKey,
+			Name:        "addkey",
+			Usage:       "Add public key to ONT ID",
+			ArgsUsage:   " ",
+			Description: "Add public key to ONT ID, signed by the identity key or the controllers of --signers.",
+			Flags:       ontIDFlags(utils.OntIDPubkeyFlag, utils.OntIDSignersFlag),
+		},
+		{
+			Action:      removeOntIDKey,
+			Name:        "removekey",
+			Usage:       "Remove public key from ONT ID",
+			ArgsUsage:   " ",
+			Description: "Revoke public key of ONT ID, signed by the identity key or the controllers of --signers.",
+			Flags:       ontIDFlags(utils.OntIDPubkeyFlag, utils.OntIDSignersFlag),
+		},
+		{
+			Action:      addOntIDAttributes,
+			Name:        "addattributes",
+			Usage:       "Add attributes to ONT ID",
+			ArgsUsage:   " ",
+			Description: "Add attributes to ONT ID, signed by the identity key or the controllers of --signers.",
+			Flags:       ontIDFlags(utils.OntIDAttributesFlag, utils.OntIDSignersFlag),
+		},
+		{
+			Action:      removeOntIDAttribute,
+			Name:        "removeattribute",
+			Usage:       "Remove attribute from ONT ID",
+			ArgsUsage:   " ",
+			Description: "Remove attribute from ONT ID, signed by the identity key or the controllers of --signers.",
+			Flags:       ontIDFlags(utils.OntIDAttributeKeyFlag, utils.OntIDSignersFlag),
+		},
+		{
+			Action:      addOntIDRecovery,
+			Name:        "addrecovery",
+			Usage:       "Add recovery address to ONT ID",
+			ArgsUsage:   " ",
+			Description: "Add recovery address to ONT ID which has no recovery, signed by the identity key.",
+			Flags:       ontIDFlags(utils.OntIDRecoveryFlag),
+		},
+		{
+			Action:      setOntIDRecovery,
+			Name:        "setrecovery",
+			Usage:       "Set recovery group of ONT ID",
+			ArgsUsage:   " ",
+			Description: "Set a group of ONT IDs as the recovery of ONT ID which has no recovery, signed by the identity key.",
+			Flags:       ontIDFlags(utils.OntIDRecoveryFlag),
+		},
+		{
+			Action:      addOntIDService,
+			Name:        "addservice",
+			Usage:       "Add service to ONT ID",
+			ArgsUsage:   " ",
+			Description: "Add service to ONT ID, signed by the identity key.",
+			Flags: ontIDFlags(utils.OntIDServiceIdFlag, utils.OntIDServiceTypeFlag,
+				utils.OntIDServiceEndpointFlag),
+		},
+		{
+			Action:      showOntIDDocument,
+			Name:        "document",
+			Usage:       "Show the DID document of ONT ID",
+			ArgsUsage:   "<ontid|label|index>",
+			Description: "Show the DID document of ONT ID in json.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.WalletFileFlag,
+			},
+		},
+		{
+			Action:      showOntIDDDO,
+			Name:        "ddo",
+			Usage:       "Show the DDO of ONT ID",
+			ArgsUsage:   "<ontid|label|index>",
+			Description: "Show the serialized DDO of ONT ID in hex.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.WalletFileFlag,
+			},
+		},
+	},
+}
+
+// sendOntIDTx sign the invocation of ONT ID method with the payer account and the keys of identities
+func sendOntIDTx(ctx *cli.Context, method string, param interface{}, keys []*account.Account) error {
+	payer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return err
+	}
+	gasPrice := ctx.Uint64(utils.TransactionGasPriceFlag.Name)
+	gasLimit := ctx.Uint64(utils.TransactionGasLimitFlag.Name)
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+	tx, err := utils.OntIDTx(gasPrice, gasLimit, method, param)
+	if err != nil {
+		return err
+	}
+	for _, signer := range append([]*account.Account{payer}, keys...) {
+		if err := utils.SignTransaction(signer, tx); err != nil {
+			return fmt.Errorf("%s sign error:%s", method, err)
+		}
+	}
+	immut, err := tx.IntoImmutable()
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.SendRawTransaction(immut)
+	if err != nil {
+		return fmt.Errorf("%s error:%s", method, err)
+	}
+	PrintInfoMsg("Invoke ONT ID %s:", method)
+	PrintInfoMsg("  Payer:%s", payer.Address.ToBase58())
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+// getOntID return the ONT ID of --ontid, the ONT ID which is not in wallet is allowed if inWallet is false
+func getOntID(ctx *cli.Context, wallet account.Client, inWallet bool) (string, error) {
+	id := ctx.String(utils.GetFlagName(utils.OntIDFlag))
+	identity, err := cmdcom.GetIdentityMulti(wallet, id)
+	if err == nil {
+		return identity.ID, nil
+	}
+	if !inWallet && account.VerifyID(id) {
+		return id, nil
+	}
+	return "", err
+}
+
+// getIdentityKey decrypt the key of identity in wallet
+func getIdentityKey(ctx *cli.Context, wallet account.Client, id string, index uint32) (*account.Account, error) {
+	identity, err := cmdcom.GetIdentityMulti(wallet, id)
+	if err != nil {
+		return nil, err
+	}
+	PrintInfoMsg("Unlock key %s#keys-%d", identity.ID, index)
+	passwd, err := cmdcom.GetPasswd(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cmdcom.ClearPasswd(passwd)
+	return cmdcom.GetIdentityAccount(wallet, identity, index, passwd)
+}
+
+// getOntIDOperator return the ONT ID of --ontid and its key of --key-index
+func getOntIDOperator(ctx *cli.Context) (string, *account.Account, error) {
+	SetRpcPort(ctx)
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := getOntID(ctx, wallet, true)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := getIdentityKey(ctx, wallet, id, uint32(ctx.Uint(utils.GetFlagName(utils.OntIDKeyIndexFlag))))
+	if err != nil {
+		return "", nil, err
+	}
+	return id, key, nil
+}
+
+// getControllerAuth return the controller signature argument of --signers and the keys of signers, the argument
+// is the key index for single controller and the serialized signers for group controller
+func getControllerAuth(ctx *cli.Context, wallet account.Client, single bool) (interface{}, []*account.Account, error) {
+	signers, err := utils.ParseOntIDSigners(ctx.String(utils.GetFlagName(utils.OntIDSignersFlag)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if single && len(signers) != 1 {
+		return nil, nil, fmt.Errorf("only one signer is allowed for single controller")
+	}
+	var keys []*account.Account
+	for _, signer := range signers {
+		key, err := getIdentityKey(ctx, wallet, string(signer.Id), signer.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+	if single {
+		return signers[0].Index, keys, nil
+	}
+	return ontid.SerializeSigners(signers), keys, nil
+}
+
+// sendOntIDControllerTx send the transaction signed by the controllers of --signers, the param is built with the
+// controller signature argument
+func sendOntIDControllerTx(ctx *cli.Context, method string,
+	buildParam func(id string, auth interface{}) (interface{}, error)) error {
+	SetRpcPort(ctx)
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := getOntID(ctx, wallet, false)
+	if err != nil {
+		return err
+	}
+	group, err := utils.IsOntIDGroupController(id)
+	if err != nil {
+		return err
+	}
+	auth, keys, err := getControllerAuth(ctx, wallet, !group)
+	if err != nil {
+		return err
+	}
+	param, err := buildParam(id, auth)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, method, param, keys)
+}
+
+func getOntIDPubkey(ctx *cli.Context) ([]byte, error) {
+	pubkey, err := hex.DecodeString(ctx.String(utils.GetFlagName(utils.OntIDPubkeyFlag)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pubkey:%s", err)
+	}
+	if _, err := keypair.DeserializePublicKey(pubkey); err != nil {
+		return nil, fmt.Errorf("invalid pubkey:%s", err)
+	}
+	return pubkey, nil
+}
+
+func listIdentity(ctx *cli.Context) error {
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	for i, identity := range wallet.GetWalletData().Identities {
+		PrintInfoMsg("Index:%d", i+1)
+		PrintInfoMsg("  ONT ID:%s", identity.ID)
+		PrintInfoMsg("  Label:%s", identity.Label)
+		for _, ctrl := range identity.Control {
+			index, err := cmdcom.GetIdentityKeyIndex(&ctrl)
+			if err != nil {
+				PrintWarnMsg("  %s", err)
+				continue
+			}
+			PrintInfoMsg("  Key:%s#keys-%d %s", identity.ID, index, ctrl.Public)
+		}
+	}
+	return nil
+}
+
+func regIDWithPublicKey(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag) {
+		return nil
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "regIDWithPublicKey", &struct {
+		Id     string
+		Pubkey []byte
+	}{id, keypair.SerializePublicKey(key.PublicKey)}, []*account.Account{key})
+}
+
+func regIDWithController(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDControllerFlag, utils.OntIDSignersFlag) {
+		return nil
+	}
+	SetRpcPort(ctx)
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := getOntID(ctx, wallet, false)
+	if err != nil {
+		return err
+	}
+	controller := ctx.String(utils.GetFlagName(utils.OntIDControllerFlag))
+	single := account.VerifyID(controller)
+	controllerData := []byte(controller)
+	if !single {
+		controllerData, err = utils.ParseOntIDGroup(controller)
+		if err != nil {
+			return err
+		}
+	}
+	auth, keys, err := getControllerAuth(ctx, wallet, single)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "regIDWithController", &struct {
+		Id         string
+		Controller []byte
+		Auth       interface{}
+	}{id, controllerData, auth}, keys)
+}
+
+func removeOntIDController(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag) {
+		return nil
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "removeController", &struct {
+		Id    string
+		Index uint32
+	}{id, uint32(ctx.Uint(utils.GetFlagName(utils.OntIDKeyIndexFlag)))}, []*account.Account{key})
+}
+
+func addOntIDKey(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDPubkeyFlag) {
+		return nil
+	}
+	pubkey, err := getOntIDPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.OntIDSignersFlag)) {
+		return sendOntIDControllerTx(ctx, "addKeyByController", func(id string, auth interface{}) (interface{}, error) {
+			return &struct {
+				Id     string
+				Pubkey []byte
+				Auth   interface{}
+			}{id, pubkey, auth}, nil
+		})
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "addKey", &struct {
+		Id       string
+		Pubkey   []byte
+		Operator []byte
+	}{id, pubkey, keypair.SerializePublicKey(key.PublicKey)}, []*account.Account{key})
+}
+
+func removeOntIDKey(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDPubkeyFlag) {
+		return nil
+	}
+	pubkey, err := getOntIDPubkey(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.OntIDSignersFlag)) {
+		return sendOntIDControllerTx(ctx, "removeKeyByController", func(id string, auth interface{}) (interface{}, error) {
+			index, err := utils.GetOntIDKeyIndex(id, hex.EncodeToString(pubkey))
+			if err != nil {
+				return nil, err
+			}
+			return &struct {
+				Id    string
+				Index uint32
+				Auth  interface{}
+			}{id, index, auth}, nil
+		})
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "removeKey", &struct {
+		Id       string
+		Pubkey   []byte
+		Operator []byte
+	}{id, pubkey, keypair.SerializePublicKey(key.PublicKey)}, []*account.Account{key})
+}
+
+func addOntIDAttributes(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDAttributesFlag) {
+		return nil
+	}
+	attributes, err := utils.ParseOntIDAttributes(ctx.String(utils.GetFlagName(utils.OntIDAttributesFlag)))
+	if err != nil {
+		return err
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.OntIDSignersFlag)) {
+		return sendOntIDControllerTx(ctx, "addAttributesByController", func(id string, auth interface{}) (interface{}, error) {
+			return &struct {
+				Id         string
+				Attributes []utils.OntIDAttribute
+				Auth       interface{}
+			}{id, attributes, auth}, nil
+		})
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "addAttributes", &struct {
+		Id         string
+		Attributes []utils.OntIDAttribute
+		Operator   []byte
+	}{id, attributes, keypair.SerializePublicKey(key.PublicKey)}, []*account.Account{key})
+}
+
+func removeOntIDAttribute(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDAttributeKeyFlag) {
+		return nil
+	}
+	attrKey := ctx.String(utils.GetFlagName(utils.OntIDAttributeKeyFlag))
+	if ctx.IsSet(utils.GetFlagName(utils.OntIDSignersFlag)) {
+		return sendOntIDControllerTx(ctx, "removeAttributeByController", func(id string, auth interface{}) (interface{}, error) {
+			return &struct {
+				Id   string
+				Key  string
+				Auth interface{}
+			}{id, attrKey, auth}, nil
+		})
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "removeAttribute", &struct {
+		Id       string
+		Key      string
+		Operator []byte
+	}{id, attrKey, keypair.SerializePublicKey(key.PublicKey)}, []*account.Account{key})
+}
+
+func addOntIDRecovery(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDRecoveryFlag) {
+		return nil
+	}
+	recovery, err := common.AddressFromBase58(ctx.String(utils.GetFlagName(utils.OntIDRecoveryFlag)))
+	if err != nil {
+		return fmt.Errorf("invalid recovery address:%s", err)
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "addRecovery", &struct {
+		Id       string
+		Recovery common.Address
+		Operator []byte
+	}{id, recovery, keypair.SerializePublicKey(key.PublicKey)}, []*account.Account{key})
+}
+
+func setOntIDRecovery(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDRecoveryFlag) {
+		return nil
+	}
+	recovery, err := utils.ParseOntIDGroup(ctx.String(utils.GetFlagName(utils.OntIDRecoveryFlag)))
+	if err != nil {
+		return err
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "setRecovery", &struct {
+		Id       string
+		Recovery []byte
+		Index    uint32
+	}{id, recovery, uint32(ctx.Uint(utils.GetFlagName(utils.OntIDKeyIndexFlag)))}, []*account.Account{key})
+}
+
+func addOntIDService(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.OntIDFlag, utils.OntIDServiceIdFlag, utils.OntIDServiceTypeFlag,
+		utils.OntIDServiceEndpointFlag) {
+		return nil
+	}
+	id, key, err := getOntIDOperator(ctx)
+	if err != nil {
+		return err
+	}
+	return sendOntIDTx(ctx, "addService", &ontid.ServiceParam{
+		OntId:          []byte(id),
+		ServiceId:      []byte(ctx.String(utils.GetFlagName(utils.OntIDServiceIdFlag))),
+		Type:           []byte(ctx.String(utils.GetFlagName(utils.OntIDServiceTypeFlag))),
+		ServiceEndpint: []byte(ctx.String(utils.GetFlagName(utils.OntIDServiceEndpointFlag))),
+		Index:          uint32(ctx.Uint(utils.GetFlagName(utils.OntIDKeyIndexFlag))),
+	}, []*account.Account{key})
+}
+
+// getQueryOntID return the ONT ID of argument, which may be an ONT ID or an identity in wallet
+func getQueryOntID(ctx *cli.Context) (string, bool, error) {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing ontid argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return "", false, nil
+	}
+	id := ctx.Args().First()
+	if account.VerifyID(id) {
+		return id, true, nil
+	}
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	identity, err := cmdcom.GetIdentityMulti(wallet, id)
+	if err != nil {
+		return "", false, err
+	}
+	return identity.ID, true, nil
+}
+
+func showOntIDDocument(ctx *cli.Context) error {
+	id, ok, err := getQueryOntID(ctx)
+	if !ok {
+		return err
+	}
+	doc, err := utils.GetOntIDDocument(id)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return fmt.Errorf("%s is not registered", id)
+	}
+	PrintJsonData(doc)
+	return nil
+}
+
+func showOntIDDDO(ctx *cli.Context) error {
+	id, ok, err := getQueryOntID(ctx)
+	if !ok {
+		return err
+	}
+	ddo, err := utils.GetOntIDDDO(id)
+	if err != nil {
+		return err
+	}
+	if ddo == nil {
+		return fmt.Errorf("%s is not registered", id)
+	}
+	PrintInfoMsg("%s", hex.EncodeToString(ddo))
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ontio/ontology/common"
+)
+
+//Checkpoint records the next source height to relay, so relayer can resume after restart
+type Checkpoint struct {
+	FromChainID uint64 `json:"fromChainId"`
+	Height      uint32 `json:"height"`
+
+	path string
+}
+
+//LoadCheckpoint load the checkpoint in file path, nil is returned if the file does not exist
+func LoadCheckpoint(path string, fromChainID uint64) (*Checkpoint, error) {
+	if !common.FileExisted(path) {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint %s error:%s", path, err)
+	}
+	checkpoint := &Checkpoint{path: path}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s:%s", path, err)
+	}
+	if checkpoint.FromChainID != fromChainID {
+		return nil, fmt.Errorf("checkpoint %s belongs to chain %d, not %d", path, checkpoint.FromChainID, fromChainID)
+	}
+	return checkpoint, nil
+}
+
+func NewCheckpoint(path string, fromChainID uint64, height uint32) *Checkpoint {
+	return &Checkpoint{
+		FromChainID: fromChainID,
+		Height:      height,
+		path:        path,
+	}
+}
+
+//Save set the next height and write the checkpoint to a temporary file before renaming it,
+//so a crash never leaves a broken checkpoint
+func (this *Checkpoint) Save(height uint32) error {
+	old := this.Height
+	this.Height = height
+	data, err := json.Marshal(this)
+	if err != nil {
+		this.Height = old
+		return fmt.Errorf("json.Marshal checkpoint error:%s", err)
+	}
+	tmp := this.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		this.Height = old
+		return fmt.Errorf("write checkpoint %s error:%s", tmp, err)
+	}
+	if err := os.Rename(tmp, this.path); err != nil {
+		this.Height = old
+		return fmt.Errorf("rename checkpoint %s error:%s", tmp, err)
+	}
+	return nil
+}
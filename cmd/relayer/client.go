@@ -0,0 +1,222 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	httpcom "github.com/ontio/ontology/http/base/common"
+)
+
+const RPC_TIMEOUT = 30 * time.Second
+
+//Chain is the json rpc interface of the chain used by relayer
+type Chain interface {
+	GetBlockCount() (uint32, error)
+	GetBlockHash(height uint32) (common.Uint256, error)
+	GetBlock(height uint32) ([]byte, error)
+	GetEvents(height uint32) ([]*httpcom.ExecuteNotify, error)
+	GetEvent(txHash common.Uint256) (*httpcom.ExecuteNotify, error)
+	GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error)
+	GetCrossStatesProof(height uint32, key []byte) ([]byte, error)
+	GetStorage(contract common.Address, key []byte) ([]byte, error)
+	PreExecute(tx *types.Transaction) (*httpcom.PreExecuteResult, error)
+	SendTransaction(tx *types.Transaction) (common.Uint256, error)
+}
+
+//RpcClient is the json rpc client of the node listening on addr
+type RpcClient struct {
+	addr   string
+	client *http.Client
+}
+
+func NewRpcClient(addr string) *RpcClient {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	return &RpcClient{
+		addr:   addr,
+		client: &http.Client{Timeout: RPC_TIMEOUT},
+	}
+}
+
+func (this *RpcClient) call(method string, params ...interface{}) (json.RawMessage, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	data, err := json.Marshal(&utils.JsonRpcRequest{
+		Version: utils.JSON_RPC_VERSION,
+		Id:      "relayer",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal JsonRpcRequest error:%s", err)
+	}
+	resp, err := this.client.Post(this.addr, "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read rpc response body error:%s", err)
+	}
+	rpcRsp := &utils.JsonRpcResponse{}
+	if err := json.Unmarshal(body, rpcRsp); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err)
+	}
+	if rpcRsp.Error != 0 {
+		return nil, fmt.Errorf("%s error:%d desc:%s", method, rpcRsp.Error, rpcRsp.Desc)
+	}
+	return rpcRsp.Result, nil
+}
+
+func (this *RpcClient) callHex(method string, params ...interface{}) ([]byte, error) {
+	data, err := this.call(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal %s result error:%s", method, err)
+	}
+	if str == "" {
+		return nil, nil
+	}
+	return hex.DecodeString(str)
+}
+
+func (this *RpcClient) GetBlockCount() (uint32, error) {
+	data, err := this.call("getblockcount")
+	if err != nil {
+		return 0, err
+	}
+	var count uint32
+	if err := json.Unmarshal(data, &count); err != nil {
+		return 0, fmt.Errorf("json.Unmarshal block count error:%s", err)
+	}
+	return count, nil
+}
+
+func (this *RpcClient) GetBlockHash(height uint32) (common.Uint256, error) {
+	data, err := this.call("getblockhash", height)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("json.Unmarshal block hash error:%s", err)
+	}
+	return common.Uint256FromHexString(str)
+}
+
+//GetBlock return the serialized block of height
+func (this *RpcClient) GetBlock(height uint32) ([]byte, error) {
+	return this.callHex("getblock", height)
+}
+
+//GetEvents return the events of transactions in block of height
+func (this *RpcClient) GetEvents(height uint32) ([]*httpcom.ExecuteNotify, error) {
+	data, err := this.call("getsmartcodeevent", height)
+	if err != nil {
+		return nil, err
+	}
+	var events []*httpcom.ExecuteNotify
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal events error:%s", err)
+	}
+	return events, nil
+}
+
+//GetEvent return the event of transaction, nil is returned if the transaction is not executed
+func (this *RpcClient) GetEvent(txHash common.Uint256) (*httpcom.ExecuteNotify, error) {
+	data, err := this.call("getsmartcodeevent", txHash.ToHexString())
+	if err != nil {
+		return nil, err
+	}
+	var event *httpcom.ExecuteNotify
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal event error:%s", err)
+	}
+	return event, nil
+}
+
+func (this *RpcClient) GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error) {
+	data, err := this.callHex("getcrosschainmsg", height)
+	if err != nil {
+		return nil, err
+	}
+	msg := new(types.CrossChainMsg)
+	if err := msg.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize cross chain msg error:%s", err)
+	}
+	return msg, nil
+}
+
+//GetCrossStatesProof return the merkle proof of the value of key in cross chain contract at height
+func (this *RpcClient) GetCrossStatesProof(height uint32, key []byte) ([]byte, error) {
+	data, err := this.call("getcrossstatesproof", height, hex.EncodeToString(key))
+	if err != nil {
+		return nil, err
+	}
+	proof := &httpcom.CrossStatesProof{}
+	if err := json.Unmarshal(data, proof); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal cross states proof error:%s", err)
+	}
+	return hex.DecodeString(proof.AuditPath)
+}
+
+//GetStorage return the storage value of contract, nil is returned if the key is not found
+func (this *RpcClient) GetStorage(contract common.Address, key []byte) ([]byte, error) {
+	return this.callHex("getstorage", contract.ToHexString(), hex.EncodeToString(key))
+}
+
+func (this *RpcClient) PreExecute(tx *types.Transaction) (*httpcom.PreExecuteResult, error) {
+	data, err := this.call("sendrawtransaction", hex.EncodeToString(common.SerializeToBytes(tx)), 1)
+	if err != nil {
+		return nil, err
+	}
+	result := &httpcom.PreExecuteResult{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal PreExecuteResult error:%s", err)
+	}
+	return result, nil
+}
+
+func (this *RpcClient) SendTransaction(tx *types.Transaction) (common.Uint256, error) {
+	data, err := this.call("sendrawtransaction", hex.EncodeToString(common.SerializeToBytes(tx)))
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("json.Unmarshal tx hash error:%s", err)
+	}
+	return common.Uint256FromHexString(str)
+}
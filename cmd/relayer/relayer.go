@@ -0,0 +1,400 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package relayer relays the cross chain transactions of a source chain to the cross chain
+//manager contract of a target chain
+package relayer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology/account"
+	cmdutils "github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	cutils "github.com/ontio/ontology/core/utils"
+	httpcom "github.com/ontio/ontology/http/base/common"
+	"github.com/ontio/ontology/merkle"
+	ccom "github.com/ontio/ontology/smartcontract/service/native/cross_chain/common"
+	"github.com/ontio/ontology/smartcontract/service/native/cross_chain/cross_chain_manager"
+	"github.com/ontio/ontology/smartcontract/service/native/cross_chain/header_sync"
+	"github.com/ontio/ontology/smartcontract/service/native/cross_chain/lock_proxy"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	VERSION_CONTRACT_CROSS_CHAIN = byte(0)
+	ERR_TX_ALREADY_DONE          = "tx already done"
+)
+
+type Config struct {
+	//chain id of source chain registered in header sync contract of target chain
+	FromChainID uint64
+	Signer      *account.Account
+	GasPrice    uint64
+	GasLimit    uint64
+	//interval to poll the source chain for new blocks
+	Interval time.Duration
+	//max interval to retry after an error, the interval doubles on every error
+	MaxRetryInterval time.Duration
+	//timeout to wait for a submitted transaction to be executed
+	ConfirmTimeout time.Duration
+}
+
+//crossChainEvent is the makeFromOntProof notify of cross chain manager contract
+type crossChainEvent struct {
+	TxHash    string
+	ToChainID uint64
+	Key       []byte
+}
+
+type Relayer struct {
+	source     Chain
+	target     Chain
+	cfg        *Config
+	checkpoint *Checkpoint
+}
+
+func NewRelayer(source, target Chain, cfg *Config, checkpoint *Checkpoint) *Relayer {
+	return &Relayer{
+		source:     source,
+		target:     target,
+		cfg:        cfg,
+		checkpoint: checkpoint,
+	}
+}
+
+//SyncedHeight return the height of the latest source header synced in header sync contract of target chain
+func SyncedHeight(target Chain, fromChainID uint64) (uint32, error) {
+	chainIDBytes, err := utils.GetUint64Bytes(fromChainID)
+	if err != nil {
+		return 0, err
+	}
+	value, err := target.GetStorage(utils.HeaderSyncContractAddress,
+		utils.ConcatBytes([]byte(header_sync.CURRENT_HEIGHT), chainIDBytes))
+	if err != nil {
+		return 0, fmt.Errorf("get synced height error:%s", err)
+	}
+	if value == nil {
+		return 0, fmt.Errorf("genesis header of chain %d is not synced in target chain", fromChainID)
+	}
+	return utils.GetBytesUint32(value)
+}
+
+//Check make sure the source headers can be verified by header sync contract of target chain
+func (this *Relayer) Check() error {
+	if _, err := SyncedHeight(this.target, this.cfg.FromChainID); err != nil {
+		return err
+	}
+	count, err := this.source.GetBlockCount()
+	if err != nil {
+		return fmt.Errorf("get source block count error:%s", err)
+	}
+	_, header, err := this.getHeader(count - 1)
+	if err != nil {
+		return err
+	}
+	hash, err := this.source.GetBlockHash(header.Height)
+	if err != nil {
+		return fmt.Errorf("get source block hash error:%s", err)
+	}
+	if header.Hash() != hash {
+		return fmt.Errorf("source chain headers are not in cross chain header format")
+	}
+	if header.ChainID != this.cfg.FromChainID {
+		return fmt.Errorf("chain id of source headers is %d, not %d", header.ChainID, this.cfg.FromChainID)
+	}
+	return nil
+}
+
+//Start relay the source blocks from the checkpoint until exit is closed. The block of height h is
+//relayed after block h+1 is produced, because the cross chain msg of h is signed in h+1
+func (this *Relayer) Start(exit <-chan struct{}) {
+	retry := time.Duration(0)
+	for {
+		wait := this.cfg.Interval
+		if err := this.relay(exit); err != nil {
+			retry = nextRetryInterval(retry, this.cfg.Interval, this.cfg.MaxRetryInterval)
+			wait = retry
+			log.Errorf("relay height %d error: %s, retry after %s", this.checkpoint.Height, err, wait)
+		} else {
+			retry = 0
+		}
+		select {
+		case <-exit:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func nextRetryInterval(retry, min, max time.Duration) time.Duration {
+	if retry < min {
+		return min
+	}
+	retry *= 2
+	if retry > max {
+		return max
+	}
+	return retry
+}
+
+func (this *Relayer) relay(exit <-chan struct{}) error {
+	count, err := this.source.GetBlockCount()
+	if err != nil {
+		return fmt.Errorf("get source block count error:%s", err)
+	}
+	for height := this.checkpoint.Height; height+2 <= count; height++ {
+		select {
+		case <-exit:
+			return nil
+		default:
+		}
+		if err := this.relayHeight(height); err != nil {
+			return err
+		}
+		if err := this.checkpoint.Save(height + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *Relayer) relayHeight(height uint32) error {
+	raw, header, err := this.getHeader(height)
+	if err != nil {
+		return err
+	}
+	if isKeyHeader(header) {
+		if err := this.syncKeyHeader(raw, header); err != nil {
+			return err
+		}
+	}
+	notifies, err := this.source.GetEvents(height)
+	if err != nil {
+		return fmt.Errorf("get events error:%s", err)
+	}
+	events := filterEvents(notifies, cross_chain_manager.ONT_CHAIN_ID)
+	if len(events) == 0 {
+		return nil
+	}
+	msg, err := this.source.GetCrossChainMsg(height)
+	if err != nil {
+		return fmt.Errorf("get cross chain msg error:%s", err)
+	}
+	raw, header, err = this.getProofHeader(height, msg.StatesRoot)
+	if err != nil {
+		return err
+	}
+	for _, evt := range events {
+		proof, err := this.source.GetCrossStatesProof(height, evt.Key)
+		if err != nil {
+			return fmt.Errorf("get proof of tx %s error:%s", evt.TxHash, err)
+		}
+		if _, err := merkle.MerkleProve(proof, msg.StatesRoot); err != nil {
+			return fmt.Errorf("verify proof of tx %s error:%s", evt.TxHash, err)
+		}
+		param := cross_chain_manager.ProcessCrossChainTxParam{
+			Address:     this.cfg.Signer.Address,
+			FromChainID: this.cfg.FromChainID,
+			Height:      header.Height,
+			Proof:       hex.EncodeToString(proof),
+			Header:      raw,
+		}
+		txHash, err := this.submit(cross_chain_manager.PROCESS_CROSS_CHAIN_TX, utils.CrossChainContractAddress, param)
+		if err != nil {
+			return fmt.Errorf("relay tx %s error:%s", evt.TxHash, err)
+		}
+		if txHash == common.UINT256_EMPTY {
+			log.Infof("cross chain tx %s of height %d is already done", evt.TxHash, height)
+		} else {
+			log.Infof("relay cross chain tx %s of height %d by tx %s", evt.TxHash, height, txHash.ToHexString())
+		}
+	}
+	return nil
+}
+
+//getHeader return the raw header in cross chain header format of source block
+func (this *Relayer) getHeader(height uint32) ([]byte, *ccom.Header, error) {
+	block, err := this.source.GetBlock(height)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get source block %d error:%s", height, err)
+	}
+	source := common.NewZeroCopySource(block)
+	header := new(ccom.Header)
+	if err := header.Deserialization(source); err != nil {
+		return nil, nil, fmt.Errorf("source chain headers are not in cross chain header format:%s", err)
+	}
+	return block[:source.Pos()], header, nil
+}
+
+//getProofHeader return the header of height or height+1 whose cross state root is root
+func (this *Relayer) getProofHeader(height uint32, root common.Uint256) ([]byte, *ccom.Header, error) {
+	for _, h := range []uint32{height + 1, height} {
+		raw, header, err := this.getHeader(h)
+		if err != nil {
+			return nil, nil, err
+		}
+		if header.CrossStateRoot == root {
+			return raw, header, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no header commits cross state root %s of height %d", root.ToHexString(), height)
+}
+
+func isKeyHeader(header *ccom.Header) bool {
+	blkInfo := &vconfig.VbftBlockInfo{}
+	if err := json.Unmarshal(header.ConsensusPayload, blkInfo); err != nil {
+		return false
+	}
+	return blkInfo.NewChainConfig != nil
+}
+
+//syncKeyHeader sync the header changing consensus peers, so the headers after it can be verified
+func (this *Relayer) syncKeyHeader(raw []byte, header *ccom.Header) error {
+	chainIDBytes, err := utils.GetUint64Bytes(this.cfg.FromChainID)
+	if err != nil {
+		return err
+	}
+	hash := header.Hash()
+	value, err := this.target.GetStorage(utils.HeaderSyncContractAddress,
+		utils.ConcatBytes([]byte(header_sync.BLOCK_HEADER), chainIDBytes, hash.ToArray()))
+	if err != nil {
+		return fmt.Errorf("get synced header %d error:%s", header.Height, err)
+	}
+	if value != nil {
+		return nil
+	}
+	param := header_sync.SyncBlockHeaderParam{
+		Address: this.cfg.Signer.Address,
+		Headers: [][]byte{raw},
+	}
+	txHash, err := this.submit(header_sync.SYNC_BLOCK_HEADER, utils.HeaderSyncContractAddress, param)
+	if err != nil {
+		return fmt.Errorf("sync key header %d error:%s", header.Height, err)
+	}
+	log.Infof("sync key header %d by tx %s", header.Height, txHash.ToHexString())
+	return nil
+}
+
+//filterEvents return the cross chain transactions to chain toChainID, and log the lock events
+func filterEvents(notifies []*httpcom.ExecuteNotify, toChainID uint64) []*crossChainEvent {
+	crossChainContract := utils.CrossChainContractAddress.ToHexString()
+	lockProxyContract := utils.LockProxyContractAddress.ToHexString()
+	var events []*crossChainEvent
+	for _, notify := range notifies {
+		if notify == nil || notify.State == 0 {
+			continue
+		}
+		for _, n := range notify.Notify {
+			states, ok := n.States.([]interface{})
+			if !ok || len(states) == 0 {
+				continue
+			}
+			name, _ := states[0].(string)
+			switch {
+			case n.ContractAddress == lockProxyContract && name == lock_proxy.LOCK_NAME:
+				log.Infof("lock event of tx %s: %v", notify.TxHash, states[1:])
+			case n.ContractAddress == crossChainContract && name == cross_chain_manager.MAKE_FROM_ONT_PROOF:
+				if evt := parseCrossChainEvent(states); evt != nil && evt.ToChainID == toChainID {
+					events = append(events, evt)
+				}
+			}
+		}
+	}
+	return events
+}
+
+//parseCrossChainEvent parse the states [makeFromOntProof, txHash, toChainID, height, key, contract, args]
+func parseCrossChainEvent(states []interface{}) *crossChainEvent {
+	if len(states) < 5 {
+		return nil
+	}
+	txHash, _ := states[1].(string)
+	toChainID, ok := states[2].(float64)
+	if !ok {
+		return nil
+	}
+	keyHex, _ := states[4].(string)
+	key, err := hex.DecodeString(keyHex)
+	if err != nil || len(key) == 0 {
+		return nil
+	}
+	return &crossChainEvent{
+		TxHash:    txHash,
+		ToChainID: uint64(toChainID),
+		Key:       key,
+	}
+}
+
+//submit send the transaction invoking method of native contract to target chain, and wait until it is executed.
+//Empty hash is returned if the cross chain transaction is already done
+func (this *Relayer) submit(method string, contract common.Address, param interface{}) (common.Uint256, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(contract, VERSION_CONTRACT_CROSS_CHAIN, method, []interface{}{param})
+	if err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("build invoke code error:%s", err)
+	}
+	mutTx := cmdutils.NewInvokeTransaction(this.cfg.GasPrice, this.cfg.GasLimit, invokeCode)
+	if err := cmdutils.SignTransaction(this.cfg.Signer, mutTx); err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("sign tx error:%s", err)
+	}
+	tx, err := mutTx.IntoImmutable()
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	result, err := this.target.PreExecute(tx)
+	if err != nil {
+		if strings.Contains(err.Error(), ERR_TX_ALREADY_DONE) {
+			return common.UINT256_EMPTY, nil
+		}
+		return common.UINT256_EMPTY, fmt.Errorf("pre execute error:%s", err)
+	}
+	if result.State == 0 {
+		return common.UINT256_EMPTY, fmt.Errorf("pre execute failed")
+	}
+	txHash, err := this.target.SendTransaction(tx)
+	if err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("send tx error:%s", err)
+	}
+	return txHash, this.waitTx(txHash)
+}
+
+func (this *Relayer) waitTx(txHash common.Uint256) error {
+	deadline := time.Now().Add(this.cfg.ConfirmTimeout)
+	for {
+		notify, err := this.target.GetEvent(txHash)
+		if err != nil {
+			return fmt.Errorf("get event of tx %s error:%s", txHash.ToHexString(), err)
+		}
+		if notify != nil {
+			if notify.State == 0 {
+				return fmt.Errorf("tx %s failed", txHash.ToHexString())
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("tx %s is not executed in %s", txHash.ToHexString(), this.cfg.ConfirmTimeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
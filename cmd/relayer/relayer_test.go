@@ -0,0 +1,296 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	httpcom "github.com/ontio/ontology/http/base/common"
+	"github.com/ontio/ontology/merkle"
+	ccom "github.com/ontio/ontology/smartcontract/service/native/cross_chain/common"
+	"github.com/ontio/ontology/smartcontract/service/native/cross_chain/cross_chain_manager"
+	"github.com/ontio/ontology/smartcontract/service/native/cross_chain/header_sync"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testChainID = 5
+
+type mockChain struct {
+	headers    []*ccom.Header
+	events     map[uint32][]*httpcom.ExecuteNotify
+	msgs       map[uint32]*types.CrossChainMsg
+	proofs     map[string][]byte
+	storage    map[string][]byte
+	preExecErr error
+	sent       []*types.Transaction
+}
+
+func newMockChain() *mockChain {
+	return &mockChain{
+		events:  make(map[uint32][]*httpcom.ExecuteNotify),
+		msgs:    make(map[uint32]*types.CrossChainMsg),
+		proofs:  make(map[string][]byte),
+		storage: make(map[string][]byte),
+	}
+}
+
+func (this *mockChain) addHeaders(n int) {
+	for i := 0; i < n; i++ {
+		this.headers = append(this.headers, &ccom.Header{
+			ChainID:          testChainID,
+			Height:           uint32(len(this.headers)),
+			ConsensusPayload: []byte("{}"),
+		})
+	}
+}
+
+func (this *mockChain) GetBlockCount() (uint32, error) {
+	return uint32(len(this.headers)), nil
+}
+
+func (this *mockChain) GetBlockHash(height uint32) (common.Uint256, error) {
+	return this.headers[height].Hash(), nil
+}
+
+func (this *mockChain) GetBlock(height uint32) ([]byte, error) {
+	if int(height) >= len(this.headers) {
+		return nil, fmt.Errorf("unknown block %d", height)
+	}
+	sink := common.NewZeroCopySink(nil)
+	this.headers[height].Serialization(sink)
+	//transactions of block
+	sink.WriteUint32(0)
+	return sink.Bytes(), nil
+}
+
+func (this *mockChain) GetEvents(height uint32) ([]*httpcom.ExecuteNotify, error) {
+	return this.events[height], nil
+}
+
+func (this *mockChain) GetEvent(txHash common.Uint256) (*httpcom.ExecuteNotify, error) {
+	return &httpcom.ExecuteNotify{TxHash: txHash.ToHexString(), State: 1}, nil
+}
+
+func (this *mockChain) GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error) {
+	msg, ok := this.msgs[height]
+	if !ok {
+		return nil, fmt.Errorf("no cross chain msg at %d", height)
+	}
+	return msg, nil
+}
+
+func (this *mockChain) GetCrossStatesProof(height uint32, key []byte) ([]byte, error) {
+	return this.proofs[hex.EncodeToString(key)], nil
+}
+
+func (this *mockChain) GetStorage(contract common.Address, key []byte) ([]byte, error) {
+	return this.storage[hex.EncodeToString(utils.ConcatKey(contract, key))], nil
+}
+
+func (this *mockChain) PreExecute(tx *types.Transaction) (*httpcom.PreExecuteResult, error) {
+	if this.preExecErr != nil {
+		return nil, this.preExecErr
+	}
+	return &httpcom.PreExecuteResult{State: 1}, nil
+}
+
+func (this *mockChain) SendTransaction(tx *types.Transaction) (common.Uint256, error) {
+	this.sent = append(this.sent, tx)
+	return tx.Hash(), nil
+}
+
+func (this *mockChain) sentMethods() []string {
+	var methods []string
+	for _, tx := range this.sent {
+		code := tx.Payload.(*payload.InvokeCode).Code
+		for _, method := range []string{cross_chain_manager.PROCESS_CROSS_CHAIN_TX, header_sync.SYNC_BLOCK_HEADER} {
+			if bytes.Contains(code, []byte(method)) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods
+}
+
+//addCrossChainTx put a cross chain tx to toChainID at height, and commit the cross state root in header height+1
+func (this *mockChain) addCrossChainTx(height uint32, toChainID uint64, id byte) {
+	key := []byte{'r', id}
+	value := []byte{'v', id}
+	hashes := []common.Uint256{merkle.HashLeaf([]byte("other")), merkle.HashLeaf(value)}
+	root := merkle.TreeHasher{}.HashFullTreeWithLeafHash(hashes)
+	proof, err := merkle.MerkleLeafPath(value, hashes)
+	if err != nil {
+		panic(err)
+	}
+	this.proofs[hex.EncodeToString(key)] = proof
+	this.msgs[height] = &types.CrossChainMsg{Height: height, StatesRoot: root}
+	this.headers[height+1].CrossStateRoot = root
+	this.events[height] = append(this.events[height], &httpcom.ExecuteNotify{
+		TxHash: fmt.Sprintf("%064x", id),
+		State:  1,
+		Notify: []httpcom.NotifyEventInfo{{
+			ContractAddress: utils.CrossChainContractAddress.ToHexString(),
+			States: []interface{}{cross_chain_manager.MAKE_FROM_ONT_PROOF, fmt.Sprintf("%064x", id),
+				float64(toChainID), float64(height), hex.EncodeToString(key), "", ""},
+		}},
+	})
+}
+
+func newTestRelayer(t *testing.T, source, target *mockChain) (*Relayer, string) {
+	dir, err := ioutil.TempDir("", "relayer")
+	assert.Nil(t, err)
+	cfg := &Config{
+		FromChainID:      testChainID,
+		Signer:           account.NewAccount(""),
+		GasLimit:         20000,
+		Interval:         time.Millisecond,
+		MaxRetryInterval: time.Second,
+		ConfirmTimeout:   time.Second,
+	}
+	checkpoint := NewCheckpoint(filepath.Join(dir, "checkpoint.json"), testChainID, 1)
+	return NewRelayer(source, target, cfg, checkpoint), dir
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relayer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	checkpoint, err := LoadCheckpoint(path, testChainID)
+	assert.Nil(t, err)
+	assert.Nil(t, checkpoint)
+
+	checkpoint = NewCheckpoint(path, testChainID, 1)
+	assert.Nil(t, checkpoint.Save(10))
+	assert.False(t, common.FileExisted(path+".tmp"))
+
+	checkpoint, err = LoadCheckpoint(path, testChainID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(10), checkpoint.Height)
+
+	_, err = LoadCheckpoint(path, testChainID+1)
+	assert.NotNil(t, err)
+}
+
+func TestFilterEvents(t *testing.T) {
+	source := newMockChain()
+	source.addHeaders(4)
+	source.addCrossChainTx(1, cross_chain_manager.ONT_CHAIN_ID, 1)
+	source.addCrossChainTx(2, 2, 2)
+	events := append(source.events[1], source.events[2]...)
+	events = append(events, &httpcom.ExecuteNotify{
+		State: 1,
+		Notify: []httpcom.NotifyEventInfo{{
+			ContractAddress: utils.LockProxyContractAddress.ToHexString(),
+			States:          []interface{}{"lock", "", float64(cross_chain_manager.ONT_CHAIN_ID)},
+		}},
+	})
+	failed := *source.events[1][0]
+	failed.State = 0
+	events = append(events, &failed)
+
+	result := filterEvents(events, cross_chain_manager.ONT_CHAIN_ID)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, []byte{'r', 1}, result[0].Key)
+}
+
+func TestRelay(t *testing.T) {
+	source, target := newMockChain(), newMockChain()
+	source.addHeaders(5)
+	source.addCrossChainTx(1, cross_chain_manager.ONT_CHAIN_ID, 1)
+	source.addCrossChainTx(3, cross_chain_manager.ONT_CHAIN_ID, 2)
+	source.headers[2].ConsensusPayload = []byte(`{"new_chain_config":{"peers":[]}}`)
+	relayer, dir := newTestRelayer(t, source, target)
+	defer os.RemoveAll(dir)
+
+	//block 4 is not produced when relaying block 3
+	source.headers = source.headers[:4]
+	assert.Nil(t, relayer.relay(nil))
+	assert.Equal(t, uint32(3), relayer.checkpoint.Height)
+	assert.Equal(t, []string{cross_chain_manager.PROCESS_CROSS_CHAIN_TX, header_sync.SYNC_BLOCK_HEADER}, target.sentMethods())
+
+	source.addHeaders(1)
+	source.headers[4].CrossStateRoot = source.msgs[3].StatesRoot
+	assert.Nil(t, relayer.relay(nil))
+	assert.Equal(t, uint32(4), relayer.checkpoint.Height)
+	assert.Equal(t, 3, len(target.sent))
+
+	checkpoint, err := LoadCheckpoint(relayer.checkpoint.path, testChainID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(4), checkpoint.Height)
+}
+
+func TestRelayRetry(t *testing.T) {
+	source, target := newMockChain(), newMockChain()
+	source.addHeaders(4)
+	source.addCrossChainTx(1, cross_chain_manager.ONT_CHAIN_ID, 1)
+	relayer, dir := newTestRelayer(t, source, target)
+	defer os.RemoveAll(dir)
+
+	target.preExecErr = errors.New("connection refused")
+	assert.NotNil(t, relayer.relay(nil))
+	assert.Equal(t, uint32(1), relayer.checkpoint.Height)
+	assert.Equal(t, 0, len(target.sent))
+
+	target.preExecErr = errors.New("ProcessCrossChainTx, VerifyOntTx error: checkDoneTx, tx already done")
+	assert.Nil(t, relayer.relay(nil))
+	assert.Equal(t, uint32(3), relayer.checkpoint.Height)
+	assert.Equal(t, 0, len(target.sent))
+}
+
+func TestRelayCheck(t *testing.T) {
+	source, target := newMockChain(), newMockChain()
+	source.addHeaders(2)
+	relayer, dir := newTestRelayer(t, source, target)
+	defer os.RemoveAll(dir)
+
+	assert.NotNil(t, relayer.Check())
+	chainIDBytes, _ := utils.GetUint64Bytes(testChainID)
+	heightBytes, _ := utils.GetUint32Bytes(0)
+	key := utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(header_sync.CURRENT_HEIGHT), chainIDBytes)
+	target.storage[hex.EncodeToString(key)] = heightBytes
+	assert.Nil(t, relayer.Check())
+
+	relayer.cfg.FromChainID = testChainID + 1
+	assert.NotNil(t, relayer.Check())
+}
+
+func TestNextRetryInterval(t *testing.T) {
+	retry := time.Duration(0)
+	var intervals []time.Duration
+	for i := 0; i < 5; i++ {
+		retry = nextRetryInterval(retry, time.Second, 5*time.Second)
+		intervals = append(intervals, retry)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, intervals)
+}
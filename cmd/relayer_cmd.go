@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cmdcom "github.com/ontio/ontology/cmd/common"
+	"github.com/ontio/ontology/cmd/relayer"
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/log"
+	"github.com/urfave/cli"
+)
+
+var RelayerCommand = cli.Command{
+	Name:      "relayer",
+	Usage:     "Relay cross chain transactions from source chain to target chain",
+	ArgsUsage: "",
+	Action:    runRelayer,
+	Flags: []cli.Flag{
+		utils.RelayerSourceRpcFlag,
+		utils.RelayerTargetRpcFlag,
+		utils.RelayerFromChainIdFlag,
+		utils.RelayerCheckpointFlag,
+		utils.RelayerStartHeightFlag,
+		utils.RelayerIntervalFlag,
+		utils.RelayerMaxRetryIntervalFlag,
+		utils.RelayerConfirmTimeoutFlag,
+		utils.WalletFileFlag,
+		utils.AccountAddressFlag,
+		utils.TransactionGasPriceFlag,
+		utils.TransactionGasLimitFlag,
+	},
+	Description: `Relayer watches the blocks of source chain for the cross chain transactions to ontology
+(makeFromOntProof events of cross chain manager contract) and the lock events of lock proxy contract.
+For every cross chain transaction, relayer fetches the merkle proof from source chain and the header
+which commits the cross state root, and submits processCrossChainTx to target chain. The headers which
+change consensus peers are submitted by syncBlockHeader, so the headers after them can be verified.
+
+The genesis header of source chain must be synced to target chain by the admin of header sync contract
+in advance, and the source headers must be in cross chain header format which carries the chain id and
+cross state root. The next source height is saved in checkpoint file after every block, so relayer
+resumes from it after restart. Failed blocks are retried with exponential backoff. The transactions
+are signed and paid by --account.`,
+}
+
+func runRelayer(ctx *cli.Context) error {
+	if !requireFlags(ctx, utils.RelayerSourceRpcFlag, utils.RelayerTargetRpcFlag, utils.RelayerFromChainIdFlag) {
+		return nil
+	}
+	log.InitLog(ctx.GlobalInt(utils.GetFlagName(utils.LogLevelFlag)), log.Stdout)
+	fromChainID := ctx.Uint64(utils.GetFlagName(utils.RelayerFromChainIdFlag))
+	interval := ctx.Uint(utils.GetFlagName(utils.RelayerIntervalFlag))
+	if interval == 0 {
+		return fmt.Errorf("relay interval cannot be 0")
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return err
+	}
+	source := relayer.NewRpcClient(ctx.String(utils.GetFlagName(utils.RelayerSourceRpcFlag)))
+	target := relayer.NewRpcClient(ctx.String(utils.GetFlagName(utils.RelayerTargetRpcFlag)))
+
+	path := ctx.String(utils.GetFlagName(utils.RelayerCheckpointFlag))
+	checkpoint, err := relayer.LoadCheckpoint(path, fromChainID)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		var height uint32
+		if ctx.IsSet(utils.GetFlagName(utils.RelayerStartHeightFlag)) {
+			height = uint32(ctx.Uint(utils.GetFlagName(utils.RelayerStartHeightFlag)))
+		} else {
+			synced, err := relayer.SyncedHeight(target, fromChainID)
+			if err != nil {
+				return err
+			}
+			height = synced + 1
+		}
+		checkpoint = relayer.NewCheckpoint(path, fromChainID, height)
+	}
+
+	cfg := &relayer.Config{
+		FromChainID:      fromChainID,
+		Signer:           signer,
+		GasPrice:         ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag)),
+		GasLimit:         ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag)),
+		Interval:         time.Duration(interval) * time.Second,
+		MaxRetryInterval: time.Duration(ctx.Uint(utils.GetFlagName(utils.RelayerMaxRetryIntervalFlag))) * time.Second,
+		ConfirmTimeout:   time.Duration(ctx.Uint(utils.GetFlagName(utils.RelayerConfirmTimeoutFlag))) * time.Second,
+	}
+	r := relayer.NewRelayer(source, target, cfg, checkpoint)
+	if err := r.Check(); err != nil {
+		return err
+	}
+	PrintInfoMsg("Relay chain %d from height %d by %s", fromChainID, checkpoint.Height, signer.Address.ToBase58())
+
+	exit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		r.Start(exit)
+		close(done)
+	}()
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	sig := <-sc
+	PrintInfoMsg("Relayer received exit signal: %v.", sig.String())
+	close(exit)
+	<-done
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/log"
+)
+
+var RollbackCommand = cli.Command{
+	Name:      "rollback",
+	Usage:     "Rollback the ledger in DB to a previous block height",
+	ArgsUsage: "",
+	Action:    rollbackLedger,
+	Flags: []cli.Flag{
+		utils.RollbackHeightFlag,
+		utils.DataDirFlag,
+		utils.DbBackendFlag,
+		utils.ConfigFlag,
+		utils.NetworkIdFlag,
+	},
+	Description: "Note that the node must be stopped, and only blocks saved with --save-reverse-write-sets and not pruned by --prune-history can be rolled back",
+}
+
+func rollbackLedger(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	if !ctx.IsSet(utils.GetFlagName(utils.RollbackHeightFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.RollbackHeightFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	height := uint32(ctx.Uint(utils.GetFlagName(utils.RollbackHeightFlag)))
+
+	ledgerStore, err := openLedgerStore(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledgerStore.Close()
+
+	PrintInfoMsg("Start rollback ledger to height:%d.", height)
+	err = ledgerStore.Rollback(height)
+	if err != nil {
+		return fmt.Errorf("rollback error:%s", err)
+	}
+	PrintInfoMsg("Rollback ledger completed, current block height:%d.", ledgerStore.GetCurrentBlockHeight())
+	return nil
+}
@@ -104,8 +104,16 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DisableLogFileFlag,
 			utils.DisableEventLogFlag,
 			utils.DataDirFlag,
+			utils.DbBackendFlag,
 			utils.ETHTxGasLimitFlag,
 			utils.WasmVerifyMethodFlag,
+			utils.TxExecModeFlag,
+			utils.PruneHistoryFlag,
+			utils.StateGCFlag,
+			utils.SaveWriteSetsFlag,
+			utils.SaveReverseWriteSetsFlag,
+			utils.StorageProofFlag,
+			utils.LightFlag,
 		},
 	},
 	{
@@ -136,6 +144,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableConsensusFlag,
 			utils.MaxTxInBlockFlag,
+			utils.RemoteSignerFlag,
+			utils.RemoteSignerSecretFlag,
 		},
 	},
 	{
@@ -199,6 +209,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableTestModeFlag,
 			utils.TestModeGenBlockTimeFlag,
+			utils.TestModeInstantSealFlag,
+			utils.TestModePrefundFileFlag,
+			utils.TestModeForkRpcFlag,
+			utils.TestModeImpersonateFlag,
 		},
 	},
 	{
@@ -265,6 +279,27 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ImportEndHeightFlag,
 		},
 	},
+	{
+		Name: "ROLLBACK",
+		Flags: []cli.Flag{
+			utils.RollbackHeightFlag,
+		},
+	},
+	{
+		Name: "DB",
+		Flags: []cli.Flag{
+			utils.DbVerifyStartHeightFlag,
+			utils.DbVerifyEndHeightFlag,
+			utils.DbRepairFlag,
+			utils.DbMigrateToFlag,
+			utils.DbInspectStoreFlag,
+			utils.DbInspectPrefixFlag,
+			utils.DbInspectLimitFlag,
+			utils.DbInspectStatsFlag,
+			utils.DbInspectContractFlag,
+			utils.DbSignRecordFileFlag,
+		},
+	},
 	{
 		Name: "MISC",
 	},
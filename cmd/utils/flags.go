@@ -32,6 +32,14 @@ const (
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
 	DEFAULT_WALLET_PATH   = "./wallet_data"
+	DEFAULT_DEVNET_DIR    = "./Devnet"
+	DEFAULT_DEVNET_NODES  = 7
+	DEFAULT_DEVNET_PORT   = 30000
+
+	DEFAULT_RELAYER_CHECKPOINT      = "./relayer_checkpoint.json"
+	DEFAULT_RELAYER_INTERVAL        = 1
+	DEFAULT_RELAYER_MAX_RETRY       = 60
+	DEFAULT_RELAYER_CONFIRM_TIMEOUT = 60
 )
 
 var (
@@ -62,6 +70,35 @@ var (
 		Name:  "enable-wasmjit-verifier",
 		Usage: "Enable wasmjit verifier to verify wasm contract",
 	}
+	TxExecModeFlag = cli.StringFlag{
+		Name:  "tx-exec-mode",
+		Usage: "Execute transactions of a block in `<mode>`: sequential, parallel, or compare which executes in both modes and reports the difference",
+		Value: "sequential",
+	}
+	PruneHistoryFlag = cli.UintFlag{
+		Name:  "prune-history",
+		Usage: "Prune the blocks and events before the latest `<number>` blocks, at least 1000. 0 means no pruning",
+	}
+	StateGCFlag = cli.BoolFlag{
+		Name:  "state-gc",
+		Usage: "Delete the storage of contracts destroyed before the latest blocks kept by --prune-history in background",
+	}
+	SaveWriteSetsFlag = cli.BoolFlag{
+		Name:  "save-write-sets",
+		Usage: "Save the states changed by each block, so the storage changes of contract between two heights can be queried",
+	}
+	SaveReverseWriteSetsFlag = cli.BoolFlag{
+		Name:  "save-reverse-write-sets",
+		Usage: "Save the previous values of the states changed by each block, so the ledger can be rolled back and the states at a previous height can be queried",
+	}
+	StorageProofFlag = cli.BoolFlag{
+		Name:  "storage-proof",
+		Usage: "Maintain the storage trie of contracts, so the proof of storage key at a height can be queried",
+	}
+	LightFlag = cli.StringFlag{
+		Name:  "light",
+		Usage: "Run as a light node which syncs the block headers only, and fetches the blocks and storage proofs on demand from the json rpc servers `<address>` of full nodes, separated by comma and tried in turn, e.g. http://127.0.0.1:20336",
+	}
 	WalletFileFlag = cli.StringFlag{
 		Name:  "wallet,w",
 		Value: config.DEFAULT_WALLET_FILE_NAME,
@@ -82,6 +119,11 @@ var (
 		Usage: "Block data storage `<path>`",
 		Value: config.DEFAULT_DATA_DIR,
 	}
+	DbBackendFlag = cli.StringFlag{
+		Name:  "db-backend",
+		Usage: "Key-value `<backend>` of the ledger stores: leveldb or bolt. The stores created by another backend should be converted by db migrate",
+		Value: config.DEFAULT_DB_BACKEND,
+	}
 	ETHTxGasLimitFlag = cli.Uint64Flag{
 		Name:  "eth-tx-gaslimit",
 		Usage: "ETH block total gas limit",
@@ -97,6 +139,14 @@ var (
 		Usage: "Max transaction `<number>` in block",
 		Value: config.DEFAULT_MAX_TX_IN_BLOCK,
 	}
+	RemoteSignerFlag = cli.StringFlag{
+		Name:  "remote-signer",
+		Usage: "Sign the consensus messages with the key kept by remote signer `<address>`, unix://<path> or tcp://<host:port>, instead of the wallet",
+	}
+	RemoteSignerSecretFlag = cli.StringFlag{
+		Name:  "remote-signer-secret",
+		Usage: "`<file>` of the secret shared with remote signer",
+	}
 	GasLimitFlag = cli.Uint64Flag{
 		Name:  "gaslimit",
 		Usage: "Min gas limit `<value>` of transaction to be accepted by tx pool.",
@@ -117,6 +167,22 @@ var (
 		Usage: "Block-out `<time>`(s) in test mode.",
 		Value: config.DEFAULT_GEN_BLOCK_TIME,
 	}
+	TestModeInstantSealFlag = cli.BoolFlag{
+		Name:  "testmode-instant-seal",
+		Usage: "Seal a block as soon as the tx pool has verified transactions in test mode, instead of on block-out time.",
+	}
+	TestModePrefundFileFlag = cli.StringFlag{
+		Name:  "testmode-prefund-file",
+		Usage: "Json `<file>` of accounts funded with ONT and ONG in genesis block in test mode.",
+	}
+	TestModeForkRpcFlag = cli.StringFlag{
+		Name:  "testmode-fork-rpc",
+		Usage: "Fork states from the json rpc server `<address>` of a reference node in test mode, e.g. http://127.0.0.1:20336",
+	}
+	TestModeImpersonateFlag = cli.StringFlag{
+		Name:  "testmode-impersonate",
+		Usage: "Comma separated payer `<addresses>` whose transactions need no signature in test mode",
+	}
 
 	//P2P setting
 	ReservedPeersOnlyFlag = cli.BoolFlag{
@@ -503,6 +569,185 @@ var (
 		Value: "m",
 	}
 
+	//Rollback setting
+	RollbackHeightFlag = cli.UintFlag{
+		Name:  "height",
+		Usage: "Rollback the ledger to block `<height>`",
+	}
+
+	//DB setting
+	DbVerifyStartHeightFlag = cli.UintFlag{
+		Name:  "start-height",
+		Usage: "Start block height `<number>` to execute again, 0 means no block is executed",
+	}
+	DbVerifyEndHeightFlag = cli.UintFlag{
+		Name:  "end-height",
+		Usage: "End block height `<number>` to execute again, 0 means the current block height",
+	}
+	DbRepairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "Rollback the ledger to the last good block height if any bad block is found",
+	}
+	DbMigrateToFlag = cli.StringFlag{
+		Name:  "to",
+		Usage: "Key-value `<backend>` the ledger stores are converted to: leveldb or bolt",
+	}
+	DbInspectStoreFlag = cli.StringFlag{
+		Name:  "store",
+		Usage: "Ledger `<store>` to inspect: block, states, ledgerevent or crosschain",
+		Value: "states",
+	}
+	DbInspectPrefixFlag = cli.StringFlag{
+		Name:  "prefix",
+		Usage: "Scan the keys with the hex `<prefix>`, the first byte is the data entry prefix",
+	}
+	DbInspectLimitFlag = cli.UintFlag{
+		Name:  "limit",
+		Usage: "Max `<number>` of keys to scan, 0 means no limit",
+		Value: 100,
+	}
+	DbInspectStatsFlag = cli.BoolFlag{
+		Name:  "stats",
+		Usage: "Show the key count and size of each data entry prefix",
+	}
+	DbInspectContractFlag = cli.StringFlag{
+		Name:  "contract",
+		Usage: "Dump the storage of contract `<address>` in json",
+	}
+	DbSignRecordFileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "Json `<file>` of the vbft sign records",
+		Value: "vbftsign.json",
+	}
+
+	//Governance setting
+	GovernancePeerPubkeyFlag = cli.StringFlag{
+		Name:  "peer-pubkey",
+		Usage: "Hex public key `<pubkey>` of peer, several peers are separated by ','",
+	}
+	GovernancePosFlag = cli.StringFlag{
+		Name:  "pos",
+		Usage: "Amount `<pos>` of ONT, several amounts are separated by ',' in the order of peers",
+	}
+	GovernanceMaxAuthorizeFlag = cli.UintFlag{
+		Name:  "max-authorize",
+		Usage: "Max `<pos>` of ONT the peer accepts from authorization",
+	}
+	GovernancePeerCostFlag = cli.UintFlag{
+		Name:  "peer-cost",
+		Usage: "Percentage `<number>` of the peer fee kept by peer owner, from 0 to 100",
+	}
+	GovernanceStakeCostFlag = cli.UintFlag{
+		Name:  "stake-cost",
+		Usage: "Percentage `<number>` of the stake fee kept by peer owner, from 0 to 100",
+	}
+	GovernanceViewFlag = cli.UintFlag{
+		Name:  "view",
+		Usage: "Governance `<view>` of peer pool. If not specific, using current view instead",
+	}
+
+	//ONT ID setting
+	OntIDFlag = cli.StringFlag{
+		Name:  "ontid",
+		Usage: "`<ontid>` of identity, the ONT ID, label or index of identity in wallet",
+	}
+	OntIDKeyIndexFlag = cli.UintFlag{
+		Name:  "key-index",
+		Usage: "Index `<number>` of the identity key which signs the transaction",
+		Value: 1,
+	}
+	OntIDPubkeyFlag = cli.StringFlag{
+		Name:  "pubkey",
+		Usage: "Hex public key `<pubkey>` to add to or remove from ONT ID",
+	}
+	OntIDSignersFlag = cli.StringFlag{
+		Name:  "signers",
+		Usage: "Key ids `<keyids>` of controller which sign the transaction, such as did:ont:xxx#keys-1, several key ids are separated by ','",
+	}
+	OntIDControllerFlag = cli.StringFlag{
+		Name:  "controller",
+		Usage: "`<controller>` of ONT ID, an ONT ID or a group in json",
+	}
+	OntIDAttributesFlag = cli.StringFlag{
+		Name:  "attributes",
+		Usage: "Attributes `<json>` of ONT ID, such as [{\"key\":\"name\",\"type\":\"string\",\"value\":\"alice\"}]",
+	}
+	OntIDAttributeKeyFlag = cli.StringFlag{
+		Name:  "attribute-key",
+		Usage: "Key `<key>` of attribute to remove",
+	}
+	OntIDRecoveryFlag = cli.StringFlag{
+		Name:  "recovery",
+		Usage: "`<recovery>` of ONT ID, a base58 address for addrecovery or a group in json for setrecovery",
+	}
+	OntIDServiceIdFlag = cli.StringFlag{
+		Name:  "service-id",
+		Usage: "`<id>` of service",
+	}
+	OntIDServiceTypeFlag = cli.StringFlag{
+		Name:  "service-type",
+		Usage: "`<type>` of service",
+	}
+	OntIDServiceEndpointFlag = cli.StringFlag{
+		Name:  "service-endpoint",
+		Usage: "Endpoint `<url>` of service",
+	}
+
+	//Devnet setting
+	DevnetNodesFlag = cli.UintFlag{
+		Name:  "nodes",
+		Usage: "Number of consensus `<number>` nodes in devnet, at least 7",
+		Value: DEFAULT_DEVNET_NODES,
+	}
+	DevnetDirFlag = cli.StringFlag{
+		Name:  "devnet-dir",
+		Usage: "Devnet `<path>` to store genesis config, wallets and node data",
+		Value: DEFAULT_DEVNET_DIR,
+	}
+	DevnetRpcPortFlag = cli.UintFlag{
+		Name:  "devnet-rpc-port",
+		Usage: "Json rpc server port `<number>` of devnet node 1, the restful server uses the next port",
+		Value: DEFAULT_DEVNET_PORT,
+	}
+
+	//Relayer setting
+	RelayerSourceRpcFlag = cli.StringFlag{
+		Name:  "source-rpc",
+		Usage: "Json rpc `<address>` of source chain, such as http://127.0.0.1:20336",
+	}
+	RelayerTargetRpcFlag = cli.StringFlag{
+		Name:  "target-rpc",
+		Usage: "Json rpc `<address>` of target chain, such as http://127.0.0.1:30001",
+	}
+	RelayerFromChainIdFlag = cli.Uint64Flag{
+		Name:  "from-chain-id",
+		Usage: "Chain `<id>` of source chain registered in header sync contract of target chain",
+	}
+	RelayerCheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "Checkpoint `<file>` to record the next source height to relay",
+		Value: DEFAULT_RELAYER_CHECKPOINT,
+	}
+	RelayerStartHeightFlag = cli.UintFlag{
+		Name:  "start-height",
+		Usage: "Source `<height>` to start relaying if no checkpoint, default is the height after the synced headers",
+	}
+	RelayerIntervalFlag = cli.UintFlag{
+		Name:  "relay-interval",
+		Usage: "Interval `<time>`(s) to poll the source chain for new blocks",
+		Value: DEFAULT_RELAYER_INTERVAL,
+	}
+	RelayerMaxRetryIntervalFlag = cli.UintFlag{
+		Name:  "max-retry-interval",
+		Usage: "Max interval `<time>`(s) to retry after an error, the interval doubles on every error",
+		Value: DEFAULT_RELAYER_MAX_RETRY,
+	}
+	RelayerConfirmTimeoutFlag = cli.UintFlag{
+		Name:  "confirm-timeout",
+		Usage: "Timeout `<time>`(s) to wait for a relayed transaction to be executed",
+		Value: DEFAULT_RELAYER_CONFIRM_TIMEOUT,
+	}
+
 	//PreExecute switcher
 	TxpoolPreExecDisableFlag = cli.BoolFlag{
 		Name:  "disable-tx-pool-pre-exec",
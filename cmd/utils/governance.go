@@ -0,0 +1,247 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	cutils "github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const VERSION_CONTRACT_GOVERNANCE = byte(0)
+
+var peerStatusNames = map[governance.Status]string{
+	governance.RegisterCandidateStatus: "RegisterCandidate",
+	governance.CandidateStatus:         "Candidate",
+	governance.ConsensusStatus:         "Consensus",
+	governance.QuitConsensusStatus:     "QuitConsensus",
+	governance.QuitingStatus:           "Quiting",
+	governance.BlackStatus:             "Black",
+}
+
+//PeerPoolItem is the readable peer of governance peer pool
+type PeerPoolItem struct {
+	Index      uint32
+	PeerPubkey string
+	Address    string
+	Status     string
+	InitPos    uint64
+	TotalPos   uint64
+}
+
+//AuthorizeInfo is the readable pos of address authorized to peer
+type AuthorizeInfo struct {
+	PeerPubkey           string
+	Address              string
+	ConsensusPos         uint64
+	CandidatePos         uint64
+	NewPos               uint64
+	WithdrawConsensusPos uint64
+	WithdrawCandidatePos uint64
+	WithdrawUnfreezePos  uint64
+}
+
+//SplitFeeAddress is the readable ong of address split from governance fee
+type SplitFeeAddress struct {
+	Address string
+	Amount  string
+}
+
+//TotalStake is the readable total pos of address staked in governance contract
+type TotalStake struct {
+	Address    string
+	Stake      uint64
+	TimeOffset uint32
+}
+
+//GovernanceTx return the transaction which invokes method of governance contract with param
+func GovernanceTx(gasPrice, gasLimit uint64, method string, param interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.GovernanceContractAddress, VERSION_CONTRACT_GOVERNANCE,
+		method, []interface{}{param})
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
+
+//ParseUint32List parse the uint32 list separated by ','
+func ParseUint32List(list string) ([]uint32, error) {
+	var values []uint32
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(item, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number:%s", item)
+		}
+		values = append(values, uint32(value))
+	}
+	return values, nil
+}
+
+//ParsePeerPubkeyList parse the hex peer public keys separated by ','
+func ParsePeerPubkeyList(list string) ([]string, error) {
+	var pubkeys []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, err := hex.DecodeString(item); err != nil {
+			return nil, fmt.Errorf("invalid peer pubkey:%s", item)
+		}
+		pubkeys = append(pubkeys, item)
+	}
+	return pubkeys, nil
+}
+
+//getGovernanceStorage return the storage value of governance contract, nil is returned if the key is not found
+func getGovernanceStorage(key []byte) ([]byte, error) {
+	return GetStorage(utils.GovernanceContractAddress.ToHexString(), hex.EncodeToString(key))
+}
+
+func GetGovernanceView() (*governance.GovernanceView, error) {
+	data, err := getGovernanceStorage([]byte(governance.GOVERNANCE_VIEW))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("governance view not found")
+	}
+	view := new(governance.GovernanceView)
+	if err := view.Deserialize(bytes.NewBuffer(data)); err != nil {
+		return nil, fmt.Errorf("deserialize governance view error:%s", err)
+	}
+	return view, nil
+}
+
+//GetPeerPoolMap return the peers of view sorted by index
+func GetPeerPoolMap(view uint32) ([]*PeerPoolItem, error) {
+	data, err := getGovernanceStorage(append([]byte(governance.PEER_POOL), governance.GetUint32Bytes(view)...))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("peer pool of view %d not found", view)
+	}
+	return decodePeerPoolMap(data)
+}
+
+func decodePeerPoolMap(data []byte) ([]*PeerPoolItem, error) {
+	peerPoolMap := new(governance.PeerPoolMap)
+	if err := peerPoolMap.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, err
+	}
+	peers := make([]*PeerPoolItem, 0, len(peerPoolMap.PeerPoolMap))
+	for _, item := range peerPoolMap.PeerPoolMap {
+		status, ok := peerStatusNames[item.Status]
+		if !ok {
+			status = strconv.Itoa(int(item.Status))
+		}
+		peers = append(peers, &PeerPoolItem{
+			Index:      item.Index,
+			PeerPubkey: item.PeerPubkey,
+			Address:    item.Address.ToBase58(),
+			Status:     status,
+			InitPos:    item.InitPos,
+			TotalPos:   item.TotalPos,
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Index < peers[j].Index
+	})
+	return peers, nil
+}
+
+//GetAuthorizeInfo return the pos of address authorized to peer, all zero if it has never authorized
+func GetAuthorizeInfo(peerPubkey string, address common.Address) (*AuthorizeInfo, error) {
+	pubkey, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer pubkey:%s", peerPubkey)
+	}
+	key := append(append(append([]byte{}, governance.AUTHORIZE_INFO_POOL...), pubkey...), address[:]...)
+	data, err := getGovernanceStorage(key)
+	if err != nil {
+		return nil, err
+	}
+	info := &governance.AuthorizeInfo{PeerPubkey: peerPubkey, Address: address}
+	if data != nil {
+		if err := info.Deserialization(common.NewZeroCopySource(data)); err != nil {
+			return nil, fmt.Errorf("deserialize authorize info error:%s", err)
+		}
+	}
+	return &AuthorizeInfo{
+		PeerPubkey:           info.PeerPubkey,
+		Address:              info.Address.ToBase58(),
+		ConsensusPos:         info.ConsensusPos,
+		CandidatePos:         info.CandidatePos,
+		NewPos:               info.NewPos,
+		WithdrawConsensusPos: info.WithdrawConsensusPos,
+		WithdrawCandidatePos: info.WithdrawCandidatePos,
+		WithdrawUnfreezePos:  info.WithdrawUnfreezePos,
+	}, nil
+}
+
+//GetSplitFeeAddress return the ong of address split from governance fee which can be withdrawn
+func GetSplitFeeAddress(address common.Address) (*SplitFeeAddress, error) {
+	data, err := getGovernanceStorage(append([]byte(governance.SPLIT_FEE_ADDRESS), address[:]...))
+	if err != nil {
+		return nil, err
+	}
+	splitFee := &governance.SplitFeeAddress{Address: address}
+	if data != nil {
+		if err := splitFee.Deserialization(common.NewZeroCopySource(data)); err != nil {
+			return nil, fmt.Errorf("deserialize split fee address error:%s", err)
+		}
+	}
+	return &SplitFeeAddress{
+		Address: splitFee.Address.ToBase58(),
+		Amount:  FormatOng(splitFee.Amount),
+	}, nil
+}
+
+//GetTotalStake return the total pos of address staked in governance contract
+func GetTotalStake(address common.Address) (*TotalStake, error) {
+	data, err := getGovernanceStorage(append([]byte(governance.TOTAL_STAKE), address[:]...))
+	if err != nil {
+		return nil, err
+	}
+	totalStake := &governance.TotalStake{Address: address}
+	if data != nil {
+		if err := totalStake.Deserialization(common.NewZeroCopySource(data)); err != nil {
+			return nil, fmt.Errorf("deserialize total stake error:%s", err)
+		}
+	}
+	return &TotalStake{
+		Address:    totalStake.Address.ToBase58(),
+		Stake:      totalStake.Stake,
+		TimeOffset: totalStake.TimeOffset,
+	}, nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGovernanceList(t *testing.T) {
+	posList, err := ParseUint32List("100, 200,")
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{100, 200}, posList)
+	_, err = ParseUint32List("100,-1")
+	assert.NotNil(t, err)
+
+	pubkeys, err := ParsePeerPubkeyList("02ab,03cd")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"02ab", "03cd"}, pubkeys)
+	_, err = ParsePeerPubkeyList("02ab,xyz")
+	assert.NotNil(t, err)
+}
+
+func TestDecodePeerPoolMap(t *testing.T) {
+	peerPoolMap := &governance.PeerPoolMap{PeerPoolMap: map[string]*governance.PeerPoolItem{
+		"03cd": {Index: 2, PeerPubkey: "03cd", Status: governance.CandidateStatus, InitPos: 10000},
+		"02ab": {Index: 1, PeerPubkey: "02ab", Status: governance.ConsensusStatus, InitPos: 20000, TotalPos: 100},
+	}}
+	sink := common.NewZeroCopySink(nil)
+	assert.Nil(t, peerPoolMap.Serialization(sink))
+	peers, err := decodePeerPoolMap(sink.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(peers))
+	assert.Equal(t, "02ab", peers[0].PeerPubkey)
+	assert.Equal(t, "Consensus", peers[0].Status)
+	assert.Equal(t, uint64(100), peers[0].TotalPos)
+	assert.Equal(t, "Candidate", peers[1].Status)
+
+	tx, err := GovernanceTx(0, 20000, governance.AUTHORIZE_FOR_PEER, &governance.AuthorizeForPeerParam{
+		PeerPubkeyList: []string{"02ab"}, PosList: []uint32{100}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(20000), tx.GasLimit)
+}
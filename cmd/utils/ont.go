@@ -520,6 +520,58 @@ func GetBlock(hashOrHeight interface{}) ([]byte, error) {
 	return nil, ontErr.Error
 }
 
+//GetStorage return the storage value of contract, nil is returned if the key is not found
+func GetStorage(contract, key string) ([]byte, error) {
+	data, ontErr := sendRpcRequest("getstorage", []interface{}{contract, key})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal storage error:%s", err)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return hex.DecodeString(value)
+}
+
+//GetStorageDiff return the storage changes of contract between two heights in json
+func GetStorageDiff(contract string, fromHeight, toHeight uint32) ([]byte, error) {
+	data, ontErr := sendRpcRequest("getstoragediff", []interface{}{contract, fromHeight, toHeight})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	return data, nil
+}
+
+//GetStorageProof return the proof of contract storage key at height in json
+func GetStorageProof(contract, key string, height uint32) ([]byte, error) {
+	data, ontErr := sendRpcRequest("getstorageproof", []interface{}{contract, key, height})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	return data, nil
+}
+
+//GetRoleFuncs return the admin and the functions assigned to each role of contract in auth contract in json
+func GetRoleFuncs(contract string) ([]byte, error) {
+	data, ontErr := sendRpcRequest("getrolefuncs", []interface{}{contract})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	return data, nil
+}
+
+//GetOntIDTokens return the roles held by each ONT ID of contract in auth contract in json
+func GetOntIDTokens(contract string) ([]byte, error) {
+	data, ontErr := sendRpcRequest("getontidtokens", []interface{}{contract})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	return data, nil
+}
+
 func GetNetworkId() (uint32, error) {
 	data, ontErr := sendRpcRequest("getnetworkid", []interface{}{})
 	if ontErr != nil {
@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	cutils "github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/service/native/ontid"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const VERSION_CONTRACT_ONTID = byte(0)
+
+//OntIDAttribute is the attribute of ONT ID, such as {"key":"name","type":"string","value":"alice"}
+type OntIDAttribute struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+//OntIDTx return the transaction which invokes method of ONT ID contract with param
+func OntIDTx(gasPrice, gasLimit uint64, method string, param interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.OntIDContractAddress, VERSION_CONTRACT_ONTID,
+		method, []interface{}{param})
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
+
+//ParseOntIDKeyId parse the key id such as did:ont:xxx#keys-1 to the ONT ID and the key index
+func ParseOntIDKeyId(keyId string) (string, uint32, error) {
+	pos := strings.LastIndex(keyId, "#keys-")
+	if pos < 0 {
+		return "", 0, fmt.Errorf("invalid key id:%s", keyId)
+	}
+	id := keyId[:pos]
+	if !account.VerifyID(id) {
+		return "", 0, fmt.Errorf("invalid ONT ID:%s", id)
+	}
+	index, err := strconv.ParseUint(keyId[pos+len("#keys-"):], 10, 32)
+	if err != nil || index == 0 {
+		return "", 0, fmt.Errorf("invalid key index of key id:%s", keyId)
+	}
+	return id, uint32(index), nil
+}
+
+//ParseOntIDSigners parse the key ids of signers separated by ','
+func ParseOntIDSigners(list string) ([]ontid.Signer, error) {
+	var signers []ontid.Signer
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, index, err := ParseOntIDKeyId(item)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, ontid.Signer{Id: []byte(id), Index: index})
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no signer")
+	}
+	return signers, nil
+}
+
+//ParseOntIDGroup serialize the group in json, such as {"members":["did:ont:xxx",{"members":[...],"threshold":1}],"threshold":2}
+func ParseOntIDGroup(data string) ([]byte, error) {
+	group := new(ontid.GroupJson)
+	if err := json.Unmarshal([]byte(data), group); err != nil {
+		return nil, fmt.Errorf("invalid group json:%s", err)
+	}
+	return serializeOntIDGroup(group, 0)
+}
+
+func serializeOntIDGroup(group *ontid.GroupJson, depth int) ([]byte, error) {
+	if depth == ontid.MAX_DEPTH {
+		return nil, fmt.Errorf("group is too deep")
+	}
+	if len(group.Members) == 0 {
+		return nil, fmt.Errorf("group has no member")
+	}
+	if group.Threshold == 0 || group.Threshold > uint(len(group.Members)) {
+		return nil, fmt.Errorf("threshold should be from 1 to %d", len(group.Members))
+	}
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, uint64(len(group.Members)))
+	for _, member := range group.Members {
+		switch m := member.(type) {
+		case string:
+			if !account.VerifyID(m) {
+				return nil, fmt.Errorf("invalid ONT ID:%s", m)
+			}
+			sink.WriteVarBytes([]byte(m))
+		case map[string]interface{}:
+			raw, _ := json.Marshal(m)
+			sub := new(ontid.GroupJson)
+			if err := json.Unmarshal(raw, sub); err != nil {
+				return nil, fmt.Errorf("invalid sub group json:%s", err)
+			}
+			data, err := serializeOntIDGroup(sub, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			sink.WriteVarBytes(data)
+		default:
+			return nil, fmt.Errorf("invalid group member:%v", member)
+		}
+	}
+	utils.EncodeVarUint(sink, uint64(group.Threshold))
+	return sink.Bytes(), nil
+}
+
+//ParseOntIDAttributes parse the attributes in json array
+func ParseOntIDAttributes(data string) ([]OntIDAttribute, error) {
+	var attributes []OntIDAttribute
+	if err := json.Unmarshal([]byte(data), &attributes); err != nil {
+		return nil, fmt.Errorf("invalid attributes json:%s", err)
+	}
+	if len(attributes) == 0 {
+		return nil, fmt.Errorf("no attribute")
+	}
+	for _, attr := range attributes {
+		if attr.Key == "" {
+			return nil, fmt.Errorf("attribute key is empty")
+		}
+	}
+	return attributes, nil
+}
+
+//queryOntID return the result of ONT ID query method, nil is returned if the result is empty
+func queryOntID(method string, id string) ([]byte, error) {
+	preResult, err := PrepareInvokeNativeContract(utils.OntIDContractAddress, VERSION_CONTRACT_ONTID,
+		method, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if preResult.State == 0 {
+		return nil, fmt.Errorf("%s failed", method)
+	}
+	result, ok := preResult.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid result of %s", method)
+	}
+	data, err := hex.DecodeString(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result of %s:%s", method, err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+//GetOntIDDocument return the DID document of ONT ID in json
+func GetOntIDDocument(id string) ([]byte, error) {
+	return queryOntID("getDocumentJson", id)
+}
+
+//GetOntIDDDO return the serialized DDO of ONT ID
+func GetOntIDDDO(id string) ([]byte, error) {
+	return queryOntID("getDDO", id)
+}
+
+//GetOntIDKeyIndex return the index of the public key which is not revoked in ONT ID
+func GetOntIDKeyIndex(id string, pubkey string) (uint32, error) {
+	data, err := queryOntID("getPublicKeysJson", id)
+	if err != nil {
+		return 0, err
+	}
+	var keys []struct {
+		Id           string `json:"id"`
+		PublicKeyHex string `json:"publicKeyHex"`
+	}
+	if data != nil {
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return 0, fmt.Errorf("invalid public keys json:%s", err)
+		}
+	}
+	for _, key := range keys {
+		if strings.EqualFold(key.PublicKeyHex, pubkey) {
+			_, index, err := ParseOntIDKeyId(key.Id)
+			return index, err
+		}
+	}
+	return 0, fmt.Errorf("public key %s not found in %s", pubkey, id)
+}
+
+//IsOntIDGroupController return whether the controller of ONT ID is a group
+func IsOntIDGroupController(id string) (bool, error) {
+	data, err := queryOntID("getControllerJson", id)
+	if err != nil {
+		return false, err
+	}
+	var controller interface{}
+	if data != nil {
+		if err := json.Unmarshal(data, &controller); err != nil {
+			return false, fmt.Errorf("invalid controller json:%s", err)
+		}
+	}
+	switch controller.(type) {
+	case string:
+		return false, nil
+	case map[string]interface{}:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%s has no controller", id)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/ontid"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOntIDSigners(t *testing.T) {
+	id1, _ := account.GenerateID()
+	id2, _ := account.GenerateID()
+	id, index, err := ParseOntIDKeyId(id1 + "#keys-2")
+	assert.Nil(t, err)
+	assert.Equal(t, id1, id)
+	assert.Equal(t, uint32(2), index)
+	_, _, err = ParseOntIDKeyId(id1 + "#keys-0")
+	assert.NotNil(t, err)
+	_, _, err = ParseOntIDKeyId("did:ont:abc#keys-1")
+	assert.NotNil(t, err)
+
+	signers, err := ParseOntIDSigners(fmt.Sprintf("%s#keys-1, %s#keys-3,", id1, id2))
+	assert.Nil(t, err)
+	assert.Equal(t, []ontid.Signer{{Id: []byte(id1), Index: 1}, {Id: []byte(id2), Index: 3}}, signers)
+	_, err = ParseOntIDSigners(",")
+	assert.NotNil(t, err)
+}
+
+func TestParseOntIDGroup(t *testing.T) {
+	id1, _ := account.GenerateID()
+	id2, _ := account.GenerateID()
+	data, err := ParseOntIDGroup(fmt.Sprintf(`{"members":["%s",{"members":["%s"],"threshold":1}],"threshold":2}`,
+		id1, id2))
+	assert.Nil(t, err)
+
+	sub := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sub, 1)
+	sub.WriteVarBytes([]byte(id2))
+	utils.EncodeVarUint(sub, 1)
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, 2)
+	sink.WriteVarBytes([]byte(id1))
+	sink.WriteVarBytes(sub.Bytes())
+	utils.EncodeVarUint(sink, 2)
+	assert.Equal(t, sink.Bytes(), data)
+
+	_, err = ParseOntIDGroup(fmt.Sprintf(`{"members":["%s"],"threshold":2}`, id1))
+	assert.NotNil(t, err)
+	_, err = ParseOntIDGroup(`{"members":["did:ont:abc"],"threshold":1}`)
+	assert.NotNil(t, err)
+}
+
+func TestParseOntIDAttributes(t *testing.T) {
+	attributes, err := ParseOntIDAttributes(`[{"key":"name","type":"string","value":"alice"}]`)
+	assert.Nil(t, err)
+	assert.Equal(t, []OntIDAttribute{{Key: "name", Type: "string", Value: "alice"}}, attributes)
+	_, err = ParseOntIDAttributes(`[]`)
+	assert.NotNil(t, err)
+	_, err = ParseOntIDAttributes(`[{"type":"string","value":"alice"}]`)
+	assert.NotNil(t, err)
+}
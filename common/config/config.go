@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
@@ -44,6 +45,14 @@ const (
 	NoneVerifyMethod
 )
 
+type ExecMode int
+
+const (
+	SequentialExecMode ExecMode = iota
+	ParallelExecMode
+	CompareExecMode //execute in both modes and report the difference
+)
+
 const (
 	DEFAULT_CONFIG_FILE_NAME = "./config.json"
 	DEFAULT_WALLET_FILE_NAME = "./wallet.dat"
@@ -82,6 +91,7 @@ const (
 
 	DEFAULT_DATA_DIR      = "./Chain/"
 	DEFAULT_RESERVED_FILE = "./peers.rsv"
+	DEFAULT_DB_BACKEND    = "leveldb"
 
 	//DEFAULT_ETH_BLOCK_GAS_LIMIT = 800000000
 	DEFAULT_ETH_TX_MAX_GAS_LIMIT = 6000000
@@ -257,6 +267,65 @@ func GetTrackDestroyedContractHeight() uint32 {
 	}
 }
 
+func GetCrossVMCallHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_CROSS_VM_CALL_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_CROSS_VM_CALL_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetMultisigHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_MULTISIG_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_MULTISIG_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetSchedulerHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_SCHEDULER_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_SCHEDULER_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetLockProxyQuotaHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_LOCK_PROXY_QUOTA_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_LOCK_PROXY_QUOTA_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetStorageRootHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_STORAGE_ROOT_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_STORAGE_ROOT_POLARIS
+	default:
+		// it changes the cross states root of blocks, so it is activated only by the genesis config of the network
+		if DefConfig.Genesis.StorageRootHeight == 0 {
+			return math.MaxUint32
+		}
+		return DefConfig.Genesis.StorageRootHeight
+	}
+}
+
 // the end of unbound timestamp offset from genesis block's timestamp
 func GetGovUnboundDeadline() (uint32, uint64) {
 	count := uint64(0)
@@ -426,11 +495,12 @@ var MainNetConfig = &GenesisConfig{
 var DefConfig = NewOntologyConfig()
 
 type GenesisConfig struct {
-	SeedList      []string
-	ConsensusType string
-	VBFT          *VBFTConfig
-	DBFT          *DBFTConfig
-	SOLO          *SOLOConfig
+	SeedList          []string
+	ConsensusType     string
+	VBFT              *VBFTConfig
+	DBFT              *DBFTConfig
+	SOLO              *SOLOConfig
+	StorageRootHeight uint32 `json:",omitempty"` // height since which the storage trie root is committed, 0 if never
 }
 
 func NewGenesisConfig() *GenesisConfig {
@@ -631,6 +701,16 @@ type DBFTConfig struct {
 type SOLOConfig struct {
 	GenBlockTime uint
 	Bookkeepers  []string
+	InstantSeal  bool                `json:",omitempty"` // seal a block as soon as the tx pool has verified transactions
+	Prefunded    []*PrefundedAccount `json:",omitempty"` // accounts funded in genesis block of solo network
+	ForkRpc      string              `json:",omitempty"` // json rpc address of the node which states are forked from
+	Impersonated []string            `json:",omitempty"` // payers whose transactions need no signature
+}
+
+type PrefundedAccount struct {
+	Address string
+	ONT     uint64
+	ONG     uint64
 }
 
 type CommonConfig struct {
@@ -644,6 +724,8 @@ type CommonConfig struct {
 	ETHTxGasLimit  uint64
 	//NGasLimit        uint64
 	WasmVerifyMethod VerifyMethod
+	TxExecMode       ExecMode
+	DBBackend        string
 }
 
 type ConsensusConfig struct {
@@ -724,6 +806,7 @@ func NewOntologyConfig() *OntologyConfig {
 			MinGasLimit:      DEFAULT_MIN_GAS_LIMIT,
 			DataDir:          DEFAULT_DATA_DIR,
 			WasmVerifyMethod: InterpVerifyMethod,
+			DBBackend:        DEFAULT_DB_BACKEND,
 			ETHTxGasLimit:    DEFAULT_ETH_TX_MAX_GAS_LIMIT,
 		},
 		Consensus: &ConsensusConfig{
@@ -799,6 +882,38 @@ func (this *OntologyConfig) GetBookkeepers() ([]keypair.PublicKey, error) {
 	return pubKeys, nil
 }
 
+// GetPrefunded returns the accounts funded in genesis block of solo network,
+// the amount funded can not exceed the total supply of ONT and ONG
+func (this *OntologyConfig) GetPrefunded() ([]common.Address, []*PrefundedAccount, error) {
+	if this.Genesis.ConsensusType != CONSENSUS_TYPE_SOLO || this.Genesis.SOLO == nil {
+		return nil, nil, nil
+	}
+	var totalOnt, totalOng uint64
+	var overflow bool
+	addrs := make([]common.Address, 0, len(this.Genesis.SOLO.Prefunded))
+	funded := make(map[common.Address]bool)
+	for _, acct := range this.Genesis.SOLO.Prefunded {
+		addr, err := common.AddressFromBase58(acct.Address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid prefunded address %s: %s", acct.Address, err)
+		}
+		if funded[addr] {
+			return nil, nil, fmt.Errorf("duplicated prefunded address %s", acct.Address)
+		}
+		funded[addr] = true
+		totalOnt, overflow = common.SafeAdd(totalOnt, acct.ONT)
+		if overflow || totalOnt > constants.ONT_TOTAL_SUPPLY {
+			return nil, nil, fmt.Errorf("prefunded ONT exceeds total supply %d", constants.ONT_TOTAL_SUPPLY)
+		}
+		totalOng, overflow = common.SafeAdd(totalOng, acct.ONG)
+		if overflow || totalOng > constants.ONG_TOTAL_SUPPLY {
+			return nil, nil, fmt.Errorf("prefunded ONG exceeds total supply %d", constants.ONG_TOTAL_SUPPLY)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, this.Genesis.SOLO.Prefunded, nil
+}
+
 func (this *OntologyConfig) GetDefaultNetworkId() (uint32, error) {
 	defaultNetworkId, err := this.getDefNetworkIDFromGenesisConfig(this.Genesis)
 	if err != nil {
@@ -103,3 +103,23 @@ const BLOCKHEIGHT_NEW_PEER_COST_POLARIS = 13400000
 
 const BLOCKHEIGHT_TRACK_DESTROYED_CONTRACT_MAINNET = 11600000
 const BLOCKHEIGHT_TRACK_DESTROYED_CONTRACT_POLARIS = 14100000
+
+//cross vm call between evm and neovm/wasm contracts, not scheduled on public networks yet
+const BLOCKHEIGHT_CROSS_VM_CALL_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_CROSS_VM_CALL_POLARIS = 0xFFFFFFFF
+
+//multisig wallet native contract, not scheduled on public networks yet
+const BLOCKHEIGHT_MULTISIG_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_MULTISIG_POLARIS = 0xFFFFFFFF
+
+//scheduler native contract and deferred call execution, not scheduled on public networks yet
+const BLOCKHEIGHT_SCHEDULER_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_SCHEDULER_POLARIS = 0xFFFFFFFF
+
+//pause and rolling window quotas of lock proxy, not scheduled on public networks yet
+const BLOCKHEIGHT_LOCK_PROXY_QUOTA_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_LOCK_PROXY_QUOTA_POLARIS = 0xFFFFFFFF
+
+//storage trie root committed in the cross states of each block, not scheduled on public networks yet
+const BLOCKHEIGHT_STORAGE_ROOT_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_STORAGE_ROOT_POLARIS = 0xFFFFFFFF
@@ -23,6 +23,19 @@ import "github.com/ontio/ontology/core/types"
 type StartConsensus struct{}
 type StopConsensus struct{}
 
+//dev mode Message, only handled by solo consensus
+type MineBlocks struct {
+	Count uint32
+}
+type AdvanceTime struct {
+	Seconds uint32
+}
+type DevModeRsp struct {
+	Height    uint32
+	Timestamp uint32
+	Error     error
+}
+
 //internal Message
 type TimeOut struct{}
 type BlockCompleted struct {
@@ -189,7 +189,7 @@ func (ctx *ConsensusContext) GetStateDetail() string {
 
 }
 
-func (ctx *ConsensusContext) Reset(bkAccount *account.Account) {
+func (ctx *ConsensusContext) Reset(bkAccount account.Signer) {
 	preHash := ledger.DefLedger.GetCurrentBlockHash()
 	height := ledger.DefLedger.GetCurrentBlockHeight()
 	header := ctx.MakeHeader()
@@ -220,7 +220,7 @@ func (ctx *ConsensusContext) Reset(bkAccount *account.Account) {
 
 	log.Debugf("bookkeepers number: %d", bookkeeperLen)
 	for i := 0; i < bookkeeperLen; i++ {
-		if keypair.ComparePublicKey(bkAccount.PublicKey, ctx.Bookkeepers[i]) {
+		if keypair.ComparePublicKey(bkAccount.PubKey(), ctx.Bookkeepers[i]) {
 			log.Debugf("this node is bookkeeper %d", i)
 			ctx.BookkeeperIndex = i
 			ctx.Owner = ctx.Bookkeepers[i]
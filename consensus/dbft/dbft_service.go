@@ -44,7 +44,7 @@ import (
 
 type DbftService struct {
 	context           ConsensusContext
-	Account           *account.Account
+	Account           account.Signer
 	timer             *time.Timer
 	timerHeight       uint32
 	timeView          byte
@@ -59,7 +59,7 @@ type DbftService struct {
 	sub *events.ActorSubscriber
 }
 
-func NewDbftService(bkAccount *account.Account, txpool *actor.PID, p2p p2p.P2P) (*DbftService, error) {
+func NewDbftService(bkAccount account.Signer, txpool *actor.PID, p2p p2p.P2P) (*DbftService, error) {
 	service := &DbftService{
 		Account:       bkAccount,
 		timer:         time.NewTimer(time.Second * 15),
@@ -483,7 +483,7 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 		return
 	}
 
-	sig, err := signature.Sign(ds.Account, blockHash[:])
+	sig, err := ds.Account.Sign(blockHash[:])
 	if err != nil {
 		log.Error("[DbftService] signing failed")
 		return
@@ -601,7 +601,7 @@ func (ds *DbftService) RequestChangeView() {
 func (ds *DbftService) SignAndRelay(payload *p2pmsg.ConsensusPayload) {
 	sink := common.NewZeroCopySink(nil)
 	payload.SerializationUnsigned(sink)
-	payload.Signature, _ = signature.Sign(ds.Account, sink.Bytes())
+	payload.Signature, _ = ds.Account.Sign(sink.Bytes())
 
 	msg := msgpack.NewConsensus(payload)
 	ds.p2p.Broadcast(msg)
@@ -694,7 +694,7 @@ func (ds *DbftService) Timeout() {
 			//build block and sign
 			block := ds.context.MakeHeader()
 			blockHash := block.Hash()
-			ds.context.Signatures[ds.context.BookkeeperIndex], _ = signature.Sign(ds.Account, blockHash[:])
+			ds.context.Signatures[ds.context.BookkeeperIndex], _ = ds.Account.Sign(blockHash[:])
 		}
 		payload := ds.context.MakePrepareRequest()
 		ds.SignAndRelay(payload)
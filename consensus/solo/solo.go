@@ -20,6 +20,7 @@ package solo
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"time"
 
@@ -31,7 +32,6 @@ import (
 	"github.com/ontio/ontology/common/log"
 	actorTypes "github.com/ontio/ontology/consensus/actor"
 	"github.com/ontio/ontology/core/ledger"
-	"github.com/ontio/ontology/core/signature"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/events"
 	"github.com/ontio/ontology/events/message"
@@ -43,22 +43,34 @@ import (
  */
 const ContextVersion uint32 = 0
 
+const (
+	INSTANT_SEAL_CHECK_INTERVAL = 100 * time.Millisecond
+	MAX_MINE_BLOCKS             = 1000
+)
+
 type SoloService struct {
-	Account          *account.Account
+	Account          account.Signer
 	poolActor        *actorTypes.TxPoolActor
 	incrValidator    *increment.IncrementValidator
 	existCh          chan interface{}
 	genBlockInterval time.Duration
+	instantSeal      bool   // seal blocks only when there are transactions to pack
+	timeOffset       uint32 // seconds added to block timestamp by AdvanceTime
 	pid              *actor.PID
 	sub              *events.ActorSubscriber
 }
 
-func NewSoloService(bkAccount *account.Account, txpool *actor.PID) (*SoloService, error) {
+func NewSoloService(bkAccount account.Signer, txpool *actor.PID) (*SoloService, error) {
 	service := &SoloService{
 		Account:          bkAccount,
 		poolActor:        &actorTypes.TxPoolActor{Pool: txpool},
 		incrValidator:    increment.NewIncrementValidator(20),
 		genBlockInterval: time.Duration(config.DefConfig.Genesis.SOLO.GenBlockTime) * time.Second,
+		instantSeal:      config.DefConfig.Genesis.SOLO.InstantSeal,
+	}
+	if service.instantSeal {
+		service.genBlockInterval = INSTANT_SEAL_CHECK_INTERVAL
+		log.Infof("solo consensus instant seal enabled")
 	}
 
 	props := actor.FromProducer(func() actor.Actor {
@@ -115,13 +127,19 @@ func (self *SoloService) Receive(context actor.Context) {
 		}
 	case *message.SaveBlockCompleteMsg:
 		log.Infof("solo actor receives block complete event. block height=%d txnum=%d", msg.Block.Header.Height, len(msg.Block.Transactions))
-		self.incrValidator.AddBlock(msg.Block)
+		if _, end := self.incrValidator.BlockRange(); end == 0 || msg.Block.Header.Height >= end {
+			self.incrValidator.AddBlock(msg.Block)
+		}
 
 	case *actorTypes.TimeOut:
-		err := self.genBlock()
+		err := self.genBlock(!self.instantSeal)
 		if err != nil {
 			log.Errorf("Solo genBlock error %s", err)
 		}
+	case *actorTypes.MineBlocks:
+		context.Respond(self.mineBlocks(msg.Count))
+	case *actorTypes.AdvanceTime:
+		context.Respond(self.advanceTime(msg.Seconds))
 	default:
 		log.Info("solo actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
 	}
@@ -141,8 +159,50 @@ func (self *SoloService) Halt() error {
 	return nil
 }
 
-func (self *SoloService) genBlock() error {
-	block, err := self.makeBlock()
+func (self *SoloService) mineBlocks(count uint32) *actorTypes.DevModeRsp {
+	rsp := &actorTypes.DevModeRsp{}
+	if count > MAX_MINE_BLOCKS {
+		rsp.Error = fmt.Errorf("can not mine more than %d blocks at once", MAX_MINE_BLOCKS)
+	}
+	for i := uint32(0); i < count && rsp.Error == nil; i++ {
+		rsp.Error = self.genBlock(true)
+	}
+	rsp.Height = ledger.DefLedger.GetCurrentBlockHeight()
+	rsp.Timestamp = self.blockTimestamp()
+	return rsp
+}
+
+// advanceTime adds seconds to the timestamp of following blocks, the timestamp must still fit in uint32
+func (self *SoloService) advanceTime(seconds uint32) *actorTypes.DevModeRsp {
+	rsp := &actorTypes.DevModeRsp{}
+	if uint64(self.blockTimestamp())+uint64(seconds) > math.MaxUint32 {
+		rsp.Error = fmt.Errorf("can not advance %d seconds, block timestamp overflows", seconds)
+	} else {
+		self.timeOffset += seconds
+		log.Infof("solo block timestamp advanced %d seconds, total offset %d seconds", seconds, self.timeOffset)
+	}
+	rsp.Height = ledger.DefLedger.GetCurrentBlockHeight()
+	rsp.Timestamp = self.blockTimestamp()
+	return rsp
+}
+
+// blockTimestamp returns the timestamp of next block, which is always later than the current block
+func (self *SoloService) blockTimestamp() uint32 {
+	timestamp := uint32(time.Now().Unix()) + self.timeOffset
+	if header, err := ledger.DefLedger.GetHeaderByHeight(ledger.DefLedger.GetCurrentBlockHeight()); err == nil &&
+		header.Timestamp >= timestamp {
+		timestamp = header.Timestamp + 1
+	}
+	return timestamp
+}
+
+func (self *SoloService) genBlock(allowEmpty bool) error {
+	transactions := self.collectTransactions()
+	if len(transactions) == 0 && !allowEmpty {
+		return nil
+	}
+
+	block, err := self.makeBlock(transactions)
 	if err != nil {
 		return fmt.Errorf("makeBlock error %s", err)
 	}
@@ -152,38 +212,45 @@ func (self *SoloService) genBlock() error {
 		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
 	}
 
-	var msg *types.CrossChainMsg
-	if result.CrossStatesRoot != common.UINT256_EMPTY {
-		msg = &types.CrossChainMsg{
-			Version:    types.CURR_CROSS_STATES_VERSION,
-			Height:     block.Header.Height,
-			StatesRoot: result.CrossStatesRoot,
-		}
-		hash := msg.Hash()
-		sig, err := signature.Sign(self.Account, hash[:])
-		if err != nil {
-			return fmt.Errorf("[Signature],Sign error:%s.", err)
-		}
-		msg.SigData = [][]byte{sig}
+	// the cross states of previous block are signed with the block, as vbft does
+	msg, err := self.makeCrossChainMsg(block.Header.Height - 1)
+	if err != nil {
+		return err
 	}
 
 	err = ledger.DefLedger.SubmitBlock(block, msg, result)
 	if err != nil {
 		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
 	}
+	// blocks may be sealed before the save block complete event arrives
+	self.incrValidator.AddBlock(block)
 	return nil
 }
 
-func (self *SoloService) makeBlock() (*types.Block, error) {
-	log.Debug()
-	owner := self.Account.PublicKey
-	nextBookkeeper, err := types.AddressFromBookkeepers([]keypair.PublicKey{owner})
+func (self *SoloService) makeCrossChainMsg(height uint32) (*types.CrossChainMsg, error) {
+	root, err := ledger.DefLedger.GetCrossStatesRoot(height)
 	if err != nil {
-		return nil, fmt.Errorf("GetBookkeeperAddress error:%s", err)
+		return nil, fmt.Errorf("GetCrossStatesRoot Height:%d error:%s", height, err)
 	}
-	prevHash := ledger.DefLedger.GetCurrentBlockHash()
-	height := ledger.DefLedger.GetCurrentBlockHeight()
+	var msg *types.CrossChainMsg
+	if root != common.UINT256_EMPTY {
+		msg = &types.CrossChainMsg{
+			Version:    types.CURR_CROSS_STATES_VERSION,
+			Height:     height,
+			StatesRoot: root,
+		}
+		hash := msg.Hash()
+		sig, err := self.Account.Sign(hash[:])
+		if err != nil {
+			return nil, fmt.Errorf("[Signature],Sign error:%s.", err)
+		}
+		msg.SigData = [][]byte{sig}
+	}
+	return msg, nil
+}
 
+func (self *SoloService) collectTransactions() []*types.Transaction {
+	height := ledger.DefLedger.GetCurrentBlockHeight()
 	validHeight := height
 
 	start, end := self.incrValidator.BlockRange()
@@ -195,7 +262,7 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 		log.Infof("increment validator block height %v != ledger block height %v", int(end)-1, height)
 	}
 
-	log.Infof("current block height %v, increment validator block cache range: [%d, %d)", height, start, end)
+	log.Debugf("current block height %v, increment validator block cache range: [%d, %d)", height, start, end)
 
 	txs := self.poolActor.GetTxnPool(true, validHeight)
 
@@ -211,6 +278,18 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 		}
 
 	}
+	return transactions
+}
+
+func (self *SoloService) makeBlock(transactions []*types.Transaction) (*types.Block, error) {
+	log.Debug()
+	owner := self.Account.PubKey()
+	nextBookkeeper, err := types.AddressFromBookkeepers([]keypair.PublicKey{owner})
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeeperAddress error:%s", err)
+	}
+	prevHash := ledger.DefLedger.GetCurrentBlockHash()
+	height := ledger.DefLedger.GetCurrentBlockHeight()
 
 	txHash := []common.Uint256{}
 	for _, t := range transactions {
@@ -224,7 +303,7 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 		PrevBlockHash:    prevHash,
 		TransactionsRoot: txRoot,
 		BlockRoot:        blockRoot,
-		Timestamp:        uint32(time.Now().Unix()),
+		Timestamp:        self.blockTimestamp(),
 		Height:           height + 1,
 		ConsensusData:    common.GetNonce(),
 		NextBookkeeper:   nextBookkeeper,
@@ -236,7 +315,7 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 
 	blockHash := block.Hash()
 
-	sig, err := signature.Sign(self.Account, blockHash[:])
+	sig, err := self.Account.Sign(blockHash[:])
 	if err != nil {
 		return nil, fmt.Errorf("[Signature],Sign error:%s.", err)
 	}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package solo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/constants"
+	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	txpool "github.com/ontio/ontology/txnpool/common"
+	"github.com/ontio/ontology/validator/increment"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPool answers the transaction pool requests of solo with the pending transactions
+type testPool struct {
+	lock    sync.Mutex
+	pending []*types.Transaction
+}
+
+func (self *testPool) add(tx *types.Transaction) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.pending = append(self.pending, tx)
+}
+
+func (self *testPool) Receive(context actor.Context) {
+	if _, ok := context.Message().(*txpool.GetTxnPoolReq); ok {
+		self.lock.Lock()
+		defer self.lock.Unlock()
+		rsp := &txpool.GetTxnPoolRsp{}
+		for _, tx := range self.pending {
+			rsp.TxnPool = append(rsp.TxnPool, &txpool.VerifiedTx{Tx: tx})
+		}
+		self.pending = nil
+		context.Respond(rsp)
+	}
+}
+
+func newTestSolo(t *testing.T, instantSeal bool, prefunded []*config.PrefundedAccount) (*SoloService, *testPool, func()) {
+	dir, err := ioutil.TempDir("", "solo")
+	assert.Nil(t, err)
+	bookkeeper := account.NewAccount("")
+	genesisConfig := config.DefConfig.Genesis
+	config.DefConfig.Genesis = &config.GenesisConfig{
+		ConsensusType: config.CONSENSUS_TYPE_SOLO,
+		SOLO: &config.SOLOConfig{
+			GenBlockTime: config.DEFAULT_GEN_BLOCK_TIME,
+			Bookkeepers:  []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey))},
+			InstantSeal:  instantSeal,
+			Prefunded:    prefunded,
+		},
+		StorageRootHeight: 1,
+	}
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+
+	bookkeepers := []keypair.PublicKey{bookkeeper.PublicKey}
+	block, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	ledger.DefLedger, err = ledger.InitLedger(dir, 0, bookkeepers, block)
+	assert.Nil(t, err)
+
+	pool := &testPool{}
+	service := &SoloService{
+		Account:       bookkeeper,
+		poolActor:     &actorTypes.TxPoolActor{Pool: actor.Spawn(actor.FromInstance(pool))},
+		incrValidator: increment.NewIncrementValidator(20),
+		instantSeal:   instantSeal,
+	}
+	return service, pool, func() {
+		ledger.DefLedger.Close()
+		os.RemoveAll(dir)
+		config.DefConfig.Genesis = genesisConfig
+		config.DefConfig.P2PNode.NetworkId = networkId
+	}
+}
+
+func newTransferTx(t *testing.T, from *account.Account, to common.Address, value uint64) *types.Transaction {
+	states := []*ont.State{{From: from.Address, To: to, Value: value}}
+	code, err := utils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, ont.TRANSFER_NAME, []interface{}{states})
+	assert.Nil(t, err)
+	mutable := utils.NewInvokeTransaction(code)
+	mutable.GasLimit = 20000
+	mutable.Payer = from.Address
+	txHash := mutable.Hash()
+	sig, err := signature.Sign(from, txHash.ToArray())
+	assert.Nil(t, err)
+	mutable.Sigs = []types.Sig{{PubKeys: []keypair.PublicKey{from.PublicKey}, M: 1, SigData: [][]byte{sig}}}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func balance(t *testing.T, contract, address common.Address) uint64 {
+	value, err := ledger.DefLedger.GetStorageItem(contract, address[:])
+	assert.Nil(t, err)
+	if len(value) == 0 {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(value)
+}
+
+func TestInstantSeal(t *testing.T) {
+	from, to := account.NewAccount(""), account.NewAccount("")
+	service, pool, clean := newTestSolo(t, true, []*config.PrefundedAccount{{Address: from.Address.ToBase58(), ONT: 100}})
+	defer clean()
+
+	//no block is sealed without transactions
+	assert.Nil(t, service.genBlock(!service.instantSeal))
+	assert.Equal(t, uint32(0), ledger.DefLedger.GetCurrentBlockHeight())
+
+	pool.add(newTransferTx(t, from, to.Address, 10))
+	assert.Nil(t, service.genBlock(!service.instantSeal))
+	assert.Equal(t, uint32(1), ledger.DefLedger.GetCurrentBlockHeight())
+	block, err := ledger.DefLedger.GetBlockByHeight(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(block.Transactions))
+	assert.Equal(t, uint64(10), balance(t, nutils.OntContractAddress, to.Address))
+
+	//the storage root of block 1 is signed with block 2
+	pool.add(newTransferTx(t, from, to.Address, 1))
+	assert.Nil(t, service.genBlock(!service.instantSeal))
+	proof, err := ledger.DefLedger.GetStorageProof(nutils.OntContractAddress, to.Address[:], 1)
+	assert.Nil(t, err)
+	header, err := ledger.DefLedger.GetHeaderByHeight(2)
+	assert.Nil(t, err)
+	value, err := proof.VerifyWithHeader(header)
+	assert.Nil(t, err)
+	assert.NotNil(t, value)
+}
+
+func TestMineBlocksAndAdvanceTime(t *testing.T) {
+	service, _, clean := newTestSolo(t, true, nil)
+	defer clean()
+
+	rsp := service.mineBlocks(MAX_MINE_BLOCKS + 1)
+	assert.NotNil(t, rsp.Error)
+	assert.Equal(t, uint32(0), rsp.Height)
+
+	rsp = service.mineBlocks(3)
+	assert.Nil(t, rsp.Error)
+	assert.Equal(t, uint32(3), rsp.Height)
+
+	now := uint32(time.Now().Unix())
+	rsp = service.advanceTime(3600)
+	assert.Nil(t, rsp.Error)
+	rsp = service.mineBlocks(2)
+	assert.Nil(t, rsp.Error)
+	assert.Equal(t, uint32(5), rsp.Height)
+
+	//timestamps always increase, and jump after advancing time
+	var last uint32
+	for height := uint32(1); height <= 5; height++ {
+		header, err := ledger.DefLedger.GetHeaderByHeight(height)
+		assert.Nil(t, err)
+		assert.True(t, header.Timestamp > last)
+		if height == 4 {
+			assert.True(t, header.Timestamp >= now+3600)
+		}
+		last = header.Timestamp
+	}
+
+	rsp = service.advanceTime(math.MaxUint32)
+	assert.NotNil(t, rsp.Error)
+	assert.Equal(t, uint32(3600), service.timeOffset)
+}
+
+func TestPrefundedGenesis(t *testing.T) {
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	prefunded := []*config.PrefundedAccount{
+		{Address: accounts[0].Address.ToBase58(), ONT: 1000, ONG: 5000},
+		{Address: accounts[1].Address.ToBase58(), ONT: 0, ONG: 7000},
+	}
+	service, _, clean := newTestSolo(t, true, prefunded)
+	defer clean()
+
+	assert.Equal(t, uint64(1000), balance(t, nutils.OntContractAddress, accounts[0].Address))
+	assert.Equal(t, uint64(5000), balance(t, nutils.OngContractAddress, accounts[0].Address))
+	assert.Equal(t, uint64(0), balance(t, nutils.OntContractAddress, accounts[1].Address))
+	assert.Equal(t, uint64(7000), balance(t, nutils.OngContractAddress, accounts[1].Address))
+
+	//the rest of the supply goes to the usual holders, so the totals are unchanged
+	bookkeeper := types.AddressFromPubKey(service.Account.PubKey())
+	assert.Equal(t, uint64(constants.ONG_TOTAL_SUPPLY-12000), balance(t, nutils.OngContractAddress, bookkeeper))
+	var ontTotal uint64
+	for _, acct := range accounts {
+		ontTotal += balance(t, nutils.OntContractAddress, acct.Address)
+	}
+	ontTotal += balance(t, nutils.OntContractAddress, bookkeeper)
+	assert.Equal(t, uint64(constants.ONT_TOTAL_SUPPLY), ontTotal)
+}
@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	actorTypes "github.com/ontio/ontology/consensus/actor"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
@@ -89,12 +91,13 @@ type p2pMsgPayload struct {
 
 type Server struct {
 	Index         uint32
-	account       *account.Account
+	account       account.Signer
 	poolActor     *actorTypes.TxPoolActor
 	p2p           p2p.P2P
 	ledger        *ledger.Ledger
 	incrValidator *increment.IncrementValidator
 	pid           *actor.PID
+	signRecordDir string
 
 	// some config
 	msgHistoryDuration uint32
@@ -112,10 +115,11 @@ type Server struct {
 	config                   *vconfig.ChainConfig
 	currentParticipantConfig *BlockParticipantConfig
 
-	chainStore *ChainStore // block store
-	msgPool    *MsgPool    // consensus msg pool
-	blockPool  *BlockPool  // received block proposals
-	peerPool   *PeerPool   // consensus peers
+	chainStore *ChainStore   // block store
+	signRecord *SignRecordDB // signed consensus msgs
+	msgPool    *MsgPool      // consensus msg pool
+	blockPool  *BlockPool    // received block proposals
+	peerPool   *PeerPool     // consensus peers
 	syncer     *Syncer
 	stateMgr   *StateMgr
 	timer      *EventTimer
@@ -124,23 +128,15 @@ type Server struct {
 	msgC       chan ConsensusMsg
 	bftActionC chan *BftAction
 	msgSendC   chan *SendMsgEvent
-	sub        *events.ActorSubscriber
+	sub        *events.ActorSubscriber // nil if the save block events are not subscribed
 	quitC      chan struct{}
 	quit       bool
 	quitWg     sync.WaitGroup
 }
 
-func NewVbftServer(account *account.Account, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
-	server := &Server{
-		msgHistoryDuration: 64,
-		account:            account,
-		poolActor:          &actorTypes.TxPoolActor{Pool: txpool},
-		p2p:                p2p,
-		ledger:             ledger.DefLedger,
-		incrValidator:      increment.NewIncrementValidator(20),
-	}
-	server.stateMgr = newStateMgr(server)
-
+func NewVbftServer(account account.Signer, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
+	signRecordDir := filepath.Join(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName, SignRecordDir)
+	server := newVbftServer(account, txpool, p2p, ledger.DefLedger, signRecordDir)
 	props := actor.FromProducer(func() actor.Actor {
 		return server
 	})
@@ -158,6 +154,39 @@ func NewVbftServer(account *account.Account, txpool *actor.PID, p2p p2p.P2P) (*S
 	return server, nil
 }
 
+//NewCustomVbftServer create a vbft server of ld which does not use the process wide ledger, actor name and save block
+//events, so that several servers can run in one process. Only the blocks committed by the server itself are handled,
+//so it should be a consensus node. Unlike NewVbftServer, the server starts a new network whose peers are all at
+//block 0.
+func NewCustomVbftServer(account account.Signer, txpool *actor.PID, p2p p2p.P2P, ld *ledger.Ledger,
+	signRecordDir string) (*Server, error) {
+	server := newVbftServer(account, txpool, p2p, ld, signRecordDir)
+	server.stateMgr.genesisConsensused = true
+	server.pid = actor.Spawn(actor.FromProducer(func() actor.Actor {
+		return server
+	}))
+	if err := server.initialize(); err != nil {
+		server.pid.Stop()
+		return nil, fmt.Errorf("vbft server start failed: %s", err)
+	}
+	return server, nil
+}
+
+func newVbftServer(account account.Signer, txpool *actor.PID, p2p p2p.P2P, ld *ledger.Ledger,
+	signRecordDir string) *Server {
+	server := &Server{
+		msgHistoryDuration: 64,
+		account:            account,
+		poolActor:          &actorTypes.TxPoolActor{Pool: txpool},
+		p2p:                p2p,
+		ledger:             ld,
+		incrValidator:      increment.NewIncrementValidator(20),
+		signRecordDir:      signRecordDir,
+	}
+	server.stateMgr = newStateMgr(server)
+	return server
+}
+
 func (self *Server) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Restarting:
@@ -174,6 +203,9 @@ func (self *Server) Receive(context actor.Context) {
 		log.Info("vbft actor start consensus")
 	case *actorTypes.StopConsensus:
 		self.stop()
+		if context.Sender() != nil {
+			context.Respond(msg)
+		}
 	case *message.SaveBlockCompleteMsg:
 		log.Infof("vbft actor SaveBlockCompleteMsg receives block complete event. block height=%d, numtx=%d",
 			msg.Block.Header.Height, len(msg.Block.Transactions))
@@ -213,6 +245,11 @@ func (self *Server) handleBlockPersistCompleted(block *types.Block) {
 	}
 	self.SetCompletedBlockNum(block.Header.Height)
 	self.incrValidator.AddBlock(block)
+	if self.signRecord != nil {
+		if err := self.signRecord.Prune(block.Header.Height); err != nil {
+			log.Errorf("prune sign records below %d failed: %s", block.Header.Height, err)
+		}
+	}
 	if self.nonConsensusNode() {
 		self.blockPool.ReloadFromLedger()
 		if self.GetCommittedBlockNo() >= self.GetCurrentBlockNo() {
@@ -364,7 +401,7 @@ func (self *Server) updateChainConfig() error {
 	// . reset remove peer connections, create new connections with new peers
 	self.updateTimerParams(self.config)
 
-	pubkey := vconfig.PubkeyID(self.account.PublicKey)
+	pubkey := vconfig.PubkeyID(self.account.PubKey())
 	peermap := make(map[uint32]string)
 	for _, p := range self.GetChainConfig().Peers {
 		peermap[p.Index] = p.ID
@@ -423,7 +460,7 @@ func (self *Server) initialize() error {
 	// TODO: load config from chain
 
 	// TODO: configurable log
-	selfNodeId := vconfig.PubkeyID(self.account.PublicKey)
+	selfNodeId := vconfig.PubkeyID(self.account.PubKey())
 	log.Infof("server: %s starting", selfNodeId)
 
 	store, err := OpenBlockStore(self.ledger, self.pid)
@@ -434,6 +471,22 @@ func (self *Server) initialize() error {
 	self.chainStore = store
 	log.Info("block store opened")
 
+	self.signRecord, err = OpenSignRecordDB(self.signRecordDir)
+	if err != nil {
+		return fmt.Errorf("failed to open sign record db: %s", err)
+	}
+	signedHeight, err := self.signRecord.Check(selfNodeId)
+	if err != nil {
+		return fmt.Errorf("failed to check sign record db: %s", err)
+	}
+	log.Infof("sign record db opened, highest signed block: %d", signedHeight)
+	// the ledger lags the sealed block by one, and the next block may be signed after sealing
+	if chainHeight := self.ledger.GetCurrentBlockHeight(); signedHeight > chainHeight+2 {
+		log.Warnf("sign records are ahead of the chain, highest signed block %d, current block %d. "+
+			"the ledger may be restored from an old copy, or the bookkeeper key is used by another node. "+
+			"messages conflicting with the records will be refused", signedHeight, chainHeight)
+	}
+
 	self.blockPool, err = newBlockPool(self, self.msgHistoryDuration, store)
 	if err != nil {
 		log.Errorf("init blockpool: %s", err)
@@ -472,14 +525,16 @@ func (self *Server) initialize() error {
 	}
 
 	//index equal math.MaxUint32  is noconsensus node
-	id := vconfig.PubkeyID(self.account.PublicKey)
+	id := vconfig.PubkeyID(self.account.PubKey())
 	index, present := self.peerPool.GetPeerIndex(id)
 	if present {
 		self.Index = index
 	} else {
 		self.Index = math.MaxUint32
 	}
-	self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	if self.sub != nil {
+		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
 	go self.syncer.run()
 	go self.stateMgr.run()
 	go self.msgSendLoop()
@@ -512,7 +567,7 @@ func (self *Server) initialize() error {
 
 func (self *Server) start() error {
 	// check if server pubkey support VRF
-	if !vrf.ValidatePrivateKey(self.account.PrivateKey) || !vrf.ValidatePublicKey(self.account.PublicKey) {
+	if !vrf.ValidatePublicKey(self.account.PubKey()) {
 		return fmt.Errorf("server %d consensus start failed: invalid account key for VRF", self.Index)
 	}
 
@@ -539,7 +594,9 @@ func (self *Server) start() error {
 func (self *Server) stop() {
 
 	self.incrValidator.Clean()
-	self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	if self.sub != nil {
+		self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
 	// stop syncer, statemgr, msgSendLoop, timer, actionLoop, msgProcessingLoop
 	self.quit = true
 	close(self.quitC)
@@ -550,9 +607,22 @@ func (self *Server) stop() {
 	self.msgPool.clean()
 	self.blockPool.clean()
 	self.chainStore.close()
+	self.signRecord.Close()
 	self.peerPool.clean()
 }
 
+// recordSign refuse to sign the msg conflicting with the one signed before
+func (self *Server) recordSign(blkNum uint32, signType SignType, blkHash common.Uint256) error {
+	if self.signRecord == nil {
+		return nil
+	}
+	return self.signRecord.Record(&SignRecord{
+		Height:    blkNum,
+		Type:      signType,
+		BlockHash: blkHash,
+	})
+}
+
 //
 // go routine per net connection
 //
@@ -1948,6 +2018,13 @@ func (self *Server) endorseBlock(proposal *blockProposalMsg, forEmpty bool) erro
 	if err != nil {
 		return fmt.Errorf("failed to construct endorse msg: %s", err)
 	}
+	signType := SIGN_ENDORSE
+	if forEmpty {
+		signType = SIGN_ENDORSE_EMPTY
+	}
+	if err := self.recordSign(blkNum, signType, endorseMsg.EndorsedBlockHash); err != nil {
+		return err
+	}
 
 	// set the block as self-endorsed-block
 	if err := self.blockPool.setProposalEndorsed(proposal, forEmpty); err != nil {
@@ -2016,6 +2093,9 @@ func (self *Server) commitBlock(proposal *blockProposalMsg, forEmpty bool) error
 	if err != nil {
 		return fmt.Errorf("failed to construct commit msg: %s", err)
 	}
+	if err := self.recordSign(blkNum, SIGN_COMMIT, blkHash); err != nil {
+		return err
+	}
 
 	// set the block as committed-block
 	if err := self.blockPool.setProposalCommitted(proposal, forEmpty); err != nil {
@@ -2176,7 +2256,7 @@ func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 
 //checkUpdateChainConfig query leveldb check is force update
 func (self *Server) checkUpdateChainConfig(blkNum uint32) bool {
-	force, err := isUpdate(self.blockPool.getExecWriteSet(blkNum-1), self.GetChainConfig().View)
+	force, err := isUpdate(self.blockPool.getExecWriteSet(blkNum-1), self.ledger, self.GetChainConfig().View)
 	if err != nil {
 		log.Errorf("checkUpdateChainConfig err:%s", err)
 		return false
@@ -2224,7 +2304,7 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	//check need upate chainconfig
 	var cfg *vconfig.ChainConfig
 	if self.checkNeedUpdateChainConfig(blkNum) || self.checkUpdateChainConfig(blkNum) {
-		chainconfig, err := getChainConfig(self.blockPool.getExecWriteSet(blkNum-1), blkNum)
+		chainconfig, err := getChainConfig(self.blockPool.getExecWriteSet(blkNum-1), self.ledger, blkNum)
 		if err != nil {
 			return fmt.Errorf("getChainConfig failed:%s", err)
 		}
@@ -2258,6 +2338,9 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	if err != nil {
 		return fmt.Errorf("failed to construct proposal: %s", err)
 	}
+	if err := self.recordSign(blkNum, SIGN_PROPOSAL, proposal.Block.Block.Hash()); err != nil {
+		return err
+	}
 
 	log.Infof("server %d make proposal for block %d", self.Index, blkNum)
 
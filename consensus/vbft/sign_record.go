@@ -0,0 +1,329 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//SignRecordDir is the dir of sign record db in the ledger dir
+const SignRecordDir = "vbftsign"
+
+type SignType byte
+
+const (
+	SIGN_PROPOSAL      SignType = 1
+	SIGN_ENDORSE       SignType = 2
+	SIGN_ENDORSE_EMPTY SignType = 3 //endorsing the empty block after the proposal is endorsed is allowed
+	SIGN_COMMIT        SignType = 4
+)
+
+var signTypeNames = map[SignType]string{
+	SIGN_PROPOSAL:      "proposal",
+	SIGN_ENDORSE:       "endorse",
+	SIGN_ENDORSE_EMPTY: "endorseempty",
+	SIGN_COMMIT:        "commit",
+}
+
+func (self SignType) String() string {
+	if name, present := signTypeNames[self]; present {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", byte(self))
+}
+
+func parseSignType(name string) (SignType, error) {
+	for t, n := range signTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sign type %s", name)
+}
+
+const (
+	signRecordKeySigner  byte = 0x01
+	signRecordKeyRecord  byte = 0x02
+	signRecordKeyHighest byte = 0x03
+)
+
+//SignRecord is a consensus message signed by the node
+type SignRecord struct {
+	Height    uint32
+	Type      SignType
+	BlockHash common.Uint256
+}
+
+func (self *SignRecord) key() []byte {
+	key := make([]byte, 6)
+	key[0] = signRecordKeyRecord
+	binary.BigEndian.PutUint32(key[1:], self.Height)
+	key[5] = byte(self.Type)
+	return key
+}
+
+func parseSignRecord(key, value []byte) (*SignRecord, error) {
+	if len(key) != 6 || key[0] != signRecordKeyRecord {
+		return nil, fmt.Errorf("invalid sign record key %x", key)
+	}
+	hash, err := common.Uint256ParseFromBytes(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block hash of sign record %x: %s", key, err)
+	}
+	rec := &SignRecord{
+		Height:    binary.BigEndian.Uint32(key[1:]),
+		Type:      SignType(key[5]),
+		BlockHash: hash,
+	}
+	if _, present := signTypeNames[rec.Type]; !present {
+		return nil, fmt.Errorf("invalid sign type of sign record %x", key)
+	}
+	return rec, nil
+}
+
+//SignRecordDB keeps the consensus messages signed by the node, so a node sharing the bookkeeper key with another one,
+//e.g. after a misconfigured failover, does not sign a conflicting message of the same height and type.
+//The records are written synchronously before the message is sent, together with the highest signed height.
+//The records below the committed height are pruned, the highest signed height is kept.
+type SignRecordDB struct {
+	lock sync.Mutex
+	db   *leveldb.DB
+}
+
+//OpenSignRecordDB open or create the sign record db in dir
+func OpenSignRecordDB(dir string) (*SignRecordDB, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SignRecordDB{db: db}, nil
+}
+
+//Close the db
+func (self *SignRecordDB) Close() error {
+	return self.db.Close()
+}
+
+//Signer return the public key in hex of the records, empty if no signer is set
+func (self *SignRecordDB) Signer() (string, error) {
+	signer, err := self.db.Get([]byte{signRecordKeySigner}, nil)
+	if err == leveldb.ErrNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(signer), nil
+}
+
+// checkSigner set the signer of an empty db, the records of another signer are refused
+func (self *SignRecordDB) checkSigner(signer string) error {
+	curr, err := self.Signer()
+	if err != nil {
+		return err
+	}
+	if curr == "" {
+		return self.db.Put([]byte{signRecordKeySigner}, []byte(signer), &opt.WriteOptions{Sync: true})
+	}
+	if curr != signer {
+		return fmt.Errorf("sign records are signed by %s, not %s", curr, signer)
+	}
+	return nil
+}
+
+//Check is called on startup, it checks the signer and return the highest signed height
+func (self *SignRecordDB) Check(signer string) (uint32, error) {
+	if err := self.checkSigner(signer); err != nil {
+		return 0, err
+	}
+	return self.highest()
+}
+
+func (self *SignRecordDB) highest() (uint32, error) {
+	data, err := self.db.Get([]byte{signRecordKeyHighest}, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(data) != 4 {
+		return 0, fmt.Errorf("invalid highest signed height %x", data)
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
+
+// putHighest add the highest signed height to batch if height is higher than curr
+func putHighest(batch *leveldb.Batch, curr, height uint32) {
+	if height > curr {
+		data := make([]byte, 4)
+		binary.BigEndian.PutUint32(data, height)
+		batch.Put([]byte{signRecordKeyHighest}, data)
+	}
+}
+
+//Prune delete the records below height, which is the committed height
+func (self *SignRecordDB) Prune(height uint32) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	limit := (&SignRecord{Height: height}).key()
+	iter := self.db.NewIterator(&util.Range{Start: []byte{signRecordKeyRecord}, Limit: limit}, nil)
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return self.db.Write(batch, nil)
+}
+
+func (self *SignRecordDB) iterate(f func(rec *SignRecord) error) error {
+	iter := self.db.NewIterator(util.BytesPrefix([]byte{signRecordKeyRecord}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		rec, err := parseSignRecord(iter.Key(), iter.Value())
+		if err != nil {
+			return err
+		}
+		if err := f(rec); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+//Record save the message to be signed, the message conflicting with the recorded one is refused
+func (self *SignRecordDB) Record(rec *SignRecord) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	key := rec.key()
+	hash, err := self.db.Get(key, nil)
+	if err == nil {
+		if string(hash) != string(rec.BlockHash[:]) {
+			signed, _ := common.Uint256ParseFromBytes(hash)
+			return fmt.Errorf("refuse to sign %s of height %d for block %s, block %s has been signed",
+				rec.Type, rec.Height, rec.BlockHash.ToHexString(), signed.ToHexString())
+		}
+		return nil
+	} else if err != leveldb.ErrNotFound {
+		return err
+	}
+	highest, err := self.highest()
+	if err != nil {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	batch.Put(key, rec.BlockHash[:])
+	putHighest(batch, highest, rec.Height)
+	return self.db.Write(batch, &opt.WriteOptions{Sync: true})
+}
+
+type signRecordJson struct {
+	Height    uint32
+	Type      string
+	BlockHash string
+}
+
+type signRecordsJson struct {
+	Signer  string
+	Records []*signRecordJson
+}
+
+//Export write the signer and all the records to w in json
+func (self *SignRecordDB) Export(w io.Writer) (int, error) {
+	signer, err := self.Signer()
+	if err != nil {
+		return 0, err
+	}
+	records := &signRecordsJson{Signer: signer, Records: make([]*signRecordJson, 0)}
+	err = self.iterate(func(rec *SignRecord) error {
+		records.Records = append(records.Records, &signRecordJson{
+			Height:    rec.Height,
+			Type:      rec.Type.String(),
+			BlockHash: rec.BlockHash.ToHexString(),
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return len(records.Records), encoder.Encode(records)
+}
+
+//Import add the records exported by another node of the same signer. A record conflicting with the existing one is
+//skipped, since both of them are signed already, and the number of imported and conflicting records are returned
+func (self *SignRecordDB) Import(r io.Reader) (int, int, error) {
+	records := &signRecordsJson{}
+	if err := json.NewDecoder(r).Decode(records); err != nil {
+		return 0, 0, fmt.Errorf("decode sign records error: %s", err)
+	}
+	if records.Signer == "" {
+		return 0, 0, fmt.Errorf("signer of sign records is empty")
+	}
+	if err := self.checkSigner(records.Signer); err != nil {
+		return 0, 0, err
+	}
+	highest, err := self.highest()
+	if err != nil {
+		return 0, 0, err
+	}
+	batch := new(leveldb.Batch)
+	imported, conflicts, maxHeight := 0, 0, uint32(0)
+	for _, r := range records.Records {
+		signType, err := parseSignType(r.Type)
+		if err != nil {
+			return 0, 0, err
+		}
+		hash, err := common.Uint256FromHexString(r.BlockHash)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid block hash %s: %s", r.BlockHash, err)
+		}
+		rec := &SignRecord{Height: r.Height, Type: signType, BlockHash: hash}
+		curr, err := self.db.Get(rec.key(), nil)
+		if err == nil {
+			if string(curr) != string(hash[:]) {
+				conflicts++
+			}
+			continue
+		} else if err != leveldb.ErrNotFound {
+			return 0, 0, err
+		}
+		batch.Put(rec.key(), hash[:])
+		imported++
+		if rec.Height > maxHeight {
+			maxHeight = rec.Height
+		}
+	}
+	putHighest(batch, highest, maxHeight)
+	return imported, conflicts, self.db.Write(batch, &opt.WriteOptions{Sync: true})
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignRecordDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signrecord")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := OpenSignRecordDB(filepath.Join(dir, "a"))
+	assert.Nil(t, err)
+	height, err := db.Check("signer1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), height)
+
+	hash1 := common.Uint256{1}
+	hash2 := common.Uint256{2}
+	assert.Nil(t, db.Record(&SignRecord{Height: 10, Type: SIGN_PROPOSAL, BlockHash: hash1}))
+	// signing the same msg again is allowed
+	assert.Nil(t, db.Record(&SignRecord{Height: 10, Type: SIGN_PROPOSAL, BlockHash: hash1}))
+	assert.NotNil(t, db.Record(&SignRecord{Height: 10, Type: SIGN_PROPOSAL, BlockHash: hash2}))
+	// endorsing the empty block after endorsing the block
+	assert.Nil(t, db.Record(&SignRecord{Height: 10, Type: SIGN_ENDORSE, BlockHash: hash1}))
+	assert.Nil(t, db.Record(&SignRecord{Height: 10, Type: SIGN_ENDORSE_EMPTY, BlockHash: hash2}))
+	assert.Nil(t, db.Record(&SignRecord{Height: 11, Type: SIGN_COMMIT, BlockHash: hash2}))
+
+	height, err = db.Check("signer1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(11), height)
+	_, err = db.Check("signer2")
+	assert.NotNil(t, err)
+
+	buf := &bytes.Buffer{}
+	count, err := db.Export(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, count)
+	exported := buf.Bytes()
+
+	// the records are moved to another node
+	other, err := OpenSignRecordDB(filepath.Join(dir, "b"))
+	assert.Nil(t, err)
+	_, err = other.Check("signer2")
+	assert.Nil(t, err)
+	_, _, err = other.Import(bytes.NewReader(exported))
+	assert.NotNil(t, err)
+	assert.Nil(t, other.Close())
+
+	other, err = OpenSignRecordDB(filepath.Join(dir, "c"))
+	assert.Nil(t, err)
+	assert.Nil(t, other.Record(&SignRecord{Height: 11, Type: SIGN_COMMIT, BlockHash: hash1}))
+	imported, conflicts, err := other.Import(bytes.NewReader(exported))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, imported)
+	assert.Equal(t, 1, conflicts)
+	assert.NotNil(t, other.Record(&SignRecord{Height: 10, Type: SIGN_PROPOSAL, BlockHash: hash2}))
+	assert.Nil(t, other.Record(&SignRecord{Height: 10, Type: SIGN_ENDORSE_EMPTY, BlockHash: hash2}))
+	signer, err := other.Signer()
+	assert.Nil(t, err)
+	assert.Equal(t, "signer1", signer)
+	assert.Nil(t, other.Close())
+
+	// the records below the committed height are pruned, the highest signed height is kept
+	assert.Nil(t, db.Prune(11))
+	buf.Reset()
+	count, err = db.Export(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	height, err = db.Check("signer1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(11), height)
+	assert.NotNil(t, db.Record(&SignRecord{Height: 11, Type: SIGN_COMMIT, BlockHash: hash1}))
+	assert.Nil(t, db.Close())
+
+	other, err = OpenSignRecordDB(filepath.Join(dir, "c"))
+	assert.Nil(t, err)
+	height, err = other.Check("signer1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(11), height)
+	assert.Nil(t, other.Close())
+}
@@ -89,6 +89,8 @@ type StateMgr struct {
 	currentState     ServerState
 	StateEventC      chan *StateEvent
 	peers            map[uint32]*PeerState
+	// treat all peers at block 0 as consensused, so that a new network can start
+	genesisConsensused bool
 
 	liveTicker             *time.Timer
 	lastTickChainHeight    uint32
@@ -441,7 +443,7 @@ func (self *StateMgr) getConsensusedCommittedBlockNum() (uint32, bool) {
 
 	for _, p := range self.peers {
 		n := p.committedBlockNum
-		if n >= myCommitted && n > maxCommitted {
+		if n >= myCommitted && (n > maxCommitted || self.genesisConsensused && n == 0) {
 
 			peerCount := 0
 			for _, k := range self.peers {
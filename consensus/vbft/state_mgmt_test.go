@@ -233,6 +233,21 @@ func TestStateMgr_getConsensusedCommittedBlockNum(t *testing.T) {
 	t.Logf("TestgetConsensusedCommittedBlockNum maxcommitted:%v, consensused:%v", maxcomit, flag)
 }
 
+func TestStateMgr_getConsensusedCommittedBlockNum_genesis(t *testing.T) {
+	statemgr := newStateMgr(constructServer())
+	statemgr.server.chainStore.chainedBlockNum = 0
+	for i := uint32(0); i < 4; i++ {
+		statemgr.peers[i] = &PeerState{peerIdx: i, committedBlockNum: 0, connected: true}
+	}
+	if _, consensused := statemgr.getConsensusedCommittedBlockNum(); consensused {
+		t.Errorf("peers at block 0 should not be consensused by default")
+	}
+	statemgr.genesisConsensused = true
+	if committed, consensused := statemgr.getConsensusedCommittedBlockNum(); committed != 0 || !consensused {
+		t.Errorf("getConsensusedCommittedBlockNum() = %d, %v, want 0, true", committed, consensused)
+	}
+}
+
 func TestStateMgr_getConsensusedCommittedBlockNum_contrived(t *testing.T) {
 
 	f := func() (uint32, bool) {
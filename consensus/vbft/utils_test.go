@@ -90,7 +90,7 @@ func TestVrf(t *testing.T) {
 	user := account.NewAccount("")
 	prevVrf := []byte("test string")
 	blkNum := uint32(10)
-	v1, p1, err := computeVrf(user.PrivKey(), blkNum, prevVrf)
+	v1, p1, err := computeVrf(user, blkNum, prevVrf)
 	if err != nil {
 		t.Fatalf("compute vrf: %s", err)
 	}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+//Package credential verifies the W3C verifiable credentials, in JSON-LD or JWT, issued by ONT ID
+package credential
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FORMAT_JSON_LD = "json-ld"
+	FORMAT_JWT     = "jwt"
+)
+
+//CredentialStatus refers to the attest contract where the credential is committed or revoked
+type CredentialStatus struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type Proof struct {
+	Type               string `json:"type,omitempty"`
+	Created            string `json:"created,omitempty"`
+	Challenge          string `json:"challenge,omitempty"`
+	Domain             string `json:"domain,omitempty"`
+	ProofPurpose       string `json:"proofPurpose,omitempty"`
+	VerificationMethod string `json:"verificationMethod,omitempty"`
+	Hex                string `json:"hex,omitempty"`
+}
+
+//VerifiableCredential is the credential in JSON-LD, the message signed is the json of it without proof.hex, the
+//fields are marshaled in the order below
+type VerifiableCredential struct {
+	Context           json.RawMessage   `json:"@context,omitempty"`
+	Id                string            `json:"id,omitempty"`
+	Type              json.RawMessage   `json:"type,omitempty"`
+	Issuer            json.RawMessage   `json:"issuer,omitempty"`
+	IssuanceDate      string            `json:"issuanceDate,omitempty"`
+	ExpirationDate    string            `json:"expirationDate,omitempty"`
+	CredentialSubject json.RawMessage   `json:"credentialSubject,omitempty"`
+	CredentialStatus  *CredentialStatus `json:"credentialStatus,omitempty"`
+	Proof             *Proof            `json:"proof,omitempty"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ,omitempty"`
+}
+
+type jwtPayload struct {
+	Iss string                `json:"iss"`
+	Sub string                `json:"sub,omitempty"`
+	Jti string                `json:"jti,omitempty"`
+	Nbf int64                 `json:"nbf,omitempty"`
+	Iat int64                 `json:"iat,omitempty"`
+	Exp int64                 `json:"exp,omitempty"`
+	VC  *VerifiableCredential `json:"vc,omitempty"`
+}
+
+//credential is the parts of JSON-LD or JWT credential to verify
+type credential struct {
+	format         string
+	id             string
+	issuer         string
+	keyID          string
+	issuanceDate   time.Time
+	expirationDate time.Time
+	status         *CredentialStatus
+	msg            []byte
+	sig            []byte
+}
+
+//parse the credential in JSON-LD if it is a json object, otherwise in JWT
+func parse(data string) (*credential, error) {
+	data = strings.TrimSpace(data)
+	if strings.HasPrefix(data, "{") {
+		return parseJSONLD([]byte(data))
+	}
+	return parseJWT(data)
+}
+
+func parseJSONLD(data []byte) (*credential, error) {
+	vc := new(VerifiableCredential)
+	if err := json.Unmarshal(data, vc); err != nil {
+		return nil, fmt.Errorf("invalid credential: %s", err)
+	}
+	if vc.Proof == nil || vc.Proof.Hex == "" {
+		return nil, errors.New("credential has no proof")
+	}
+	issuer, err := parseIssuer(vc.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	issuanceDate, err := time.Parse(time.RFC3339, vc.IssuanceDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuanceDate %s", vc.IssuanceDate)
+	}
+	cred := &credential{
+		format:       FORMAT_JSON_LD,
+		id:           vc.Id,
+		issuer:       issuer,
+		keyID:        vc.Proof.VerificationMethod,
+		issuanceDate: issuanceDate,
+		status:       vc.CredentialStatus,
+	}
+	if vc.ExpirationDate != "" {
+		if cred.expirationDate, err = time.Parse(time.RFC3339, vc.ExpirationDate); err != nil {
+			return nil, fmt.Errorf("invalid expirationDate %s", vc.ExpirationDate)
+		}
+	}
+	if cred.sig, err = hex.DecodeString(vc.Proof.Hex); err != nil {
+		return nil, fmt.Errorf("invalid proof hex: %s", err)
+	}
+	proof := *vc.Proof
+	proof.Hex = ""
+	vc.Proof = &proof
+	if cred.msg, err = json.Marshal(vc); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+func parseJWT(data string) (*credential, error) {
+	parts := strings.Split(data, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid credential: neither json-ld nor jwt")
+	}
+	header, payload := new(jwtHeader), new(jwtPayload)
+	if err := decodeJWTPart(parts[0], header); err != nil {
+		return nil, fmt.Errorf("invalid jwt header: %s", err)
+	}
+	if err := decodeJWTPart(parts[1], payload); err != nil {
+		return nil, fmt.Errorf("invalid jwt payload: %s", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt signature: %s", err)
+	}
+	cred := &credential{
+		format: FORMAT_JWT,
+		id:     payload.Jti,
+		issuer: payload.Iss,
+		keyID:  header.Kid,
+		msg:    []byte(parts[0] + "." + parts[1]),
+		sig:    sig,
+	}
+	switch {
+	case payload.Nbf != 0:
+		cred.issuanceDate = time.Unix(payload.Nbf, 0)
+	case payload.Iat != 0:
+		cred.issuanceDate = time.Unix(payload.Iat, 0)
+	default:
+		return nil, errors.New("jwt has neither nbf nor iat")
+	}
+	if payload.Exp != 0 {
+		cred.expirationDate = time.Unix(payload.Exp, 0)
+	}
+	if payload.VC != nil {
+		if cred.id == "" {
+			cred.id = payload.VC.Id
+		}
+		cred.status = payload.VC.CredentialStatus
+	}
+	return cred, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+//parseIssuer return the issuer which is either the ONT ID or the object with ONT ID as id
+func parseIssuer(data json.RawMessage) (string, error) {
+	var issuer string
+	if err := json.Unmarshal(data, &issuer); err == nil {
+		return issuer, nil
+	}
+	obj := new(struct {
+		Id string `json:"id"`
+	})
+	if err := json.Unmarshal(data, obj); err != nil || obj.Id == "" {
+		return "", fmt.Errorf("invalid issuer %s", string(data))
+	}
+	return obj.Id, nil
+}
+
+//parseKeyID return the ONT ID and the key index of key id did:ont:xxx#keys-n
+func parseKeyID(keyID string) (string, uint32, error) {
+	i := strings.LastIndex(keyID, "#keys-")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid key id %s", keyID)
+	}
+	index, err := strconv.ParseUint(keyID[i+len("#keys-"):], 10, 32)
+	if err != nil || index == 0 {
+		return "", 0, fmt.Errorf("invalid key id %s", keyID)
+	}
+	return keyID[:i], uint32(index), nil
+}
@@ -0,0 +1,247 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package credential
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	sstates "github.com/ontio/ontology/smartcontract/states"
+)
+
+//the status of credential in the attest contract referred by credentialStatus
+const (
+	STATUS_TYPE_ATTEST_CONTRACT = "AttestContract"
+	ATTEST_GET_STATUS           = "GetStatus"
+
+	STATUS_ATTESTED     = "attested"
+	STATUS_REVOKED      = "revoked"
+	STATUS_NOT_ATTESTED = "not attested"
+)
+
+var attestStatus = map[string]string{"01": STATUS_ATTESTED, "00": STATUS_REVOKED}
+
+//Ledger pre-executes the read only invocations which the credential is verified with, the node implements it with
+//its ledger and the client could implement it with rpc
+type Ledger interface {
+	//PreExecute run the transactions in the current states
+	PreExecute(txes []*types.Transaction) ([]*sstates.PreExecResult, error)
+}
+
+//Result is the outcome of verification, Error is the reason if the credential is not valid
+type Result struct {
+	Valid          bool
+	Format         string
+	Id             string
+	Issuer         string
+	KeyId          string
+	IssuanceDate   string
+	ExpirationDate string
+	Status         string
+	Error          string
+}
+
+type Verifier struct {
+	ledger Ledger
+}
+
+func NewVerifier(ledger Ledger) *Verifier {
+	return &Verifier{ledger: ledger}
+}
+
+//Verify check the signature, the issuer key, the expiry and the attest status of credential at time now. The error is
+//returned only if the states could not be read, the invalid credential is told by Result
+func (this *Verifier) Verify(data string, now time.Time) (*Result, error) {
+	cred, err := parse(data)
+	if err != nil {
+		return &Result{Error: err.Error()}, nil
+	}
+	result := &Result{
+		Format:       cred.format,
+		Id:           cred.id,
+		Issuer:       cred.issuer,
+		KeyId:        cred.keyID,
+		IssuanceDate: cred.issuanceDate.UTC().Format(time.RFC3339),
+	}
+	if !cred.expirationDate.IsZero() {
+		result.ExpirationDate = cred.expirationDate.UTC().Format(time.RFC3339)
+	}
+	invalid := func(format string, a ...interface{}) (*Result, error) {
+		result.Error = fmt.Sprintf(format, a...)
+		return result, nil
+	}
+
+	if now.Before(cred.issuanceDate) {
+		return invalid("credential is issued in the future")
+	}
+	if !cred.expirationDate.IsZero() && !now.Before(cred.expirationDate) {
+		return invalid("credential is expired")
+	}
+	ontID, index, err := parseKeyID(cred.keyID)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	if !account.VerifyID(ontID) {
+		return invalid("invalid ONT ID %s", ontID)
+	}
+	if ontID != cred.issuer {
+		return invalid("key %s is not the key of issuer %s", cred.keyID, cred.issuer)
+	}
+	var contract common.Address
+	if cred.status != nil {
+		if cred.status.Type != STATUS_TYPE_ATTEST_CONTRACT {
+			return invalid("unsupported credentialStatus type %s", cred.status.Type)
+		}
+		if contract, err = common.AddressFromHexString(cred.status.Id); err != nil {
+			return invalid("invalid attest contract %s", cred.status.Id)
+		}
+	}
+
+	pk, reason, err := this.getIssuerKey(ontID, index)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return invalid("%s", reason)
+	}
+	if err := signature.Verify(pk, cred.msg, cred.sig); err != nil {
+		return invalid("%s", err)
+	}
+	if cred.status != nil {
+		if result.Status, err = this.getStatus(contract, cred.id); err != nil {
+			result.Status = STATUS_NOT_ATTESTED
+			return invalid("GetStatus of attest contract %s failed: %s", cred.status.Id, err)
+		}
+		if result.Status != STATUS_ATTESTED {
+			return invalid("credential is %s in attest contract %s", result.Status, cred.status.Id)
+		}
+	}
+	result.Valid = true
+	return result, nil
+}
+
+//keyParam is the param of getPublicKeyByIndex
+type keyParam struct {
+	OntID []byte
+	Index uint64
+}
+
+//getIssuerKey return the key of ONT ID by index if it is in use now. The issuance time is claimed by the issuer, so the
+//revoked key is not usable for any credential, otherwise whoever gets a revoked key could issue backdated credentials.
+//The reason is returned if the key is not usable
+func (this *Verifier) getIssuerKey(ontID string, index uint32) (keypair.PublicKey, string, error) {
+	code, err := utils.BuildNativeInvokeCode(nutils.OntIDContractAddress, 0, "getPublicKeyByIndex",
+		[]interface{}{&keyParam{OntID: []byte(ontID), Index: uint64(index)}})
+	if err != nil {
+		return nil, "", err
+	}
+	tx, err := utils.NewInvokeTransaction(code).IntoImmutable()
+	if err != nil {
+		return nil, "", err
+	}
+	results, err := this.ledger.PreExecute([]*types.Transaction{tx})
+	if err != nil {
+		return nil, "", err
+	}
+	pk, err := keyInUse(results)
+	if err != nil {
+		return nil, "", err
+	}
+	if pk == nil {
+		return nil, fmt.Sprintf("key %s#keys-%d is revoked or not exist", ontID, index), nil
+	}
+	return pk, "", nil
+}
+
+//keyInUse return the key from the result of getPublicKeyByIndex, nil if the ONT ID is not valid or the key is revoked or
+//not exist
+func keyInUse(results []*sstates.PreExecResult) (keypair.PublicKey, error) {
+	data, err := resultBytes(results, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(data[0]) == 0 {
+		return nil, nil
+	}
+	source := common.NewZeroCopySource(data[0])
+	key, _, irregular, eof := source.NextVarBytes()
+	revoked, irregular2, eof2 := source.NextBool()
+	if irregular || eof || irregular2 || eof2 {
+		return nil, fmt.Errorf("invalid public key: %x", data[0])
+	}
+	if revoked {
+		return nil, nil
+	}
+	return keypair.DeserializePublicKey(key)
+}
+
+//getStatus return the status of credential by invoking GetStatus of attest contract
+func (this *Verifier) getStatus(contract common.Address, credentialID string) (string, error) {
+	code, err := utils.BuildNeoVMInvokeCode(contract, []interface{}{ATTEST_GET_STATUS, []interface{}{credentialID}})
+	if err != nil {
+		return "", err
+	}
+	tx, err := utils.NewInvokeTransaction(code).IntoImmutable()
+	if err != nil {
+		return "", err
+	}
+	results, err := this.ledger.PreExecute([]*types.Transaction{tx})
+	if err != nil {
+		return "", err
+	}
+	data, err := resultBytes(results, 1)
+	if err != nil {
+		return "", err
+	}
+	if status, ok := attestStatus[hex.EncodeToString(data[0])]; ok {
+		return status, nil
+	}
+	return STATUS_NOT_ATTESTED, nil
+}
+
+func resultBytes(results []*sstates.PreExecResult, n int) ([][]byte, error) {
+	if len(results) != n {
+		return nil, fmt.Errorf("expect %d results, got %d", n, len(results))
+	}
+	data := make([][]byte, 0, n)
+	for _, result := range results {
+		if result.State == 0 {
+			return nil, fmt.Errorf("pre-execution failed")
+		}
+		str, ok := result.Result.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result %v", result.Result)
+		}
+		value, err := hex.DecodeString(str)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, value)
+	}
+	return data, nil
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package credential
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	sstates "github.com/ontio/ontology/smartcontract/states"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAttestContract = "36bb5c053b6b839c8f6b923fe852f91239b9fccc"
+
+//mockLedger return the key of getPublicKeyByIndex and the status of GetStatus
+type mockLedger struct {
+	key       []byte
+	status    []byte
+	statusErr error
+}
+
+func (this *mockLedger) results(txes []*types.Transaction, key []byte) []*sstates.PreExecResult {
+	results := make([]*sstates.PreExecResult, 0, len(txes))
+	for _, tx := range txes {
+		value := this.status
+		if bytes.Contains(tx.Payload.(*payload.InvokeCode).Code, []byte("getPublicKeyByIndex")) {
+			value = key
+		}
+		results = append(results, &sstates.PreExecResult{State: 1, Result: hex.EncodeToString(value)})
+	}
+	return results
+}
+
+func (this *mockLedger) PreExecute(txes []*types.Transaction) ([]*sstates.PreExecResult, error) {
+	if this.statusErr != nil && !bytes.Contains(txes[0].Payload.(*payload.InvokeCode).Code, []byte("getPublicKeyByIndex")) {
+		return nil, this.statusErr
+	}
+	return this.results(txes, this.key), nil
+}
+
+func keyResult(acct *account.Account, revoked bool) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(keypair.SerializePublicKey(acct.PublicKey))
+	sink.WriteBool(revoked)
+	return sink.Bytes()
+}
+
+func newJSONLDCredential(t *testing.T, acct *account.Account, issuer string, issuanceDate, expirationDate time.Time) string {
+	vc := &VerifiableCredential{
+		Context:           json.RawMessage(`["https://www.w3.org/2018/credentials/v1"]`),
+		Id:                "urn:uuid:a5b6b8a1-3c33-4bd3-9a36-2e2f7b5b4d1c",
+		Type:              json.RawMessage(`["VerifiableCredential"]`),
+		Issuer:            json.RawMessage(`{"id":"` + issuer + `","name":"kyc"}`),
+		IssuanceDate:      issuanceDate.UTC().Format(time.RFC3339),
+		ExpirationDate:    expirationDate.UTC().Format(time.RFC3339),
+		CredentialSubject: json.RawMessage(`{"id":"did:ont:AJ4C9aTYxTGUhEpaZdPjFSqCqzMCqJDRUd","level":"3"}`),
+		CredentialStatus:  &CredentialStatus{Id: testAttestContract, Type: STATUS_TYPE_ATTEST_CONTRACT},
+		Proof: &Proof{
+			Type:               "EcdsaSecp256r1Signature2019",
+			Created:            issuanceDate.UTC().Format(time.RFC3339),
+			ProofPurpose:       "assertionMethod",
+			VerificationMethod: issuer + "#keys-1",
+		},
+	}
+	msg, err := json.Marshal(vc)
+	assert.Nil(t, err)
+	sig, err := signature.Sign(acct, msg)
+	assert.Nil(t, err)
+	vc.Proof.Hex = hex.EncodeToString(sig)
+	data, err := json.Marshal(vc)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func newJWTCredential(t *testing.T, acct *account.Account, issuer string, issuanceDate, expirationDate time.Time) string {
+	header, err := json.Marshal(&jwtHeader{Alg: "ES256", Kid: issuer + "#keys-1", Typ: "JWT"})
+	assert.Nil(t, err)
+	payload, err := json.Marshal(&jwtPayload{
+		Iss: issuer,
+		Sub: "did:ont:AJ4C9aTYxTGUhEpaZdPjFSqCqzMCqJDRUd",
+		Jti: "urn:uuid:a5b6b8a1-3c33-4bd3-9a36-2e2f7b5b4d1c",
+		Nbf: issuanceDate.Unix(),
+		Exp: expirationDate.Unix(),
+		VC:  &VerifiableCredential{CredentialStatus: &CredentialStatus{Id: testAttestContract, Type: STATUS_TYPE_ATTEST_CONTRACT}},
+	})
+	assert.Nil(t, err)
+	msg := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := signature.Sign(acct, []byte(msg))
+	assert.Nil(t, err)
+	return msg + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	acct := account.NewAccount("")
+	other := account.NewAccount("")
+	issuer := "did:ont:" + acct.Address.ToBase58()
+	now := time.Now()
+	issuanceDate, expirationDate := now.Add(-time.Hour), now.Add(time.Hour)
+
+	for _, newCredential := range []func(*testing.T, *account.Account, string, time.Time, time.Time) string{
+		newJSONLDCredential, newJWTCredential,
+	} {
+		cred := newCredential(t, acct, issuer, issuanceDate, expirationDate)
+		ledger := &mockLedger{key: keyResult(acct, false), status: []byte{1}}
+		verifier := NewVerifier(ledger)
+		result, err := verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.True(t, result.Valid, result.Error)
+		assert.Equal(t, issuer, result.Issuer)
+		assert.Equal(t, issuer+"#keys-1", result.KeyId)
+		assert.Equal(t, STATUS_ATTESTED, result.Status)
+
+		// expired or not issued yet
+		result, err = verifier.Verify(cred, expirationDate)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		result, err = verifier.Verify(cred, issuanceDate.Add(-time.Second))
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+
+		// revoked in attest contract
+		ledger.status = []byte{0}
+		result, err = verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, STATUS_REVOKED, result.Status)
+		ledger.status = nil
+		result, err = verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, STATUS_NOT_ATTESTED, result.Status)
+		ledger.statusErr = errors.New("the given contract does not exist")
+		result, err = verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, STATUS_NOT_ATTESTED, result.Status)
+		ledger.status, ledger.statusErr = []byte{1}, nil
+
+		// the revoked key is not usable even if the credential is backdated before the revocation
+		backdated := newCredential(t, acct, issuer, now.AddDate(-1, 0, 0), expirationDate)
+		result, err = verifier.Verify(backdated, now)
+		assert.Nil(t, err)
+		assert.True(t, result.Valid, result.Error)
+		ledger.key = keyResult(acct, true)
+		result, err = verifier.Verify(backdated, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Error, "revoked")
+		result, err = verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		ledger.key = nil
+		result, err = verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+
+		// signed by other key
+		ledger.key = keyResult(other, false)
+		result, err = verifier.Verify(cred, now)
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		ledger.key = keyResult(acct, false)
+	}
+
+	// tampered JSON-LD credential
+	cred := newJSONLDCredential(t, acct, issuer, issuanceDate, expirationDate)
+	cred = strings.Replace(cred, `"level":"3"`, `"level":"4"`, 1)
+	result, err := NewVerifier(&mockLedger{key: keyResult(acct, false), status: []byte{1}}).Verify(cred, now)
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+
+	// the key is not the key of issuer
+	cred = newJSONLDCredential(t, acct, "did:ont:"+other.Address.ToBase58(), issuanceDate, expirationDate)
+	cred = strings.Replace(cred, "did:ont:"+other.Address.ToBase58()+"#keys-1", issuer+"#keys-1", 1)
+	result, err = NewVerifier(&mockLedger{key: keyResult(acct, false), status: []byte{1}}).Verify(cred, now)
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+
+	result, err = NewVerifier(&mockLedger{}).Verify("not a credential", now)
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+}
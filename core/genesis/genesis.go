@@ -215,10 +215,21 @@ func newGoverningInit() *types.Transaction {
 		addr = temp
 	}
 
-	distribute := []struct {
+	prefundAddrs, prefunded, err := config.DefConfig.GetPrefunded()
+	if err != nil {
+		panic(fmt.Sprint("wrong prefunded accounts config, caused by", err))
+	}
+	type part struct {
 		addr  common.Address
 		value uint64
-	}{{addr, constants.ONT_TOTAL_SUPPLY}}
+	}
+	distribute := make([]part, 0, len(prefunded)+1)
+	remain := constants.ONT_TOTAL_SUPPLY
+	for i, acct := range prefunded {
+		distribute = append(distribute, part{prefundAddrs[i], acct.ONT})
+		remain -= acct.ONT
+	}
+	distribute = append(distribute, part{addr, remain})
 
 	args := common.NewZeroCopySink(nil)
 	nutils.EncodeVarUint(args, uint64(len(distribute)))
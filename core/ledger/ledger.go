@@ -23,6 +23,7 @@ import (
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/forkstore"
 	"github.com/ontio/ontology/core/store/ledgerstore"
 	"github.com/ontio/ontology/core/types"
 )
@@ -43,8 +44,33 @@ func InitLedger(dataDir string, stateHashHeight uint32, defaultBookkeeper []keyp
 	if err != nil {
 		return nil, fmt.Errorf("NewLedgerStore error %s", err)
 	}
+	return initLedgerStore(ldgStore, defaultBookkeeper, genesisBlock)
+}
+
+//InitForkLedger init a ledger whose states are fetched from remote on demand, the states
+//of genesis block are not initialized
+func InitForkLedger(dataDir string, stateHashHeight uint32, defaultBookkeeper []keypair.PublicKey,
+	genesisBlock *types.Block, remote forkstore.RemoteStorage) (*Ledger, error) {
+	ldgStore, err := ledgerstore.NewForkLedgerStore(dataDir, stateHashHeight, remote)
+	if err != nil {
+		return nil, fmt.Errorf("NewForkLedgerStore error %s", err)
+	}
+	return initLedgerStore(ldgStore, defaultBookkeeper, genesisBlock)
+}
 
-	err = ldgStore.InitLedgerStoreWithGenesisBlock(genesisBlock, defaultBookkeeper)
+//InitLightLedger init a ledger which only saves the verified headers, the blocks are fetched from remotes on demand
+func InitLightLedger(dataDir string, defaultBookkeeper []keypair.PublicKey, genesisBlock *types.Block,
+	remotes []ledgerstore.LightRemote) (*Ledger, error) {
+	ldgStore, err := ledgerstore.NewLightLedgerStore(dataDir, remotes)
+	if err != nil {
+		return nil, fmt.Errorf("NewLightLedgerStore error %s", err)
+	}
+	return initLedgerStore(ldgStore, defaultBookkeeper, genesisBlock)
+}
+
+func initLedgerStore(ldgStore *ledgerstore.LedgerStoreImp, defaultBookkeeper []keypair.PublicKey,
+	genesisBlock *types.Block) (*Ledger, error) {
+	err := ldgStore.InitLedgerStoreWithGenesisBlock(genesisBlock, defaultBookkeeper)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltdbstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ontio/ontology/core/store/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DBFileName = "bolt.db" //The file of store in the store dir
+	// the number of key-value pairs an iterator loads in one read transaction
+	ITERATOR_CHUNK_SIZE = 1024
+)
+
+var dataBucket = []byte("data")
+
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+//BoltDB store
+type BoltDBStore struct {
+	db    *bolt.DB // BoltDB instance
+	batch []batchOp
+}
+
+//NewBoltDBStore return BoltDBStore instance, the data is saved in DBFileName of dir
+func NewBoltDBStore(dir string) (*BoltDBStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(dir, DBFileName), 0644, &bolt.Options{
+		Timeout:        time.Second,
+		NoFreelistSync: true,
+		FreelistType:   bolt.FreelistMapType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(dataBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDBStore{
+		db: db,
+	}, nil
+}
+
+//NewReadOnlyBoltDBStore return BoltDBStore instance of an existing store in dir which can not be written
+func NewReadOnlyBoltDBStore(dir string) (*BoltDBStore, error) {
+	db, err := bolt.Open(filepath.Join(dir, DBFileName), 0644, &bolt.Options{
+		Timeout:  time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(dataBucket) == nil {
+			return fmt.Errorf("bucket %s is not found", dataBucket)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDBStore{
+		db: db,
+	}, nil
+}
+
+//Put a key-value pair to boltdb
+func (self *BoltDBStore) Put(key []byte, value []byte) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(dataBucket).Put(key, value)
+	})
+}
+
+//Get the value of a key from boltdb
+func (self *BoltDBStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := self.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(dataBucket).Get(key)
+		if v == nil {
+			return common.ErrNotFound
+		}
+		// the value is valid in the transaction only
+		value = append([]byte{}, v...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+//Has return whether the key is exist in boltdb
+func (self *BoltDBStore) Has(key []byte) (bool, error) {
+	var has bool
+	err := self.db.View(func(tx *bolt.Tx) error {
+		has = tx.Bucket(dataBucket).Get(key) != nil
+		return nil
+	})
+	return has, err
+}
+
+//Delete the key in boltdb
+func (self *BoltDBStore) Delete(key []byte) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(dataBucket).Delete(key)
+	})
+}
+
+//NewBatch start commit batch
+func (self *BoltDBStore) NewBatch() {
+	self.batch = make([]batchOp, 0)
+}
+
+//BatchPut put a key-value pair to boltdb batch
+func (self *BoltDBStore) BatchPut(key []byte, value []byte) {
+	self.batch = append(self.batch, batchOp{key: append([]byte{}, key...), value: append([]byte{}, value...)})
+}
+
+//BatchDelete delete a key to boltdb batch
+func (self *BoltDBStore) BatchDelete(key []byte) {
+	self.batch = append(self.batch, batchOp{key: append([]byte{}, key...), delete: true})
+}
+
+//BatchCommit commit batch to boltdb in one transaction
+func (self *BoltDBStore) BatchCommit() error {
+	err := self.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(dataBucket)
+		for _, op := range self.batch {
+			var err error
+			if op.delete {
+				err = bucket.Delete(op.key)
+			} else {
+				err = bucket.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	self.batch = nil
+	return nil
+}
+
+//Close boltdb
+func (self *BoltDBStore) Close() error {
+	return self.db.Close()
+}
+
+//NewIterator return a iterator of boltdb with the key prefix. The pairs are loaded in chunks with short read
+//transactions, since a long one blocks the remapping of data file by the writes of the same goroutine, so the
+//iterator does not see a snapshot of the store: keys written after the current position may be returned.
+func (self *BoltDBStore) NewIterator(prefix []byte) common.StoreIterator {
+	return &Iterator{
+		db:     self.db,
+		prefix: append([]byte{}, prefix...),
+	}
+}
+
+//Iterator of BoltDBStore
+type Iterator struct {
+	db      *bolt.DB
+	prefix  []byte
+	keys    [][]byte
+	values  [][]byte
+	pos     int
+	started bool
+	end     bool
+	err     error
+}
+
+//First move to the first pair with the prefix
+func (self *Iterator) First() bool {
+	self.started = false
+	self.end = false
+	self.keys = nil
+	self.values = nil
+	return self.Next()
+}
+
+//Next move to the next pair with the prefix
+func (self *Iterator) Next() bool {
+	if self.err != nil || self.end {
+		return false
+	}
+	if !self.started {
+		self.started = true
+		self.load(self.prefix, false)
+	} else {
+		self.pos++
+		if self.pos >= len(self.keys) && len(self.keys) == ITERATOR_CHUNK_SIZE {
+			self.load(self.keys[len(self.keys)-1], true)
+		}
+	}
+	if self.err != nil || self.pos >= len(self.keys) {
+		self.end = true
+		self.keys = nil
+		self.values = nil
+		return false
+	}
+	return true
+}
+
+// load the next chunk from the seek key, the key itself is skipped if it's the last key of previous chunk
+func (self *Iterator) load(seek []byte, skip bool) {
+	self.keys = self.keys[:0]
+	self.values = self.values[:0]
+	self.pos = 0
+	self.err = self.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(dataBucket).Cursor()
+		k, v := cursor.Seek(seek)
+		if skip && k != nil && bytes.Equal(k, seek) {
+			k, v = cursor.Next()
+		}
+		for ; k != nil && bytes.HasPrefix(k, self.prefix) && len(self.keys) < ITERATOR_CHUNK_SIZE; k, v = cursor.Next() {
+			self.keys = append(self.keys, append([]byte{}, k...))
+			self.values = append(self.values, append([]byte{}, v...))
+		}
+		return nil
+	})
+}
+
+//Key return the key of current pair
+func (self *Iterator) Key() []byte {
+	if self.pos >= len(self.keys) {
+		return nil
+	}
+	return self.keys[self.pos]
+}
+
+//Value return the value of current pair
+func (self *Iterator) Value() []byte {
+	if self.pos >= len(self.values) {
+		return nil
+	}
+	return self.values[self.pos]
+}
+
+//Release the iterator
+func (self *Iterator) Release() {
+	self.end = true
+	self.keys = nil
+	self.values = nil
+}
+
+//Error return the error of loading pairs
+func (self *Iterator) Error() error {
+	return self.err
+}
@@ -18,6 +18,8 @@
 
 package common
 
+import "fmt"
+
 // DataEntryPrefix
 type DataEntryPrefix byte
 
@@ -27,6 +29,9 @@ const (
 	DATA_HEADER                            = 0x01 //Block hash => block header+txhashes key prefix
 	DATA_TRANSACTION                       = 0x02 //Transction hash => transaction key prefix
 	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
+	DATA_STATE_REVERSE_WRITE_SET           = 0x26 // block height => previous values of the states changed by the block
+	DATA_STATE_WRITE_SET                   = 0x27 // block height => states changed by the block, a deleted state has empty value
+	DATA_STORAGE_TRIE_ROOT                 = 0x28 // block height => root hash of storage trie after the block
 
 	// Transaction
 	ST_BOOKKEEPER DataEntryPrefix = 0x03 //BookKeeper state key prefix
@@ -38,6 +43,9 @@ const (
 	ST_ETH_CODE    DataEntryPrefix = 0x30 // eth contract code:hash -> bytes
 	ST_ETH_ACCOUNT DataEntryPrefix = 0x31 // eth account: address -> [nonce, codeHash]
 
+	// storage trie of contracts for storage proof
+	ST_STORAGE_TRIE DataEntryPrefix = 0x32 // trie node hash -> rlp encoded node
+
 	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 //Block height => block hash key prefix
 
 	//SYSTEM
@@ -47,8 +55,50 @@ const (
 	SYS_BLOCK_MERKLE_TREE    DataEntryPrefix = 0x13 // Block merkle tree root key prefix
 	SYS_STATE_MERKLE_TREE    DataEntryPrefix = 0x20 // state merkle tree root key prefix
 	SYS_CROSS_CHAIN_MSG      DataEntryPrefix = 0x22 // state merkle tree root key prefix
+	SYS_FORK_HEIGHT          DataEntryPrefix = 0x23 // height of the remote state a forked ledger is based on
+	SYS_FORK_FETCHED         DataEntryPrefix = 0x24 // state key => mark that the key is fetched from remote or written locally
+	SYS_STATE_GC             DataEntryPrefix = 0x25 // destroyed contract address => whether its storage is deleted by state gc
+	SYS_FORK_ITERATED        DataEntryPrefix = 0x29 // state key prefix => mark that the remote states of prefix are fetched
 
 	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
 
 	DATA_BLOCK_PRUNE_HEIGHT DataEntryPrefix = 0x80 //  last pruned block height, genesis block can not be pruned
 )
+
+var dataEntryPrefixNames = map[DataEntryPrefix]string{
+	DATA_BLOCK_HASH:              "DATA_BLOCK_HASH",
+	DATA_HEADER:                  "DATA_HEADER",
+	DATA_TRANSACTION:             "DATA_TRANSACTION",
+	DATA_STATE_MERKLE_ROOT:       "DATA_STATE_MERKLE_ROOT",
+	DATA_STATE_REVERSE_WRITE_SET: "DATA_STATE_REVERSE_WRITE_SET",
+	DATA_STATE_WRITE_SET:         "DATA_STATE_WRITE_SET",
+	DATA_STORAGE_TRIE_ROOT:       "DATA_STORAGE_TRIE_ROOT",
+	ST_BOOKKEEPER:                "ST_BOOKKEEPER",
+	ST_CONTRACT:                  "ST_CONTRACT",
+	ST_STORAGE:                   "ST_STORAGE",
+	ST_DESTROYED:                 "ST_DESTROYED",
+	ST_ETH_CODE:                  "ST_ETH_CODE",
+	ST_ETH_ACCOUNT:               "ST_ETH_ACCOUNT",
+	ST_STORAGE_TRIE:              "ST_STORAGE_TRIE",
+	IX_HEADER_HASH_LIST:          "IX_HEADER_HASH_LIST",
+	SYS_CURRENT_BLOCK:            "SYS_CURRENT_BLOCK",
+	SYS_VERSION:                  "SYS_VERSION",
+	SYS_CURRENT_CROSS_STATES:     "SYS_CURRENT_CROSS_STATES",
+	SYS_BLOCK_MERKLE_TREE:        "SYS_BLOCK_MERKLE_TREE",
+	SYS_STATE_MERKLE_TREE:        "SYS_STATE_MERKLE_TREE",
+	SYS_CROSS_CHAIN_MSG:          "SYS_CROSS_CHAIN_MSG",
+	SYS_FORK_HEIGHT:              "SYS_FORK_HEIGHT",
+	SYS_FORK_FETCHED:             "SYS_FORK_FETCHED",
+	SYS_STATE_GC:                 "SYS_STATE_GC",
+	SYS_FORK_ITERATED:            "SYS_FORK_ITERATED",
+	EVENT_NOTIFY:                 "EVENT_NOTIFY",
+	DATA_BLOCK_PRUNE_HEIGHT:      "DATA_BLOCK_PRUNE_HEIGHT",
+}
+
+//String return the name of prefix, or its hex value if unknown
+func (self DataEntryPrefix) String() string {
+	if name, ok := dataEntryPrefixNames[self]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(self))
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package forkstore
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+)
+
+//RemoteStorage is the reference node which forked state is fetched from
+type RemoteStorage interface {
+	//GetState return the raw value of contract state key after the block of height, nil if not exist
+	GetState(key []byte, height uint32) ([]byte, error)
+	//FindStates return the raw contract states whose keys start with prefix after the block of height
+	FindStates(prefix []byte, height uint32) ([]*store.StateItem, error)
+	//GetCurrentBlockHeight return the height of remote state
+	GetCurrentBlockHeight() (uint32, error)
+}
+
+//ForkStore is a PersistStore backed by the state of a remote node. Contract state keys missing locally are
+//fetched on demand at the fork height and cached in the local store, other keys are local only. Iterating a
+//prefix inside a contract fetches all remote states of the prefix first, iterating wider prefixes of contract
+//states is not supported.
+type ForkStore struct {
+	scom.PersistStore //local store
+	remote            RemoteStorage
+	height            uint32
+	lock              sync.Mutex
+}
+
+//NewForkStore return a store forked from remote, a new local store is pinned to the current remote height
+func NewForkStore(local scom.PersistStore, remote RemoteStorage) (*ForkStore, error) {
+	store := &ForkStore{
+		PersistStore: local,
+		remote:       remote,
+	}
+	val, err := local.Get([]byte{byte(scom.SYS_FORK_HEIGHT)})
+	if err == nil {
+		store.height, err = common.NewZeroCopySource(val).ReadUint32()
+		if err != nil {
+			return nil, fmt.Errorf("read fork height error: %s", err)
+		}
+		return store, nil
+	}
+	if err != scom.ErrNotFound {
+		return nil, err
+	}
+	has, err := local.Has([]byte{byte(scom.SYS_CURRENT_BLOCK)})
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return nil, fmt.Errorf("local store is not forked from remote")
+	}
+	store.height, err = remote.GetCurrentBlockHeight()
+	if err != nil {
+		return nil, fmt.Errorf("get remote height error: %s", err)
+	}
+	if err = store.putHeight(); err != nil {
+		return nil, err
+	}
+	log.Infof("fork state from remote at height %d", store.height)
+	return store, nil
+}
+
+//Height return the remote height which the store is forked at
+func (self *ForkStore) Height() uint32 {
+	return self.height
+}
+
+func (self *ForkStore) putHeight() error {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(self.height)
+	return self.PersistStore.Put([]byte{byte(scom.SYS_FORK_HEIGHT)}, sink.Bytes())
+}
+
+//IsRemoteKey return whether the key is a contract state which is fetched from remote, like the contract,
+//storage, destroyed contract and eth account and code. It is also used to check a prefix inside a contract.
+func IsRemoteKey(key []byte) bool {
+	if len(key) < 1+common.ADDR_LEN {
+		return false
+	}
+	return isRemotePrefix(key[0])
+}
+
+func isRemotePrefix(prefix byte) bool {
+	switch scom.DataEntryPrefix(prefix) {
+	case scom.ST_CONTRACT, scom.ST_STORAGE, scom.ST_DESTROYED, scom.ST_ETH_CODE, scom.ST_ETH_ACCOUNT:
+		return true
+	}
+	return false
+}
+
+func fetchedKey(key []byte) []byte {
+	return append([]byte{byte(scom.SYS_FORK_FETCHED)}, key...)
+}
+
+func iteratedKey(prefix []byte) []byte {
+	return append([]byte{byte(scom.SYS_FORK_ITERATED)}, prefix...)
+}
+
+//Get the value of key, fetch it from remote if it is not cached
+func (self *ForkStore) Get(key []byte) ([]byte, error) {
+	if !IsRemoteKey(key) {
+		return self.PersistStore.Get(key)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	fetched, err := self.PersistStore.Has(fetchedKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if fetched {
+		return self.PersistStore.Get(key)
+	}
+
+	val, err := self.remote.GetState(key, self.height)
+	if err != nil {
+		return nil, fmt.Errorf("fetch state %x at height %d error: %s", key, self.height, err)
+	}
+	// not use batch here, it may be in use by the ledger
+	if err = self.cache(key, val); err != nil {
+		return nil, err
+	}
+	if len(val) == 0 {
+		return nil, scom.ErrNotFound
+	}
+	return val, nil
+}
+
+//cache the remote value of key, an empty value means the key is not exist
+func (self *ForkStore) cache(key, val []byte) error {
+	if len(val) != 0 {
+		if err := self.PersistStore.Put(key, val); err != nil {
+			return err
+		}
+	}
+	return self.PersistStore.Put(fetchedKey(key), nil)
+}
+
+//NewIterator return the iterator of keys with prefix. The remote states of a prefix inside a contract are fetched at
+//the first time, the iterator of a wider prefix which may contain contract states returns error.
+func (self *ForkStore) NewIterator(prefix []byte) scom.StoreIterator {
+	if len(prefix) != 0 && !isRemotePrefix(prefix[0]) {
+		return self.PersistStore.NewIterator(prefix)
+	}
+	if err := self.fetchPrefix(prefix); err != nil {
+		log.Errorf("fork store iterate prefix %x error: %s", prefix, err)
+		return &errIterator{err: err}
+	}
+	return self.PersistStore.NewIterator(prefix)
+}
+
+func (self *ForkStore) fetchPrefix(prefix []byte) error {
+	if !IsRemoteKey(prefix) {
+		return fmt.Errorf("iterating forked states not inside a contract is not supported")
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	iterated, err := self.PersistStore.Has(iteratedKey(prefix))
+	if err != nil || iterated {
+		return err
+	}
+	items, err := self.remote.FindStates(prefix, self.height)
+	if err != nil {
+		return fmt.Errorf("fetch states at height %d error: %s", self.height, err)
+	}
+	for _, item := range items {
+		if !bytes.HasPrefix(item.Key, prefix) {
+			return fmt.Errorf("remote state %x is not with prefix", item.Key)
+		}
+		// the states fetched before or written locally are newer
+		fetched, err := self.PersistStore.Has(fetchedKey(item.Key))
+		if err != nil {
+			return err
+		}
+		if !fetched {
+			if err = self.cache(item.Key, item.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return self.PersistStore.Put(iteratedKey(prefix), nil)
+}
+
+//errIterator is an empty iterator returning the error of creating it
+type errIterator struct {
+	err error
+}
+
+func (self *errIterator) Next() bool    { return false }
+func (self *errIterator) First() bool   { return false }
+func (self *errIterator) Key() []byte   { return nil }
+func (self *errIterator) Value() []byte { return nil }
+func (self *errIterator) Release()      {}
+func (self *errIterator) Error() error  { return self.err }
+
+//Has return whether the key is exist in store
+func (self *ForkStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//Put the key-value pair to store
+func (self *ForkStore) Put(key []byte, value []byte) error {
+	if !IsRemoteKey(key) {
+		return self.PersistStore.Put(key, value)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if err := self.PersistStore.Put(fetchedKey(key), nil); err != nil {
+		return err
+	}
+	return self.PersistStore.Put(key, value)
+}
+
+//Delete the key in store
+func (self *ForkStore) Delete(key []byte) error {
+	if !IsRemoteKey(key) {
+		return self.PersistStore.Delete(key)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if err := self.PersistStore.Put(fetchedKey(key), nil); err != nil {
+		return err
+	}
+	return self.PersistStore.Delete(key)
+}
+
+//NewBatch start commit batch
+func (self *ForkStore) NewBatch() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.PersistStore.NewBatch()
+}
+
+//BatchPut put a key-value pair to batch
+func (self *ForkStore) BatchPut(key []byte, value []byte) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if IsRemoteKey(key) {
+		self.PersistStore.BatchPut(fetchedKey(key), nil)
+	}
+	self.PersistStore.BatchPut(key, value)
+}
+
+//BatchDelete delete the key in batch
+func (self *ForkStore) BatchDelete(key []byte) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if IsRemoteKey(key) {
+		self.PersistStore.BatchPut(fetchedKey(key), nil)
+	}
+	self.PersistStore.BatchDelete(key)
+}
+
+//BatchCommit commit batch to store
+func (self *ForkStore) BatchCommit() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.PersistStore.BatchCommit()
+}
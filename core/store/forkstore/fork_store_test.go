@@ -0,0 +1,307 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package forkstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/types"
+	berr "github.com/ontio/ontology/http/base/error"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRemote struct {
+	height  uint32
+	states  map[string][]byte
+	fetches int
+	heights []uint32
+}
+
+func newMockRemote(height uint32) *mockRemote {
+	return &mockRemote{
+		height: height,
+		states: make(map[string][]byte),
+	}
+}
+
+func (self *mockRemote) GetState(key []byte, height uint32) ([]byte, error) {
+	self.fetches += 1
+	self.heights = append(self.heights, height)
+	return self.states[string(key)], nil
+}
+
+func (self *mockRemote) FindStates(prefix []byte, height uint32) ([]*store.StateItem, error) {
+	self.fetches += 1
+	self.heights = append(self.heights, height)
+	var items []*store.StateItem
+	for key, val := range self.states {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			items = append(items, &store.StateItem{Key: []byte(key), Value: val})
+		}
+	}
+	return items, nil
+}
+
+func (self *mockRemote) GetCurrentBlockHeight() (uint32, error) {
+	return self.height, nil
+}
+
+func storageKey(contract common.Address, key []byte) []byte {
+	return append(append([]byte{byte(scom.ST_STORAGE)}, contract[:]...), key...)
+}
+
+func TestForkStoreFetch(t *testing.T) {
+	contract := common.Address{1, 2, 3}
+	remote := newMockRemote(100)
+	contractKey := append([]byte{byte(scom.ST_CONTRACT)}, contract[:]...)
+	key := storageKey(contract, []byte("key"))
+	remote.states[string(contractKey)] = []byte("code")
+	remote.states[string(key)] = states.GenRawStorageItem([]byte("value"))
+
+	store, err := NewForkStore(leveldbstore.NewMemLevelDBStore(), remote)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(100), store.Height())
+
+	code, err := store.Get(contractKey)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("code"), code)
+
+	for i := 0; i < 2; i++ {
+		val, err := store.Get(key)
+		assert.Nil(t, err)
+		assert.Equal(t, states.GenRawStorageItem([]byte("value")), val)
+	}
+	assert.Equal(t, 2, remote.fetches)
+
+	for i := 0; i < 2; i++ {
+		has, err := store.Has(storageKey(contract, []byte("missing")))
+		assert.Nil(t, err)
+		assert.False(t, has)
+	}
+	assert.Equal(t, 3, remote.fetches)
+
+	_, err = store.Get([]byte{byte(scom.SYS_CURRENT_BLOCK)})
+	assert.Equal(t, scom.ErrNotFound, err)
+	assert.Equal(t, 3, remote.fetches)
+}
+
+func TestForkStoreFetchAllStates(t *testing.T) {
+	contract := common.Address{1, 2, 3}
+	codeHash := common.Uint256{4, 5, 6}
+	remote := newMockRemote(100)
+	keys := [][]byte{
+		append([]byte{byte(scom.ST_DESTROYED)}, contract[:]...),
+		append([]byte{byte(scom.ST_ETH_ACCOUNT)}, contract[:]...),
+		append([]byte{byte(scom.ST_ETH_CODE)}, codeHash[:]...),
+	}
+	for i, key := range keys {
+		remote.states[string(key)] = []byte{byte(i)}
+	}
+
+	store, err := NewForkStore(leveldbstore.NewMemLevelDBStore(), remote)
+	assert.Nil(t, err)
+	// the remote keeps synchronizing, but states are still fetched at the fork height
+	remote.height = 200
+	for i, key := range keys {
+		val, err := store.Get(key)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{byte(i)}, val)
+	}
+	assert.Equal(t, []uint32{100, 100, 100}, remote.heights)
+}
+
+func TestForkStoreIterator(t *testing.T) {
+	contract := common.Address{1, 2, 3}
+	remote := newMockRemote(100)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		remote.states[string(storageKey(contract, []byte(key)))] = []byte("remote " + key)
+	}
+	remote.states[string(storageKey(common.Address{4}, []byte("a")))] = []byte("other")
+
+	store, err := NewForkStore(leveldbstore.NewMemLevelDBStore(), remote)
+	assert.Nil(t, err)
+	store.NewBatch()
+	store.BatchPut(storageKey(contract, []byte("a")), []byte("local a"))
+	store.BatchDelete(storageKey(contract, []byte("b")))
+	assert.Nil(t, store.BatchCommit())
+	_, err = store.Get(storageKey(contract, []byte("c")))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, remote.fetches)
+
+	collect := func(prefix []byte) map[string]string {
+		iter := store.NewIterator(prefix)
+		defer iter.Release()
+		kvs := make(map[string]string)
+		for has := iter.First(); has; has = iter.Next() {
+			kvs[string(iter.Key()[len(prefix):])] = string(iter.Value())
+		}
+		assert.Nil(t, iter.Error())
+		return kvs
+	}
+	prefix := storageKey(contract, nil)
+	expected := map[string]string{"a": "local a", "c": "remote c", "d": "remote d"}
+	assert.Equal(t, expected, collect(prefix))
+	assert.Equal(t, 2, remote.fetches)
+	assert.Equal(t, expected, collect(prefix))
+	assert.Equal(t, 2, remote.fetches)
+	// fetched by iterator
+	_, err = store.Get(storageKey(contract, []byte("d")))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, remote.fetches)
+
+	// the prefix of all contracts is not supported
+	iter := store.NewIterator([]byte{byte(scom.ST_STORAGE)})
+	assert.False(t, iter.First())
+	assert.NotNil(t, iter.Error())
+	iter = store.NewIterator(nil)
+	assert.NotNil(t, iter.Error())
+	// other keys are local only
+	iter = store.NewIterator([]byte{byte(scom.SYS_FORK_HEIGHT)})
+	assert.True(t, iter.First())
+	assert.Nil(t, iter.Error())
+	iter.Release()
+	assert.Equal(t, 2, remote.fetches)
+}
+
+func TestForkStoreLocalWrite(t *testing.T) {
+	contract := common.Address{1, 2, 3}
+	remote := newMockRemote(100)
+	remote.states[string(storageKey(contract, []byte("a")))] = []byte("remote a")
+	remote.states[string(storageKey(contract, []byte("b")))] = []byte("remote b")
+
+	store, err := NewForkStore(leveldbstore.NewMemLevelDBStore(), remote)
+	assert.Nil(t, err)
+
+	store.NewBatch()
+	store.BatchPut(storageKey(contract, []byte("a")), []byte("local a"))
+	store.BatchDelete(storageKey(contract, []byte("b")))
+	assert.Nil(t, store.BatchCommit())
+
+	val, err := store.Get(storageKey(contract, []byte("a")))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("local a"), val)
+	_, err = store.Get(storageKey(contract, []byte("b")))
+	assert.Equal(t, scom.ErrNotFound, err)
+	assert.Equal(t, 0, remote.fetches)
+}
+
+func TestForkStorePinnedHeight(t *testing.T) {
+	local := leveldbstore.NewMemLevelDBStore()
+	_, err := NewForkStore(local, newMockRemote(100))
+	assert.Nil(t, err)
+
+	store, err := NewForkStore(local, newMockRemote(200))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(100), store.Height())
+
+	local = leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, local.Put([]byte{byte(scom.SYS_CURRENT_BLOCK)}, []byte{0}))
+	_, err = NewForkStore(local, newMockRemote(100))
+	assert.NotNil(t, err)
+}
+
+func TestRpcClient(t *testing.T) {
+	prefix := storageKey(common.Address{1, 2, 3}, nil)
+	key := storageKey(common.Address{1, 2, 3}, []byte("key"))
+	block := &types.Block{Header: &types.Header{Height: 7, Bookkeepers: []keypair.PublicKey{}, SigData: [][]byte{}}}
+	txHash := common.Uint256{7}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &rpcRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		res := map[string]interface{}{"error": berr.SUCCESS, "desc": "SUCCESS"}
+		switch req.Method {
+		case "getblockcount":
+			res["result"] = 101
+		case "getstateatheight":
+			if req.Params[0] == common.ToHexString(key) && req.Params[1] == float64(100) {
+				res["result"] = common.ToHexString([]byte("value"))
+			} else {
+				res["result"] = nil
+			}
+		case "findstatesatheight":
+			if req.Params[0] == common.ToHexString(prefix) && req.Params[1] == float64(100) {
+				res["result"] = []map[string]string{{"Key": common.ToHexString(key), "Value": common.ToHexString([]byte("value"))}}
+			} else {
+				res["error"] = berr.INVALID_PARAMS
+				res["desc"] = "INVALID PARAMS"
+			}
+		case "getblock":
+			if req.Params[0] == float64(7) {
+				res["result"] = common.ToHexString(block.ToArray())
+			} else {
+				res["error"] = berr.UNKNOWN_BLOCK
+				res["desc"] = "UNKNOWN BLOCK"
+			}
+		case "getblockheightbytxhash":
+			if req.Params[0] == txHash.ToHexString() {
+				res["result"] = 7
+			} else {
+				res["error"] = berr.INVALID_PARAMS
+				res["desc"] = "INVALID PARAMS"
+			}
+		}
+		data, _ := json.Marshal(res)
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client := NewRpcClient(server.URL)
+	height, err := client.GetCurrentBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(100), height)
+
+	val, err := client.GetState(key, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	val, err = client.GetState(key, 99)
+	assert.Nil(t, err)
+	assert.Nil(t, val)
+
+	items, err := client.FindStates(prefix, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, []*store.StateItem{{Key: key, Value: []byte("value")}}, items)
+	_, err = client.FindStates(prefix, 99)
+	assert.NotNil(t, err)
+
+	blk, err := client.GetBlockByHeight(7)
+	assert.Nil(t, err)
+	assert.Equal(t, block.Hash(), blk.Hash())
+	_, err = client.GetBlockByHeight(8)
+	assert.NotNil(t, err)
+	height, err = client.GetBlockHeightByTxHash(txHash)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(7), height)
+	_, err = client.GetBlockHeightByTxHash(common.Uint256{8})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package forkstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/stateproof"
+	"github.com/ontio/ontology/core/types"
+	berr "github.com/ontio/ontology/http/base/error"
+)
+
+const RPC_REQUEST_TIMEOUT = 30 * time.Second
+
+type rpcRequest struct {
+	Version string        `json:"jsonrpc"`
+	Id      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Error  int64           `json:"error"`
+	Desc   string          `json:"desc"`
+	Result json.RawMessage `json:"result"`
+}
+
+//RpcClient fetch state from the json rpc server of a reference node
+type RpcClient struct {
+	address string
+	client  *http.Client
+}
+
+//NewRpcClient return a client of the json rpc server, address like http://127.0.0.1:20336
+func NewRpcClient(address string) *RpcClient {
+	return &RpcClient{
+		address: address,
+		client:  &http.Client{Timeout: RPC_REQUEST_TIMEOUT},
+	}
+}
+
+func (self *RpcClient) call(method string, params ...interface{}) (*rpcResponse, error) {
+	data, err := json.Marshal(&rpcRequest{
+		Version: "2.0",
+		Id:      "fork",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := self.client.Post(self.address, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %s", method, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s read response error: %s", method, err)
+	}
+	res := &rpcResponse{}
+	if err = json.Unmarshal(body, res); err != nil {
+		return nil, fmt.Errorf("%s unmarshal response error: %s", method, err)
+	}
+	return res, nil
+}
+
+func (self *RpcClient) callHex(method string, params ...interface{}) ([]byte, error) {
+	res, err := self.call(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != berr.SUCCESS {
+		return nil, fmt.Errorf("%s error: %d %s", method, res.Error, res.Desc)
+	}
+	var str *string
+	if err = json.Unmarshal(res.Result, &str); err != nil {
+		return nil, fmt.Errorf("%s invalid result: %s", method, err)
+	}
+	if str == nil {
+		return nil, nil
+	}
+	return common.HexToBytes(*str)
+}
+
+func (self *RpcClient) GetState(key []byte, height uint32) ([]byte, error) {
+	return self.callHex("getstateatheight", common.ToHexString(key), height)
+}
+
+func (self *RpcClient) FindStates(prefix []byte, height uint32) ([]*store.StateItem, error) {
+	res, err := self.call("findstatesatheight", common.ToHexString(prefix), height)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != berr.SUCCESS {
+		return nil, fmt.Errorf("findstatesatheight error: %d %s", res.Error, res.Desc)
+	}
+	var list []struct {
+		Key   string
+		Value string
+	}
+	if err = json.Unmarshal(res.Result, &list); err != nil {
+		return nil, fmt.Errorf("findstatesatheight invalid result: %s", err)
+	}
+	items := make([]*store.StateItem, 0, len(list))
+	for _, kv := range list {
+		key, err := common.HexToBytes(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("findstatesatheight invalid key: %s", err)
+		}
+		value, err := common.HexToBytes(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("findstatesatheight invalid value: %s", err)
+		}
+		items = append(items, &store.StateItem{Key: key, Value: value})
+	}
+	return items, nil
+}
+
+func (self *RpcClient) GetBlockByHeight(height uint32) (*types.Block, error) {
+	raw, err := self.callHex("getblock", height)
+	if err != nil {
+		return nil, err
+	}
+	return types.BlockFromRawBytes(raw)
+}
+
+func (self *RpcClient) GetBlockHeightByTxHash(txHash common.Uint256) (uint32, error) {
+	res, err := self.call("getblockheightbytxhash", txHash.ToHexString())
+	if err != nil {
+		return 0, err
+	}
+	if res.Error != berr.SUCCESS {
+		return 0, fmt.Errorf("getblockheightbytxhash error: %d %s", res.Error, res.Desc)
+	}
+	var height uint32
+	if err = json.Unmarshal(res.Result, &height); err != nil {
+		return 0, fmt.Errorf("getblockheightbytxhash invalid result: %s", res.Result)
+	}
+	return height, nil
+}
+
+func (self *RpcClient) GetStorageProof(contract common.Address, key []byte, height uint32) (*stateproof.StorageProof, error) {
+	res, err := self.call("getstorageproof", contract.ToHexString(), common.ToHexString(key), height)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != berr.SUCCESS {
+		return nil, fmt.Errorf("getstorageproof error: %d %s", res.Error, res.Desc)
+	}
+	proof := &stateproof.StorageProof{}
+	if err = json.Unmarshal(res.Result, proof); err != nil {
+		return nil, fmt.Errorf("getstorageproof invalid result: %s", err)
+	}
+	return proof, nil
+}
+
+func (self *RpcClient) GetCurrentBlockHeight() (uint32, error) {
+	res, err := self.call("getblockcount")
+	if err != nil {
+		return 0, err
+	}
+	if res.Error != berr.SUCCESS {
+		return 0, fmt.Errorf("getblockcount error: %d %s", res.Error, res.Desc)
+	}
+	var count uint32
+	if err = json.Unmarshal(res.Result, &count); err != nil || count == 0 {
+		return 0, fmt.Errorf("getblockcount invalid result: %s", res.Result)
+	}
+	return count - 1, nil
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package kvstore
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ontio/ontology/core/store/boltdbstore"
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func init() {
+	Register(&Backend{
+		Name: "leveldb",
+		Open: func(dir string) (common.PersistStore, error) {
+			return leveldbstore.NewLevelDBStore(dir)
+		},
+		OpenReadOnly: func(dir string) (common.PersistStore, error) {
+			return leveldbstore.NewReadOnlyLevelDBStore(dir)
+		},
+		Exists: func(dir string) bool {
+			return fileExists(filepath.Join(dir, "CURRENT"))
+		},
+	})
+	Register(&Backend{
+		Name: "bolt",
+		Open: func(dir string) (common.PersistStore, error) {
+			return boltdbstore.NewBoltDBStore(dir)
+		},
+		OpenReadOnly: func(dir string) (common.PersistStore, error) {
+			return boltdbstore.NewReadOnlyBoltDBStore(dir)
+		},
+		Exists: func(dir string) bool {
+			return fileExists(filepath.Join(dir, boltdbstore.DBFileName))
+		},
+	})
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package kvstore is the registry of the key-value engines which the ledger stores are persisted with
+package kvstore
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ontio/ontology/core/store/common"
+)
+
+//DefaultBackend is used when no backend is configured
+const DefaultBackend = "leveldb"
+
+//Backend is a key-value engine implementing PersistStore
+type Backend struct {
+	Name         string                                        //Name of backend in config
+	Open         func(dir string) (common.PersistStore, error) //Open or create the store in dir
+	OpenReadOnly func(dir string) (common.PersistStore, error) //Open the existing store in dir which can not be written
+	Exists       func(dir string) bool                         //Whether dir holds a store created by the backend
+}
+
+var (
+	lock     sync.RWMutex
+	backends = make(map[string]*Backend)
+)
+
+//Register add a backend to the registry, it panics if the name is registered already
+func Register(backend *Backend) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := backends[backend.Name]; ok {
+		panic(fmt.Sprintf("kvstore: backend %s is registered twice", backend.Name))
+	}
+	backends[backend.Name] = backend
+}
+
+//Get return the backend by name, the default backend is returned for empty name
+func Get(name string) (*Backend, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown db backend %s, supported: %s", name, strings.Join(names(), ", "))
+	}
+	return backend, nil
+}
+
+//Names return the names of registered backends in order
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return names()
+}
+
+func names() []string {
+	list := make([]string, 0, len(backends))
+	for name := range backends {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+//Detect return the name of backend which created the store in dir, empty if no store is found
+func Detect(dir string) string {
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, name := range names() {
+		if backends[name].Exists(dir) {
+			return name
+		}
+	}
+	return ""
+}
+
+//Open open the store in dir with the backend. An existing store created by another backend is not opened,
+//it should be converted by Migrate first
+func Open(name, dir string) (common.PersistStore, error) {
+	backend, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if exist := Detect(dir); exist != "" && exist != backend.Name {
+		return nil, fmt.Errorf("store %s is created by db backend %s, not %s, please migrate it first",
+			dir, exist, backend.Name)
+	}
+	return backend.Open(dir)
+}
+
+//OpenReadOnly open the existing store in dir read-only with the backend which created it
+func OpenReadOnly(dir string) (common.PersistStore, error) {
+	name := Detect(dir)
+	if name == "" {
+		return nil, fmt.Errorf("no store is found in %s", dir)
+	}
+	backend, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return backend.OpenReadOnly(dir)
+}
+
+//Copy write all the key-value pairs of src to dst, batchSize pairs are committed in each batch
+func Copy(dst, src common.PersistStore, batchSize int) (uint64, error) {
+	iter := src.NewIterator(nil)
+	defer iter.Release()
+	var count uint64
+	dst.NewBatch()
+	for iter.Next() {
+		dst.BatchPut(iter.Key(), iter.Value())
+		count++
+		if count%uint64(batchSize) == 0 {
+			if err := dst.BatchCommit(); err != nil {
+				return count, err
+			}
+			dst.NewBatch()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return count, err
+	}
+	if err := dst.BatchCommit(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+//Migrate convert the store in dir to the backend, and return the backend it is converted from and the
+//number of key-value pairs copied. The store is written to a temporary dir first, the original one is kept
+//in backupDir once the conversion succeeds. Nothing is done if the store is created by the backend already
+func Migrate(dir, backupDir, name string, batchSize int) (string, uint64, error) {
+	backend, err := Get(name)
+	if err != nil {
+		return "", 0, err
+	}
+	from := Detect(dir)
+	if from == "" {
+		return "", 0, fmt.Errorf("no store is found in %s", dir)
+	}
+	if from == backend.Name {
+		return from, 0, nil
+	}
+	if _, err := os.Stat(backupDir); err == nil {
+		return from, 0, fmt.Errorf("backup dir %s exists already", backupDir)
+	}
+	src, err := Open(from, dir)
+	if err != nil {
+		return from, 0, err
+	}
+	tmpDir := dir + ".migrate"
+	err = os.RemoveAll(tmpDir)
+	if err != nil {
+		src.Close()
+		return from, 0, err
+	}
+	dst, err := backend.Open(tmpDir)
+	if err != nil {
+		src.Close()
+		return from, 0, err
+	}
+	count, err := Copy(dst, src, batchSize)
+	src.Close()
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return from, count, err
+	}
+	if err := os.Rename(dir, backupDir); err != nil {
+		return from, count, err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return from, count, err
+	}
+	return from, count, nil
+}
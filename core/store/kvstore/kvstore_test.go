@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package kvstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	assert.Equal(t, []string{"bolt", "leveldb"}, Names())
+	backend, err := Get("")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultBackend, backend.Name)
+	_, err = Get("unknown")
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { Register(&Backend{Name: "bolt"}) })
+}
+
+func TestMigrate(t *testing.T) {
+	root, err := ioutil.TempDir("", "kvstore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "store")
+
+	_, _, err = Migrate(dir, dir+".bak", "bolt", 10)
+	assert.NotNil(t, err)
+
+	store, err := Open("leveldb", dir)
+	assert.Nil(t, err)
+	for i := 0; i < 25; i++ {
+		assert.Nil(t, store.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i))))
+	}
+	assert.Nil(t, store.Close())
+	assert.Equal(t, "leveldb", Detect(dir))
+
+	_, err = Open("bolt", dir)
+	assert.NotNil(t, err)
+
+	from, count, err := Migrate(dir, dir+".bak", "bolt", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "leveldb", from)
+	assert.Equal(t, uint64(25), count)
+	assert.Equal(t, "bolt", Detect(dir))
+	assert.Equal(t, "leveldb", Detect(dir+".bak"))
+
+	store, err = Open("bolt", dir)
+	assert.Nil(t, err)
+	for i := 0; i < 25; i++ {
+		value, err := store.Get([]byte(fmt.Sprintf("key%d", i)))
+		assert.Nil(t, err)
+		assert.Equal(t, fmt.Sprintf("value%d", i), string(value))
+	}
+	assert.Nil(t, store.Close())
+
+	from, count, err = Migrate(dir, dir+".bak2", "bolt", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "bolt", from)
+	assert.Equal(t, uint64(0), count)
+
+	_, _, err = Migrate(dir, dir+".bak", "leveldb", 10)
+	assert.NotNil(t, err)
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	root, err := ioutil.TempDir("", "kvstore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	for _, name := range Names() {
+		dir := filepath.Join(root, name)
+		_, err = OpenReadOnly(dir)
+		assert.NotNil(t, err)
+
+		store, err := Open(name, dir)
+		assert.Nil(t, err)
+		assert.Nil(t, store.Put([]byte("key"), []byte("value")))
+		assert.Nil(t, store.Close())
+
+		store, err = OpenReadOnly(dir)
+		assert.Nil(t, err, name)
+		value, err := store.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, "value", string(value))
+		assert.NotNil(t, store.Put([]byte("key"), []byte("other")), name)
+		assert.Nil(t, store.Close())
+	}
+}
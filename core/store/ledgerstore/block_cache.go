@@ -102,3 +102,9 @@ func (this *BlockCache) GetTransaction(txHash common.Uint256) (*types.Transactio
 func (this *BlockCache) ContainTransaction(txHash common.Uint256) bool {
 	return this.transactionCache.Contains(string(txHash.ToArray()))
 }
+
+//Purge the cached blocks and transactions
+func (this *BlockCache) Purge() {
+	this.blockCache.Purge()
+	this.transactionCache.Purge()
+}
@@ -27,16 +27,15 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/serialization"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/types"
 )
 
 //Block store save the data of block & transaction
 type BlockStore struct {
-	enableCache bool                       //Is enable lru cache
-	dbDir       string                     //The path of store file
-	cache       *BlockCache                //The cache of block, if have.
-	store       *leveldbstore.LevelDBStore //block store handler
+	enableCache bool              //Is enable lru cache
+	dbDir       string            //The path of store file
+	cache       *BlockCache       //The cache of block, if have.
+	store       scom.PersistStore //block store handler
 }
 
 //NewBlockStore return the block store instance
@@ -50,7 +49,7 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 		}
 	}
 
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -348,6 +347,32 @@ func (this *BlockStore) SaveBlockHash(height uint32, blockHash common.Uint256) {
 	this.store.BatchPut(key, blockHash.ToArray())
 }
 
+//DeleteBlockHash delete the block hash of height in batch
+func (this *BlockStore) DeleteBlockHash(height uint32) {
+	key := genBlockHashKey(height)
+	this.store.BatchDelete(key)
+}
+
+//DeleteHeaderIndexListAbove delete the header index lists containing the height above the height in batch
+func (this *BlockStore) DeleteHeaderIndexListAbove(height uint32) error {
+	iter := this.store.NewIterator([]byte{byte(scom.IX_HEADER_HASH_LIST)})
+	defer iter.Release()
+	for iter.Next() {
+		startHeight, err := genStartHeightByHeaderIndexKey(iter.Key())
+		if err != nil {
+			return fmt.Errorf("genStartHeightByHeaderIndexKey error %s", err)
+		}
+		count, err := serialization.ReadUint32(bytes.NewReader(iter.Value()))
+		if err != nil {
+			return fmt.Errorf("serialization.ReadUint32 count error %s", err)
+		}
+		if uint64(startHeight)+uint64(count) > uint64(height)+1 {
+			this.store.BatchDelete(iter.Key())
+		}
+	}
+	return iter.Error()
+}
+
 //SaveTransaction persist transaction to store
 func (this *BlockStore) SaveTransaction(tx *types.Transaction, height uint32) {
 	if this.enableCache {
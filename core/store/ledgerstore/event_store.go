@@ -28,7 +28,6 @@ import (
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/common/serialization"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/smartcontract/event"
 )
 
@@ -38,13 +37,13 @@ var UseNumber = false
 
 //Saving event notifies gen by smart contract execution
 type EventStore struct {
-	dbDir string                     //Store path
-	store *leveldbstore.LevelDBStore //Store handler
+	dbDir string            //Store path
+	store scom.PersistStore //Store handler
 }
 
 //NewEventStore return event store instance
 func NewEventStore(dbDir string) (*EventStore, error) {
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +100,8 @@ func (this *EventStore) GetEventNotifyByTx(txHash common.Uint256) (*event.Execut
 	return &notify, nil
 }
 
-//GetEventNotifyByBlock return all event notify of transaction in block
-func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error) {
+//GetEventNotifyTxsByBlock return the hashes of transaction in block saved to event store
+func (this *EventStore) GetEventNotifyTxsByBlock(height uint32) ([]common.Uint256, error) {
 	key := genEventNotifyByBlockKey(height)
 	data, err := this.store.Get(key)
 	if err != nil {
@@ -113,13 +112,26 @@ func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNo
 	if err != nil {
 		return nil, fmt.Errorf("ReadUint32 error %s", err)
 	}
-	evtNotifies := make([]*event.ExecuteNotify, 0)
+	txHashes := make([]common.Uint256, 0, size)
 	for i := uint32(0); i < size; i++ {
 		var txHash common.Uint256
 		err = txHash.Deserialize(reader)
 		if err != nil {
 			return nil, fmt.Errorf("txHash.Deserialize error %s", err)
 		}
+		txHashes = append(txHashes, txHash)
+	}
+	return txHashes, nil
+}
+
+//GetEventNotifyByBlock return all event notify of transaction in block
+func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error) {
+	txHashes, err := this.GetEventNotifyTxsByBlock(height)
+	if err != nil {
+		return nil, err
+	}
+	evtNotifies := make([]*event.ExecuteNotify, 0)
+	for _, txHash := range txHashes {
 		evtNotify, err := this.GetEventNotifyByTx(txHash)
 		if err != nil {
 			log.Errorf("getEventNotifyByTx Height:%d by txhash:%s error:%s", height, txHash.ToHexString(), err)
@@ -0,0 +1,363 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/storage"
+)
+
+//InspectEntry is a key-value pair of ledger stores decoded by its key prefix
+type InspectEntry struct {
+	Prefix scom.DataEntryPrefix
+	Key    string
+	Value  string
+}
+
+func (self *InspectEntry) String() string {
+	if self.Key == "" {
+		return fmt.Sprintf("%s => %s", self.Prefix, self.Value)
+	}
+	return fmt.Sprintf("%s %s => %s", self.Prefix, self.Key, self.Value)
+}
+
+//DecodeStoreEntry decode the key-value pair of ledger stores, the part can not be decoded is shown in hex
+func DecodeStoreEntry(key, value []byte) *InspectEntry {
+	if len(key) == 0 {
+		return &InspectEntry{Key: "", Value: hex.EncodeToString(value)}
+	}
+	entry := &InspectEntry{Prefix: scom.DataEntryPrefix(key[0])}
+	var err error
+	entry.Key, err = decodeStoreKey(entry.Prefix, key[1:])
+	if err != nil {
+		entry.Key = hex.EncodeToString(key[1:])
+	}
+	entry.Value, err = decodeStoreValue(entry.Prefix, key[1:], value)
+	if err != nil {
+		entry.Value = fmt.Sprintf("%x (%s)", value, err)
+	}
+	return entry
+}
+
+func decodeHeight(data []byte) (string, error) {
+	if len(data) != 4 {
+		return "", io.ErrUnexpectedEOF
+	}
+	return fmt.Sprintf("height=%d", binary.LittleEndian.Uint32(data)), nil
+}
+
+func decodeHash(data []byte) (string, error) {
+	hash, err := common.Uint256ParseFromBytes(data)
+	if err != nil {
+		return "", err
+	}
+	return hash.ToHexString(), nil
+}
+
+func decodeAddress(data []byte) (string, error) {
+	addr, err := common.AddressParseFromBytes(data)
+	if err != nil {
+		return "", err
+	}
+	return addr.ToHexString(), nil
+}
+
+func decodeHashes(source *common.ZeroCopySource, count uint64) (string, error) {
+	hashes := make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash, eof := source.NextHash()
+		if eof {
+			return "", io.ErrUnexpectedEOF
+		}
+		hashes = append(hashes, hash.ToHexString())
+	}
+	return "[" + strings.Join(hashes, " ") + "]", nil
+}
+
+func decodeStoreKey(prefix scom.DataEntryPrefix, key []byte) (string, error) {
+	switch prefix {
+	case scom.DATA_BLOCK_HASH, scom.DATA_STATE_MERKLE_ROOT, scom.DATA_STATE_REVERSE_WRITE_SET, scom.DATA_STATE_WRITE_SET,
+		scom.DATA_STORAGE_TRIE_ROOT, scom.SYS_CURRENT_CROSS_STATES, scom.SYS_CROSS_CHAIN_MSG, scom.IX_HEADER_HASH_LIST:
+		return decodeHeight(key)
+	case scom.DATA_HEADER, scom.DATA_TRANSACTION:
+		return decodeHash(key)
+	case scom.ST_CONTRACT, scom.ST_DESTROYED, scom.SYS_STATE_GC:
+		return decodeAddress(key)
+	case scom.ST_STORAGE:
+		if len(key) < common.ADDR_LEN {
+			return "", io.ErrUnexpectedEOF
+		}
+		contract, _ := decodeAddress(key[:common.ADDR_LEN])
+		return fmt.Sprintf("contract=%s key=%x", contract, key[common.ADDR_LEN:]), nil
+	case scom.ST_ETH_CODE, scom.ST_STORAGE_TRIE:
+		if len(key) != common2.HashLength {
+			return "", io.ErrUnexpectedEOF
+		}
+		return common2.BytesToHash(key).Hex(), nil
+	case scom.ST_ETH_ACCOUNT:
+		if len(key) != common2.AddressLength {
+			return "", io.ErrUnexpectedEOF
+		}
+		return common2.BytesToAddress(key).Hex(), nil
+	case scom.EVENT_NOTIFY:
+		if len(key) == 4 {
+			return decodeHeight(key)
+		}
+		return decodeHash(key)
+	case scom.SYS_FORK_FETCHED:
+		if len(key) == 0 {
+			return "", io.ErrUnexpectedEOF
+		}
+		entry := DecodeStoreEntry(key, nil)
+		return fmt.Sprintf("%s %s", entry.Prefix, entry.Key), nil
+	case scom.ST_BOOKKEEPER:
+		return string(key), nil
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func decodeStoreValue(prefix scom.DataEntryPrefix, key, value []byte) (string, error) {
+	source := common.NewZeroCopySource(value)
+	switch prefix {
+	case scom.DATA_BLOCK_HASH:
+		return decodeHash(value)
+	case scom.DATA_HEADER:
+		sysFee := new(common.Fixed64)
+		if err := sysFee.Deserialization(source); err != nil {
+			return "", err
+		}
+		header := new(types.Header)
+		if err := header.Deserialization(source); err != nil {
+			return "", err
+		}
+		txCount, eof := source.NextUint32()
+		if eof {
+			return "", io.ErrUnexpectedEOF
+		}
+		return fmt.Sprintf("height=%d prev=%s timestamp=%d txs=%d sysfee=%d", header.Height,
+			header.PrevBlockHash.ToHexString(), header.Timestamp, txCount, *sysFee), nil
+	case scom.DATA_TRANSACTION:
+		height, eof := source.NextUint32()
+		if eof {
+			return "", io.ErrUnexpectedEOF
+		}
+		tx := new(types.Transaction)
+		if err := tx.Deserialization(source); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("height=%d type=0x%x payer=%s nonce=%d gasprice=%d gaslimit=%d size=%d", height,
+			byte(tx.TxType), tx.Payer.ToBase58(), tx.Nonce, tx.GasPrice, tx.GasLimit, len(tx.Raw)), nil
+	case scom.DATA_STATE_MERKLE_ROOT:
+		writeSetHash, eof := source.NextHash()
+		root, eof2 := source.NextHash()
+		if eof || eof2 {
+			return "", io.ErrUnexpectedEOF
+		}
+		return fmt.Sprintf("writeset=%s root=%s", writeSetHash.ToHexString(), root.ToHexString()), nil
+	case scom.DATA_STATE_REVERSE_WRITE_SET, scom.DATA_STATE_WRITE_SET:
+		count, eof := source.NextUint32()
+		if eof {
+			return "", io.ErrUnexpectedEOF
+		}
+		return fmt.Sprintf("states=%d size=%d", count, len(value)), nil
+	case scom.ST_BOOKKEEPER:
+		state := new(states.BookkeeperState)
+		if err := state.Deserialization(source); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("curr=%d next=%d", len(state.CurrBookkeeper), len(state.NextBookkeeper)), nil
+	case scom.ST_CONTRACT:
+		contract := new(payload.DeployCode)
+		if err := contract.Deserialization(source); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("name=%q version=%q author=%q vm=%d code=%d bytes", contract.Name, contract.Version,
+			contract.Author, contract.VmType(), len(contract.GetRawCode())), nil
+	case scom.ST_DESTROYED, scom.SYS_FORK_HEIGHT, scom.DATA_BLOCK_PRUNE_HEIGHT:
+		return decodeHeight(value)
+	case scom.ST_ETH_CODE:
+		return fmt.Sprintf("code=%d bytes", len(value)), nil
+	case scom.ST_STORAGE_TRIE:
+		return fmt.Sprintf("node=%d bytes", len(value)), nil
+	case scom.DATA_STORAGE_TRIE_ROOT:
+		if len(value) != common2.HashLength {
+			return "", io.ErrUnexpectedEOF
+		}
+		return common2.BytesToHash(value).Hex(), nil
+	case scom.ST_ETH_ACCOUNT:
+		account := new(storage.EthAccount)
+		if err := account.Deserialization(source); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("nonce=%d codehash=%s", account.Nonce, account.CodeHash.Hex()), nil
+	case scom.IX_HEADER_HASH_LIST:
+		count, eof := source.NextUint32()
+		if eof {
+			return "", io.ErrUnexpectedEOF
+		}
+		return fmt.Sprintf("count=%d", count), nil
+	case scom.SYS_CURRENT_BLOCK:
+		hash, eof := source.NextHash()
+		height, eof2 := source.NextUint32()
+		if eof || eof2 {
+			return "", io.ErrUnexpectedEOF
+		}
+		return fmt.Sprintf("hash=%s height=%d", hash.ToHexString(), height), nil
+	case scom.SYS_VERSION:
+		if len(value) != 1 {
+			return "", io.ErrUnexpectedEOF
+		}
+		return fmt.Sprintf("version=%d", value[0]), nil
+	case scom.SYS_CURRENT_CROSS_STATES:
+		return decodeHashes(source, uint64(len(value)/common.UINT256_SIZE))
+	case scom.SYS_BLOCK_MERKLE_TREE, scom.SYS_STATE_MERKLE_TREE:
+		treeSize, eof := source.NextUint32()
+		if eof {
+			return "", io.ErrUnexpectedEOF
+		}
+		hashes, err := decodeHashes(source, uint64(len(value)-4)/common.UINT256_SIZE)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("size=%d hashes=%s", treeSize, hashes), nil
+	case scom.SYS_CROSS_CHAIN_MSG:
+		msg := new(types.CrossChainMsg)
+		if err := msg.Deserialization(source); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("version=%d height=%d root=%s sigs=%d", msg.Version, msg.Height,
+			msg.StatesRoot.ToHexString(), len(msg.SigData)), nil
+	case scom.SYS_STATE_GC:
+		if len(value) != 1 {
+			return "", io.ErrUnexpectedEOF
+		}
+		if value[0] == stateGCCollected {
+			return "collected", nil
+		}
+		return "collecting", nil
+	case scom.EVENT_NOTIFY:
+		if len(key) == 4 {
+			count, eof := source.NextUint32()
+			if eof {
+				return "", io.ErrUnexpectedEOF
+			}
+			return decodeHashes(source, uint64(count))
+		}
+		return string(value), nil
+	}
+	return hex.EncodeToString(value), nil
+}
+
+//PrefixStat is the count and size of the keys with a prefix in store
+type PrefixStat struct {
+	Prefix    scom.DataEntryPrefix
+	Count     uint64
+	KeySize   uint64
+	ValueSize uint64
+}
+
+//StorePrefixStats return the statistics of the keys in store by prefix, in the order of prefix
+func StorePrefixStats(store scom.PersistStore) ([]*PrefixStat, error) {
+	stats := make(map[scom.DataEntryPrefix]*PrefixStat)
+	iter := store.NewIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) == 0 {
+			continue
+		}
+		prefix := scom.DataEntryPrefix(key[0])
+		stat := stats[prefix]
+		if stat == nil {
+			stat = &PrefixStat{Prefix: prefix}
+			stats[prefix] = stat
+		}
+		stat.Count++
+		stat.KeySize += uint64(len(key))
+		stat.ValueSize += uint64(len(iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	result := make([]*PrefixStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Prefix < result[j].Prefix
+	})
+	return result, nil
+}
+
+//StorageEntry is a storage key-value pair of contract in hex
+type StorageEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+//ContractStorage is the storage dump of a contract
+type ContractStorage struct {
+	Contract string          `json:"contract"`
+	Height   uint32          `json:"height"`
+	Count    int             `json:"count"`
+	Storage  []*StorageEntry `json:"storage"`
+}
+
+//DumpContractStorage return all the storage of contract in the state store
+func DumpContractStorage(store scom.PersistStore, contract common.Address) (*ContractStorage, error) {
+	dump := &ContractStorage{
+		Contract: contract.ToHexString(),
+		Storage:  make([]*StorageEntry, 0),
+	}
+	value, err := store.Get([]byte{byte(scom.SYS_CURRENT_BLOCK)})
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	if err == nil {
+		source := common.NewZeroCopySource(value)
+		source.Skip(common.UINT256_SIZE)
+		dump.Height, _ = source.NextUint32()
+	}
+	prefix := append([]byte{byte(scom.ST_STORAGE)}, contract[:]...)
+	iter := store.NewIterator(prefix)
+	defer iter.Release()
+	for iter.Next() {
+		dump.Storage = append(dump.Storage, &StorageEntry{
+			Key:   hex.EncodeToString(iter.Key()[len(prefix):]),
+			Value: hex.EncodeToString(iter.Value()),
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	dump.Count = len(dump.Storage)
+	return dump, nil
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	store := leveldbstore.NewMemLevelDBStore()
+	contract := common.AddressFromVmCode([]byte("contract"))
+	other := common.AddressFromVmCode([]byte("other"))
+	hash := common.Uint256{1, 2, 3}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteHash(hash)
+	sink.WriteUint32(10)
+
+	store.NewBatch()
+	store.BatchPut([]byte{byte(scom.SYS_CURRENT_BLOCK)}, sink.Bytes())
+	store.BatchPut(genBlockHashKey(10), hash[:])
+	store.BatchPut(append(append([]byte{byte(scom.ST_STORAGE)}, contract[:]...), 0x01), []byte{0xaa})
+	store.BatchPut(append(append([]byte{byte(scom.ST_STORAGE)}, contract[:]...), 0x02), []byte{0xbb, 0xcc})
+	store.BatchPut(append(append([]byte{byte(scom.ST_STORAGE)}, other[:]...), 0x01), []byte{0xdd})
+	store.BatchPut(genStateGCKey(other[:]), []byte{stateGCCollected})
+	assert.Nil(t, store.BatchCommit())
+
+	entry := DecodeStoreEntry(genBlockHashKey(10), hash[:])
+	assert.Equal(t, "DATA_BLOCK_HASH height=10 => "+hash.ToHexString(), entry.String())
+	entry = DecodeStoreEntry([]byte{byte(scom.SYS_CURRENT_BLOCK)}, sink.Bytes())
+	assert.Equal(t, "SYS_CURRENT_BLOCK => hash="+hash.ToHexString()+" height=10", entry.String())
+	entry = DecodeStoreEntry(genStateGCKey(other[:]), []byte{stateGCCollected})
+	assert.Equal(t, "SYS_STATE_GC "+other.ToHexString()+" => collected", entry.String())
+	// undecodable value is shown in hex
+	entry = DecodeStoreEntry(genBlockHashKey(10), []byte{0x01})
+	assert.Equal(t, scom.DataEntryPrefix(scom.DATA_BLOCK_HASH), entry.Prefix)
+	assert.Contains(t, entry.Value, "01 (")
+	assert.Equal(t, "0x7f", scom.DataEntryPrefix(0x7f).String())
+
+	stats, err := StorePrefixStats(store)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(stats))
+	assert.Equal(t, scom.DataEntryPrefix(scom.DATA_BLOCK_HASH), stats[0].Prefix)
+	assert.Equal(t, scom.ST_STORAGE, stats[1].Prefix)
+	assert.Equal(t, uint64(3), stats[1].Count)
+	assert.Equal(t, uint64(3*(2+common.ADDR_LEN)), stats[1].KeySize)
+	assert.Equal(t, uint64(4), stats[1].ValueSize)
+
+	dump, err := DumpContractStorage(store, contract)
+	assert.Nil(t, err)
+	assert.Equal(t, contract.ToHexString(), dump.Contract)
+	assert.Equal(t, uint32(10), dump.Height)
+	assert.Equal(t, 2, dump.Count)
+	assert.Equal(t, []*StorageEntry{{Key: "01", Value: "aa"}, {Key: "02", Value: "bbcc"}}, dump.Storage)
+}
@@ -33,6 +33,7 @@ import (
 
 	common2 "github.com/ethereum/go-ethereum/common"
 	types3 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
@@ -44,7 +45,10 @@ import (
 	"github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/core/store"
 	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/forkstore"
+	"github.com/ontio/ontology/core/store/kvstore"
 	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/store/stateproof"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/errors"
 	"github.com/ontio/ontology/events"
@@ -68,6 +72,8 @@ import (
 const (
 	SYSTEM_VERSION          = byte(1)      //Version of ledger store
 	HEADER_INDEX_BATCH_SIZE = uint32(2000) //Bath size of saving header index
+	MAX_FIND_STATES         = 10000        //Max count of states returned by FindStatesAtHeight
+	MAX_REPLAY_BLOCKS       = 10000        //Max count of blocks reverted to restore the states at a previous height
 )
 
 var (
@@ -78,6 +84,11 @@ var (
 	MerkleTreeStorePath = "merkle_tree.db"
 )
 
+//openStore open the store in dir with the key-value backend in config
+func openStore(dbDir string) (scom.PersistStore, error) {
+	return kvstore.Open(sysconfig.DefConfig.Common.DBBackend, dbDir)
+}
+
 type PrexecuteParam struct {
 	JitMode    bool
 	WasmFactor uint64
@@ -102,16 +113,35 @@ type LedgerStoreImp struct {
 	savingBlockSemaphore       chan bool
 	closing                    bool
 	preserveBlockHistoryLength uint32 // block could be pruned if blockHeight + preserveBlockHistoryLength < currHeight , disable prune if equals 0
+	forked                     bool   // states are forked from remote, genesis block does not change states
+	stateGC                    *stateGC
+	saveWriteSet               bool           // persist the write set of each block for querying storage diff
+	saveReverseWriteSet        bool           // persist the previous values of states changed by each block for rollback
+	storageTrie                *trie.Database // nodes of storage trie for storage proof, nil if disabled
+	light                      []LightRemote  // only the headers are saved, blocks are fetched from remotes if not nil
+	maxReplayBlocks            uint32         // max count of blocks reverted to restore the states at a previous height
+	dataDir                    string
 }
 
 //NewLedgerStore return LedgerStoreImp instance
 func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, error) {
+	return newLedgerStore(dataDir, stateHashHeight, nil)
+}
+
+//NewForkLedgerStore return LedgerStoreImp instance whose states are lazily forked from remote
+func NewForkLedgerStore(dataDir string, stateHashHeight uint32, remote forkstore.RemoteStorage) (*LedgerStoreImp, error) {
+	return newLedgerStore(dataDir, stateHashHeight, remote)
+}
+
+func newLedgerStore(dataDir string, stateHashHeight uint32, remote forkstore.RemoteStorage) (*LedgerStoreImp, error) {
 	ledgerStore := &LedgerStoreImp{
 		headerIndex:          make(map[uint32]common.Uint256),
 		headerCache:          make(map[common.Uint256]*types.Header, 0),
 		vbftPeerInfoMap:      make(map[uint32]map[string]uint32),
 		savingBlockSemaphore: make(chan bool, 1),
 		stateHashCheckHeight: stateHashHeight,
+		maxReplayBlocks:      MAX_REPLAY_BLOCKS,
+		dataDir:              dataDir,
 	}
 
 	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true)
@@ -128,7 +158,13 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 
 	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
 	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
-	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight)
+	var stateStore *StateStore
+	if remote != nil {
+		stateStore, err = NewForkStateStore(dbPath, merklePath, stateHashHeight, remote)
+		ledgerStore.forked = true
+	} else {
+		stateStore, err = NewStateStore(dbPath, merklePath, stateHashHeight)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("NewStateStore error %s", err)
 	}
@@ -207,46 +243,55 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if err != nil {
 			return err
 		}
-		blkInfo, err := vconfig.VbftBlock(header)
+		err = this.loadVbftPeerInfo(header)
 		if err != nil {
 			return err
 		}
-		var cfg *vconfig.ChainConfig
-		var chainConfigHeight uint32
-		if blkInfo.NewChainConfig != nil {
-			cfg = blkInfo.NewChainConfig
-			chainConfigHeight = header.Height
-		} else {
-			cfgHeader, err := this.GetHeaderByHeight(blkInfo.LastConfigBlockNum)
-			if err != nil {
-				return err
-			}
-			Info, err := vconfig.VbftBlock(cfgHeader)
-			if err != nil {
-				return err
-			}
-			if Info.NewChainConfig == nil {
-				return fmt.Errorf("getNewChainConfig error block num:%d", blkInfo.LastConfigBlockNum)
-			}
-			cfg = Info.NewChainConfig
-			chainConfigHeight = cfgHeader.Height
-		}
-		this.lock.Lock()
-		vbftPeerInfo := make(map[string]uint32)
-		this.vbftPeerInfoMap = make(map[uint32]map[string]uint32)
-		for _, p := range cfg.Peers {
-			vbftPeerInfo[p.ID] = p.Index
-		}
-		this.vbftPeerInfoMap[chainConfigHeight] = vbftPeerInfo
-		this.lock.Unlock()
-		val, _ := json.Marshal(vbftPeerInfo)
-		log.Infof("loading vbftPeerInfo at height: %s : %s", header.Height, string(val))
 	}
 	// check and fix imcompatible states
 	err = this.stateStore.CheckStorage()
 	return err
 }
 
+//loadVbftPeerInfo load the vbft peers of the chain config used by the header
+func (this *LedgerStoreImp) loadVbftPeerInfo(header *types.Header) error {
+	blkInfo, err := vconfig.VbftBlock(header)
+	if err != nil {
+		return err
+	}
+	var cfg *vconfig.ChainConfig
+	var chainConfigHeight uint32
+	if blkInfo.NewChainConfig != nil {
+		cfg = blkInfo.NewChainConfig
+		chainConfigHeight = header.Height
+	} else {
+		cfgHeader, err := this.GetHeaderByHeight(blkInfo.LastConfigBlockNum)
+		if err != nil {
+			return err
+		}
+		Info, err := vconfig.VbftBlock(cfgHeader)
+		if err != nil {
+			return err
+		}
+		if Info.NewChainConfig == nil {
+			return fmt.Errorf("getNewChainConfig error block num:%d", blkInfo.LastConfigBlockNum)
+		}
+		cfg = Info.NewChainConfig
+		chainConfigHeight = cfgHeader.Height
+	}
+	this.lock.Lock()
+	vbftPeerInfo := make(map[string]uint32)
+	this.vbftPeerInfoMap = make(map[uint32]map[string]uint32)
+	for _, p := range cfg.Peers {
+		vbftPeerInfo[p.ID] = p.Index
+	}
+	this.vbftPeerInfoMap[chainConfigHeight] = vbftPeerInfo
+	this.lock.Unlock()
+	val, _ := json.Marshal(vbftPeerInfo)
+	log.Infof("loading vbftPeerInfo at height: %s : %s", header.Height, string(val))
+	return nil
+}
+
 func (this *LedgerStoreImp) hasAlreadyInitGenesisBlock() (bool, error) {
 	version, err := this.blockStore.GetVersion()
 	if err != nil && err != scom.ErrNotFound {
@@ -268,6 +313,9 @@ func (this *LedgerStoreImp) init() error {
 	if err != nil {
 		return fmt.Errorf("loadHeaderIndexList error %s", err)
 	}
+	if this.light != nil {
+		return nil
+	}
 	err = this.recoverStore()
 	if err != nil {
 		return fmt.Errorf("recoverStore error %s", err)
@@ -336,7 +384,7 @@ func (this *LedgerStoreImp) recoverStore() error {
 		if err != nil {
 			return fmt.Errorf("save to state store height:%d error:%s", i, err)
 		}
-		this.saveBlockToEventStore(block)
+		this.saveBlockToEventStore(block, result)
 		err = this.eventStore.CommitTo()
 		if err != nil {
 			return fmt.Errorf("eventStore.CommitTo height:%d error %s", i, err)
@@ -566,6 +614,9 @@ func (this *LedgerStoreImp) AddHeader(header *types.Header) error {
 	if err != nil {
 		return fmt.Errorf("verifyHeader error %s", err)
 	}
+	if this.light != nil {
+		return this.addLightHeader(header)
+	}
 	this.addHeaderCache(header)
 	this.setHeaderIndex(header.Height, header.Hash())
 	return nil
@@ -591,6 +642,10 @@ func (this *LedgerStoreImp) GetStateMerkleRoot(height uint32) (common.Uint256, e
 }
 
 func (this *LedgerStoreImp) ExecuteBlock(block *types.Block) (result store.ExecuteResult, err error) {
+	if this.light != nil {
+		err = errLightLedger
+		return
+	}
 	this.getSavingBlockLock()
 	defer this.releaseSavingBlockLock()
 	currBlockHeight := this.GetCurrentBlockHeight()
@@ -610,6 +665,9 @@ func (this *LedgerStoreImp) ExecuteBlock(block *types.Block) (result store.Execu
 }
 
 func (this *LedgerStoreImp) SubmitBlock(block *types.Block, ccMsg *types.CrossChainMsg, result store.ExecuteResult) error {
+	if this.light != nil {
+		return errLightLedger
+	}
 	this.getSavingBlockLock()
 	defer this.releaseSavingBlockLock()
 	if this.closing {
@@ -659,6 +717,9 @@ func (this *LedgerStoreImp) SubmitBlock(block *types.Block, ccMsg *types.CrossCh
 //AddBlock add the block to store.
 //When the block is not the next block, it will be cache. until the missing block arrived
 func (this *LedgerStoreImp) AddBlock(block *types.Block, ccMsg *types.CrossChainMsg, stateMerkleRoot common.Uint256) error {
+	if this.light != nil {
+		return errLightLedger
+	}
 	currBlockHeight := this.GetCurrentBlockHeight()
 	blockHeight := block.Header.Height
 	if blockHeight <= currBlockHeight {
@@ -720,7 +781,22 @@ func (this *LedgerStoreImp) saveBlockToBlockStore(block *types.Block) error {
 }
 
 func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.ExecuteResult, err error) {
-	overlay := this.stateStore.NewOverlayDB()
+	result, err = this.executeBlockOnStates(block, this.stateStore.store)
+	if err != nil {
+		return
+	}
+	// the block reviving a contract collected by state gc is executed again on the restored states
+	restored, err := this.restoreRevivedContracts(result.WriteSet)
+	if err != nil || !restored {
+		return
+	}
+	return this.executeBlockOnStates(block, this.stateStore.store)
+}
+
+// executeBlockOnStates execute block on the states before it, the state merkle root of result is calculated
+// with the current state merkle tree
+func (this *LedgerStoreImp) executeBlockOnStates(block *types.Block, states scom.PersistStore) (result store.ExecuteResult, err error) {
+	overlay := overlaydb.NewOverlayDB(states)
 	if block.Header.Height != 0 {
 		config := &smartcontract.Config{
 			Time:   block.Header.Timestamp,
@@ -728,7 +804,7 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 			Tx:     &types.Transaction{},
 		}
 
-		err = refreshGlobalParam(config, storage.NewCacheDB(this.stateStore.NewOverlayDB()), this)
+		err = refreshGlobalParam(config, storage.NewCacheDB(overlaydb.NewOverlayDB(states)), this)
 		if err != nil {
 			return
 		}
@@ -741,25 +817,51 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 
 		return true
 	})
-	cache := storage.NewCacheDB(overlay)
-	for i, tx := range block.Transactions {
-		cache.Reset()
-		notify, crossStateHashes, e := this.handleTransaction(overlay, cache, gasTable, block, tx, uint32(i))
-		if e != nil {
-			err = e
+	if block.Header.Height == 0 {
+		if !this.forked { // keep the forked states instead of initializing them
+			result.Notify, result.CrossStates, err = this.executeTransactions(overlay, gasTable, block)
+		}
+	} else {
+		var scheduledCrossStates []common.Uint256
+		result.ScheduledNotify, scheduledCrossStates, err = this.executeScheduledCalls(overlay, gasTable, block)
+		if err != nil {
 			return
 		}
+		switch sysconfig.DefConfig.Common.TxExecMode {
+		case sysconfig.ParallelExecMode:
+			result.Notify, result.CrossStates, _, err = this.executeTransactionsParallel(overlay, gasTable, block)
+		case sysconfig.CompareExecMode:
+			result.Notify, result.CrossStates, err = this.compareExecution(states, overlay, gasTable, block)
+		default:
+			result.Notify, result.CrossStates, err = this.executeTransactions(overlay, gasTable, block)
+		}
+		result.CrossStates = append(scheduledCrossStates, result.CrossStates...)
+	}
+	if err != nil {
+		return
+	}
+	for i, notify := range result.Notify {
+		tx := block.Transactions[i]
 		if tx.GasPrice != 0 {
 			notify.GasStepUsed = notify.GasConsumed / tx.GasPrice
 		}
 		notify.TxIndex = uint32(i)
-		result.Notify = append(result.Notify, notify)
-		result.CrossStates = append(result.CrossStates, crossStateHashes...)
 	}
 	result.Hash = overlay.ChangeHash()
 	result.WriteSet = overlay.GetWriteSet()
 	if len(result.CrossStates) != 0 {
 		log.Infof("executeBlock: %d cross states generated at block height:%d", len(result.CrossStates), block.Header.Height)
+	}
+	// the forked ledger can not build the storage trie from remote states
+	if block.Header.Height != 0 && block.Header.Height >= config.GetStorageRootHeight() && !this.forked {
+		root, e := this.executedStorageRoot(block.Header.Height, result.WriteSet)
+		if e != nil {
+			err = e
+			return
+		}
+		result.CrossStates = append(result.CrossStates, stateproof.RootLeaf(root))
+	}
+	if len(result.CrossStates) != 0 {
 		result.CrossStatesRoot = merkle.TreeHasher{}.HashFullTreeWithLeafHash(result.CrossStates)
 	} else {
 		result.CrossStatesRoot = common.UINT256_EMPTY
@@ -815,12 +917,33 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 
+	for _, notify := range result.ScheduledNotify {
+		if err := SaveNotify(this.eventStore, notify.TxHash, notify); err != nil {
+			return err
+		}
+	}
 	for _, notify := range result.Notify {
 		if err := SaveNotify(this.eventStore, notify.TxHash, notify); err != nil {
 			return err
 		}
 	}
 
+	if blockHeight != 0 {
+		if this.saveReverseWriteSet {
+			if err := this.stateStore.SaveReverseWriteSet(blockHeight, result.WriteSet); err != nil {
+				return fmt.Errorf("SaveReverseWriteSet error %s", err)
+			}
+		}
+		if this.saveWriteSet {
+			this.stateStore.SaveWriteSet(blockHeight, result.WriteSet)
+		}
+		if this.storageTrie != nil {
+			if err := this.updateStorageTrie(blockHeight, result.WriteSet); err != nil {
+				return fmt.Errorf("updateStorageTrie error %s", err)
+			}
+		}
+	}
+
 	err := this.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
 	if err != nil {
 		return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
@@ -843,6 +966,10 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 
 	log.Debugf("the state transition hash of block %d is:%s", blockHeight, result.Hash.ToHexString())
 
+	// the contract may be collected by state gc after the block is executed
+	if _, err = this.restoreRevivedContracts(result.WriteSet); err != nil {
+		return err
+	}
 	result.WriteSet.ForEach(func(key, val []byte) {
 		if len(val) == 0 {
 			this.stateStore.BatchDeleteRawKey(key)
@@ -854,10 +981,13 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 	return nil
 }
 
-func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) {
+func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block, result store.ExecuteResult) {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 	txs := make([]common.Uint256, 0)
+	for _, notify := range result.ScheduledNotify {
+		txs = append(txs, notify.TxHash)
+	}
 	for _, tx := range block.Transactions {
 		txHash := tx.Hash()
 		txs = append(txs, txHash)
@@ -913,6 +1043,8 @@ func (this *LedgerStoreImp) tryPruneBlock(header *types.Header) bool {
 		hash := this.GetBlockHash(pruneHeight)
 		txHashes := this.blockStore.PruneBlock(hash)
 		this.eventStore.PruneBlock(pruneHeight, txHashes)
+		this.stateStore.DeleteReverseWriteSet(pruneHeight)
+		this.stateStore.DeleteWriteSet(pruneHeight)
 	}
 	this.blockStore.SaveBlockPrunedHeight(pruneHeight)
 	return true
@@ -944,7 +1076,7 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, crossChainMsg *types
 	if err != nil {
 		return fmt.Errorf("save to state store height:%d error:%s", blockHeight, err)
 	}
-	this.saveBlockToEventStore(block)
+	this.saveBlockToEventStore(block, result)
 	err = this.blockStore.CommitTo()
 	if err != nil {
 		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
@@ -959,6 +1091,7 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, crossChainMsg *types
 		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
 	}
 	this.setCurrentBlock(blockHeight, blockHash)
+	this.tryStateGC(blockHeight)
 
 	if events.DefActorPublisher != nil {
 		events.DefActorPublisher.Publish(
@@ -1035,7 +1168,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			Height:    block.Header.Height,
 			Timestamp: block.Header.Timestamp,
 		}
-		_, err = this.stateStore.HandleEIP155Transaction(this, cache, eiptx, ctx, notify, true)
+		_, crossStateHashes, err = this.stateStore.HandleEIP155Transaction(this, cache, eiptx, ctx, notify, true)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1109,6 +1242,9 @@ func (this *LedgerStoreImp) GetCrossChainMsg(height uint32) (*types.CrossChainMs
 }
 
 func (this *LedgerStoreImp) GetCrossStatesProof(height uint32, key []byte) ([]byte, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	hashes, err := this.stateStore.GetCrossStates(height)
 	if err != nil {
 		return nil, fmt.Errorf("GetCrossStates:%s", err)
@@ -1159,11 +1295,17 @@ func (this *LedgerStoreImp) GetSysFeeAmount(blockHash common.Uint256) (common.Fi
 
 //GetTransaction return transaction by transaction hash. Wrap function of BlockStore.GetTransaction
 func (this *LedgerStoreImp) GetTransaction(txHash common.Uint256) (*types.Transaction, uint32, error) {
+	if this.light != nil {
+		return this.getLightTransaction(txHash)
+	}
 	return this.blockStore.GetTransaction(txHash)
 }
 
 //GetBlockByHash return block by block hash. Wrap function of BlockStore.GetBlockByHash
 func (this *LedgerStoreImp) GetBlockByHash(blockHash common.Uint256) (*types.Block, error) {
+	if this.light != nil {
+		return this.getLightBlock(blockHash)
+	}
 	return this.blockStore.GetBlock(blockHash)
 }
 
@@ -1189,11 +1331,17 @@ func (this *LedgerStoreImp) GetMerkleProof(proofHeight, rootHeight uint32) ([]co
 
 //GetContractState return contract by contract address. Wrap function of StateStore.GetContractState
 func (this *LedgerStoreImp) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	return this.stateStore.GetContractState(contractHash)
 }
 
 //GetStorageItem return the storage value of the key in smart contract. Wrap function of StateStore.GetStorageState
 func (this *LedgerStoreImp) GetStorageItem(contract common.Address, key []byte) ([]byte, error) {
+	if this.light != nil {
+		return this.getLightStorageItem(contract, key)
+	}
 	storageKey := &states.StorageKey{
 		ContractAddress: contract,
 		Key:             key,
@@ -1210,11 +1358,17 @@ func (this *LedgerStoreImp) GetStorageItem(contract common.Address, key []byte)
 
 //GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (this *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	return this.eventStore.GetEventNotifyByTx(tx)
 }
 
 //GetEventNotifyByBlock return the transaction hash which have event notice after execution of smart contract. Wrap function of EventStore.GetEventNotifyByBlock
 func (this *LedgerStoreImp) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	return this.eventStore.GetEventNotifyByBlock(height)
 }
 
@@ -1238,29 +1392,189 @@ func (this *LedgerStoreImp) PreExecuteContractBatch(txes []*types.Transaction, a
 	return results, height, nil
 }
 
+//PreExecuteContractAtHeight return the results of smart contract execution on the states after the block of height,
+//which are restored from current states by the reverse write sets of the later blocks. The eip155 transaction is not
+//supported, and the states are not available if any reverse write set is pruned or the height is more than
+//MAX_REPLAY_BLOCKS blocks before current height.
+func (this *LedgerStoreImp) PreExecuteContractAtHeight(txes []*types.Transaction, height uint32) ([]*sstate.PreExecResult, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
+	overlay, release, err := this.stateOverlayAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	header, err := this.GetHeaderByHeight(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderByHeight height:%d error:%s", height, err)
+	}
+	param := PrexecuteParam{MinGas: true}
+	results := make([]*sstate.PreExecResult, 0, len(txes))
+	for _, tx := range txes {
+		if tx.IsEipTx() {
+			return nil, fmt.Errorf("eip155 transaction is not supported at height %d", height)
+		}
+		res, err := this.preExecuteOnOverlay(tx, param, height, header.Timestamp+1, overlay)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+//GetStateAtHeight return the raw value of contract state key after the block of height, nil if not exist. The key
+//should be one of the contract states, like the contract, storage, destroyed contract and eth account and code.
+func (this *LedgerStoreImp) GetStateAtHeight(key []byte, height uint32) ([]byte, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
+	if !forkstore.IsRemoteKey(key) {
+		return nil, fmt.Errorf("key %x is not a contract state", key)
+	}
+	overlay, release, err := this.stateOverlayAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return overlay.Get(key)
+}
+
+//FindStatesAtHeight return the raw contract states whose keys start with prefix after the block of height, the prefix
+//should be inside a single contract, and no more than MAX_FIND_STATES states are returned
+func (this *LedgerStoreImp) FindStatesAtHeight(prefix []byte, height uint32) ([]*store.StateItem, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
+	if !forkstore.IsRemoteKey(prefix) {
+		return nil, fmt.Errorf("prefix %x is not inside a contract", prefix)
+	}
+	overlay, release, err := this.stateOverlayAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	iter := overlay.NewIterator(prefix)
+	defer iter.Release()
+	var items []*store.StateItem
+	for has := iter.First(); has; has = iter.Next() {
+		if len(items) == MAX_FIND_STATES {
+			return nil, fmt.Errorf("more than %d states are found with prefix %x", MAX_FIND_STATES, prefix)
+		}
+		items = append(items, &store.StateItem{
+			Key:   append([]byte{}, iter.Key()...),
+			Value: append([]byte{}, iter.Value()...),
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// snapshotStore is the persist store which can keep a read only view of its current data
+type snapshotStore interface {
+	NewSnapshot() (scom.PersistStore, error)
+}
+
+//stateOverlayAtHeight return the states after the block of height, which are restored from a snapshot of current
+//states by the reverse write sets of the later blocks, and the function releasing the snapshot. The saving block lock
+//is only held to take the snapshot, and no more than maxReplayBlocks blocks are reverted.
+func (this *LedgerStoreImp) stateOverlayAtHeight(height uint32) (*overlaydb.OverlayDB, func(), error) {
+	snapshot, stateHeight, err := this.stateSnapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	if height > stateHeight {
+		snapshot.Close()
+		return nil, nil, fmt.Errorf("height %d is higher than current block height %d", height, stateHeight)
+	}
+	if stateHeight-height > this.maxReplayBlocks {
+		snapshot.Close()
+		return nil, nil, fmt.Errorf("states of height %d are not available, more than %d blocks before current height %d",
+			height, this.maxReplayBlocks, stateHeight)
+	}
+	overlay := overlaydb.NewOverlayDB(snapshot)
+	for h := stateHeight; h > height; h-- {
+		writeSet, err := this.stateStore.getReverseWriteSet(snapshot, h)
+		if err == scom.ErrNotFound {
+			err = fmt.Errorf("states of height %d are not available, reverse write set of block %d is pruned", height, h)
+		} else if err != nil {
+			err = fmt.Errorf("stateStore.GetReverseWriteSet height:%d error:%s", h, err)
+		}
+		if err != nil {
+			snapshot.Close()
+			return nil, nil, err
+		}
+		writeSet.ForEach(func(key, val []byte) {
+			if len(val) == 0 {
+				overlay.Delete(key)
+			} else {
+				overlay.Put(key, val)
+			}
+		})
+	}
+	return overlay, func() { snapshot.Close() }, nil
+}
+
+// stateSnapshot return a snapshot of state store and its current height under the saving block lock
+func (this *LedgerStoreImp) stateSnapshot() (scom.PersistStore, uint32, error) {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	if this.forked {
+		return nil, 0, fmt.Errorf("states at height are not supported by the ledger forked from remote")
+	}
+	store, ok := this.stateStore.store.(snapshotStore)
+	if !ok {
+		return nil, 0, fmt.Errorf("states at height are not supported by the state store")
+	}
+	_, stateHeight, err := this.stateStore.GetCurrentBlock()
+	if err != nil {
+		return nil, 0, fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	snapshot, err := store.NewSnapshot()
+	if err != nil {
+		return nil, 0, fmt.Errorf("state store snapshot error %s", err)
+	}
+	return snapshot, stateHeight, nil
+}
+
 func (this *LedgerStoreImp) PreExecuteEIP155(tx *types3.Transaction, ctx Eip155Context) (*types4.ExecutionResult, *event.ExecuteNotify, error) {
 	overlay := this.stateStore.NewOverlayDB()
 	cache := storage.NewCacheDB(overlay)
 
 	notify := &event.ExecuteNotify{State: event.CONTRACT_STATE_FAIL, TxIndex: ctx.TxIndex}
-	result, err := this.stateStore.HandleEIP155Transaction(this, cache, tx, ctx, notify, false)
+	result, _, err := this.stateStore.HandleEIP155Transaction(this, cache, tx, ctx, notify, false)
 	return result, notify, err
 }
 
 func (this *LedgerStoreImp) GetEthCode(hash common2.Hash) ([]byte, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	return this.stateStore.GetEthCode(hash)
 }
 
 func (this *LedgerStoreImp) GetEthState(address common2.Address, key common2.Hash) ([]byte, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	return this.stateStore.GetEthState(address, key)
 }
 
 func (this *LedgerStoreImp) GetEthAccount(address common2.Address) (*storage.EthAccount, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	return this.stateStore.GetEthAccount(address)
 }
 
 //PreExecuteContract return the result of smart contract execution without commit to store
 func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, preParam PrexecuteParam) (*sstate.PreExecResult, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	height := this.GetCurrentBlockHeight()
 	// use previous block time to make it predictable for easy test
 	blockTime := uint32(time.Now().Unix())
@@ -1290,6 +1604,14 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 		return stf, nil
 	}
 
+	return this.preExecuteOnOverlay(tx, preParam, height, blockTime, this.stateStore.NewOverlayDB())
+}
+
+//preExecuteOnOverlay pre-execute the neovm, wasm or deploy transaction on the states of overlay after the block of
+//height
+func (this *LedgerStoreImp) preExecuteOnOverlay(tx *types.Transaction, preParam PrexecuteParam, height uint32,
+	blockTime uint32, overlay *overlaydb.OverlayDB) (*sstate.PreExecResult, error) {
+	stf := &sstate.PreExecResult{State: event.CONTRACT_STATE_FAIL, Gas: neovm.MIN_TRANSACTION_GAS, Result: nil}
 	sconfig := &smartcontract.Config{
 		Time:      blockTime,
 		Height:    height + 1,
@@ -1297,7 +1619,6 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 		BlockHash: this.GetBlockHash(height),
 	}
 
-	overlay := this.stateStore.NewOverlayDB()
 	cache := storage.NewCacheDB(overlay)
 	gasTable := make(map[string]uint64)
 	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
@@ -1393,6 +1714,9 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 }
 
 func (this *LedgerStoreImp) PreExecuteEip155Tx(msg types3.Message) (*types4.ExecutionResult, error) {
+	if this.light != nil {
+		return nil, errLightLedger
+	}
 	height := this.GetCurrentBlockHeight()
 	// use previous block time to make it predictable for easy test
 	blockTime := uint32(time.Now().Unix())
@@ -1418,6 +1742,7 @@ func (this *LedgerStoreImp) PreExecuteEip155Tx(msg types3.Message) (*types4.Exec
 
 //Close ledger store.
 func (this *LedgerStoreImp) Close() error {
+	this.stopStateGC()
 	// wait block saving complete, and get the lock to avoid subsequent block saving
 	this.getSavingBlockLock()
 	defer this.releaseSavingBlockLock()
@@ -1436,6 +1761,10 @@ func (this *LedgerStoreImp) Close() error {
 	if err != nil {
 		return fmt.Errorf("stateStore close error %s", err)
 	}
+	err = this.crossChainStore.Close()
+	if err != nil {
+		return fmt.Errorf("crossChainStore close error %s", err)
+	}
 	return nil
 }
 
@@ -1451,6 +1780,25 @@ func (this *LedgerStoreImp) EnableBlockPrune(numBeforeCurr uint32) {
 	this.preserveBlockHistoryLength = numBeforeCurr
 }
 
+//EnableWriteSetSaving persist the states changed by each block saved later and their previous values, which
+//GetStorageDiff is based on
+func (this *LedgerStoreImp) EnableWriteSetSaving() {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	this.saveWriteSet = true
+	this.saveReverseWriteSet = true
+}
+
+//EnableReverseWriteSetSaving persist the previous values of the states changed by each block saved later, which
+//rollback and querying the states at a previous height are based on
+func (this *LedgerStoreImp) EnableReverseWriteSetSaving() {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	this.saveReverseWriteSet = true
+}
+
 func (this *LedgerStoreImp) maxAllowedPruneHeight(currHeader *types.Header) uint32 {
 	if currHeader.Height <= config.GetContractApiDeprecateHeight() {
 		return 0
@@ -0,0 +1,224 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/stateproof"
+	"github.com/ontio/ontology/core/types"
+)
+
+// only the contract storage is committed by block headers since the storage root height, the other states and the
+// events can not be verified by the light ledger
+var errLightLedger = fmt.Errorf("states other than contract storage and events are not available in light ledger")
+
+//LightRemote fetch the blocks and storage proofs of light ledger on demand from a full node, they are verified with
+//the headers synced by light ledger, so the full node is not trusted
+type LightRemote interface {
+	GetBlockByHeight(height uint32) (*types.Block, error)
+	GetBlockHeightByTxHash(txHash common.Uint256) (uint32, error)
+	GetStorageProof(contract common.Address, key []byte, height uint32) (*stateproof.StorageProof, error)
+}
+
+//NewLightLedgerStore return LedgerStoreImp instance which only saves the verified block headers, the blocks are
+//fetched from the remotes in turn when queried and the transactions are not executed
+func NewLightLedgerStore(dataDir string, remotes []LightRemote) (*LedgerStoreImp, error) {
+	if len(remotes) == 0 {
+		return nil, fmt.Errorf("no remote of light ledger")
+	}
+	ledgerStore, err := newLedgerStore(dataDir, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	ledgerStore.light = remotes
+	return ledgerStore, nil
+}
+
+//IsLight return whether the ledger only saves the block headers
+func (this *LedgerStoreImp) IsLight() bool {
+	return this.light != nil
+}
+
+// addLightHeader persist the verified header as the current block of light ledger, with the block merkle tree
+// updated so the block root of next header can be checked
+func (this *LedgerStoreImp) addLightHeader(header *types.Header) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	if this.closing {
+		return fmt.Errorf("save header error: ledger is closing")
+	}
+	blockHash := header.Hash()
+	blockHeight := header.Height
+	blockRoot := this.GetBlockRootWithNewTxRoots(blockHeight, []common.Uint256{header.TransactionsRoot})
+	if blockRoot != header.BlockRoot {
+		return fmt.Errorf("wrong block root at height:%d, expected:%s, got:%s",
+			blockHeight, blockRoot.ToHexString(), header.BlockRoot.ToHexString())
+	}
+
+	this.blockStore.NewBatch()
+	this.stateStore.NewBatch()
+	this.setHeaderIndex(blockHeight, blockHash)
+	if err := this.saveHeaderIndexList(); err != nil {
+		return fmt.Errorf("saveHeaderIndexList error %s", err)
+	}
+	if err := this.blockStore.SaveCurrentBlock(blockHeight, blockHash); err != nil {
+		return fmt.Errorf("SaveCurrentBlock error %s", err)
+	}
+	this.blockStore.SaveBlockHash(blockHeight, blockHash)
+	if err := this.blockStore.SaveHeader(&types.Block{Header: header}, 0); err != nil {
+		return fmt.Errorf("SaveHeader height %d error %s", blockHeight, err)
+	}
+	if err := this.stateStore.AddBlockMerkleTreeRoot(header.TransactionsRoot); err != nil {
+		return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
+	}
+	if err := this.blockStore.CommitTo(); err != nil {
+		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	if err := this.stateStore.CommitTo(); err != nil {
+		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	this.setCurrentBlock(blockHeight, blockHash)
+	return nil
+}
+
+// fetchLight call fetch with the remotes in turn until one of them serves the verified data, and return the error
+// of the last remote if none of them does
+func (this *LedgerStoreImp) fetchLight(fetch func(remote LightRemote) error) error {
+	var err error
+	for i, remote := range this.light {
+		if err = fetch(remote); err == nil {
+			return nil
+		}
+		log.Warnf("[fetchLight] remote %d of light ledger error: %s", i, err)
+	}
+	return err
+}
+
+// getLightBlock fetch the block of header from remote, and check it with the header
+func (this *LedgerStoreImp) getLightBlock(blockHash common.Uint256) (*types.Block, error) {
+	header, err := this.blockStore.GetHeader(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	var block *types.Block
+	err = this.fetchLight(func(remote LightRemote) error {
+		fetched, err := remote.GetBlockByHeight(header.Height)
+		if err != nil {
+			return fmt.Errorf("fetch block of height %d error: %s", header.Height, err)
+		}
+		if err = verifyLightBlock(header, fetched); err != nil {
+			return fmt.Errorf("invalid block of height %d: %s", header.Height, err)
+		}
+		block = fetched
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func verifyLightBlock(header *types.Header, block *types.Block) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("block of height %d is not found", header.Height)
+	}
+	if block.Hash() != header.Hash() {
+		return fmt.Errorf("block hash %s is not %s", block.Hash().ToHexString(), header.Hash().ToHexString())
+	}
+	hashes := make([]common.Uint256, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		hashes = append(hashes, tx.Hash())
+	}
+	if root := common.ComputeMerkleRoot(hashes); root != header.TransactionsRoot {
+		return fmt.Errorf("transactions root %s is not %s", root.ToHexString(), header.TransactionsRoot.ToHexString())
+	}
+	return nil
+}
+
+// getLightTransaction find the transaction in the block of the height reported by remote
+func (this *LedgerStoreImp) getLightTransaction(txHash common.Uint256) (*types.Transaction, uint32, error) {
+	var tx *types.Transaction
+	var height uint32
+	err := this.fetchLight(func(remote LightRemote) error {
+		var err error
+		height, err = remote.GetBlockHeightByTxHash(txHash)
+		if err != nil {
+			return fmt.Errorf("fetch height of transaction %s error: %s", txHash.ToHexString(), err)
+		}
+		header, err := this.GetHeaderByHeight(height)
+		if err != nil {
+			return fmt.Errorf("header of height %d is not synced", height)
+		}
+		block, err := remote.GetBlockByHeight(height)
+		if err != nil {
+			return fmt.Errorf("fetch block of height %d error: %s", height, err)
+		}
+		if err = verifyLightBlock(header, block); err != nil {
+			return fmt.Errorf("invalid block of height %d: %s", height, err)
+		}
+		for _, t := range block.Transactions {
+			if t.Hash() == txHash {
+				tx = t
+				return nil
+			}
+		}
+		return scom.ErrNotFound
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return tx, height, nil
+}
+
+// getLightStorageItem fetch the proof of contract storage at the height before current header, and verify it with the
+// current header whose bookkeepers sign the storage root, so the value is the one before the latest block
+func (this *LedgerStoreImp) getLightStorageItem(contract common.Address, key []byte) ([]byte, error) {
+	header, err := this.GetHeaderByHeight(this.GetCurrentHeaderHeight())
+	if err != nil {
+		return nil, err
+	}
+	if header.Height < 2 || header.Height-1 < config.GetStorageRootHeight() {
+		return nil, fmt.Errorf("storage root of height %d is not committed", header.Height-1)
+	}
+	height := header.Height - 1
+	var value []byte
+	err = this.fetchLight(func(remote LightRemote) error {
+		proof, err := remote.GetStorageProof(contract, key, height)
+		if err != nil {
+			return fmt.Errorf("fetch storage proof of height %d error: %s", height, err)
+		}
+		if proof.Contract != contract.ToHexString() || proof.Key != hex.EncodeToString(key) {
+			return fmt.Errorf("storage proof of %s %s is not the one queried", proof.Contract, proof.Key)
+		}
+		if value, err = proof.VerifyWithHeader(header); err != nil {
+			return fmt.Errorf("invalid storage proof of height %d: %s", height, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
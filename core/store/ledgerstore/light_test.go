@@ -0,0 +1,192 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/store/stateproof"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockLightRemote serves the blocks and storage proofs of a full ledger, the transactions of block are dropped and the
+// storage value is changed if tamper is set
+type mockLightRemote struct {
+	full       *LedgerStoreImp
+	bookkeeper *account.Account
+	tamper     bool
+}
+
+func (self *mockLightRemote) GetBlockByHeight(height uint32) (*types.Block, error) {
+	block, err := self.full.GetBlockByHeight(height)
+	if err != nil || !self.tamper {
+		return block, err
+	}
+	return &types.Block{Header: block.Header}, nil
+}
+
+func (self *mockLightRemote) GetBlockHeightByTxHash(txHash common.Uint256) (uint32, error) {
+	_, height, err := self.full.GetTransaction(txHash)
+	return height, err
+}
+
+// GetStorageProof sign the cross chain msg of the height with the bookkeeper, which is not saved by the test blocks
+func (self *mockLightRemote) GetStorageProof(contract common.Address, key []byte, height uint32) (*stateproof.StorageProof, error) {
+	proof, err := self.full.GetStorageProof(contract, key, height)
+	if err != nil {
+		return nil, err
+	}
+	root, err := self.full.GetCrossStatesRoot(height)
+	if err != nil {
+		return nil, err
+	}
+	msg := &types.CrossChainMsg{Version: types.CURR_CROSS_STATES_VERSION, Height: height, StatesRoot: root}
+	hash := msg.Hash()
+	sig, err := signature.Sign(self.bookkeeper, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	msg.SigData = [][]byte{sig}
+	proof.CrossMsg = hex.EncodeToString(common.SerializeToBytes(msg))
+	if self.tamper {
+		proof.Value = hex.EncodeToString([]byte("tampered"))
+	}
+	return proof, nil
+}
+
+func TestLightLedger(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	bookkeeper := account.NewAccount("")
+	genesisConfig := config.DefConfig.Genesis
+	defer func() { config.DefConfig.Genesis = genesisConfig }()
+	config.DefConfig.Genesis = &config.GenesisConfig{
+		ConsensusType: config.CONSENSUS_TYPE_SOLO,
+		SOLO: &config.SOLOConfig{
+			Bookkeepers: []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey))},
+		},
+		StorageRootHeight: 1,
+	}
+
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	full := newTestLedger(t, "test/lightfull", bookkeeper, accounts)
+	defer full.Close()
+	var txs []*types.Transaction
+	for i := 0; i < 3; i++ {
+		tx := newTransferTx(t, accounts[0], accounts[1].Address, 1)
+		addTestBlock(t, full, bookkeeper, []*types.Transaction{tx})
+		txs = append(txs, tx)
+	}
+
+	remote := &mockLightRemote{full: full, bookkeeper: bookkeeper}
+	backup := &mockLightRemote{full: full, bookkeeper: bookkeeper}
+	light, err := NewLightLedgerStore("test/light", []LightRemote{remote, backup})
+	assert.Nil(t, err)
+	defer light.Close()
+	genesis, err := full.GetBlockByHeight(0)
+	assert.Nil(t, err)
+	bookkeepers := []keypair.PublicKey{bookkeeper.PublicKey}
+	assert.Nil(t, light.InitLedgerStoreWithGenesisBlock(genesis, bookkeepers))
+	assert.True(t, light.IsLight())
+
+	// the header signed by other bookkeeper is refused
+	forged := newTestBlock(t, light, account.NewAccount(""), nil)
+	assert.NotNil(t, light.AddHeaders([]*types.Header{forged.Header}))
+
+	var headers []*types.Header
+	for h := uint32(1); h <= 3; h++ {
+		header, err := full.GetHeaderByHeight(h)
+		assert.Nil(t, err)
+		headers = append(headers, header)
+	}
+	assert.Nil(t, light.AddHeaders(headers))
+	assert.Equal(t, uint32(3), light.GetCurrentBlockHeight())
+	assert.Equal(t, uint32(3), light.GetCurrentHeaderHeight())
+	assert.Equal(t, full.GetCurrentBlockHash(), light.GetCurrentBlockHash())
+
+	// the blocks and transactions are fetched and verified with the headers
+	block, err := light.GetBlockByHeight(2)
+	assert.Nil(t, err)
+	assert.Equal(t, full.GetBlockHash(2), block.Hash())
+	assert.Equal(t, 1, len(block.Transactions))
+	tx, txHeight, err := light.GetTransaction(txs[2].Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), txHeight)
+	assert.Equal(t, txs[2].Hash(), tx.Hash())
+
+	// the storage before current header is proven with the storage root signed by its bookkeepers
+	expectedValue, err := full.GetStorageItem(utils.OntContractAddress, accounts[0].Address[:])
+	assert.Nil(t, err)
+	value, err := light.GetStorageItem(utils.OntContractAddress, accounts[0].Address[:])
+	assert.Nil(t, err)
+	assert.NotEqual(t, expectedValue, value)
+	proof, err := full.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 2)
+	assert.Nil(t, err)
+	assert.Equal(t, proof.Value, hex.EncodeToString(value))
+
+	// the invalid data of remote is skipped, and refused if no remote serves the valid one
+	remote.tamper = true
+	block, err = light.GetBlockByHeight(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(block.Transactions))
+	_, _, err = light.GetTransaction(txs[2].Hash())
+	assert.Nil(t, err)
+	value, err = light.GetStorageItem(utils.OntContractAddress, accounts[0].Address[:])
+	assert.Nil(t, err)
+	assert.Equal(t, proof.Value, hex.EncodeToString(value))
+	backup.tamper = true
+	_, err = light.GetBlockByHeight(2)
+	assert.NotNil(t, err)
+	_, _, err = light.GetTransaction(txs[2].Hash())
+	assert.NotNil(t, err)
+	_, err = light.GetStorageItem(utils.OntContractAddress, accounts[0].Address[:])
+	assert.NotNil(t, err)
+
+	// the block merkle tree is kept with headers
+	merkleProof, err := light.GetMerkleProof(1, 3)
+	assert.Nil(t, err)
+	expected, err := full.GetMerkleProof(1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, merkleProof)
+
+	_, err = light.GetContractState(utils.OntContractAddress)
+	assert.Equal(t, errLightLedger, err)
+	_, err = light.GetEventNotifyByBlock(1)
+	assert.Equal(t, errLightLedger, err)
+	assert.NotNil(t, light.AddBlock(block, nil, common.UINT256_EMPTY))
+
+	// the headers are persisted as the current block
+	hash, height, err := light.blockStore.GetCurrentBlock()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), height)
+	assert.Equal(t, full.GetBlockHash(3), hash)
+	header, err := light.blockStore.GetHeader(hash)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, header.Hash())
+}
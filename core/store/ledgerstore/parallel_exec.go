@@ -0,0 +1,334 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/serialization"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// overlayStore is the read only store view of the states in overlay
+type overlayStore struct {
+	scom.PersistStore // write methods are never called by the overlay on it
+	state             *overlaydb.OverlayDB
+}
+
+func (self *overlayStore) Get(key []byte) ([]byte, error) {
+	val, err := self.state.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, scom.ErrNotFound
+	}
+	return val, nil
+}
+
+func (self *overlayStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (self *overlayStore) NewIterator(prefix []byte) scom.StoreIterator {
+	return self.state.NewIterator(prefix)
+}
+
+// govOngBalanceKey is the ong balance of governance contract, which receives the gas fee of every transaction
+var govOngBalanceKey = append([]byte{byte(scom.ST_STORAGE)},
+	ont.GenBalanceKey(utils.OngContractAddress, utils.GovernanceContractAddress)...)
+
+// readSetStore is the state view of a transaction executed speculatively on the block state,
+// it records the keys and iterator prefixes read from the block state
+type readSetStore struct {
+	overlayStore
+	keys     map[string]struct{}
+	prefixes [][]byte
+	feeReads int    // times the governance ong balance is read
+	feeBase  []byte // the governance ong balance read
+}
+
+func newReadSetStore(state *overlaydb.OverlayDB) *readSetStore {
+	return &readSetStore{
+		overlayStore: overlayStore{state: state},
+		keys:         make(map[string]struct{}),
+	}
+}
+
+func (self *readSetStore) Get(key []byte) ([]byte, error) {
+	self.keys[string(key)] = struct{}{}
+	val, err := self.overlayStore.Get(key)
+	if bytes.Equal(key, govOngBalanceKey) {
+		self.feeReads += 1
+		self.feeBase = val
+	}
+	return val, err
+}
+
+func (self *readSetStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (self *readSetStore) NewIterator(prefix []byte) scom.StoreIterator {
+	prefix = append([]byte{}, prefix...)
+	self.prefixes = append(self.prefixes, prefix)
+	return self.overlayStore.NewIterator(prefix)
+}
+
+// conflict return whether any state read by the transaction is changed in the write set, the governance
+// ong balance is skipped if the gas fee credited to it is settled on the latest balance
+func (self *readSetStore) conflict(writeSet *overlaydb.MemDB, feeSettled bool) bool {
+	for key := range self.keys {
+		if feeSettled && key == string(govOngBalanceKey) {
+			continue
+		}
+		if _, unknown := writeSet.Get([]byte(key)); !unknown {
+			return true
+		}
+	}
+	for _, prefix := range self.prefixes {
+		iter := writeSet.NewIterator(util.BytesPrefix(prefix))
+		changed := iter.First()
+		iter.Release()
+		if changed {
+			return true
+		}
+	}
+	return false
+}
+
+type speculativeResult struct {
+	overlay          *overlaydb.OverlayDB
+	reads            *readSetStore
+	notify           *event.ExecuteNotify
+	crossStateHashes []common.Uint256
+	err              error
+}
+
+// settledFee return the gas fee the transaction credits to governance contract, and whether the fee can be settled
+// on the latest balance in order, so the fee credits of the former transactions are not conflicts. It is only the
+// case when the balance is read once by the transfer of gas fee, and increased by the gas consumed exactly.
+func (self *speculativeResult) settledFee() (uint64, bool) {
+	if self.err != nil || self.reads.feeReads != 1 {
+		return 0, false
+	}
+	val, unknown := self.overlay.GetWriteSet().Get(govOngBalanceKey)
+	if unknown {
+		return 0, false
+	}
+	before, err := decodeOngBalance(self.reads.feeBase)
+	if err != nil {
+		return 0, false
+	}
+	after, err := decodeOngBalance(val)
+	if err != nil || after < before || after-before != self.notify.GasConsumed {
+		return 0, false
+	}
+	return after - before, true
+}
+
+// commit the write set of transaction to the block state, the gas fee settled is added to the latest balance
+// of governance contract like the transfer of gas fee does
+func (self *speculativeResult) commit(overlay *overlaydb.OverlayDB, fee uint64, feeSettled bool) error {
+	self.overlay.GetWriteSet().ForEach(func(key, val []byte) {
+		if feeSettled && bytes.Equal(key, govOngBalanceKey) {
+			return
+		}
+		if len(val) == 0 {
+			overlay.Delete(key)
+		} else {
+			overlay.Put(key, val)
+		}
+	})
+	if !feeSettled {
+		return nil
+	}
+	val, err := overlay.Get(govOngBalanceKey)
+	if err != nil {
+		return err
+	}
+	balance, err := decodeOngBalance(val)
+	if err != nil {
+		return err
+	}
+	overlay.Put(govOngBalanceKey, ont.GetToUInt64StorageItem(balance, fee).ToArray())
+	return nil
+}
+
+func decodeOngBalance(raw []byte) (uint64, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	item := new(states.StorageItem)
+	if err := item.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return 0, err
+	}
+	return serialization.ReadUint64(bytes.NewBuffer(item.Value))
+}
+
+func (this *LedgerStoreImp) executeSpeculatively(state *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block, txIndex uint32) (result *speculativeResult) {
+	reads := newReadSetStore(state)
+	overlay := overlaydb.NewOverlayDB(reads)
+	result = &speculativeResult{overlay: overlay, reads: reads}
+	defer func() {
+		// the transaction is executed in order again, so do not crash the node here
+		if r := recover(); r != nil {
+			result.err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	cache := storage.NewCacheDB(overlay)
+	result.notify, result.crossStateHashes, result.err = this.handleTransaction(overlay, cache, gasTable, block,
+		block.Transactions[txIndex], txIndex)
+	return
+}
+
+// executeTransactionsParallel executes all the transactions concurrently on the state before the block,
+// then commits their write sets in order. The transaction reading state changed by the former transactions
+// of block is executed again on the latest state, so the result is identical to sequential execution. The
+// gas fee credited to governance contract is settled in order on commit instead of being a conflict.
+func (this *LedgerStoreImp) executeTransactionsParallel(overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block) (notifies []*event.ExecuteNotify, crossStates []common.Uint256, reexecuted int, err error) {
+	txs := block.Transactions
+	results := make([]*speculativeResult, len(txs))
+	workers := runtime.NumCPU()
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	next := int64(-1)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				index := atomic.AddInt64(&next, 1)
+				if index >= int64(len(txs)) {
+					return
+				}
+				results[index] = this.executeSpeculatively(overlay, gasTable, block, uint32(index))
+			}
+		}()
+	}
+	wg.Wait()
+
+	cache := storage.NewCacheDB(overlay)
+	for i, tx := range txs {
+		res := results[i]
+		fee, feeSettled := res.settledFee()
+		if res.err != nil || res.overlay.Error() != nil || res.reads.conflict(overlay.GetWriteSet(), feeSettled) {
+			reexecuted += 1
+			cache.Reset()
+			res.notify, res.crossStateHashes, err = this.handleTransaction(overlay, cache, gasTable, block, tx, uint32(i))
+			if err != nil {
+				return
+			}
+		} else if err = res.commit(overlay, fee, feeSettled); err != nil {
+			return
+		}
+		notifies = append(notifies, res.notify)
+		crossStates = append(crossStates, res.crossStateHashes...)
+	}
+	return
+}
+
+func (this *LedgerStoreImp) executeTransactions(overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block) (notifies []*event.ExecuteNotify, crossStates []common.Uint256, err error) {
+	cache := storage.NewCacheDB(overlay)
+	for i, tx := range block.Transactions {
+		cache.Reset()
+		notify, crossStateHashes, e := this.handleTransaction(overlay, cache, gasTable, block, tx, uint32(i))
+		if e != nil {
+			err = e
+			return
+		}
+		notifies = append(notifies, notify)
+		crossStates = append(crossStates, crossStateHashes...)
+	}
+	return
+}
+
+// compareExecution executes the block in both modes on the states and reports the difference, the result
+// of sequential execution on overlay is returned
+func (this *LedgerStoreImp) compareExecution(states scom.PersistStore, overlay *overlaydb.OverlayDB,
+	gasTable map[string]uint64, block *types.Block) ([]*event.ExecuteNotify, []common.Uint256, error) {
+	height := block.Header.Height
+	start := time.Now()
+	notifies, crossStates, err := this.executeTransactions(overlay, gasTable, block)
+	if err != nil {
+		return nil, nil, err
+	}
+	sequential := time.Since(start)
+
+	parallelOverlay := overlaydb.NewOverlayDB(states)
+	if _, _, err := this.executeScheduledCalls(parallelOverlay, gasTable, block); err != nil {
+		log.Errorf("compareExecution: scheduled calls of block %d error: %s", height, err)
+		return notifies, crossStates, nil
+	}
+	start = time.Now()
+	parallelNotifies, parallelCrossStates, reexecuted, err := this.executeTransactionsParallel(parallelOverlay,
+		gasTable, block)
+	parallel := time.Since(start)
+	if err != nil {
+		log.Errorf("compareExecution: parallel execution of block %d error: %s", height, err)
+		return notifies, crossStates, nil
+	}
+	log.Infof("compareExecution: block %d with %d txs, sequential: %s, parallel: %s, re-executed: %d",
+		height, len(block.Transactions), sequential, parallel, reexecuted)
+
+	if hash, parallelHash := overlay.ChangeHash(), parallelOverlay.ChangeHash(); hash != parallelHash {
+		log.Errorf("compareExecution: write set hash of block %d mismatch, sequential: %s, parallel: %s",
+			height, hash.ToHexString(), parallelHash.ToHexString())
+	}
+	if !reflect.DeepEqual(crossStates, parallelCrossStates) {
+		log.Errorf("compareExecution: cross states of block %d mismatch", height)
+	}
+	for i := range notifies {
+		if !reflect.DeepEqual(notifies[i], parallelNotifies[i]) {
+			log.Errorf("compareExecution: execute notify of tx %s in block %d mismatch",
+				notifies[i].TxHash.ToHexString(), height)
+		}
+	}
+	return notifies, crossStates, nil
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/constants"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/signature"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTransferTx(t *testing.T, from *account.Account, to common.Address, value uint64) *types.Transaction {
+	states := []*ont.State{{From: from.Address, To: to, Value: value}}
+	code, err := utils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, ont.TRANSFER_NAME, []interface{}{states})
+	assert.Nil(t, err)
+	return newInvokeTx(t, from, code, 0)
+}
+
+func newInvokeTx(t *testing.T, from *account.Account, code []byte, gasPrice uint64) *types.Transaction {
+	mutable := utils.NewInvokeTransaction(code)
+	mutable.GasLimit = math.MaxUint64
+	mutable.GasPrice = gasPrice
+	mutable.Payer = from.Address
+	txHash := mutable.Hash()
+	sig, err := signature.Sign(from, txHash.ToArray())
+	assert.Nil(t, err)
+	mutable.Sigs = []types.Sig{{PubKeys: []keypair.PublicKey{from.PublicKey}, M: 1, SigData: [][]byte{sig}}}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func newTestLedger(t *testing.T, dataDir string, bookkeeper *account.Account, accounts []*account.Account) *LedgerStoreImp {
+	genesisConfig := config.DefConfig.Genesis
+	defer func() { config.DefConfig.Genesis = genesisConfig }()
+	solo := &config.SOLOConfig{
+		GenBlockTime: config.DEFAULT_GEN_BLOCK_TIME,
+		Bookkeepers:  []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey))},
+	}
+	for _, acct := range accounts {
+		solo.Prefunded = append(solo.Prefunded, &config.PrefundedAccount{Address: acct.Address.ToBase58(), ONT: 1000})
+	}
+	config.DefConfig.Genesis = &config.GenesisConfig{ConsensusType: config.CONSENSUS_TYPE_SOLO, SOLO: solo}
+
+	bookkeepers := []keypair.PublicKey{bookkeeper.PublicKey}
+	block, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	ledger, err := NewLedgerStore(dataDir, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitLedgerStoreWithGenesisBlock(block, bookkeepers))
+	return ledger
+}
+
+func TestExecuteTransactionsParallel(t *testing.T) {
+	accounts := make([]*account.Account, 8)
+	for i := range accounts {
+		accounts[i] = account.NewAccount("")
+	}
+	ledger := newTestLedger(t, "test/parallel", account.NewAccount(""), accounts)
+	defer ledger.Close()
+
+	gasTable := make(map[string]uint64)
+	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		gasTable[k.(string)] = value.(uint64)
+		return true
+	})
+	executeOn := func(states scom.PersistStore, txs []*types.Transaction) ([]byte, int) {
+		block := &types.Block{
+			Header:       &types.Header{Height: 1, Timestamp: constants.GENESIS_BLOCK_TIMESTAMP + 1},
+			Transactions: txs,
+		}
+		overlay := overlaydb.NewOverlayDB(states)
+		notifies, crossStates, err := ledger.executeTransactions(overlay, gasTable, block)
+		assert.Nil(t, err)
+		parallelOverlay := overlaydb.NewOverlayDB(states)
+		parallelNotifies, parallelCrossStates, reexecuted, err := ledger.executeTransactionsParallel(parallelOverlay,
+			gasTable, block)
+		assert.Nil(t, err)
+
+		assert.Equal(t, notifies, parallelNotifies)
+		assert.Equal(t, crossStates, parallelCrossStates)
+		assert.Equal(t, overlay.ChangeHash(), parallelOverlay.ChangeHash())
+		stateHash, err := calculateTotalStateHash(overlay)
+		assert.Nil(t, err)
+		parallelStateHash, err := calculateTotalStateHash(parallelOverlay)
+		assert.Nil(t, err)
+		assert.Equal(t, stateHash, parallelStateHash)
+		results := make([]byte, 0, len(notifies))
+		for _, notify := range notifies {
+			results = append(results, notify.State)
+		}
+		return results, reexecuted
+	}
+	execute := func(txs []*types.Transaction) ([]byte, int) {
+		return executeOn(ledger.stateStore.store, txs)
+	}
+
+	// disjoint transfers
+	var txs []*types.Transaction
+	for i := 0; i < len(accounts)/2; i++ {
+		txs = append(txs, newTransferTx(t, accounts[i], accounts[len(accounts)-1-i].Address, 10))
+	}
+	states, reexecuted := execute(txs)
+	assert.Equal(t, []byte{1, 1, 1, 1}, states)
+	assert.Equal(t, 0, reexecuted)
+
+	// each transfer spends the balance received by the former one
+	txs = nil
+	for i := 0; i < len(accounts)-1; i++ {
+		txs = append(txs, newTransferTx(t, accounts[i], accounts[i+1].Address, uint64(1000*(i+1))))
+	}
+	states, reexecuted = execute(txs)
+	assert.Equal(t, []byte{1, 1, 1, 1, 1, 1, 1}, states)
+	assert.Equal(t, len(accounts)-2, reexecuted)
+
+	// transfers in failure
+	txs = []*types.Transaction{
+		newTransferTx(t, accounts[0], accounts[1].Address, 2000),
+		newTransferTx(t, accounts[1], accounts[2].Address, 500),
+		newTransferTx(t, accounts[2], accounts[3].Address, 500),
+	}
+	states, _ = execute(txs)
+	assert.Equal(t, []byte{0, 1, 1}, states)
+
+	// every transaction pays gas fee to governance contract, which is settled in order
+	const gasPrice = 500
+	funded := ledger.stateStore.NewOverlayDB()
+	for _, acct := range accounts {
+		funded.Put(append([]byte{byte(scom.ST_STORAGE)}, ont.GenBalanceKey(nutils.OngContractAddress, acct.Address)...),
+			nutils.GenUInt64StorageItem(1000000000).ToArray())
+	}
+	fundedStore := &overlayStore{state: funded}
+	txs = nil
+	for i := 0; i < len(accounts)/2; i++ {
+		states := []*ont.State{{From: accounts[i].Address, To: accounts[len(accounts)-1-i].Address, Value: 10}}
+		code, err := utils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, ont.TRANSFER_NAME, []interface{}{states})
+		assert.Nil(t, err)
+		txs = append(txs, newInvokeTx(t, accounts[i], code, gasPrice))
+	}
+	states, reexecuted = executeOn(fundedStore, txs)
+	assert.Equal(t, []byte{1, 1, 1, 1}, states)
+	assert.Equal(t, 0, reexecuted)
+
+	// reading the balance of governance contract conflicts with the fee of former transactions
+	code, err := utils.BuildNativeInvokeCode(nutils.OngContractAddress, 0, ont.BALANCEOF_NAME,
+		[]interface{}{nutils.GovernanceContractAddress[:]})
+	assert.Nil(t, err)
+	txs = []*types.Transaction{txs[0], newInvokeTx(t, accounts[5], code, gasPrice), txs[1]}
+	states, reexecuted = executeOn(fundedStore, txs)
+	assert.Equal(t, []byte{1, 1, 1}, states)
+	assert.Equal(t, 1, reexecuted)
+}
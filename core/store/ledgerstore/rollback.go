@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
+)
+
+//Rollback revert the block, state, event and cross chain stores to the block height with the reverse write sets
+//saved with blocks. The ledger should not be in service, and an interrupted rollback can be finished by doing it again.
+func (this *LedgerStoreImp) Rollback(height uint32) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	_, blockHeight, err := this.blockStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("blockStore.GetCurrentBlock error %s", err)
+	}
+	_, stateHeight, err := this.stateStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	if height >= blockHeight && height >= stateHeight {
+		return fmt.Errorf("rollback height %d is not lower than current block height %d", height, blockHeight)
+	}
+	pruned, err := this.blockStore.GetBlockPrunedHeight()
+	if err != nil {
+		return fmt.Errorf("blockStore.GetBlockPrunedHeight error %s", err)
+	}
+	if height < pruned {
+		return fmt.Errorf("blocks before height %d are pruned", pruned)
+	}
+	for h := height + 1; h <= stateHeight; h++ {
+		has, err := this.stateStore.HasReverseWriteSet(h)
+		if err != nil {
+			return fmt.Errorf("stateStore.HasReverseWriteSet height:%d error:%s", h, err)
+		}
+		if !has {
+			return fmt.Errorf("reverse write set of block %d is not found", h)
+		}
+	}
+	blockHash, err := this.blockStore.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("blockStore.GetBlockHash height:%d error:%s", height, err)
+	}
+
+	// revert block store first, so the blocks are not executed again by recovering when interrupted
+	if height < blockHeight {
+		if err = this.rollbackBlocks(height, blockHeight, blockHash); err != nil {
+			return err
+		}
+	}
+	for h := stateHeight; h > height; h-- {
+		writeSet, err := this.stateStore.GetReverseWriteSet(h)
+		if err != nil {
+			return fmt.Errorf("stateStore.GetReverseWriteSet height:%d error:%s", h, err)
+		}
+		this.stateStore.NewBatch()
+		writeSet.ForEach(func(key, val []byte) {
+			if len(val) == 0 {
+				this.stateStore.BatchDeleteRawKey(key)
+			} else {
+				this.stateStore.BatchPutRawKeyVal(key, val)
+			}
+		})
+		this.stateStore.DeleteReverseWriteSet(h)
+		this.stateStore.DeleteWriteSet(h)
+		this.stateStore.DeleteStorageTrieRoot(h)
+		err = this.stateStore.CommitTo()
+		if err != nil {
+			return fmt.Errorf("stateStore.CommitTo height:%d error %s", h, err)
+		}
+	}
+	stateHash, stateHeight, err := this.stateStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	if stateHeight == height && stateHash != blockHash {
+		return fmt.Errorf("block hash of reverted states %s is not %s", stateHash.ToHexString(), blockHash.ToHexString())
+	}
+
+	if err = this.stateStore.reload(stateHeight); err != nil {
+		return fmt.Errorf("stateStore.reload error %s", err)
+	}
+	this.lock.Lock()
+	this.headerCache = make(map[common.Uint256]*types.Header)
+	this.lock.Unlock()
+	if err = this.loadCurrentBlock(); err != nil {
+		return err
+	}
+	if err = this.loadHeaderIndexList(); err != nil {
+		return err
+	}
+	log.Infof("[Rollback] ledger is reverted from height %d to %d", blockHeight, height)
+	return nil
+}
+
+func (this *LedgerStoreImp) rollbackBlocks(height, blockHeight uint32, blockHash common.Uint256) error {
+	this.blockStore.NewBatch()
+	this.eventStore.NewBatch()
+	for h := blockHeight; h > height; h-- {
+		hash, err := this.blockStore.GetBlockHash(h)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlockHash height:%d error:%s", h, err)
+		}
+		txHashes := this.blockStore.PruneBlock(hash)
+		this.blockStore.DeleteBlockHash(h)
+		this.eventStore.PruneBlock(h, txHashes)
+		if err = this.crossChainStore.DeleteCrossChainMsg(h); err != nil {
+			return fmt.Errorf("crossChainStore.DeleteCrossChainMsg height:%d error:%s", h, err)
+		}
+	}
+	err := this.blockStore.DeleteHeaderIndexListAbove(height)
+	if err != nil {
+		return fmt.Errorf("blockStore.DeleteHeaderIndexListAbove error %s", err)
+	}
+	err = this.blockStore.SaveCurrentBlock(height, blockHash)
+	if err != nil {
+		return fmt.Errorf("blockStore.SaveCurrentBlock error %s", err)
+	}
+	this.eventStore.SaveCurrentBlock(height, blockHash)
+	err = this.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo error %s", err)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+	if this.blockStore.enableCache {
+		this.blockStore.cache.Purge()
+	}
+	return nil
+}
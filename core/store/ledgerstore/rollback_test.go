@@ -0,0 +1,285 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/constants"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBlock(t *testing.T, ledger *LedgerStoreImp, bookkeeper *account.Account, txs []*types.Transaction) *types.Block {
+	height, hash := ledger.GetCurrentBlock()
+	txHashes := make([]common.Uint256, 0, len(txs))
+	for _, tx := range txs {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	txRoot := common.ComputeMerkleRoot(txHashes)
+	block := &types.Block{
+		Header: &types.Header{
+			PrevBlockHash:    hash,
+			TransactionsRoot: txRoot,
+			BlockRoot:        ledger.GetBlockRootWithNewTxRoots(height+1, []common.Uint256{txRoot}),
+			Timestamp:        constants.GENESIS_BLOCK_TIMESTAMP + height + 1,
+			Height:           height + 1,
+			NextBookkeeper:   types.AddressFromPubKey(bookkeeper.PublicKey),
+			Bookkeepers:      []keypair.PublicKey{bookkeeper.PublicKey},
+		},
+		Transactions: txs,
+	}
+	blockHash := block.Hash()
+	sig, err := signature.Sign(bookkeeper, blockHash[:])
+	assert.Nil(t, err)
+	block.Header.SigData = [][]byte{sig}
+	return block
+}
+
+func addTestBlock(t *testing.T, ledger *LedgerStoreImp, bookkeeper *account.Account, txs []*types.Transaction) *types.Block {
+	block := newTestBlock(t, ledger, bookkeeper, txs)
+	result, err := ledger.executeBlock(block)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.submitBlock(block, nil, result))
+	return block
+}
+
+func TestRollback(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/rollback", bookkeeper, accounts)
+	defer ledger.Close()
+
+	stateHash := func() common.Uint256 {
+		hash, err := calculateTotalStateHash(ledger.stateStore.NewOverlayDB())
+		assert.Nil(t, err)
+		return hash
+	}
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10)})
+	ledger.EnableReverseWriteSetSaving()
+	stateHash1 := stateHash()
+	stateMerkleRoot1, err := ledger.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+
+	var blocks []*types.Block
+	var stateHashes []common.Uint256
+	for i := uint64(0); i < 3; i++ {
+		tx := newTransferTx(t, accounts[1], accounts[0].Address, 1+i)
+		blocks = append(blocks, addTestBlock(t, ledger, bookkeeper, []*types.Transaction{tx}))
+		stateHashes = append(stateHashes, stateHash())
+	}
+	assert.Equal(t, uint32(4), ledger.GetCurrentBlockHeight())
+
+	assert.NotNil(t, ledger.Rollback(4))
+	// the reverse write set of block 1 is not saved
+	assert.NotNil(t, ledger.Rollback(0))
+	assert.Nil(t, ledger.Rollback(1))
+	assert.Equal(t, uint32(1), ledger.GetCurrentBlockHeight())
+	assert.Equal(t, uint32(1), ledger.GetCurrentHeaderHeight())
+	assert.Equal(t, stateHash1, stateHash())
+	_, height, err := ledger.stateStore.GetCurrentBlock()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), height)
+	for _, block := range blocks {
+		_, err = ledger.GetBlockByHash(block.Hash())
+		assert.NotNil(t, err)
+		exist, err := ledger.IsContainTransaction(block.Transactions[0].Hash())
+		assert.Nil(t, err)
+		assert.False(t, exist)
+	}
+	stateMerkleRoot, err := ledger.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+	assert.Equal(t, stateMerkleRoot1, stateMerkleRoot)
+	_, err = ledger.GetStateMerkleRoot(2)
+	assert.NotNil(t, err)
+
+	// the reverted blocks can be saved again with the restored merkle trees
+	for i, block := range blocks {
+		result, err := ledger.executeBlock(block)
+		assert.Nil(t, err)
+		assert.Nil(t, ledger.submitBlock(block, nil, result))
+		assert.Equal(t, stateHashes[i], stateHash())
+	}
+
+	// refuse to rollback when the reverse write sets are not kept
+	ledger.stateStore.NewBatch()
+	ledger.stateStore.DeleteReverseWriteSet(2)
+	assert.Nil(t, ledger.stateStore.CommitTo())
+	assert.NotNil(t, ledger.Rollback(1))
+	assert.Equal(t, uint32(4), ledger.GetCurrentBlockHeight())
+	assert.Nil(t, ledger.Rollback(2))
+	assert.Equal(t, stateHashes[0], stateHash())
+}
+
+func TestPreExecuteContractAtHeight(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/preexecheight", bookkeeper, accounts)
+	defer ledger.Close()
+	ledger.EnableReverseWriteSetSaving()
+
+	for i := uint64(0); i < 3; i++ {
+		addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10+i)})
+	}
+	code, err := utils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, ont.BALANCEOF_NAME,
+		[]interface{}{accounts[1].Address[:]})
+	assert.Nil(t, err)
+	tx, err := utils.NewInvokeTransaction(code).IntoImmutable()
+	assert.Nil(t, err)
+	balanceAt := func(height uint32) uint64 {
+		results, err := ledger.PreExecuteContractAtHeight([]*types.Transaction{tx}, height)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(results))
+		data, err := common.HexToBytes(results[0].Result.(string))
+		assert.Nil(t, err)
+		return common.BigIntFromNeoBytes(data).Uint64()
+	}
+	assert.Equal(t, uint64(1000), balanceAt(0))
+	assert.Equal(t, uint64(1010), balanceAt(1))
+	assert.Equal(t, uint64(1021), balanceAt(2))
+	assert.Equal(t, uint64(1033), balanceAt(3))
+
+	_, err = ledger.PreExecuteContractAtHeight([]*types.Transaction{tx}, 4)
+	assert.NotNil(t, err)
+
+	// no more than maxReplayBlocks blocks are reverted
+	ledger.maxReplayBlocks = 2
+	assert.Equal(t, uint64(1010), balanceAt(1))
+	_, err = ledger.PreExecuteContractAtHeight([]*types.Transaction{tx}, 0)
+	assert.NotNil(t, err)
+	ledger.maxReplayBlocks = MAX_REPLAY_BLOCKS
+
+	// the blocks are saved while the states at height are in use, which are not changed by them
+	overlay, release, err := ledger.stateOverlayAtHeight(1)
+	assert.Nil(t, err)
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 13)})
+	balanceKey := append(append([]byte{byte(scom.ST_STORAGE)}, nutils.OntContractAddress[:]...), accounts[1].Address[:]...)
+	raw, err := overlay.Get(balanceKey)
+	assert.Nil(t, err)
+	value, err := states.GetValueFromRawStorageItem(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1010), common.BigIntFromNeoBytes(value).Uint64())
+	release()
+	assert.Equal(t, uint64(1046), balanceAt(4))
+
+	// the states before a pruned reverse write set are not available
+	ledger.stateStore.NewBatch()
+	ledger.stateStore.DeleteReverseWriteSet(2)
+	assert.Nil(t, ledger.stateStore.CommitTo())
+	assert.Equal(t, uint64(1021), balanceAt(2))
+	_, err = ledger.PreExecuteContractAtHeight([]*types.Transaction{tx}, 1)
+	assert.NotNil(t, err)
+}
+
+func TestGetStateAtHeight(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/stateheight", bookkeeper, accounts)
+	defer ledger.Close()
+	ledger.EnableReverseWriteSetSaving()
+
+	for i := uint64(0); i < 2; i++ {
+		addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10+i)})
+	}
+	prefix := append([]byte{byte(scom.ST_STORAGE)}, nutils.OntContractAddress[:]...)
+	balanceKey := append(append([]byte{}, prefix...), accounts[1].Address[:]...)
+	balanceAt := func(height uint32) uint64 {
+		raw, err := ledger.GetStateAtHeight(balanceKey, height)
+		assert.Nil(t, err)
+		value, err := states.GetValueFromRawStorageItem(raw)
+		assert.Nil(t, err)
+		return common.BigIntFromNeoBytes(value).Uint64()
+	}
+	assert.Equal(t, uint64(1000), balanceAt(0))
+	assert.Equal(t, uint64(1010), balanceAt(1))
+	assert.Equal(t, uint64(1021), balanceAt(2))
+
+	missing, err := ledger.GetStateAtHeight(append(append([]byte{}, prefix...), "missing"...), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+	_, err = ledger.GetStateAtHeight(balanceKey, 3)
+	assert.NotNil(t, err)
+	// only contract states can be read
+	_, err = ledger.GetStateAtHeight([]byte{byte(scom.SYS_CURRENT_BLOCK)}, 1)
+	assert.NotNil(t, err)
+
+	items, err := ledger.FindStatesAtHeight(balanceKey, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, balanceKey, items[0].Key)
+	raw, err := ledger.GetStateAtHeight(balanceKey, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, raw, items[0].Value)
+	items, err = ledger.FindStatesAtHeight(prefix, 2)
+	assert.Nil(t, err)
+	assert.True(t, len(items) >= 2)
+	_, err = ledger.FindStatesAtHeight([]byte{byte(scom.ST_STORAGE)}, 1)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkSaveBlockToStateStore(b *testing.B) {
+	// each block updates 1000 existing storage items
+	writeSet := overlaydb.NewMemDB(0, 0)
+	for i := 0; i < 1000; i++ {
+		key := append([]byte{byte(scom.ST_STORAGE)}, nutils.OntContractAddress[:]...)
+		writeSet.Put(append(key, byte(i>>8), byte(i)), make([]byte, 32))
+	}
+	newLedger := func(dataDir string) *LedgerStoreImp {
+		ledger, err := NewLedgerStore(dataDir, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return ledger
+	}
+	bench := func(ledger *LedgerStoreImp) func(b *testing.B) {
+		height := uint32(0)
+		return func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				height++
+				block := &types.Block{Header: &types.Header{Height: height}}
+				result := store.ExecuteResult{WriteSet: writeSet, Hash: common.Uint256{byte(height)}}
+				ledger.stateStore.NewBatch()
+				if err := ledger.saveBlockToStateStore(block, result); err != nil {
+					b.Fatal(err)
+				}
+				if err := ledger.stateStore.CommitTo(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+	ledger := newLedger("test/benchsave")
+	defer ledger.Close()
+	b.Run("Default", bench(ledger))
+	reverse := newLedger("test/benchsavereverse")
+	defer reverse.Close()
+	reverse.EnableReverseWriteSetSaving()
+	b.Run("ReverseWriteSet", bench(reverse))
+}
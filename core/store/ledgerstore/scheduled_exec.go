@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ontio/ontology/common"
+	sysconfig "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/scheduler"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+)
+
+// executeScheduledCalls executes the calls scheduled at the height of block before its transactions, in the order
+// they were scheduled. The calls are removed from the scheduler contract before any of them runs, so a running call
+// can not cancel itself or the other due calls and get their prepayments refunded twice
+func (this *LedgerStoreImp) executeScheduledCalls(overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block) (notifies []*event.ExecuteNotify, crossStates []common.Uint256, err error) {
+	height := block.Header.Height
+	if height < sysconfig.GetSchedulerHeight() {
+		return
+	}
+	calls, err := scheduler.GetDueCalls(storage.NewCacheDB(overlay), height)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get scheduled calls of height %d error: %s", height, err)
+	}
+	if len(calls) == 0 {
+		return
+	}
+	cache := storage.NewCacheDB(overlay)
+	scheduler.RemoveDueCalls(cache, height, calls)
+	cache.Commit()
+	for _, call := range calls {
+		notify, crossStateHashes, e := handleScheduledCall(this, overlay, gasTable, block, call)
+		if e != nil {
+			return nil, nil, fmt.Errorf("scheduled call %d error: %s", call.Id, e)
+		}
+		if overlay.Error() != nil {
+			return nil, nil, fmt.Errorf("scheduled call %d error: %s", call.Id, overlay.Error())
+		}
+		notifies = append(notifies, notify)
+		crossStates = append(crossStates, crossStateHashes...)
+	}
+	return
+}
+
+// handleScheduledCall executes the code of call as a transaction signed and paid by the owner. The effects are kept
+// only if the execution succeeds, while the gas used is always charged from the prepayment and the rest is refunded.
+// The returned error is only for failures of the ledger itself
+func handleScheduledCall(store store.LedgerStore, overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block, call *scheduler.ScheduledCall) (*event.ExecuteNotify, []common.Uint256, error) {
+	notify := &event.ExecuteNotify{TxHash: scheduler.CallHash(call.Id), State: event.CONTRACT_STATE_FAIL}
+	config := &smartcontract.Config{
+		Time:   block.Header.Timestamp,
+		Height: block.Header.Height,
+		Tx: &types.Transaction{
+			TxType:     call.TxType,
+			GasPrice:   call.GasPrice,
+			GasLimit:   call.GasLimit,
+			Payer:      call.Owner,
+			Payload:    &payload.InvokeCode{Code: call.Code},
+			SignedAddr: []common.Address{call.Owner},
+		},
+		BlockHash: block.Hash(),
+	}
+
+	var crossStateHashes []common.Uint256
+	var execErr error
+	gasUsed := call.GasLimit
+	codeLenGasLimit := calcGasByCodeLen(len(call.Code), gasTable[neovm.UINT_INVOKE_CODE_LEN_NAME])
+	if call.GasLimit < codeLenGasLimit {
+		execErr = fmt.Errorf("gas limit %d is less than code length gas %d", call.GasLimit, codeLenGasLimit)
+	} else {
+		sc := smartcontract.SmartContract{
+			Config:       config,
+			CacheDB:      storage.NewCacheDB(overlay),
+			Store:        store,
+			GasTable:     gasTable,
+			Gas:          call.GasLimit - codeLenGasLimit,
+			WasmExecStep: sysconfig.DEFAULT_WASM_MAX_STEPCOUNT,
+			PreExec:      false,
+		}
+		engine, err := sc.NewExecuteEngine(call.Code, call.TxType)
+		if err == nil {
+			_, err = engine.Invoke()
+		}
+		if sc.IsInternalErr() {
+			overlay.SetError(fmt.Errorf("[handleScheduledCall] %s", err))
+			return notify, nil, nil
+		}
+		gasUsed = call.GasLimit - sc.Gas
+		if gasUsed < neovm.MIN_TRANSACTION_GAS {
+			gasUsed = neovm.MIN_TRANSACTION_GAS
+		}
+		if err != nil {
+			execErr = err
+		} else {
+			sc.CacheDB.Commit()
+			notify.Notify = append(notify.Notify, sc.Notifications...)
+			notify.State = event.CONTRACT_STATE_SUCCESS
+			crossStateHashes = sc.CrossHashes
+		}
+	}
+
+	result := ""
+	if execErr != nil {
+		result = execErr.Error()
+	}
+	notify.Notify = append(notify.Notify, &event.NotifyEventInfo{
+		ContractAddress: utils.SchedulerContractAddress,
+		States:          []interface{}{scheduler.EXECUTE_NAME, call.Id, call.Owner.ToBase58(), result},
+	})
+
+	cache := storage.NewCacheDB(overlay)
+	notifies, err := settleScheduledCall(call, gasUsed, config, cache, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	cache.Commit()
+	notify.Notify = append(notify.Notify, notifies...)
+	notify.GasStepUsed = gasUsed
+	notify.GasConsumed = gasUsed * call.GasPrice
+	return notify, crossStateHashes, nil
+}
+
+// settleScheduledCall pays the gas used from the prepayment held by the scheduler contract and refunds the rest
+func settleScheduledCall(call *scheduler.ScheduledCall, gasUsed uint64, config *smartcontract.Config,
+	cache *storage.CacheDB, store store.LedgerStore) ([]*event.NotifyEventInfo, error) {
+	transfers := &ont.Transfers{States: []ont.State{
+		{From: utils.SchedulerContractAddress, To: utils.GovernanceContractAddress, Value: gasUsed * call.GasPrice},
+		{From: utils.SchedulerContractAddress, To: call.Owner, Value: (call.GasLimit - gasUsed) * call.GasPrice},
+	}}
+
+	sc := smartcontract.SmartContract{
+		Config:  config,
+		CacheDB: cache,
+		Store:   store,
+		Gas:     math.MaxUint64,
+	}
+	sc.PushContext(&context.Context{ContractAddress: utils.SchedulerContractAddress})
+
+	service, _ := sc.NewNativeService()
+	_, err := service.NativeCall(utils.OngContractAddress, ont.TRANSFER_NAME, common.SerializeToBytes(transfers))
+	if err != nil {
+		return nil, fmt.Errorf("settle gas error: %s", err)
+	}
+	return sc.Notifications, nil
+}
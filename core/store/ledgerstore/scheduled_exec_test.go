@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/constants"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/scheduler"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteScheduledCalls(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	owner, to := account.NewAccount(""), account.NewAccount("")
+	ledger := newTestLedger(t, "test/scheduled", account.NewAccount(""), []*account.Account{owner})
+	defer ledger.Close()
+	gasTable := make(map[string]uint64)
+	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		gasTable[k.(string)] = value.(uint64)
+		return true
+	})
+
+	const gasPrice, gasLimit, ongBalance = 1, 100000, 1000000
+	overlay := ledger.stateStore.NewOverlayDB()
+	cache := storage.NewCacheDB(overlay)
+	cache.Put(ont.GenBalanceKey(nutils.OngContractAddress, owner.Address), nutils.GenUInt64StorageItem(ongBalance).ToArray())
+	transfer := func(value uint64) []byte {
+		states := []*ont.State{{From: owner.Address, To: to.Address, Value: value}}
+		code, err := utils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, ont.TRANSFER_NAME, []interface{}{states})
+		assert.Nil(t, err)
+		return code
+	}
+	cancel := func(id uint64) []byte {
+		param := &scheduler.CancelParam{Owner: owner.Address, Id: id}
+		code, err := utils.BuildNativeInvokeCode(nutils.SchedulerContractAddress, 0, scheduler.CANCEL_NAME, []interface{}{param})
+		assert.Nil(t, err)
+		return code
+	}
+	schedule := func(height uint32, code []byte) uint64 {
+		param := &scheduler.ScheduleParam{Owner: owner.Address, Height: height, TxType: types.InvokeNeo, Code: code,
+			GasLimit: gasLimit}
+		sc := &smartcontract.SmartContract{
+			Config: &smartcontract.Config{
+				Height: 1,
+				Tx:     &types.Transaction{GasPrice: gasPrice, SignedAddr: []common.Address{owner.Address}},
+			},
+			CacheDB: cache,
+			Gas:     math.MaxUint64,
+		}
+		service, _ := sc.NewNativeService()
+		res, err := service.NativeCall(nutils.SchedulerContractAddress, scheduler.SCHEDULE_NAME, common.SerializeToBytes(param))
+		assert.Nil(t, err)
+		return common.BigIntFromNeoBytes(res).Uint64()
+	}
+	// the second call transfers more ont than the balance and fails, the fourth and fifth try to cancel themselves
+	// and a sibling due at the same height, which must fail instead of refunding their prepayments twice
+	success, failure, later := schedule(2, transfer(10)), schedule(2, transfer(1000000)), schedule(3, transfer(10))
+	selfCancel := schedule(2, cancel(later+1))
+	siblingCancel := schedule(2, cancel(failure))
+	assert.Equal(t, later+1, selfCancel)
+	cache.Commit()
+
+	block := &types.Block{
+		Header: &types.Header{Height: 2, Timestamp: constants.GENESIS_BLOCK_TIMESTAMP + 2},
+	}
+	notifies, _, err := ledger.executeScheduledCalls(overlay, gasTable, block)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(notifies))
+	assert.Equal(t, scheduler.CallHash(success), notifies[0].TxHash)
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, notifies[0].State)
+	assert.Equal(t, scheduler.CallHash(failure), notifies[1].TxHash)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, notifies[1].State)
+	assert.Equal(t, scheduler.CallHash(selfCancel), notifies[2].TxHash)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, notifies[2].State)
+	assert.Equal(t, scheduler.CallHash(siblingCancel), notifies[3].TxHash)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, notifies[3].State)
+
+	cache = storage.NewCacheDB(overlay)
+	balance := func(contract, address common.Address) uint64 {
+		balance, err := nutils.GetStorageUInt64(cache, ont.GenBalanceKey(contract, address))
+		assert.Nil(t, err)
+		return balance
+	}
+	assert.Equal(t, uint64(10), balance(nutils.OntContractAddress, to.Address))
+	gasConsumed := uint64(0)
+	for _, notify := range notifies {
+		gasConsumed += notify.GasConsumed
+	}
+	assert.True(t, gasConsumed >= 4*neovm.MIN_TRANSACTION_GAS*gasPrice)
+	assert.Equal(t, uint64(ongBalance-gasLimit*gasPrice)-gasConsumed, balance(nutils.OngContractAddress, owner.Address))
+	assert.Equal(t, uint64(gasLimit*gasPrice), balance(nutils.OngContractAddress, nutils.SchedulerContractAddress))
+
+	calls, err := scheduler.GetDueCalls(cache, 2)
+	assert.Nil(t, err)
+	assert.Empty(t, calls)
+	calls, err = scheduler.GetDueCalls(cache, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(calls))
+	assert.Equal(t, later, calls[0].Id)
+}
@@ -0,0 +1,426 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/common/serialization"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	stateGCBatchSize  = 1000
+	stateGCArchiveDir = "stategc"
+
+	stateGCCollecting byte = 0
+	stateGCCollected  byte = 1
+)
+
+// stateGC deletes the storage of contracts destroyed before the preserved block history in background
+type stateGC struct {
+	trigger chan uint32 // current block height
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+type rangeCompactor interface {
+	CompactRange(prefix []byte) error
+}
+
+//EnableStateGC start deleting the storage of destroyed contracts, it works with block prune only.
+//The deleted states are archived in a compressed file and restored when the contract is revived by governance.
+func (this *LedgerStoreImp) EnableStateGC() {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	if this.stateGC != nil {
+		return
+	}
+	if this.preserveBlockHistoryLength == 0 {
+		log.Warnf("[EnableStateGC] state gc is ignored since block prune is disabled")
+		return
+	}
+
+	this.stateGC = &stateGC{
+		trigger: make(chan uint32, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go this.runStateGC(this.stateGC)
+}
+
+func (this *LedgerStoreImp) stopStateGC() {
+	this.getSavingBlockLock()
+	gc := this.stateGC
+	this.stateGC = nil
+	this.releaseSavingBlockLock()
+	if gc != nil {
+		close(gc.quit)
+		<-gc.done
+	}
+}
+
+// must hold the saving block lock
+func (this *LedgerStoreImp) tryStateGC(height uint32) {
+	if this.stateGC == nil {
+		return
+	}
+	select {
+	case this.stateGC.trigger <- height:
+	default:
+	}
+}
+
+func (this *LedgerStoreImp) runStateGC(gc *stateGC) {
+	defer close(gc.done)
+	for {
+		select {
+		case <-gc.quit:
+			return
+		case height := <-gc.trigger:
+			if height <= this.preserveBlockHistoryLength {
+				continue
+			}
+			err := this.collectDestroyedContracts(gc, height-this.preserveBlockHistoryLength)
+			if err != nil {
+				log.Errorf("[runStateGC] collect destroyed contracts error: %s", err)
+			}
+		}
+	}
+}
+
+func genStateGCKey(addr []byte) []byte {
+	return append([]byte{byte(scom.SYS_STATE_GC)}, addr...)
+}
+
+// collectDestroyedContracts deletes the storage of contracts destroyed at or before the height
+func (this *LedgerStoreImp) collectDestroyedContracts(gc *stateGC, height uint32) error {
+	store := this.stateStore.store
+	var addrs []common.Address
+	iter := store.NewIterator([]byte{byte(scom.ST_DESTROYED)})
+	for has := iter.First(); has; has = iter.Next() {
+		key := iter.Key()
+		destroyed, eof := common.NewZeroCopySource(iter.Value()).NextUint32()
+		if len(key) != 1+common.ADDR_LEN || eof || destroyed > height {
+			continue
+		}
+		state, err := store.Get(genStateGCKey(key[1:]))
+		if err == nil && len(state) != 0 && state[0] == stateGCCollected {
+			continue
+		}
+		var addr common.Address
+		copy(addr[:], key[1:])
+		addrs = append(addrs, addr)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		select {
+		case <-gc.quit:
+			return nil
+		default:
+		}
+		if err := this.collectContract(gc, addr); err != nil {
+			return fmt.Errorf("contract %s: %s", addr.ToHexString(), err)
+		}
+	}
+	return nil
+}
+
+func (this *LedgerStoreImp) stateGCArchivePath(addr common.Address) string {
+	return filepath.Join(this.dataDir, stateGCArchiveDir, addr.ToHexString()+".gz")
+}
+
+func (this *LedgerStoreImp) collectContract(gc *stateGC, addr common.Address) error {
+	store := this.stateStore.store
+	gcKey := genStateGCKey(addr[:])
+	path := this.stateGCArchivePath(addr)
+
+	// mark the contract with the saving block lock held, so a block reviving it restores the archived states
+	this.getSavingBlockLock()
+	destroyed, err := store.Has(append([]byte{byte(scom.ST_DESTROYED)}, addr[:]...))
+	marked := false
+	if err == nil && destroyed {
+		marked, err = store.Has(gcKey)
+		if err == nil && !marked {
+			err = store.Put(gcKey, []byte{stateGCCollecting})
+		}
+	}
+	this.releaseSavingBlockLock()
+	if err != nil || !destroyed {
+		return err
+	}
+
+	// the contract marked by an interrupted collection may have been partly deleted, keep its archive
+	if _, err := os.Stat(path); !marked || err != nil {
+		if err := this.archiveContract(addr, path); err != nil {
+			return fmt.Errorf("archive error: %s", err)
+		}
+	}
+
+	prefix := append([]byte{byte(scom.ST_STORAGE)}, addr[:]...)
+	deleted := 0
+	for {
+		select {
+		case <-gc.quit:
+			return nil
+		default:
+		}
+		count, revived, err := this.deleteCollectingBatch(addr, prefix)
+		if err != nil {
+			return err
+		}
+		if revived {
+			os.Remove(path)
+			log.Infof("[collectContract] destroyed contract %s is revived while collecting", addr.ToHexString())
+			return nil
+		}
+		if count == 0 {
+			break
+		}
+		deleted += count
+	}
+	if compactor, ok := store.(rangeCompactor); ok && deleted != 0 {
+		if err := compactor.CompactRange(prefix); err != nil {
+			return err
+		}
+	}
+	log.Infof("[collectContract] %d storage items of destroyed contract %s are deleted", deleted, addr.ToHexString())
+	return nil
+}
+
+// deleteCollectingBatch deletes a batch of the storage of a contract still marked as collecting, and marks it
+// collected after deleting its code when no storage left
+func (this *LedgerStoreImp) deleteCollectingBatch(addr common.Address, prefix []byte) (count int, revived bool, err error) {
+	store := this.stateStore.store
+	gcKey := genStateGCKey(addr[:])
+
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	marked, err := store.Has(gcKey)
+	if err != nil || !marked {
+		return 0, !marked, err
+	}
+	// the batch of store is used by block saving, so delete the keys directly
+	keys := make([][]byte, 0, stateGCBatchSize)
+	iter := store.NewIterator(prefix)
+	for has := iter.First(); has && len(keys) < stateGCBatchSize; has = iter.Next() {
+		keys = append(keys, append([]byte{}, iter.Key()...))
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return 0, false, err
+	}
+	for _, key := range keys {
+		if err = store.Delete(key); err != nil {
+			return 0, false, err
+		}
+	}
+	if len(keys) != 0 {
+		return len(keys), false, nil
+	}
+	if err = store.Delete(append([]byte{byte(scom.ST_CONTRACT)}, addr[:]...)); err != nil {
+		return 0, false, err
+	}
+	return 0, false, store.Put(gcKey, []byte{stateGCCollected})
+}
+
+// archiveContract writes the code and storage of a contract to a compressed file, from which they are restored
+// when the contract is revived
+func (this *LedgerStoreImp) archiveContract(addr common.Address, path string) error {
+	store := this.stateStore.store
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path + ".tmp")
+	defer file.Close()
+	writer := gzip.NewWriter(file)
+	for _, prefix := range [][]byte{
+		append([]byte{byte(scom.ST_CONTRACT)}, addr[:]...),
+		append([]byte{byte(scom.ST_STORAGE)}, addr[:]...),
+	} {
+		iter := store.NewIterator(prefix)
+		for has := iter.First(); has && err == nil; has = iter.Next() {
+			err = serialization.WriteVarBytes(writer, iter.Key())
+			if err == nil {
+				err = serialization.WriteVarBytes(writer, iter.Value())
+			}
+		}
+		iter.Release()
+		if err == nil {
+			err = iter.Error()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// restoreRevivedContracts restores the archived states of the contracts revived by the write set, which is
+// executed on the states without them, and returns whether any contract is restored. must hold the saving block lock
+func (this *LedgerStoreImp) restoreRevivedContracts(writeSet *overlaydb.MemDB) (bool, error) {
+	var addrs []common.Address
+	iter := writeSet.NewIterator(util.BytesPrefix([]byte{byte(scom.ST_DESTROYED)}))
+	for has := iter.First(); has; has = iter.Next() {
+		if len(iter.Value()) != 0 || len(iter.Key()) != 1+common.ADDR_LEN {
+			continue
+		}
+		var addr common.Address
+		copy(addr[:], iter.Key()[1:])
+		addrs = append(addrs, addr)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return false, err
+	}
+
+	restored := false
+	for _, addr := range addrs {
+		ok, err := this.restoreContract(addr)
+		if err != nil {
+			return false, fmt.Errorf("restore contract %s error: %s", addr.ToHexString(), err)
+		}
+		restored = restored || ok
+	}
+	return restored, nil
+}
+
+func (this *LedgerStoreImp) restoreContract(addr common.Address) (bool, error) {
+	store := this.stateStore.store
+	gcKey := genStateGCKey(addr[:])
+	marked, err := store.Has(gcKey)
+	if err != nil || !marked {
+		return false, err
+	}
+	path := this.stateGCArchivePath(addr)
+	restored := 0
+	err = readStateGCArchive(path, func(key, val []byte) error {
+		restored++
+		// the batch of store is used by block saving, so put the keys directly
+		return store.Put(key, val)
+	})
+	if err == nil {
+		err = os.Remove(path)
+	}
+	// the archive is written before deleting any state, so nothing is lost without it
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if err = store.Delete(gcKey); err != nil {
+		return false, err
+	}
+	log.Infof("[restoreContract] %d states of revived contract %s are restored", restored, addr.ToHexString())
+	return restored != 0, nil
+}
+
+// forEachArchivedState iterates the archived states of the contracts marked by state gc
+func (this *LedgerStoreImp) forEachArchivedState(fn func(key, val []byte) error) error {
+	iter := this.stateStore.store.NewIterator([]byte{byte(scom.SYS_STATE_GC)})
+	defer iter.Release()
+	for has := iter.First(); has; has = iter.Next() {
+		addr, err := common.AddressParseFromBytes(iter.Key()[1:])
+		if err != nil {
+			continue
+		}
+		err = readStateGCArchive(this.stateGCArchivePath(addr), fn)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read archive of contract %s error: %s", addr.ToHexString(), err)
+		}
+	}
+	return iter.Error()
+}
+
+func readStateGCArchive(path string, fn func(key, val []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewReader(reader)
+	for {
+		key, err := serialization.ReadVarBytes(buf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		val, err := serialization.ReadVarBytes(buf)
+		if err != nil {
+			return err
+		}
+		if err = fn(key, val); err != nil {
+			return err
+		}
+	}
+}
+
+//GetDiskUsage return the disk space in bytes used by each store of ledger
+func (this *LedgerStoreImp) GetDiskUsage() (map[string]uint64, error) {
+	entries, err := ioutil.ReadDir(this.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]uint64, len(entries))
+	for _, entry := range entries {
+		var size uint64
+		err = filepath.Walk(filepath.Join(this.dataDir, entry.Name()), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				size += uint64(info.Size())
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage[entry.Name()] = size
+	}
+	return usage, nil
+}
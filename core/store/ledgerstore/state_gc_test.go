@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"os"
+	"testing"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateGC(t *testing.T) {
+	ledger, err := NewLedgerStore("test/stategc", 0)
+	assert.Nil(t, err)
+	defer ledger.Close()
+	store := ledger.stateStore.store
+
+	destroyedHeight := func(height uint32) []byte {
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteUint32(height)
+		return sink.Bytes()
+	}
+	putContract := func(addr common.Address, height uint32, items int) {
+		assert.Nil(t, store.Put(append([]byte{byte(scom.ST_CONTRACT)}, addr[:]...), []byte{1}))
+		for i := 0; i < items; i++ {
+			key := append([]byte{byte(scom.ST_STORAGE)}, addr[:]...)
+			key = append(key, byte(i>>8), byte(i))
+			assert.Nil(t, store.Put(key, []byte{1}))
+		}
+		if height != 0 {
+			assert.Nil(t, store.Put(append([]byte{byte(scom.ST_DESTROYED)}, addr[:]...), destroyedHeight(height)))
+		}
+	}
+	countStorage := func(addr common.Address) int {
+		count := 0
+		iter := store.NewIterator(append([]byte{byte(scom.ST_STORAGE)}, addr[:]...))
+		for has := iter.First(); has; has = iter.Next() {
+			count++
+		}
+		iter.Release()
+		return count
+	}
+
+	alive := common.Address{1}
+	old := common.Address{2}
+	recent := common.Address{3}
+	putContract(alive, 0, 10)
+	putContract(old, 10, stateGCBatchSize+10)
+	putContract(recent, 100, 10)
+
+	storageRoot := func(height uint32) common2.Hash {
+		assert.Nil(t, ledger.buildStorageTrie(trie.NewDatabase(memorydb.New()), height))
+		root, err := ledger.stateStore.GetStorageTrieRoot(height)
+		assert.Nil(t, err)
+		return root
+	}
+	rootBeforeGC := storageRoot(0)
+
+	gc := &stateGC{quit: make(chan struct{})}
+	assert.Nil(t, ledger.collectDestroyedContracts(gc, 50))
+	// the storage trie built after gc is the same
+	assert.Equal(t, rootBeforeGC, storageRoot(1))
+	assert.Equal(t, 10, countStorage(alive))
+	assert.Equal(t, 0, countStorage(old))
+	assert.Equal(t, 10, countStorage(recent))
+	has, err := store.Has(append([]byte{byte(scom.ST_CONTRACT)}, old[:]...))
+	assert.Nil(t, err)
+	assert.False(t, has)
+	state, err := store.Get(genStateGCKey(old[:]))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{stateGCCollected}, state)
+
+	_, err = os.Stat(ledger.stateGCArchivePath(old))
+	assert.Nil(t, err)
+
+	// reviving a collected contract restores its archived states
+	writeSet := overlaydb.NewMemDB(0, 0)
+	writeSet.Delete(append([]byte{byte(scom.ST_DESTROYED)}, recent[:]...))
+	restored, err := ledger.restoreRevivedContracts(writeSet)
+	assert.Nil(t, err)
+	assert.False(t, restored)
+	writeSet.Delete(append([]byte{byte(scom.ST_DESTROYED)}, old[:]...))
+	restored, err = ledger.restoreRevivedContracts(writeSet)
+	assert.Nil(t, err)
+	assert.True(t, restored)
+	assert.Equal(t, stateGCBatchSize+10, countStorage(old))
+	code, err := store.Get(append([]byte{byte(scom.ST_CONTRACT)}, old[:]...))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1}, code)
+	has, err = store.Has(genStateGCKey(old[:]))
+	assert.Nil(t, err)
+	assert.False(t, has)
+	_, err = os.Stat(ledger.stateGCArchivePath(old))
+	assert.True(t, os.IsNotExist(err))
+
+	// a contract revived while collecting is kept
+	assert.Nil(t, store.Put(genStateGCKey(recent[:]), []byte{stateGCCollecting}))
+	assert.Nil(t, ledger.archiveContract(recent, ledger.stateGCArchivePath(recent)))
+	_, err = ledger.restoreRevivedContracts(writeSet)
+	assert.Nil(t, err)
+	count, revived, err := ledger.deleteCollectingBatch(recent, append([]byte{byte(scom.ST_STORAGE)}, recent[:]...))
+	assert.Nil(t, err)
+	assert.True(t, revived)
+	assert.Zero(t, count)
+	assert.Equal(t, 10, countStorage(recent))
+
+	usage, err := ledger.GetDiskUsage()
+	assert.Nil(t, err)
+	assert.Contains(t, usage, DBDirState)
+	assert.NotZero(t, usage[DBDirState])
+}
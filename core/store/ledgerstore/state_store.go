@@ -32,6 +32,7 @@ import (
 	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/states"
 	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/forkstore"
 	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/store/overlaydb"
 	"github.com/ontio/ontology/merkle"
@@ -57,11 +58,28 @@ type StateStore struct {
 
 //NewStateStore return state store instance
 func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
-	var err error
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
+	return newStateStore(dbDir, merklePath, store, stateHashCheckHeight)
+}
+
+//NewForkStateStore return state store instance which fetches missing states from remote
+func NewForkStateStore(dbDir, merklePath string, stateHashCheckHeight uint32, remote forkstore.RemoteStorage) (*StateStore, error) {
+	local, err := openStore(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	store, err := forkstore.NewForkStore(local, remote)
+	if err != nil {
+		local.Close()
+		return nil, err
+	}
+	return newStateStore(dbDir, merklePath, store, stateHashCheckHeight)
+}
+
+func newStateStore(dbDir, merklePath string, store scom.PersistStore, stateHashCheckHeight uint32) (*StateStore, error) {
 	stateStore := &StateStore{
 		dbDir:                dbDir,
 		store:                store,
@@ -132,6 +150,15 @@ func (self *StateStore) init(currBlockHeight uint32) error {
 	return nil
 }
 
+//reload the merkle trees after the states are reverted to the block height
+func (self *StateStore) reload(currBlockHeight uint32) error {
+	if self.merkleHashStore != nil {
+		self.merkleHashStore.Close()
+	}
+	self.deltaMerkleTree = merkle.NewTree(0, nil, nil)
+	return self.init(currBlockHeight)
+}
+
 //GetStateMerkleTree return merkle tree size an tree node
 func (self *StateStore) GetStateMerkleTree() (uint32, []common.Uint256, error) {
 	key := self.genStateMerkleTreeKey()
@@ -188,6 +215,19 @@ func (self *StateStore) GetStateMerkleRoot(height uint32) (result common.Uint256
 	return
 }
 
+//GetStateWriteSetHash return the write set hash of block which is the leaf of state merkle tree
+func (self *StateStore) GetStateWriteSetHash(height uint32) (common.Uint256, error) {
+	value, err := self.store.Get(self.genStateMerkleRootKey(height))
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	hash, eof := common.NewZeroCopySource(value).NextHash()
+	if eof {
+		return common.UINT256_EMPTY, io.ErrUnexpectedEOF
+	}
+	return hash, nil
+}
+
 func (self *StateStore) AddStateMerkleTreeRoot(blockHeight uint32, writeSetHash common.Uint256) error {
 	if blockHeight < self.stateHashCheckHeight {
 		return nil
@@ -215,6 +255,123 @@ func (self *StateStore) AddStateMerkleTreeRoot(blockHeight uint32, writeSetHash
 	return nil
 }
 
+//SaveReverseWriteSet persist the previous values of the states changed by block, including the write set and the
+//system states saved with the block. It must be called before the block is saved to state store.
+func (self *StateStore) SaveReverseWriteSet(blockHeight uint32, writeSet *overlaydb.MemDB) error {
+	keys := [][]byte{self.genBlockMerkleTreeKey(), self.genStateMerkleTreeKey(), self.getCurrentBlockKey(),
+		self.genStateMerkleRootKey(blockHeight), self.genCrossStatesKey(blockHeight)}
+	writeSet.ForEach(func(key, val []byte) {
+		keys = append(keys, append([]byte{}, key...))
+	})
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(uint32(len(keys)))
+	for _, key := range keys {
+		value, err := self.store.Get(key)
+		if err != nil && err != scom.ErrNotFound {
+			return err
+		}
+		sink.WriteVarBytes(key)
+		sink.WriteVarBytes(value)
+	}
+	self.store.BatchPut(self.genReverseWriteSetKey(blockHeight), sink.Bytes())
+	return nil
+}
+
+//GetReverseWriteSet return the write set reverting the states changed by block, a deleted state has empty value
+func (self *StateStore) GetReverseWriteSet(blockHeight uint32) (*overlaydb.MemDB, error) {
+	return self.getReverseWriteSet(self.store, blockHeight)
+}
+
+// getReverseWriteSet read the reverse write set of block from the store, which may be a snapshot of state store
+func (self *StateStore) getReverseWriteSet(store scom.PersistStore, blockHeight uint32) (*overlaydb.MemDB, error) {
+	data, err := store.Get(self.genReverseWriteSetKey(blockHeight))
+	if err != nil {
+		return nil, err
+	}
+	return deserializeWriteSet(data)
+}
+
+//HasReverseWriteSet return whether the reverse write set of block is kept
+func (self *StateStore) HasReverseWriteSet(blockHeight uint32) (bool, error) {
+	return self.store.Has(self.genReverseWriteSetKey(blockHeight))
+}
+
+//DeleteReverseWriteSet delete the reverse write set of block in batch
+func (self *StateStore) DeleteReverseWriteSet(blockHeight uint32) {
+	self.store.BatchDelete(self.genReverseWriteSetKey(blockHeight))
+}
+
+//SaveWriteSet persist the states changed by block in batch
+func (self *StateStore) SaveWriteSet(blockHeight uint32, writeSet *overlaydb.MemDB) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(uint32(writeSet.Len()))
+	writeSet.ForEach(func(key, val []byte) {
+		sink.WriteVarBytes(key)
+		sink.WriteVarBytes(val)
+	})
+	self.store.BatchPut(self.genWriteSetKey(blockHeight), sink.Bytes())
+}
+
+//GetWriteSet return the states changed by block, a deleted state has empty value
+func (self *StateStore) GetWriteSet(blockHeight uint32) (*overlaydb.MemDB, error) {
+	data, err := self.store.Get(self.genWriteSetKey(blockHeight))
+	if err != nil {
+		return nil, err
+	}
+	return deserializeWriteSet(data)
+}
+
+//DeleteWriteSet delete the write set of block in batch
+func (self *StateStore) DeleteWriteSet(blockHeight uint32) {
+	self.store.BatchDelete(self.genWriteSetKey(blockHeight))
+}
+
+//SaveStorageTrieRoot persist the root of storage trie after block in batch
+func (self *StateStore) SaveStorageTrieRoot(blockHeight uint32, root common2.Hash) {
+	self.store.BatchPut(self.genStorageTrieRootKey(blockHeight), root[:])
+}
+
+//GetStorageTrieRoot return the root of storage trie after block
+func (self *StateStore) GetStorageTrieRoot(blockHeight uint32) (common2.Hash, error) {
+	data, err := self.store.Get(self.genStorageTrieRootKey(blockHeight))
+	if err != nil {
+		return common2.Hash{}, err
+	}
+	if len(data) != common2.HashLength {
+		return common2.Hash{}, fmt.Errorf("invalid storage trie root %x", data)
+	}
+	return common2.BytesToHash(data), nil
+}
+
+//DeleteStorageTrieRoot delete the root of storage trie after block in batch
+func (self *StateStore) DeleteStorageTrieRoot(blockHeight uint32) {
+	self.store.BatchDelete(self.genStorageTrieRootKey(blockHeight))
+}
+
+func deserializeWriteSet(data []byte) (*overlaydb.MemDB, error) {
+	source := common.NewZeroCopySource(data)
+	count, eof := source.NextUint32()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	writeSet := overlaydb.NewMemDB(0, int(count))
+	for i := uint32(0); i < count; i++ {
+		key, _, irregular, eof := source.NextVarBytes()
+		if irregular {
+			return nil, common.ErrIrregularData
+		}
+		value, _, irregular, eof2 := source.NextVarBytes()
+		if irregular {
+			return nil, common.ErrIrregularData
+		}
+		if eof || eof2 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		writeSet.Put(key, value)
+	}
+	return writeSet, nil
+}
+
 //AddBlockMerkleTreeRoot add a new tree root
 func (self *StateStore) AddBlockMerkleTreeRoot(txRoot common.Uint256) error {
 	key := self.genBlockMerkleTreeKey()
@@ -509,6 +666,27 @@ func (self *StateStore) genStateMerkleRootKey(height uint32) []byte {
 	return key
 }
 
+func (self *StateStore) genReverseWriteSetKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.DATA_STATE_REVERSE_WRITE_SET)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
+
+func (self *StateStore) genWriteSetKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.DATA_STATE_WRITE_SET)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
+
+func (self *StateStore) genStorageTrieRootKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.DATA_STORAGE_TRIE_ROOT)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
+
 //ClearAll clear all data in state store
 func (self *StateStore) ClearAll() error {
 	self.store.NewBatch()
@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+//GetStorageDiff return the storage changes of contract made by the blocks in (fromHeight, toHeight], the write sets
+//of the blocks should be saved by EnableWriteSetSaving, and the blocks should not be pruned.
+func (this *LedgerStoreImp) GetStorageDiff(contract common.Address, fromHeight, toHeight uint32) (*store.StorageDiff, error) {
+	if fromHeight >= toHeight {
+		return nil, fmt.Errorf("from height %d is not lower than to height %d", fromHeight, toHeight)
+	}
+	if currHeight := this.GetCurrentBlockHeight(); toHeight > currHeight {
+		return nil, fmt.Errorf("to height %d is higher than current block height %d", toHeight, currHeight)
+	}
+	prefix := append([]byte{byte(scom.ST_STORAGE)}, contract[:]...)
+	oldValues := make(map[string][]byte)
+	newValues := make(map[string][]byte)
+	for h := fromHeight + 1; h <= toHeight; h++ {
+		writeSet, err := this.stateStore.GetWriteSet(h)
+		if err == scom.ErrNotFound {
+			return nil, fmt.Errorf("write set of block %d is not saved", h)
+		} else if err != nil {
+			return nil, fmt.Errorf("stateStore.GetWriteSet height:%d error:%s", h, err)
+		}
+		var changed [][]byte
+		writeSet.ForEach(func(key, val []byte) {
+			if bytes.HasPrefix(key, prefix) {
+				changed = append(changed, key)
+				newValues[string(key[len(prefix):])] = val
+			}
+		})
+		if len(changed) == 0 {
+			continue
+		}
+		reverse, err := this.stateStore.GetReverseWriteSet(h)
+		if err == scom.ErrNotFound {
+			return nil, fmt.Errorf("reverse write set of block %d is not found", h)
+		} else if err != nil {
+			return nil, fmt.Errorf("stateStore.GetReverseWriteSet height:%d error:%s", h, err)
+		}
+		for _, key := range changed {
+			k := string(key[len(prefix):])
+			if _, ok := oldValues[k]; ok {
+				continue
+			}
+			value, unknown := reverse.Get(key)
+			if unknown {
+				return nil, fmt.Errorf("previous value of key %x is not found in reverse write set of block %d", key, h)
+			}
+			oldValues[k] = value
+		}
+	}
+
+	diff := &store.StorageDiff{
+		Contract:   contract.ToHexString(),
+		FromHeight: fromHeight,
+		ToHeight:   toHeight,
+		Changes:    make([]*store.StorageChange, 0, len(newValues)),
+	}
+	for k, newValue := range newValues {
+		oldValue := oldValues[k]
+		if bytes.Equal(oldValue, newValue) {
+			continue
+		}
+		key := []byte(k)
+		change := &store.StorageChange{
+			Key:      hex.EncodeToString(key),
+			OldValue: hex.EncodeToString(oldValue),
+			NewValue: hex.EncodeToString(newValue),
+		}
+		var decodeValue func([]byte) (string, error)
+		change.KeyName, decodeValue = decodeNativeStorageKey(contract, key)
+		if decodeValue != nil {
+			change.OldDecoded = decodeNativeStorageValue(oldValue, decodeValue)
+			change.NewDecoded = decodeNativeStorageValue(newValue, decodeValue)
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Key < diff.Changes[j].Key
+	})
+	return diff, nil
+}
+
+// the storage key prefixes of governance contract, a longer prefix is matched first
+var governanceKeyPrefixes = sortedByLength([]string{governance.GLOBAL_PARAM, governance.GLOBAL_PARAM2,
+	governance.VBFT_CONFIG, governance.GOVERNANCE_VIEW, governance.CANDIDITE_INDEX, governance.PEER_POOL,
+	governance.PEER_INDEX, governance.BLACK_LIST, governance.TOTAL_STAKE, governance.PENALTY_STAKE,
+	governance.SPLIT_CURVE, governance.PEER_ATTRIBUTES, governance.SPLIT_FEE, governance.SPLIT_FEE_ADDRESS,
+	governance.PROMISE_POS, governance.PRE_CONFIG, governance.GAS_ADDRESS, string(governance.AUTHORIZE_INFO_POOL)})
+
+func sortedByLength(list []string) []string {
+	sort.Slice(list, func(i, j int) bool {
+		return len(list[i]) > len(list[j])
+	})
+	return list
+}
+
+// decodeNativeStorageKey return the readable key of native contract and the decoder of its value, empty name is
+// returned if the key is unknown
+func decodeNativeStorageKey(contract common.Address, key []byte) (string, func([]byte) (string, error)) {
+	switch contract {
+	case utils.OntContractAddress, utils.OngContractAddress:
+		switch {
+		case string(key) == ont.TOTAL_SUPPLY_NAME:
+			return ont.TOTAL_SUPPLY_NAME, decodeUint64
+		case bytes.HasPrefix(key, []byte(ont.UNBOUND_TIME_OFFSET)):
+			rest := key[len(ont.UNBOUND_TIME_OFFSET):]
+			if len(rest) == 0 {
+				return ont.UNBOUND_TIME_OFFSET, decodeUint32
+			}
+			if len(rest) == common.ADDR_LEN {
+				addr, _ := common.AddressParseFromBytes(rest)
+				return ont.UNBOUND_TIME_OFFSET + " " + addr.ToBase58(), decodeUint32
+			}
+		case len(key) == common.ADDR_LEN:
+			addr, _ := common.AddressParseFromBytes(key)
+			return "balance " + addr.ToBase58(), decodeUint64
+		case len(key) == 2*common.ADDR_LEN:
+			from, _ := common.AddressParseFromBytes(key[:common.ADDR_LEN])
+			to, _ := common.AddressParseFromBytes(key[common.ADDR_LEN:])
+			return "allowance " + from.ToBase58() + " " + to.ToBase58(), decodeUint64
+		}
+	case utils.GovernanceContractAddress:
+		for _, prefix := range governanceKeyPrefixes {
+			if bytes.HasPrefix(key, []byte(prefix)) {
+				if rest := key[len(prefix):]; len(rest) != 0 {
+					return prefix + " " + hex.EncodeToString(rest), nil
+				}
+				return prefix, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func decodeNativeStorageValue(value []byte, decode func([]byte) (string, error)) string {
+	if len(value) == 0 {
+		return ""
+	}
+	item := new(states.StorageItem)
+	if err := item.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return ""
+	}
+	result, err := decode(item.Value)
+	if err != nil {
+		return ""
+	}
+	return result
+}
+
+func decodeUint64(data []byte) (string, error) {
+	source := common.NewZeroCopySource(data)
+	val, eof := source.NextUint64()
+	if eof {
+		return "", fmt.Errorf("invalid uint64 %x", data)
+	}
+	return strconv.FormatUint(val, 10), nil
+}
+
+func decodeUint32(data []byte) (string, error) {
+	source := common.NewZeroCopySource(data)
+	val, eof := source.NextUint32()
+	if eof {
+		return "", fmt.Errorf("invalid uint32 %x", data)
+	}
+	return strconv.FormatUint(uint64(val), 10), nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStorageDiff(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/storagediff", bookkeeper, accounts)
+	defer ledger.Close()
+
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10)})
+	ledger.EnableWriteSetSaving()
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[1], accounts[0].Address, 3)})
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 5)})
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[1], accounts[0].Address, 5)})
+
+	// the write set of block 1 is not saved
+	_, err := ledger.GetStorageDiff(utils.OntContractAddress, 0, 2)
+	assert.NotNil(t, err)
+	_, err = ledger.GetStorageDiff(utils.OntContractAddress, 2, 2)
+	assert.NotNil(t, err)
+	_, err = ledger.GetStorageDiff(utils.OntContractAddress, 1, 5)
+	assert.NotNil(t, err)
+
+	change := func(diff *store.StorageDiff, key []byte) *store.StorageChange {
+		for _, change := range diff.Changes {
+			if change.Key == hex.EncodeToString(key) {
+				return change
+			}
+		}
+		return nil
+	}
+	balanceChange := func(change *store.StorageChange) int64 {
+		oldBalance, err := strconv.ParseInt(change.OldDecoded, 10, 64)
+		assert.Nil(t, err)
+		newBalance, err := strconv.ParseInt(change.NewDecoded, 10, 64)
+		assert.Nil(t, err)
+		return newBalance - oldBalance
+	}
+	diff, err := ledger.GetStorageDiff(utils.OntContractAddress, 1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.OntContractAddress.ToHexString(), diff.Contract)
+	// the balances and unbound ong time offsets of both accounts are changed
+	assert.Equal(t, 4, len(diff.Changes))
+	sender := change(diff, accounts[0].Address[:])
+	assert.NotNil(t, sender)
+	assert.Equal(t, "balance "+accounts[0].Address.ToBase58(), sender.KeyName)
+	assert.Equal(t, int64(-2), balanceChange(sender))
+	assert.Equal(t, int64(2), balanceChange(change(diff, accounts[1].Address[:])))
+	for i := 1; i < len(diff.Changes); i++ {
+		assert.True(t, diff.Changes[i-1].Key < diff.Changes[i].Key)
+	}
+	offset := change(diff, append([]byte(ont.UNBOUND_TIME_OFFSET), accounts[0].Address[:]...))
+	assert.NotNil(t, offset)
+	assert.Equal(t, "unboundTimeOffset "+accounts[0].Address.ToBase58(), offset.KeyName)
+
+	// the balances changed back are not reported
+	diff, err = ledger.GetStorageDiff(utils.OntContractAddress, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(diff.Changes))
+	assert.Nil(t, change(diff, accounts[0].Address[:]))
+
+	// the write sets are deleted with the reverted blocks
+	assert.Nil(t, ledger.Rollback(2))
+	_, err = ledger.stateStore.GetWriteSet(3)
+	assert.NotNil(t, err)
+	_, err = ledger.stateStore.GetWriteSet(2)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,410 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/store/stateproof"
+	"github.com/ontio/ontology/merkle"
+)
+
+const (
+	// memory allowance in MB of the clean node cache of storage trie
+	storageTrieCacheSize = 16
+	// the number of storage keys inserted before the nodes are flushed when building storage trie
+	storageTrieBuildBatchSize = 100000
+)
+
+//EnableStorageProof maintain the storage trie of contracts with the blocks saved later, the trie is built from the
+//current states if its root of current block is not found. It is always maintained since the storage root height.
+func (this *LedgerStoreImp) EnableStorageProof() error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	return this.enableStorageProof()
+}
+
+// must hold the saving block lock
+func (this *LedgerStoreImp) enableStorageProof() error {
+	if this.storageTrie != nil {
+		return nil
+	}
+	if this.forked {
+		return fmt.Errorf("storage proof is not supported by the ledger forked from remote")
+	}
+	if this.light != nil {
+		return errLightLedger
+	}
+	db := trie.NewDatabaseWithConfig(&trieNodeStore{store: this.stateStore.store},
+		&trie.Config{Cache: storageTrieCacheSize})
+	height := this.GetCurrentBlockHeight()
+	_, err := this.stateStore.GetStorageTrieRoot(height)
+	if err == scom.ErrNotFound {
+		err = this.buildStorageTrie(db, height)
+	}
+	if err != nil {
+		return err
+	}
+	this.storageTrie = db
+	return nil
+}
+
+// buildStorageTrie insert all the contract storage into an empty trie, and save the root as the one of height
+func (this *LedgerStoreImp) buildStorageTrie(db *trie.Database, height uint32) error {
+	log.Infof("building storage trie at height %d", height)
+	t, err := trie.New(common2.Hash{}, db)
+	if err != nil {
+		return err
+	}
+	flush := func() (common2.Hash, error) {
+		root, err := t.Commit(nil)
+		if err != nil {
+			return root, err
+		}
+		this.stateStore.NewBatch()
+		if err := db.Commit(root, false, nil); err != nil {
+			return root, err
+		}
+		return root, this.stateStore.CommitTo()
+	}
+	iter := this.stateStore.store.NewIterator([]byte{byte(scom.ST_STORAGE)})
+	defer iter.Release()
+	count := 0
+	for iter.Next() {
+		key := iter.Key()[1:]
+		if len(key) < common.ADDR_LEN {
+			continue
+		}
+		contract, _ := common.AddressParseFromBytes(key[:common.ADDR_LEN])
+		if err := t.TryUpdate(stateproof.TrieKey(contract, key[common.ADDR_LEN:]), iter.Value()); err != nil {
+			return err
+		}
+		count++
+		if count%storageTrieBuildBatchSize == 0 {
+			root, err := flush()
+			if err != nil {
+				return err
+			}
+			if t, err = trie.New(root, db); err != nil {
+				return err
+			}
+			log.Infof("%d storage keys are inserted into storage trie", count)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	// the storage deleted by state gc is still in the trie of the nodes maintaining it
+	err = this.forEachArchivedState(func(key, val []byte) error {
+		if len(key) < 1+common.ADDR_LEN || key[0] != byte(scom.ST_STORAGE) {
+			return nil
+		}
+		contract, _ := common.AddressParseFromBytes(key[1 : 1+common.ADDR_LEN])
+		return t.TryUpdate(stateproof.TrieKey(contract, key[1+common.ADDR_LEN:]), val)
+	})
+	if err != nil {
+		return err
+	}
+	root, err := flush()
+	if err != nil {
+		return err
+	}
+	this.stateStore.NewBatch()
+	this.stateStore.SaveStorageTrieRoot(height, root)
+	if err := this.stateStore.CommitTo(); err != nil {
+		return err
+	}
+	log.Infof("storage trie of %d keys is built, root %x", count, root[:])
+	return nil
+}
+
+// applyStorageWriteSet apply the contract storage changed by block to the trie of previous block in memory
+func (this *LedgerStoreImp) applyStorageWriteSet(height uint32, writeSet *overlaydb.MemDB) (*trie.Trie, error) {
+	root, err := this.stateStore.GetStorageTrieRoot(height - 1)
+	if err != nil {
+		return nil, fmt.Errorf("GetStorageTrieRoot height:%d error:%s", height-1, err)
+	}
+	t, err := trie.New(root, this.storageTrie)
+	if err != nil {
+		return nil, err
+	}
+	writeSet.ForEach(func(key, val []byte) {
+		if err != nil || len(key) < 1+common.ADDR_LEN || key[0] != byte(scom.ST_STORAGE) {
+			return
+		}
+		contract, _ := common.AddressParseFromBytes(key[1 : 1+common.ADDR_LEN])
+		err = t.TryUpdate(stateproof.TrieKey(contract, key[1+common.ADDR_LEN:]), val)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// executedStorageRoot return the storage trie root after the block executed, it's committed as the last cross state
+// of the blocks since the storage root height, so the trie is maintained by all nodes since then
+func (this *LedgerStoreImp) executedStorageRoot(height uint32, writeSet *overlaydb.MemDB) (common2.Hash, error) {
+	if err := this.enableStorageProof(); err != nil {
+		return common2.Hash{}, fmt.Errorf("enable storage proof error: %s", err)
+	}
+	t, err := this.applyStorageWriteSet(height, writeSet)
+	if err != nil {
+		return common2.Hash{}, err
+	}
+	return t.Hash(), nil
+}
+
+// updateStorageTrie apply the contract storage changed by block to the trie of previous block, the nodes and root
+// are saved in the batch of state store
+func (this *LedgerStoreImp) updateStorageTrie(height uint32, writeSet *overlaydb.MemDB) error {
+	t, err := this.applyStorageWriteSet(height, writeSet)
+	if err != nil {
+		return err
+	}
+	root, err := t.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err = this.storageTrie.Commit(root, false, nil); err != nil {
+		return err
+	}
+	this.stateStore.SaveStorageTrieRoot(height, root)
+	return nil
+}
+
+//GetStorageProof return the proof of the value of contract storage key at height, the storage proof should be enabled
+//before the block of height is saved.
+func (this *LedgerStoreImp) GetStorageProof(contract common.Address, key []byte, height uint32) (*stateproof.StorageProof, error) {
+	db := this.storageTrie
+	if db == nil {
+		return nil, fmt.Errorf("storage proof is not enabled")
+	}
+	if currHeight := this.GetCurrentBlockHeight(); height > currHeight {
+		return nil, fmt.Errorf("height %d is higher than current block height %d", height, currHeight)
+	}
+	root, err := this.stateStore.GetStorageTrieRoot(height)
+	if err == scom.ErrNotFound {
+		return nil, fmt.Errorf("storage trie root of height %d is not found", height)
+	} else if err != nil {
+		return nil, err
+	}
+	t, err := trie.New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	trieKey := stateproof.TrieKey(contract, key)
+	value, err := t.TryGet(trieKey)
+	if err != nil {
+		return nil, err
+	}
+	nodes := &proofNodes{}
+	if err := t.Prove(trieKey, 0, nodes); err != nil {
+		return nil, err
+	}
+	proof := &stateproof.StorageProof{
+		Contract:    contract.ToHexString(),
+		Key:         hex.EncodeToString(key),
+		Value:       hex.EncodeToString(value),
+		Height:      height,
+		StorageRoot: hex.EncodeToString(root[:]),
+		Proof:       nodes.list,
+	}
+	if height == 0 || height < config.GetStorageRootHeight() {
+		return proof, nil
+	}
+	// the root is anchored by the cross chain msg once the next block is saved
+	hashes, err := this.stateStore.GetCrossStates(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetCrossStates height:%d error:%s", height, err)
+	}
+	path, err := merkle.MerkleLeafHashPath(stateproof.RootLeaf(root), hashes)
+	if err != nil {
+		return nil, fmt.Errorf("storage root of height %d is not in cross states: %s", height, err)
+	}
+	proof.RootProof = hex.EncodeToString(path)
+	msg, err := this.crossChainStore.GetCrossChainMsg(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetCrossChainMsg height:%d error:%s", height, err)
+	}
+	if msg != nil {
+		proof.CrossMsg = hex.EncodeToString(common.SerializeToBytes(msg))
+	}
+	return proof, nil
+}
+
+// proofNodes collects the trie nodes of proof in order
+type proofNodes struct {
+	list []string
+}
+
+func (self *proofNodes) Put(key []byte, value []byte) error {
+	self.list = append(self.list, hex.EncodeToString(value))
+	return nil
+}
+
+func (self *proofNodes) Delete(key []byte) error {
+	return errors.New("not supported")
+}
+
+// trieNodeStore is the node store of storage trie in state store, the writes are put to the batch of state store
+type trieNodeStore struct {
+	store scom.PersistStore
+}
+
+func (self *trieNodeStore) key(key []byte) []byte {
+	return append([]byte{byte(scom.ST_STORAGE_TRIE)}, key...)
+}
+
+func (self *trieNodeStore) Has(key []byte) (bool, error) {
+	return self.store.Has(self.key(key))
+}
+
+func (self *trieNodeStore) Get(key []byte) ([]byte, error) {
+	return self.store.Get(self.key(key))
+}
+
+func (self *trieNodeStore) Put(key []byte, value []byte) error {
+	self.store.BatchPut(self.key(key), value)
+	return nil
+}
+
+func (self *trieNodeStore) Delete(key []byte) error {
+	self.store.BatchDelete(self.key(key))
+	return nil
+}
+
+func (self *trieNodeStore) NewBatch() ethdb.Batch {
+	return &trieNodeBatch{store: self}
+}
+
+func (self *trieNodeStore) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	return &trieNodeIterator{iter: self.store.NewIterator(self.key(prefix)), start: start}
+}
+
+func (self *trieNodeStore) Stat(property string) (string, error) {
+	return "", errors.New("not supported")
+}
+
+func (self *trieNodeStore) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// the store is closed by state store
+func (self *trieNodeStore) Close() error {
+	return nil
+}
+
+type trieNodeOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// trieNodeBatch puts the writes to the batch of state store when it's written
+type trieNodeBatch struct {
+	store *trieNodeStore
+	ops   []trieNodeOp
+	size  int
+}
+
+func (self *trieNodeBatch) Put(key []byte, value []byte) error {
+	self.ops = append(self.ops, trieNodeOp{key: common2.CopyBytes(key), value: common2.CopyBytes(value)})
+	self.size += len(value)
+	return nil
+}
+
+func (self *trieNodeBatch) Delete(key []byte) error {
+	self.ops = append(self.ops, trieNodeOp{key: common2.CopyBytes(key), delete: true})
+	self.size++
+	return nil
+}
+
+func (self *trieNodeBatch) ValueSize() int {
+	return self.size
+}
+
+func (self *trieNodeBatch) Write() error {
+	return self.Replay(self.store)
+}
+
+func (self *trieNodeBatch) Reset() {
+	self.ops = self.ops[:0]
+	self.size = 0
+}
+
+func (self *trieNodeBatch) Replay(w ethdb.KeyValueWriter) error {
+	for _, op := range self.ops {
+		var err error
+		if op.delete {
+			err = w.Delete(op.key)
+		} else {
+			err = w.Put(op.key, op.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trieNodeIterator iterates the nodes of storage trie from the start key
+type trieNodeIterator struct {
+	iter  scom.StoreIterator
+	start []byte
+}
+
+func (self *trieNodeIterator) Next() bool {
+	for self.iter.Next() {
+		if bytes.Compare(self.Key(), self.start) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *trieNodeIterator) Error() error {
+	return self.iter.Error()
+}
+
+func (self *trieNodeIterator) Key() []byte {
+	key := self.iter.Key()
+	if len(key) == 0 {
+		return nil
+	}
+	return key[1:]
+}
+
+func (self *trieNodeIterator) Value() []byte {
+	return self.iter.Value()
+}
+
+func (self *trieNodeIterator) Release() {
+	self.iter.Release()
+}
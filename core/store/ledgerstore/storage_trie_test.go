@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"testing"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStorageProof(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/storageproof", bookkeeper, accounts)
+	defer ledger.Close()
+	ledger.EnableReverseWriteSetSaving()
+
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10)})
+	_, err := ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 1)
+	assert.NotNil(t, err)
+
+	// the trie is built from the states of block 1
+	assert.Nil(t, ledger.EnableStorageProof())
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[1], accounts[0].Address, 3)})
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 5)})
+
+	_, err = ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 0)
+	assert.NotNil(t, err)
+	_, err = ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 4)
+	assert.NotNil(t, err)
+
+	// the trie value is the stored item
+	balanceKey := append(append([]byte{byte(scom.ST_STORAGE)}, utils.OntContractAddress[:]...), accounts[0].Address[:]...)
+	balance, err := ledger.stateStore.store.Get(balanceKey)
+	assert.Nil(t, err)
+	var roots []common2.Hash
+	for h := uint32(1); h <= 3; h++ {
+		proof, err := ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], h)
+		assert.Nil(t, err)
+		root, err := ledger.stateStore.GetStorageTrieRoot(h)
+		assert.Nil(t, err)
+		value, err := proof.Verify(root)
+		assert.Nil(t, err)
+		if h == 3 {
+			assert.Equal(t, balance, value)
+		}
+		roots = append(roots, root)
+	}
+	assert.NotEqual(t, roots[0], roots[1])
+	assert.NotEqual(t, roots[1], roots[2])
+	// the proof of block 2 is not valid for the root of block 3
+	proof, err := ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 2)
+	assert.Nil(t, err)
+	_, err = proof.Verify(roots[2])
+	assert.NotNil(t, err)
+
+	// the absence of key is proven with empty value
+	proof, err = ledger.GetStorageProof(utils.OntContractAddress, []byte("unknown"), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "", proof.Value)
+	assert.Equal(t, hex.EncodeToString([]byte("unknown")), proof.Key)
+	value, err := proof.Verify(roots[2])
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	// the roots are deleted with the reverted blocks, and the trie is updated from the root of block 2 again
+	assert.Nil(t, ledger.Rollback(2))
+	_, err = ledger.stateStore.GetStorageTrieRoot(3)
+	assert.NotNil(t, err)
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 1)})
+	proof, err = ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 3)
+	assert.Nil(t, err)
+	root, err := ledger.stateStore.GetStorageTrieRoot(3)
+	assert.Nil(t, err)
+	value, err = proof.Verify(root)
+	assert.Nil(t, err)
+	balance, err = ledger.stateStore.store.Get(balanceKey)
+	assert.Nil(t, err)
+	assert.Equal(t, balance, value)
+}
+
+func TestStorageRootHeight(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	bookkeeper := account.NewAccount("")
+	genesisConfig := config.DefConfig.Genesis
+	defer func() { config.DefConfig.Genesis = genesisConfig }()
+	config.DefConfig.Genesis = &config.GenesisConfig{
+		ConsensusType: config.CONSENSUS_TYPE_SOLO,
+		SOLO: &config.SOLOConfig{
+			Bookkeepers: []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey))},
+		},
+	}
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/storageroot", bookkeeper, accounts)
+	defer ledger.Close()
+
+	// the cross states of the blocks are unchanged if the height is not configured
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10)})
+	root, err := ledger.GetCrossStatesRoot(1)
+	assert.Nil(t, err)
+	assert.Equal(t, common.UINT256_EMPTY, root)
+	_, err = ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 1)
+	assert.NotNil(t, err)
+
+	config.DefConfig.Genesis.StorageRootHeight = 3
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10)})
+	root, err = ledger.GetCrossStatesRoot(2)
+	assert.Nil(t, err)
+	assert.Equal(t, common.UINT256_EMPTY, root)
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 10)})
+	root, err = ledger.GetCrossStatesRoot(3)
+	assert.Nil(t, err)
+	assert.NotEqual(t, common.UINT256_EMPTY, root)
+	_, err = ledger.GetStorageProof(utils.OntContractAddress, accounts[0].Address[:], 3)
+	assert.Nil(t, err)
+}
@@ -421,25 +421,62 @@ type Eip155Context struct {
 	Timestamp uint32
 }
 
+//HandleEIP155Transaction deal with evm transaction, the cross chain state hashes pushed by neovm and wasmvm contracts
+//it calls through cross vm call are returned together with the execution result
 func (self *StateStore) HandleEIP155Transaction(store store.LedgerStore, cache *storage.CacheDB,
-	tx *types2.Transaction, ctx Eip155Context, notify *event.ExecuteNotify, checkNonce bool) (*types3.ExecutionResult, error) {
+	tx *types2.Transaction, ctx Eip155Context, notify *event.ExecuteNotify, checkNonce bool) (*types3.ExecutionResult, []common.Uint256, error) {
 	usedGas := uint64(0)
 	config := params.GetChainConfig(sysconfig.DefConfig.P2PNode.EVMChainId)
 	statedb := storage.NewStateDB(cache, tx.Hash(), common2.Hash(ctx.BlockHash), ong.OngBalanceHandle{})
+	vmConfig := evm.Config{}
+	if ctx.Height >= sysconfig.GetCrossVMCallHeight() {
+		vmConfig.CrossVM = newEIP155CrossVMCaller(store, cache, statedb, tx, ctx)
+	}
 	result, receipt, err := evm2.ApplyTransaction(config, store, statedb, ctx.Height, ctx.Timestamp, tx, &usedGas,
-		utils.GovernanceContractAddress, evm.Config{}, checkNonce)
+		utils.GovernanceContractAddress, vmConfig, checkNonce)
 
 	if err != nil {
 		cache.SetDbErr(err)
-		return nil, err
+		return nil, nil, err
 	}
 	if err = statedb.DbErr(); err != nil {
 		cache.SetDbErr(err)
-		return nil, err
+		return nil, nil, err
 	}
 	receipt.TxIndex = ctx.TxIndex
 
 	*notify = *event.ExecuteNotifyFromEthReceipt(receipt)
+	notify.Notify = append(notify.Notify, statedb.GetNotifications()...)
+
+	return result, statedb.GetCrossStateHashes(), nil
+}
+
+// newEIP155CrossVMCaller creates the smart contract environment neovm and wasmvm contracts
+// called by an evm transaction run in, it returns nil if the transaction sender can not be recovered
+func newEIP155CrossVMCaller(store store.LedgerStore, cache *storage.CacheDB, statedb *storage.StateDB,
+	tx *types2.Transaction, ctx Eip155Context) evm.CrossVMCaller {
+	otx, err := types.TransactionFromEIP155(tx)
+	if err != nil {
+		return nil
+	}
 
-	return result, nil
+	gasTable := make(map[string]uint64)
+	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		gasTable[k.(string)] = value.(uint64)
+		return true
+	})
+
+	sc := &smartcontract.SmartContract{
+		Config: &smartcontract.Config{
+			Time:      ctx.Timestamp,
+			Height:    ctx.Height,
+			Tx:        otx,
+			BlockHash: ctx.BlockHash,
+		},
+		CacheDB:      cache,
+		Store:        store,
+		GasTable:     gasTable,
+		WasmExecStep: sysconfig.DEFAULT_WASM_MAX_STEPCOUNT,
+	}
+	return sc.NewCrossVMCaller(statedb)
 }
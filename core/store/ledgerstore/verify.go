@@ -0,0 +1,371 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/merkle"
+)
+
+const verifyLogInterval = 100000
+
+//VerifyReport is the result of ledger verification
+type VerifyReport struct {
+	CurrentHeight   uint32   // current block height of block store
+	StateHeight     uint32   // current block height of state store
+	CheckedHeight   uint32   // blocks are checked from the height, the blocks before it are pruned
+	ExecStartHeight uint32   // blocks in [ExecStartHeight, ExecEndHeight] are executed again
+	ExecEndHeight   uint32   // no block is executed again if ExecStartHeight is 0
+	BadHeight       uint32   // the first bad height, the ledger is good if Reason is empty
+	Reason          string   // why the block of bad height is bad
+	Warnings        []string // the checks skipped or the inconsistency fixed by node itself
+}
+
+//Good return whether no bad block is found
+func (self *VerifyReport) Good() bool {
+	return self.Reason == ""
+}
+
+// fail keeps the lowest bad height
+func (self *VerifyReport) fail(height uint32, format string, args ...interface{}) {
+	if !self.Good() && self.BadHeight <= height {
+		return
+	}
+	self.BadHeight = height
+	self.Reason = fmt.Sprintf(format, args...)
+}
+
+func (self *VerifyReport) warn(format string, args ...interface{}) {
+	self.Warnings = append(self.Warnings, fmt.Sprintf(format, args...))
+}
+
+//Verify check the header chain, block merkle tree, state merkle tree and the consistency of block, event and cross
+//chain stores, and execute the blocks in [execStart, execEnd] again on the states reverted by reverse write sets to
+//compare the write set hashes and cross chain roots. No block is executed if execStart is 0, and execEnd 0 means the
+//current height of states. The ledger should not be in service.
+func (this *LedgerStoreImp) Verify(execStart, execEnd uint32) (*VerifyReport, error) {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	report := &VerifyReport{}
+	if err := this.loadCurrentBlock(); err != nil {
+		return nil, err
+	}
+	if err := this.loadHeaderIndexList(); err != nil {
+		return nil, err
+	}
+	report.CurrentHeight = this.GetCurrentBlockHeight()
+	_, stateHeight, err := this.stateStore.GetCurrentBlock()
+	if err != nil {
+		return nil, fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	report.StateHeight = stateHeight
+	if stateHeight > report.CurrentHeight {
+		report.fail(report.CurrentHeight+1, "states are saved to height %d above block height %d", stateHeight,
+			report.CurrentHeight)
+	} else if stateHeight < report.CurrentHeight {
+		report.warn("states of blocks above height %d are not saved, they are executed again when node starts",
+			stateHeight)
+	}
+	report.CheckedHeight, err = this.blockStore.GetBlockPrunedHeight()
+	if err != nil {
+		return nil, fmt.Errorf("blockStore.GetBlockPrunedHeight error %s", err)
+	}
+
+	if err = this.verifyBlocks(report); err != nil {
+		return nil, err
+	}
+	if err = this.verifyStateMerkleTree(report); err != nil {
+		return nil, err
+	}
+	if execStart != 0 {
+		if execEnd == 0 {
+			execEnd = report.StateHeight
+		}
+		if err = this.verifyExecution(report, execStart, execEnd); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func (this *LedgerStoreImp) verifyBlocks(report *VerifyReport) error {
+	start := report.CheckedHeight
+	prevHash, err := this.blockStore.GetBlockHash(start)
+	if err != nil {
+		report.fail(start, "load block hash error %s", err)
+		return nil
+	}
+	prevHeader, txHashes, err := this.blockStore.loadHeaderWithTx(prevHash)
+	if err != nil {
+		report.fail(start, "load header error %s", err)
+		return nil
+	}
+	if !this.verifyBlockData(report, start, txHashes) {
+		return nil
+	}
+	if strings.ToLower(config.DefConfig.Genesis.ConsensusType) == "vbft" {
+		if err = this.loadVbftPeerInfo(prevHeader); err != nil {
+			return fmt.Errorf("load vbft peers at height %d error %s, please check the genesis config", start, err)
+		}
+	}
+	var tree *merkle.CompactMerkleTree
+	if start == 0 {
+		tree = merkle.NewTree(0, nil, nil)
+		tree.AppendHash(prevHeader.TransactionsRoot)
+	} else {
+		report.warn("block merkle tree is not checked since blocks before height %d are pruned", start)
+	}
+
+	for height := start + 1; height <= report.CurrentHeight; height++ {
+		hash, err := this.blockStore.GetBlockHash(height)
+		if err != nil {
+			report.fail(height, "load block hash error %s", err)
+			return nil
+		}
+		header, txHashes, err := this.blockStore.loadHeaderWithTx(hash)
+		if err != nil {
+			report.fail(height, "load header error %s", err)
+			return nil
+		}
+		if header.Height != height || header.Hash() != hash {
+			report.fail(height, "header of block %s is not saved at the height", hash.ToHexString())
+			return nil
+		}
+		if header.PrevBlockHash != prevHash {
+			report.fail(height, "previous block hash %s is not %s", header.PrevBlockHash.ToHexString(),
+				prevHash.ToHexString())
+			return nil
+		}
+		if err = this.verifyHeader(header); err != nil {
+			report.fail(height, "verify header error %s", err)
+			return nil
+		}
+		if txRoot := common.ComputeMerkleRoot(txHashes); txRoot != header.TransactionsRoot {
+			report.fail(height, "transactions root %s is not %s", txRoot.ToHexString(),
+				header.TransactionsRoot.ToHexString())
+			return nil
+		}
+		if tree != nil {
+			if blockRoot := tree.GetRootWithNewLeaf(header.TransactionsRoot); blockRoot != header.BlockRoot {
+				report.fail(height, "block root %s is not %s", blockRoot.ToHexString(), header.BlockRoot.ToHexString())
+				return nil
+			}
+			tree.AppendHash(header.TransactionsRoot)
+		}
+		if !this.verifyBlockData(report, height, txHashes) {
+			return nil
+		}
+		prevHash = hash
+		if height%verifyLogInterval == 0 {
+			log.Infof("[Verify] blocks are checked to height %d", height)
+		}
+	}
+
+	if tree != nil && report.StateHeight == report.CurrentHeight {
+		treeSize, hashes, err := this.stateStore.GetBlockMerkleTree()
+		if err != nil {
+			return fmt.Errorf("stateStore.GetBlockMerkleTree error %s", err)
+		}
+		if treeSize != tree.TreeSize() || !equalHashes(hashes, tree.Hashes()) {
+			report.fail(report.StateHeight, "saved block merkle tree is inconsistent with blocks")
+		}
+	}
+	return nil
+}
+
+// verifyBlockData check the transactions, events and cross chain msg saved with block
+func (this *LedgerStoreImp) verifyBlockData(report *VerifyReport, height uint32, txHashes []common.Uint256) bool {
+	for _, txHash := range txHashes {
+		tx, txHeight, err := this.blockStore.GetTransaction(txHash)
+		if err != nil {
+			report.fail(height, "load transaction %s error %s", txHash.ToHexString(), err)
+			return false
+		}
+		if txHeight != height || tx.Hash() != txHash {
+			report.fail(height, "transaction %s is not saved with the block", txHash.ToHexString())
+			return false
+		}
+	}
+	// event store and cross chain store are saved again with the states of block
+	if height > report.StateHeight {
+		return true
+	}
+	if len(txHashes) != 0 {
+		eventTxHashes, err := this.eventStore.GetEventNotifyTxsByBlock(height)
+		if err != nil {
+			report.fail(height, "load transactions of event store error %s", err)
+			return false
+		}
+		if !equalHashes(eventTxHashes, txHashes) {
+			report.fail(height, "transactions of event store are inconsistent with block")
+			return false
+		}
+	}
+	msg, err := this.crossChainStore.GetCrossChainMsg(height)
+	if err != nil {
+		report.fail(height, "load cross chain msg error %s", err)
+		return false
+	}
+	if msg != nil {
+		root, err := this.stateStore.GetCrossStatesRoot(height)
+		if err != nil {
+			report.fail(height, "load cross states root error %s", err)
+			return false
+		}
+		if msg.StatesRoot != root {
+			report.fail(height, "cross states root %s of cross chain msg is not %s", msg.StatesRoot.ToHexString(),
+				root.ToHexString())
+			return false
+		}
+	}
+	return true
+}
+
+func (this *LedgerStoreImp) verifyStateMerkleTree(report *VerifyReport) error {
+	if report.StateHeight < this.stateHashCheckHeight {
+		return nil
+	}
+	tree := merkle.NewTree(0, nil, nil)
+	for height := this.stateHashCheckHeight; height <= report.StateHeight; height++ {
+		writeSetHash, err := this.stateStore.GetStateWriteSetHash(height)
+		if err != nil {
+			report.fail(height, "load write set hash error %s", err)
+			return nil
+		}
+		tree.AppendHash(writeSetHash)
+		root, err := this.stateStore.GetStateMerkleRoot(height)
+		if err != nil {
+			report.fail(height, "load state merkle root error %s", err)
+			return nil
+		}
+		if root != tree.Root() {
+			report.fail(height, "state merkle root %s is not %s", root.ToHexString(), tree.Root().ToHexString())
+			return nil
+		}
+	}
+	treeSize, hashes, err := this.stateStore.GetStateMerkleTree()
+	if err != nil {
+		return fmt.Errorf("stateStore.GetStateMerkleTree error %s", err)
+	}
+	if treeSize != tree.TreeSize() || !equalHashes(hashes, tree.Hashes()) {
+		report.fail(report.StateHeight, "saved state merkle tree is inconsistent with state merkle roots")
+	}
+	return nil
+}
+
+func (this *LedgerStoreImp) verifyExecution(report *VerifyReport, start, end uint32) error {
+	if start > end || end > report.StateHeight {
+		return fmt.Errorf("invalid execution range [%d, %d], it should be in [1, %d]", start, end, report.StateHeight)
+	}
+	for height := start; height <= report.StateHeight; height++ {
+		has, err := this.stateStore.HasReverseWriteSet(height)
+		if err != nil {
+			return fmt.Errorf("stateStore.HasReverseWriteSet height:%d error:%s", height, err)
+		}
+		if !has {
+			return fmt.Errorf("reverse write set of block %d is not found, the states before it can not be "+
+				"reverted", height)
+		}
+	}
+	// revert the states to the height before start block
+	states := overlaydb.NewOverlayDB(this.stateStore.store)
+	for height := report.StateHeight; height >= start; height-- {
+		writeSet, err := this.stateStore.GetReverseWriteSet(height)
+		if err != nil {
+			return fmt.Errorf("stateStore.GetReverseWriteSet height:%d error:%s", height, err)
+		}
+		writeSet.ForEach(func(key, val []byte) {
+			if len(val) == 0 {
+				states.Delete(key)
+			} else {
+				states.Put(key, val)
+			}
+		})
+	}
+	if start < this.stateHashCheckHeight {
+		report.warn("write set hashes of blocks before height %d are not compared", this.stateHashCheckHeight)
+	}
+
+	report.ExecStartHeight, report.ExecEndHeight = start, end
+	for height := start; height <= end; height++ {
+		hash, err := this.blockStore.GetBlockHash(height)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlockHash height:%d error:%s", height, err)
+		}
+		block, err := this.blockStore.GetBlock(hash)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlock height:%d error:%s", height, err)
+		}
+		result, err := this.executeBlockOnStates(block, &overlayStore{state: states})
+		if err != nil {
+			report.fail(height, "execute block error %s", err)
+			return nil
+		}
+		if height >= this.stateHashCheckHeight {
+			writeSetHash, err := this.stateStore.GetStateWriteSetHash(height)
+			if err != nil {
+				return fmt.Errorf("stateStore.GetStateWriteSetHash height:%d error:%s", height, err)
+			}
+			if result.Hash != writeSetHash {
+				report.fail(height, "write set hash %s of execution is not %s", result.Hash.ToHexString(),
+					writeSetHash.ToHexString())
+				return nil
+			}
+		}
+		crossStatesRoot, err := this.stateStore.GetCrossStatesRoot(height)
+		if err != nil {
+			return fmt.Errorf("stateStore.GetCrossStatesRoot height:%d error:%s", height, err)
+		}
+		if result.CrossStatesRoot != crossStatesRoot {
+			report.fail(height, "cross states root %s of execution is not %s", result.CrossStatesRoot.ToHexString(),
+				crossStatesRoot.ToHexString())
+			return nil
+		}
+		result.WriteSet.ForEach(func(key, val []byte) {
+			if len(val) == 0 {
+				states.Delete(key)
+			} else {
+				states.Put(key, val)
+			}
+		})
+		if height%verifyLogInterval == 0 {
+			log.Infof("[Verify] blocks are executed to height %d", height)
+		}
+	}
+	return nil
+}
+
+func equalHashes(a, b []common.Uint256) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	accounts := []*account.Account{account.NewAccount(""), account.NewAccount("")}
+	ledger := newTestLedger(t, "test/verify", bookkeeper, accounts)
+	defer ledger.Close()
+	ledger.EnableReverseWriteSetSaving()
+	genesisConfig := config.DefConfig.Genesis
+	defer func() { config.DefConfig.Genesis = genesisConfig }()
+	config.DefConfig.Genesis = &config.GenesisConfig{ConsensusType: config.CONSENSUS_TYPE_SOLO}
+
+	for i := uint64(0); i < 2; i++ {
+		addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 1+i)})
+	}
+	report, err := ledger.Verify(1, 0)
+	assert.Nil(t, err)
+	assert.True(t, report.Good(), report.Reason)
+	assert.Equal(t, uint32(2), report.CurrentHeight)
+	assert.Equal(t, uint32(1), report.ExecStartHeight)
+	assert.Equal(t, uint32(2), report.ExecEndHeight)
+
+	// save the states of block 3 changed by a bad node
+	block := newTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[1], accounts[0].Address, 1)})
+	result, err := ledger.executeBlock(block)
+	assert.Nil(t, err)
+	badKey := append([]byte{byte(scom.ST_STORAGE)}, accounts[0].Address[:]...)
+	result.WriteSet.Put(badKey, []byte{1})
+	result.Hash = common.Uint256{1}
+	result.MerkleRoot = ledger.stateStore.GetStateMerkleRootWithNewHash(result.Hash)
+	assert.Nil(t, ledger.submitBlock(block, nil, result))
+	addTestBlock(t, ledger, bookkeeper, []*types.Transaction{newTransferTx(t, accounts[0], accounts[1].Address, 3)})
+
+	report, err = ledger.Verify(0, 0)
+	assert.Nil(t, err)
+	assert.True(t, report.Good(), report.Reason)
+	report, err = ledger.Verify(2, 0)
+	assert.Nil(t, err)
+	assert.False(t, report.Good())
+	assert.Equal(t, uint32(3), report.BadHeight)
+
+	assert.Nil(t, ledger.Rollback(report.BadHeight-1))
+	has, err := ledger.stateStore.store.Has(badKey)
+	assert.Nil(t, err)
+	assert.False(t, has)
+	report, err = ledger.Verify(1, 0)
+	assert.Nil(t, err)
+	assert.True(t, report.Good(), report.Reason)
+
+	// lose a transaction of block 2
+	hash := ledger.GetBlockHash(2)
+	_, txHashes, err := ledger.blockStore.loadHeaderWithTx(hash)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.blockStore.store.Delete(genTransactionKey(txHashes[0])))
+	report, err = ledger.Verify(0, 0)
+	assert.Nil(t, err)
+	assert.False(t, report.Good())
+	assert.Equal(t, uint32(2), report.BadHeight)
+}
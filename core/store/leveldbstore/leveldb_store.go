@@ -74,6 +74,23 @@ func NewLevelDBStore(file string) (*LevelDBStore, error) {
 	}, nil
 }
 
+//NewReadOnlyLevelDBStore return LevelDBStore instance of an existing store which can not be written
+func NewReadOnlyLevelDBStore(file string) (*LevelDBStore, error) {
+	o := opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+		Filter:         filter.NewBloomFilter(BITSPERKEY),
+	}
+	db, err := leveldb.OpenFile(file, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBStore{
+		db:    db,
+		batch: nil,
+	}, nil
+}
+
 func NewMemLevelDBStore() *LevelDBStore {
 	store := storage.NewMemStorage()
 	// default Options
@@ -157,3 +174,77 @@ func (self *LevelDBStore) NewIterator(prefix []byte) common.StoreIterator {
 
 	return iter
 }
+
+//CompactRange compact the underlying storage of the keys with the prefix, the space of deleted keys is reclaimed
+func (self *LevelDBStore) CompactRange(prefix []byte) error {
+	return self.db.CompactRange(*util.BytesPrefix(prefix))
+}
+
+//NewSnapshot return a read only store of the current data in leveldb, which is not changed by the later writes. It
+//should be closed after use.
+func (self *LevelDBStore) NewSnapshot() (common.PersistStore, error) {
+	snapshot, err := self.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBSnapshot{snapshot: snapshot}, nil
+}
+
+//LevelDBSnapshot is a read only store of leveldb at a point in time
+type LevelDBSnapshot struct {
+	snapshot *leveldb.Snapshot
+}
+
+var errReadOnlySnapshot = errors.New("leveldb snapshot is read only")
+
+//Put is not supported by snapshot
+func (self *LevelDBSnapshot) Put(key []byte, value []byte) error {
+	return errReadOnlySnapshot
+}
+
+//Get the value of a key from snapshot
+func (self *LevelDBSnapshot) Get(key []byte) ([]byte, error) {
+	dat, err := self.snapshot.Get(key, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return dat, nil
+}
+
+//Has return whether the key is exist in snapshot
+func (self *LevelDBSnapshot) Has(key []byte) (bool, error) {
+	return self.snapshot.Has(key, nil)
+}
+
+//Delete is not supported by snapshot
+func (self *LevelDBSnapshot) Delete(key []byte) error {
+	return errReadOnlySnapshot
+}
+
+//NewBatch does nothing since the batch can not be committed to snapshot
+func (self *LevelDBSnapshot) NewBatch() {}
+
+//BatchPut does nothing since the batch can not be committed to snapshot
+func (self *LevelDBSnapshot) BatchPut(key []byte, value []byte) {}
+
+//BatchDelete does nothing since the batch can not be committed to snapshot
+func (self *LevelDBSnapshot) BatchDelete(key []byte) {}
+
+//BatchCommit is not supported by snapshot
+func (self *LevelDBSnapshot) BatchCommit() error {
+	return errReadOnlySnapshot
+}
+
+//Close release the snapshot
+func (self *LevelDBSnapshot) Close() error {
+	self.snapshot.Release()
+	return nil
+}
+
+//NewIterator return a iterator of snapshot with the key prefix
+func (self *LevelDBSnapshot) NewIterator(prefix []byte) common.StoreIterator {
+	return self.snapshot.NewIterator(util.BytesPrefix(prefix), nil)
+}
@@ -21,6 +21,9 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/storetest"
 )
 
 var testLevelDB *LevelDBStore
@@ -140,3 +143,44 @@ func TestIterator(t *testing.T) {
 	}
 
 }
+
+func TestConformance(t *testing.T) {
+	storetest.TestPersistStore(t, func(dir string) (common.PersistStore, error) {
+		return NewLevelDBStore(dir)
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	key := []byte("snapshot")
+	if err := testLevelDB.Put(key, []byte("old")); err != nil {
+		t.Errorf("Put error:%s", err)
+		return
+	}
+	snapshot, err := testLevelDB.NewSnapshot()
+	if err != nil {
+		t.Errorf("NewSnapshot error:%s", err)
+		return
+	}
+	defer snapshot.Close()
+	if err := testLevelDB.Put(key, []byte("new")); err != nil {
+		t.Errorf("Put error:%s", err)
+		return
+	}
+	v, err := snapshot.Get(key)
+	if err != nil {
+		t.Errorf("Get error:%s", err)
+		return
+	}
+	if string(v) != "old" {
+		t.Errorf("Get %s != old", v)
+		return
+	}
+	iter := snapshot.NewIterator(key)
+	if !iter.Next() || string(iter.Value()) != "old" {
+		t.Errorf("Iterator value %s != old", iter.Value())
+	}
+	iter.Release()
+	if err := snapshot.Put(key, []byte("new")); err == nil {
+		t.Errorf("Put to snapshot should fail")
+	}
+}
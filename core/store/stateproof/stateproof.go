@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package stateproof verifies the proofs of contract storage. The storage of all contracts is kept in a merkle patricia
+//trie keyed by the keccak256 hash of contract address and storage key, so the value of a key at a height can be proven
+//with the root of the trie at the height. Since the storage root height, the root is a leaf of the cross states of the
+//block, whose root is signed by the bookkeepers of the next block in the cross chain msg, so the proof is anchored in
+//the header of the next block.
+package stateproof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/merkle"
+)
+
+//StorageProof proves the value of a contract storage key at a height, the fields are in hex
+type StorageProof struct {
+	Contract    string   //contract address
+	Key         string   //storage key
+	Value       string   //stored value, empty if the key does not exist
+	Height      uint32   //block height
+	StorageRoot string   //root hash of storage trie after the block
+	Proof       []string //rlp encoded trie nodes on the path of key from the root
+	RootProof   string   //merkle path of the storage root leaf in the cross states of the block
+	CrossMsg    string   //serialized cross chain msg of the block signed by the bookkeepers of the next block
+}
+
+//TrieKey return the key of contract storage in storage trie
+func TrieKey(contract common.Address, key []byte) []byte {
+	return crypto.Keccak256(contract[:], key)
+}
+
+const rootLeafPrefix = 2
+
+//RootLeaf return the cross state leaf of storage trie root, which is hashed with a prefix other than the one of
+//merkle leaf so it never equals the leaf of a cross chain state
+func RootLeaf(root common2.Hash) common.Uint256 {
+	return sha256.Sum256(append([]byte{rootLeafPrefix}, root[:]...))
+}
+
+//VerifyWithHeader check the proof with the header of next block, which the caller trusts, and return the proven value,
+//nil if the key does not exist
+func (self *StorageProof) VerifyWithHeader(header *types.Header) ([]byte, error) {
+	if header.Height != self.Height+1 {
+		return nil, fmt.Errorf("header height %d is not the next height of proof %d", header.Height, self.Height)
+	}
+	if self.CrossMsg == "" {
+		return nil, fmt.Errorf("storage root of height %d is not signed", self.Height)
+	}
+	data, err := hex.DecodeString(self.CrossMsg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cross chain msg %s: %s", self.CrossMsg, err)
+	}
+	msg := new(types.CrossChainMsg)
+	if err := msg.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("invalid cross chain msg: %s", err)
+	}
+	if msg.Height != self.Height {
+		return nil, fmt.Errorf("cross chain msg height %d is not the height of proof %d", msg.Height, self.Height)
+	}
+	hash := msg.Hash()
+	err = signature.VerifyMultiSignature(hash[:], header.Bookkeepers, len(header.Bookkeepers), msg.SigData)
+	if err != nil {
+		return nil, fmt.Errorf("cross chain msg is not signed by bookkeepers: %s", err)
+	}
+	path, err := hex.DecodeString(self.RootProof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root proof %s: %s", self.RootProof, err)
+	}
+	root, err := hex.DecodeString(self.StorageRoot)
+	if err != nil || len(root) != common2.HashLength {
+		return nil, fmt.Errorf("invalid storage root %s", self.StorageRoot)
+	}
+	if err := merkle.MerkleProveLeafHash(RootLeaf(common2.BytesToHash(root)), path, msg.StatesRoot); err != nil {
+		return nil, fmt.Errorf("invalid root proof: %s", err)
+	}
+	return self.Verify(common2.BytesToHash(root))
+}
+
+//Verify check the proof with the storage trie root of the height which the caller trusts, and return the proven
+//value, nil if the key does not exist
+func (self *StorageProof) Verify(root common2.Hash) ([]byte, error) {
+	if self.StorageRoot != hex.EncodeToString(root[:]) {
+		return nil, fmt.Errorf("storage root %s of proof is not %x", self.StorageRoot, root[:])
+	}
+	contract, err := common.AddressFromHexString(self.Contract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract %s: %s", self.Contract, err)
+	}
+	key, err := hex.DecodeString(self.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %s", self.Key, err)
+	}
+	expected, err := hex.DecodeString(self.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %s: %s", self.Value, err)
+	}
+	nodes := memorydb.New()
+	for _, str := range self.Proof {
+		node, err := hex.DecodeString(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof node %s: %s", str, err)
+		}
+		if err := nodes.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	value, err := trie.VerifyProof(root, TrieKey(contract, key), nodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof: %s", err)
+	}
+	if !bytes.Equal(value, expected) {
+		return nil, fmt.Errorf("proven value %x is not %s", value, self.Value)
+	}
+	return value, nil
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stateproof
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/merkle"
+	"github.com/stretchr/testify/assert"
+)
+
+type proofList []string
+
+func (self *proofList) Put(key []byte, value []byte) error {
+	*self = append(*self, hex.EncodeToString(value))
+	return nil
+}
+
+func (self *proofList) Delete(key []byte) error {
+	return nil
+}
+
+func genProof(t *testing.T, contract common.Address, key []byte) (*StorageProof, common2.Hash) {
+	tr, err := trie.New(common2.Hash{}, trie.NewDatabase(memorydb.New()))
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		k := []byte(fmt.Sprintf("key%d", i))
+		assert.Nil(t, tr.TryUpdate(TrieKey(contract, k), []byte(fmt.Sprintf("value%d", i))))
+	}
+	root := tr.Hash()
+	value, err := tr.TryGet(TrieKey(contract, key))
+	assert.Nil(t, err)
+	var nodes proofList
+	assert.Nil(t, tr.Prove(TrieKey(contract, key), 0, &nodes))
+	return &StorageProof{
+		Contract:    contract.ToHexString(),
+		Key:         hex.EncodeToString(key),
+		Value:       hex.EncodeToString(value),
+		StorageRoot: hex.EncodeToString(root[:]),
+		Proof:       nodes,
+	}, root
+}
+
+func TestVerify(t *testing.T) {
+	contract := common.AddressFromVmCode([]byte("contract"))
+	proof, root := genProof(t, contract, []byte("key1"))
+	value, err := proof.Verify(root)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), value)
+
+	// wrong root
+	_, err = proof.Verify(common2.Hash{1})
+	assert.NotNil(t, err)
+
+	// tampered value
+	proof.Value = hex.EncodeToString([]byte("value2"))
+	_, err = proof.Verify(root)
+	assert.NotNil(t, err)
+
+	// the value is proven for other contract
+	proof.Value = hex.EncodeToString([]byte("value1"))
+	other := common.AddressFromVmCode([]byte("other"))
+	proof.Contract = other.ToHexString()
+	_, err = proof.Verify(root)
+	assert.NotNil(t, err)
+
+	// tampered node
+	proof, root = genProof(t, contract, []byte("key1"))
+	node, _ := hex.DecodeString(proof.Proof[len(proof.Proof)-1])
+	node[len(node)-1] ^= 1
+	proof.Proof[len(proof.Proof)-1] = hex.EncodeToString(node)
+	_, err = proof.Verify(root)
+	assert.NotNil(t, err)
+
+	// absent key
+	proof, root = genProof(t, contract, []byte("unknown"))
+	assert.Equal(t, "", proof.Value)
+	value, err = proof.Verify(root)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	proof.Value = hex.EncodeToString([]byte("value1"))
+	_, err = proof.Verify(root)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyWithHeader(t *testing.T) {
+	contract := common.AddressFromVmCode([]byte("contract"))
+	bookkeeper := account.NewAccount("")
+	genAnchoredProof := func(leafOf func(root common2.Hash) common.Uint256) (*StorageProof, *types.Header) {
+		proof, root := genProof(t, contract, []byte("key1"))
+		proof.Height = 1
+		leaf := leafOf(root)
+		hashes := []common.Uint256{merkle.HashLeaf([]byte("cross chain state")), leaf}
+		path, err := merkle.MerkleLeafHashPath(leaf, hashes)
+		assert.Nil(t, err)
+		proof.RootProof = hex.EncodeToString(path)
+		msg := &types.CrossChainMsg{
+			Version:    types.CURR_CROSS_STATES_VERSION,
+			Height:     1,
+			StatesRoot: merkle.TreeHasher{}.HashFullTreeWithLeafHash(hashes),
+		}
+		hash := msg.Hash()
+		sig, err := signature.Sign(bookkeeper, hash[:])
+		assert.Nil(t, err)
+		msg.SigData = [][]byte{sig}
+		proof.CrossMsg = hex.EncodeToString(common.SerializeToBytes(msg))
+		return proof, &types.Header{Height: 2, Bookkeepers: []keypair.PublicKey{bookkeeper.PublicKey}}
+	}
+
+	proof, header := genAnchoredProof(RootLeaf)
+	value, err := proof.VerifyWithHeader(header)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), value)
+
+	// the header is not the next one
+	header.Height = 3
+	_, err = proof.VerifyWithHeader(header)
+	assert.NotNil(t, err)
+
+	// the msg is not signed by the bookkeepers of header
+	proof, header = genAnchoredProof(RootLeaf)
+	header.Bookkeepers = []keypair.PublicKey{account.NewAccount("").PublicKey}
+	_, err = proof.VerifyWithHeader(header)
+	assert.NotNil(t, err)
+
+	// the storage root is not in the signed cross states
+	proof, header = genAnchoredProof(RootLeaf)
+	other, _ := genProof(t, common.AddressFromVmCode([]byte("other")), []byte("key1"))
+	proof.StorageRoot = other.StorageRoot
+	_, err = proof.VerifyWithHeader(header)
+	assert.NotNil(t, err)
+
+	// a cross chain state with the data of storage root can not be used as storage root
+	proof, header = genAnchoredProof(func(root common2.Hash) common.Uint256 { return merkle.HashLeaf(root[:]) })
+	_, err = proof.VerifyWithHeader(header)
+	assert.NotNil(t, err)
+
+	// the storage root is not signed yet
+	proof, header = genAnchoredProof(RootLeaf)
+	proof.CrossMsg = ""
+	_, err = proof.VerifyWithHeader(header)
+	assert.NotNil(t, err)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/store/stateproof"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
 	types3 "github.com/ontio/ontology/smartcontract/service/evm/types"
@@ -40,6 +41,33 @@ type ExecuteResult struct {
 	CrossStates     []common.Uint256
 	CrossStatesRoot common.Uint256
 	Notify          []*event.ExecuteNotify
+	//notifies of the scheduled calls executed before the transactions of block, in execution order
+	ScheduledNotify []*event.ExecuteNotify
+}
+
+//StorageChange is a storage key of contract whose value is changed between two heights, the values are in hex and
+//empty if the key does not exist
+type StorageChange struct {
+	Key        string //key without the contract address
+	KeyName    string `json:",omitempty"` //readable key of native contract
+	OldValue   string
+	NewValue   string
+	OldDecoded string `json:",omitempty"` //readable old value of native contract
+	NewDecoded string `json:",omitempty"` //readable new value of native contract
+}
+
+//StorageDiff is the storage changes of contract made by the blocks in (FromHeight, ToHeight]
+type StorageDiff struct {
+	Contract   string
+	FromHeight uint32
+	ToHeight   uint32
+	Changes    []*StorageChange
+}
+
+//StateItem is a raw key-value pair of contract states
+type StateItem struct {
+	Key   []byte
+	Value []byte
 }
 
 // LedgerStore provides func with store package.
@@ -69,8 +97,13 @@ type LedgerStore interface {
 	GetContractState(contractHash common.Address) (*payload.DeployCode, error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(codeHash common.Address, key []byte) ([]byte, error)
+	GetStorageDiff(contract common.Address, fromHeight, toHeight uint32) (*StorageDiff, error)
+	GetStorageProof(contract common.Address, key []byte, height uint32) (*stateproof.StorageProof, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
 	PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*cstates.PreExecResult, uint32, error)
+	PreExecuteContractAtHeight(txes []*types.Transaction, height uint32) ([]*cstates.PreExecResult, error)
+	GetStateAtHeight(key []byte, height uint32) ([]byte, error)
+	FindStatesAtHeight(prefix []byte, height uint32) ([]*StateItem, error)
 	PreExecuteEip155Tx(msg types2.Message) (*types3.ExecutionResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
@@ -82,6 +115,11 @@ type LedgerStore interface {
 	GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error)
 	GetCrossStatesProof(height uint32, key []byte) ([]byte, error)
 	EnableBlockPrune(numBeforeCurr uint32)
+	EnableStateGC()
+	EnableWriteSetSaving()
+	EnableReverseWriteSetSaving()
+	EnableStorageProof() error
+	GetDiskUsage() (map[string]uint64, error)
 	//expose the cache db
 	GetCacheDB() *storage.CacheDB
 }
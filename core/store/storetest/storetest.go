@@ -0,0 +1,350 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package storetest is the conformance test suite every PersistStore backend must pass
+package storetest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/core/store/common"
+)
+
+//OpenFunc open or create the store in dir
+type OpenFunc func(dir string) (common.PersistStore, error)
+
+//TestPersistStore run the conformance tests against the stores opened by open
+func TestPersistStore(t *testing.T, open OpenFunc) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store common.PersistStore)
+	}{
+		{"PutGetDelete", testPutGetDelete},
+		{"EmptyValue", testEmptyValue},
+		{"Batch", testBatch},
+		{"BatchOrder", testBatchOrder},
+		{"PrefixIterator", testPrefixIterator},
+		{"IteratorFirst", testIteratorFirst},
+		{"LargeIterator", testLargeIterator},
+		{"DeleteWhileIterating", testDeleteWhileIterating},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+			store, err := open(dir)
+			if err != nil {
+				t.Fatalf("open error:%s", err)
+			}
+			defer store.Close()
+			test.test(t, store)
+		})
+	}
+	t.Run("Reopen", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		testReopen(t, dir, open)
+	})
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "storetest")
+	if err != nil {
+		t.Fatalf("TempDir error:%s", err)
+	}
+	return dir
+}
+
+func mustGet(t *testing.T, store common.PersistStore, key string, value string) {
+	t.Helper()
+	v, err := store.Get([]byte(key))
+	if err != nil {
+		t.Fatalf("Get %s error:%s", key, err)
+	}
+	if string(v) != value {
+		t.Fatalf("Get %s: %q != %q", key, v, value)
+	}
+	has, err := store.Has([]byte(key))
+	if err != nil || !has {
+		t.Fatalf("Has %s: %v, %v", key, has, err)
+	}
+}
+
+func mustNotFound(t *testing.T, store common.PersistStore, key string) {
+	t.Helper()
+	_, err := store.Get([]byte(key))
+	if err != common.ErrNotFound {
+		t.Fatalf("Get %s: error %v is not ErrNotFound", key, err)
+	}
+	has, err := store.Has([]byte(key))
+	if err != nil || has {
+		t.Fatalf("Has %s: %v, %v", key, has, err)
+	}
+}
+
+func collect(t *testing.T, iter common.StoreIterator) []string {
+	t.Helper()
+	var pairs []string
+	for iter.Next() {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", iter.Key(), iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatalf("iterator error:%s", err)
+	}
+	return pairs
+}
+
+func mustPairs(t *testing.T, pairs []string, expected ...string) {
+	t.Helper()
+	if fmt.Sprint(pairs) != fmt.Sprint(expected) {
+		t.Fatalf("iterate %v, expected %v", pairs, expected)
+	}
+}
+
+func testPutGetDelete(t *testing.T, store common.PersistStore) {
+	mustNotFound(t, store, "foo")
+	if err := store.Put([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatalf("Put error:%s", err)
+	}
+	mustGet(t, store, "foo", "bar")
+	if err := store.Put([]byte("foo"), []byte("baz")); err != nil {
+		t.Fatalf("Put error:%s", err)
+	}
+	mustGet(t, store, "foo", "baz")
+
+	// the store must not keep the buffers of caller
+	key, value := []byte("key"), []byte("value")
+	if err := store.Put(key, value); err != nil {
+		t.Fatalf("Put error:%s", err)
+	}
+	copy(key, "xxx")
+	copy(value, "xxxxx")
+	mustGet(t, store, "key", "value")
+	v, _ := store.Get([]byte("key"))
+	copy(v, "xxxxx")
+	mustGet(t, store, "key", "value")
+
+	if err := store.Delete([]byte("foo")); err != nil {
+		t.Fatalf("Delete error:%s", err)
+	}
+	mustNotFound(t, store, "foo")
+	if err := store.Delete([]byte("foo")); err != nil {
+		t.Fatalf("Delete missing key error:%s", err)
+	}
+}
+
+func testEmptyValue(t *testing.T, store common.PersistStore) {
+	if err := store.Put([]byte("empty"), []byte{}); err != nil {
+		t.Fatalf("Put error:%s", err)
+	}
+	mustGet(t, store, "empty", "")
+	store.NewBatch()
+	store.BatchPut([]byte("empty2"), nil)
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	mustGet(t, store, "empty2", "")
+	mustPairs(t, collect(t, store.NewIterator([]byte("empty"))), "empty=", "empty2=")
+}
+
+func testBatch(t *testing.T, store common.PersistStore) {
+	if err := store.Put([]byte("foo1"), []byte("bar1")); err != nil {
+		t.Fatalf("Put error:%s", err)
+	}
+	store.NewBatch()
+	key := []byte("foo2")
+	store.BatchPut(key, []byte("bar2"))
+	copy(key, "xxxx")
+	store.BatchPut([]byte("foo3"), []byte("bar3"))
+	store.BatchDelete([]byte("foo1"))
+	mustNotFound(t, store, "foo2")
+	mustGet(t, store, "foo1", "bar1")
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	mustGet(t, store, "foo2", "bar2")
+	mustGet(t, store, "foo3", "bar3")
+	mustNotFound(t, store, "foo1")
+
+	// a new batch discards the uncommitted one
+	store.NewBatch()
+	store.BatchPut([]byte("foo4"), []byte("bar4"))
+	store.NewBatch()
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	mustNotFound(t, store, "foo4")
+}
+
+func testBatchOrder(t *testing.T, store common.PersistStore) {
+	store.NewBatch()
+	store.BatchPut([]byte("a"), []byte("1"))
+	store.BatchDelete([]byte("a"))
+	store.BatchDelete([]byte("b"))
+	store.BatchPut([]byte("b"), []byte("2"))
+	store.BatchPut([]byte("c"), []byte("3"))
+	store.BatchPut([]byte("c"), []byte("4"))
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	mustNotFound(t, store, "a")
+	mustGet(t, store, "b", "2")
+	mustGet(t, store, "c", "4")
+}
+
+func testPrefixIterator(t *testing.T, store common.PersistStore) {
+	keys := [][]byte{{0x01}, {0x01, 0x00}, {0x01, 0xff}, {0x02, 0x01}, {0x02}, {0x01, 0xff, 0xff}, {0xff, 0x01}, {0xff}}
+	store.NewBatch()
+	for _, key := range keys {
+		store.BatchPut(key, key)
+	}
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	check := func(prefix []byte, expected ...[]byte) {
+		t.Helper()
+		iter := store.NewIterator(prefix)
+		defer iter.Release()
+		var count int
+		for iter.Next() {
+			if count >= len(expected) {
+				t.Fatalf("prefix %x: unexpected key %x", prefix, iter.Key())
+			}
+			if !bytes.Equal(iter.Key(), expected[count]) || !bytes.Equal(iter.Value(), expected[count]) {
+				t.Fatalf("prefix %x: %x=%x, expected %x", prefix, iter.Key(), iter.Value(), expected[count])
+			}
+			count++
+		}
+		if iter.Error() != nil {
+			t.Fatalf("iterator error:%s", iter.Error())
+		}
+		if count != len(expected) {
+			t.Fatalf("prefix %x: iterate %d keys, expected %d", prefix, count, len(expected))
+		}
+	}
+	check([]byte{0x01}, []byte{0x01}, []byte{0x01, 0x00}, []byte{0x01, 0xff}, []byte{0x01, 0xff, 0xff})
+	check([]byte{0x01, 0xff}, []byte{0x01, 0xff}, []byte{0x01, 0xff, 0xff})
+	check([]byte{0x02}, []byte{0x02}, []byte{0x02, 0x01})
+	check([]byte{0xff}, []byte{0xff}, []byte{0xff, 0x01})
+	check([]byte{0x03})
+	check(nil, []byte{0x01}, []byte{0x01, 0x00}, []byte{0x01, 0xff}, []byte{0x01, 0xff, 0xff}, []byte{0x02},
+		[]byte{0x02, 0x01}, []byte{0xff}, []byte{0xff, 0x01})
+}
+
+func testIteratorFirst(t *testing.T, store common.PersistStore) {
+	for _, key := range []string{"k1", "k2", "k3"} {
+		if err := store.Put([]byte(key), []byte("v")); err != nil {
+			t.Fatalf("Put error:%s", err)
+		}
+	}
+	iter := store.NewIterator([]byte("k"))
+	defer iter.Release()
+	if !iter.First() || string(iter.Key()) != "k1" {
+		t.Fatalf("First: %s", iter.Key())
+	}
+	if !iter.Next() || string(iter.Key()) != "k2" {
+		t.Fatalf("Next: %s", iter.Key())
+	}
+	if !iter.First() || string(iter.Key()) != "k1" {
+		t.Fatalf("First again: %s", iter.Key())
+	}
+	empty := store.NewIterator([]byte("x"))
+	defer empty.Release()
+	if empty.First() {
+		t.Fatalf("First of empty iterator: %s", empty.Key())
+	}
+}
+
+func testLargeIterator(t *testing.T, store common.PersistStore) {
+	const count = 5000
+	store.NewBatch()
+	for i := 0; i < count; i++ {
+		store.BatchPut([]byte(fmt.Sprintf("large%08d", i)), []byte(fmt.Sprintf("%d", i)))
+	}
+	store.BatchPut([]byte("largf"), []byte("out of prefix"))
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	iter := store.NewIterator([]byte("large"))
+	defer iter.Release()
+	i := 0
+	for ; iter.Next(); i++ {
+		if string(iter.Key()) != fmt.Sprintf("large%08d", i) || string(iter.Value()) != fmt.Sprintf("%d", i) {
+			t.Fatalf("iterate %s=%s at %d", iter.Key(), iter.Value(), i)
+		}
+	}
+	if i != count {
+		t.Fatalf("iterate %d keys, expected %d", i, count)
+	}
+}
+
+func testDeleteWhileIterating(t *testing.T, store common.PersistStore) {
+	const count = 3000
+	store.NewBatch()
+	for i := 0; i < count; i++ {
+		store.BatchPut([]byte(fmt.Sprintf("del%08d", i)), []byte("v"))
+	}
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	iter := store.NewIterator([]byte("del"))
+	i := 0
+	for ; iter.Next(); i++ {
+		if string(iter.Key()) != fmt.Sprintf("del%08d", i) {
+			t.Fatalf("iterate %s at %d", iter.Key(), i)
+		}
+		if err := store.Delete(iter.Key()); err != nil {
+			t.Fatalf("Delete error:%s", err)
+		}
+	}
+	iter.Release()
+	if i != count {
+		t.Fatalf("iterate %d keys, expected %d", i, count)
+	}
+	mustPairs(t, collect(t, store.NewIterator([]byte("del"))))
+}
+
+func testReopen(t *testing.T, dir string, open OpenFunc) {
+	store, err := open(dir)
+	if err != nil {
+		t.Fatalf("open error:%s", err)
+	}
+	store.NewBatch()
+	store.BatchPut([]byte("persist1"), []byte("v1"))
+	store.BatchPut([]byte("persist2"), []byte("v2"))
+	if err := store.BatchCommit(); err != nil {
+		t.Fatalf("BatchCommit error:%s", err)
+	}
+	if err := store.Put([]byte("persist3"), []byte("v3")); err != nil {
+		t.Fatalf("Put error:%s", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close error:%s", err)
+	}
+	store, err = open(dir)
+	if err != nil {
+		t.Fatalf("reopen error:%s", err)
+	}
+	defer store.Close()
+	mustPairs(t, collect(t, store.NewIterator([]byte("persist"))), "persist1=v1", "persist2=v2", "persist3=v3")
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"sync"
+
+	"github.com/ontio/ontology/common"
+)
+
+//impersonated payers are treated as signed without signature, only used in test mode
+var impersonated sync.Map
+
+//Impersonate make transactions paid by addr pass the signature check
+func Impersonate(addr common.Address) {
+	impersonated.Store(addr, true)
+}
+
+//StopImpersonating remove addr from impersonated payers
+func StopImpersonating(addr common.Address) {
+	impersonated.Delete(addr)
+}
+
+//IsImpersonated return whether addr is an impersonated payer
+func IsImpersonated(addr common.Address) bool {
+	_, ok := impersonated.Load(addr)
+	return ok
+}
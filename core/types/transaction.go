@@ -441,6 +441,9 @@ func (self *Transaction) GetSignatureAddresses() []common.Address {
 		for _, prog := range self.Sigs {
 			addrs = append(addrs, common.AddressFromVmCode(prog.Verify))
 		}
+		if IsImpersonated(self.Payer) {
+			addrs = append(addrs, self.Payer)
+		}
 		self.SignedAddr = addrs
 	}
 	return self.SignedAddr
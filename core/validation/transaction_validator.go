@@ -102,6 +102,9 @@ func checkTransactionSignatures(tx *types.Transaction) error {
 		}
 	}
 
+	if types.IsImpersonated(tx.Payer) {
+		address[tx.Payer] = true
+	}
 	// check payer in address
 	if !address[tx.Payer] {
 		return errors.New("signature missing for payer: " + tx.Payer.ToBase58())
@@ -19,10 +19,15 @@
 package actor
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ontio/ontology-eventbus/actor"
 	cactor "github.com/ontio/ontology/consensus/actor"
 )
 
+const DEV_MODE_REQ_TIMEOUT = 60 * time.Second
+
 var consensusSrvPid *actor.PID
 
 func SetConsensusPid(actr *actor.PID) {
@@ -44,3 +49,28 @@ func ConsensusSrvHalt() error {
 	}
 	return nil
 }
+
+//mine blocks immediately, only supported by solo consensus
+func ConsensusSrvMineBlocks(count uint32) (*cactor.DevModeRsp, error) {
+	return consensusDevModeRequest(&cactor.MineBlocks{Count: count})
+}
+
+//advance the timestamp of following blocks, only supported by solo consensus
+func ConsensusSrvAdvanceTime(seconds uint32) (*cactor.DevModeRsp, error) {
+	return consensusDevModeRequest(&cactor.AdvanceTime{Seconds: seconds})
+}
+
+func consensusDevModeRequest(msg interface{}) (*cactor.DevModeRsp, error) {
+	if consensusSrvPid == nil {
+		return nil, errors.New("consensus service is not started")
+	}
+	result, err := consensusSrvPid.RequestFuture(msg, DEV_MODE_REQ_TIMEOUT).Result()
+	if err != nil {
+		return nil, err
+	}
+	rsp, ok := result.(*cactor.DevModeRsp)
+	if !ok {
+		return nil, errors.New("dev mode is not supported by current consensus")
+	}
+	return rsp, rsp.Error
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/stateproof"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
 	types3 "github.com/ontio/ontology/smartcontract/service/evm/types"
@@ -77,6 +79,16 @@ func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
+//GetStorageDiff from ledger
+func GetStorageDiff(contract common.Address, fromHeight, toHeight uint32) (*store.StorageDiff, error) {
+	return ledger.DefLedger.GetStorageDiff(contract, fromHeight, toHeight)
+}
+
+//GetStorageProof from ledger
+func GetStorageProof(contract common.Address, key []byte, height uint32) (*stateproof.StorageProof, error) {
+	return ledger.DefLedger.GetStorageProof(contract, key, height)
+}
+
 //GetContractStateFromStore from ledger
 func GetContractStateFromStore(hash common.Address) (*payload.DeployCode, error) {
 	hash = updateNativeSCAddr(hash)
@@ -98,6 +110,21 @@ func PreExecuteContractBatch(tx []*types.Transaction, atomic bool) ([]*cstate.Pr
 	return ledger.DefLedger.PreExecuteContractBatch(tx, atomic)
 }
 
+//PreExecuteContractAtHeight from ledger
+func PreExecuteContractAtHeight(txes []*types.Transaction, height uint32) ([]*cstate.PreExecResult, error) {
+	return ledger.DefLedger.PreExecuteContractAtHeight(txes, height)
+}
+
+//GetStateAtHeight from ledger
+func GetStateAtHeight(key []byte, height uint32) ([]byte, error) {
+	return ledger.DefLedger.GetStateAtHeight(key, height)
+}
+
+//FindStatesAtHeight from ledger
+func FindStatesAtHeight(prefix []byte, height uint32) ([]*store.StateItem, error) {
+	return ledger.DefLedger.FindStatesAtHeight(prefix, height)
+}
+
 //GetEventNotifyByTxHash from ledger
 func GetEventNotifyByTxHash(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByTx(txHash)
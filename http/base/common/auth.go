@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/auth"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+type RoleFuncsRsp struct {
+	Role      string
+	FuncNames []string
+}
+
+type ContractRoleFuncsRsp struct {
+	Contract string
+	Admin    string
+	Roles    []RoleFuncsRsp
+}
+
+type OntIDTokenRsp struct {
+	Role       string
+	ExpireTime uint32
+	Level      uint8
+}
+
+type OntIDTokensRsp struct {
+	OntID  string
+	Tokens []OntIDTokenRsp
+}
+
+type ContractOntIDTokensRsp struct {
+	Contract string
+	OntIDs   []OntIDTokensRsp
+}
+
+//GetRoleFuncs return the admin ONT ID of contract and the functions assigned to each role in auth contract
+func GetRoleFuncs(contract common.Address) (*ContractRoleFuncsRsp, error) {
+	data, err := preExecuteNative(utils.AuthContractAddress, auth.GET_ROLE_FUNCS, contract[:])
+	if err != nil {
+		return nil, err
+	}
+	roles := new(auth.ContractRoleFuncs)
+	if err := roles.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, err
+	}
+	rsp := &ContractRoleFuncsRsp{
+		Contract: roles.ContractAddr.ToHexString(),
+		Admin:    string(roles.AdminOntID),
+		Roles:    make([]RoleFuncsRsp, 0, len(roles.Roles)),
+	}
+	for _, role := range roles.Roles {
+		rsp.Roles = append(rsp.Roles, RoleFuncsRsp{Role: string(role.Role), FuncNames: role.FuncNames})
+	}
+	return rsp, nil
+}
+
+//GetOntIDTokens return the roles held by each ONT ID of contract in auth contract, with the expire time and
+//delegation level
+func GetOntIDTokens(contract common.Address) (*ContractOntIDTokensRsp, error) {
+	data, err := preExecuteNative(utils.AuthContractAddress, auth.GET_ONTID_TOKENS, contract[:])
+	if err != nil {
+		return nil, err
+	}
+	tokens := new(auth.ContractOntIDTokens)
+	if err := tokens.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, err
+	}
+	rsp := &ContractOntIDTokensRsp{
+		Contract: tokens.ContractAddr.ToHexString(),
+		OntIDs:   make([]OntIDTokensRsp, 0, len(tokens.OntIDs)),
+	}
+	for _, ontID := range tokens.OntIDs {
+		item := OntIDTokensRsp{OntID: string(ontID.OntID), Tokens: make([]OntIDTokenRsp, 0, len(ontID.Tokens))}
+		for _, token := range ontID.Tokens {
+			item.Tokens = append(item.Tokens, OntIDTokenRsp{
+				Role:       string(token.Role),
+				ExpireTime: token.ExpireTime,
+				Level:      token.Level,
+			})
+		}
+		rsp.OntIDs = append(rsp.OntIDs, item)
+	}
+	return rsp, nil
+}
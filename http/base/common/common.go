@@ -119,6 +119,11 @@ type Sig struct {
 	SigData []string
 }
 
+type StateItem struct {
+	Key   string
+	Value string
+}
+
 type CrossStatesProof struct {
 	Type      string
 	AuditPath string
@@ -486,6 +491,26 @@ func GetContractAllowance(cVersion byte, contractAddr, fromAddr, toAddr common.A
 	return allowance.Uint64(), nil
 }
 
+//preExecuteNative pre-execute the read only method of native contract and return the result bytes
+func preExecuteNative(contract common.Address, method string, param interface{}) ([]byte, error) {
+	mutable, err := NewNativeInvokeTransaction(0, 0, contract, 0, method, []interface{}{param})
+	if err != nil {
+		return nil, fmt.Errorf("NewNativeInvokeTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bactor.PreExecuteContract(tx)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareInvokeContract error:%s", err)
+	}
+	if result.State == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	return hex.DecodeString(result.Result.(string))
+}
+
 func GetGasPrice() (gasPrice uint64, height uint32, err error) {
 	start := bactor.GetCurrentBlockHeight()
 	var end uint32 = 0
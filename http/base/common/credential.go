@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package common
+
+import (
+	"time"
+
+	"github.com/ontio/ontology/core/credential"
+	"github.com/ontio/ontology/core/types"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	cstate "github.com/ontio/ontology/smartcontract/states"
+)
+
+//credentialLedger pre-executes the invocations of credential verification with the ledger of node
+type credentialLedger struct{}
+
+func (this credentialLedger) PreExecute(txes []*types.Transaction) ([]*cstate.PreExecResult, error) {
+	results, _, err := bactor.PreExecuteContractBatch(txes, true)
+	return results, err
+}
+
+//VerifyCredential verify the JWT or JSON-LD credential issued by ONT ID against the states of ledger
+func VerifyCredential(data string) (*credential.Result, error) {
+	return credential.NewVerifier(credentialLedger{}).Verify(data, time.Now())
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/core/types"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	"github.com/ontio/ontology/smartcontract/service/native/ontid"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	cstate "github.com/ontio/ontology/smartcontract/states"
+)
+
+const (
+	DID_RESOLUTION_CONTEXT      = "https://w3id.org/did-resolution/v1"
+	DID_DOCUMENT_CONTENT_TYPE   = "application/did+ld+json"
+	DID_RESOLUTION_CONTENT_TYPE = `application/ld+json;profile="https://w3id.org/did-resolution"`
+)
+
+//the errors of DID resolution defined by W3C DID Resolution
+const (
+	DID_ERR_INVALID_DID                  = "invalidDid"
+	DID_ERR_NOT_FOUND                    = "notFound"
+	DID_ERR_METHOD_NOT_SUPPORTED         = "methodNotSupported"
+	DID_ERR_REPRESENTATION_NOT_SUPPORTED = "representationNotSupported"
+	DID_ERR_INVALID_OPTIONS              = "invalidOptions"
+	DID_ERR_INTERNAL                     = "internalError"
+)
+
+type DIDResolutionMetadata struct {
+	ContentType  string `json:"contentType,omitempty"`
+	Retrieved    string `json:"retrieved"`
+	Error        string `json:"error,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+type DIDDocumentMetadata struct {
+	Created     string `json:"created,omitempty"`
+	Updated     string `json:"updated,omitempty"`
+	Deactivated bool   `json:"deactivated,omitempty"`
+	BlockHeight uint32 `json:"blockHeight,omitempty"` //height of the states the document is resolved from
+}
+
+//DIDResolutionResult is the result of resolving DID, DIDDocument is null if the DID is not resolved or deactivated
+type DIDResolutionResult struct {
+	Context               string                 `json:"@context"`
+	DIDDocument           json.RawMessage        `json:"didDocument"`
+	DIDResolutionMetadata *DIDResolutionMetadata `json:"didResolutionMetadata"`
+	DIDDocumentMetadata   *DIDDocumentMetadata   `json:"didDocumentMetadata"`
+}
+
+//ResolveDID resolve the ONT ID to its document by the states of current block, or by the states of the last block not
+//later than versionTime in RFC3339 format if it is not empty, which are only available if the reverse write sets of
+//the later blocks are not pruned
+func ResolveDID(did string, versionTime string) *DIDResolutionResult {
+	result := &DIDResolutionResult{
+		Context:               DID_RESOLUTION_CONTEXT,
+		DIDResolutionMetadata: &DIDResolutionMetadata{Retrieved: time.Now().UTC().Format(time.RFC3339)},
+		DIDDocumentMetadata:   &DIDDocumentMetadata{},
+	}
+	fail := func(code string, format string, args ...interface{}) *DIDResolutionResult {
+		result.DIDResolutionMetadata.Error = code
+		result.DIDResolutionMetadata.ErrorMessage = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	if !strings.HasPrefix(did, "did:") {
+		return fail(DID_ERR_INVALID_DID, "%s is not a DID", did)
+	}
+	if !strings.HasPrefix(did, "did:ont:") {
+		return fail(DID_ERR_METHOD_NOT_SUPPORTED, "only did:ont method is supported")
+	}
+	if !account.VerifyID(did) {
+		return fail(DID_ERR_INVALID_DID, "%s is not a valid ONT ID", did)
+	}
+
+	txes := make([]*types.Transaction, 0, 2)
+	for _, method := range []string{"getIDState", "getDocumentJson"} {
+		mutable, err := NewNativeInvokeTransaction(0, 0, utils.OntIDContractAddress, 0, method, []interface{}{[]byte(did)})
+		if err != nil {
+			return fail(DID_ERR_INTERNAL, "NewNativeInvokeTransaction error:%s", err)
+		}
+		tx, err := mutable.IntoImmutable()
+		if err != nil {
+			return fail(DID_ERR_INTERNAL, "IntoImmutable error:%s", err)
+		}
+		txes = append(txes, tx)
+	}
+	var data [][]byte
+	var height uint32
+	if versionTime == "" {
+		results, currentHeight, err := bactor.PreExecuteContractBatch(txes, true)
+		if err != nil {
+			return fail(DID_ERR_INTERNAL, "PreExecuteContractBatch error:%s", err)
+		}
+		if data, err = preExecResultBytes(results); err != nil {
+			return fail(DID_ERR_INTERNAL, "%s", err)
+		}
+		height = currentHeight
+	} else {
+		t, err := time.Parse(time.RFC3339, versionTime)
+		if err != nil {
+			return fail(DID_ERR_INVALID_OPTIONS, "invalid versionTime %s", versionTime)
+		}
+		var found bool
+		height, found, err = getHeightByTime(t)
+		if err != nil {
+			return fail(DID_ERR_INTERNAL, "%s", err)
+		}
+		if !found {
+			return fail(DID_ERR_NOT_FOUND, "versionTime %s is earlier than genesis block", versionTime)
+		}
+		results, err := bactor.PreExecuteContractAtHeight(txes, height)
+		if err != nil {
+			return fail(DID_ERR_NOT_FOUND, "document at versionTime %s is not available: %s", versionTime, err)
+		}
+		if data, err = preExecResultBytes(results); err != nil {
+			return fail(DID_ERR_INTERNAL, "%s", err)
+		}
+	}
+	result.DIDDocumentMetadata.BlockHeight = height
+
+	switch string(data[0]) {
+	case ontid.ID_STATE_VALID:
+	case ontid.ID_STATE_REVOKED:
+		result.DIDDocumentMetadata.Deactivated = true
+		return result
+	default:
+		return fail(DID_ERR_NOT_FOUND, "%s is not registered", did)
+	}
+	document := new(ontid.Document)
+	if err := json.Unmarshal(data[1], document); err != nil {
+		return fail(DID_ERR_INTERNAL, "invalid document:%s", err)
+	}
+	result.DIDDocument = data[1]
+	result.DIDResolutionMetadata.ContentType = DID_DOCUMENT_CONTENT_TYPE
+	if document.Created != 0 {
+		result.DIDDocumentMetadata.Created = time.Unix(int64(document.Created), 0).UTC().Format(time.RFC3339)
+	}
+	if document.Updated != 0 {
+		result.DIDDocumentMetadata.Updated = time.Unix(int64(document.Updated), 0).UTC().Format(time.RFC3339)
+	}
+	return result
+}
+
+func preExecResultBytes(results []*cstate.PreExecResult) ([][]byte, error) {
+	data := make([][]byte, 0, len(results))
+	for _, result := range results {
+		if result.State == 0 {
+			return nil, fmt.Errorf("prepare invoke failed")
+		}
+		value, err := hex.DecodeString(result.Result.(string))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, value)
+	}
+	return data, nil
+}
+
+//getHeightByTime return the height of the last block whose timestamp is not later than t
+func getHeightByTime(t time.Time) (uint32, bool, error) {
+	var err error
+	timestamp := t.Unix()
+	current := bactor.GetCurrentBlockHeight()
+	n := sort.Search(int(current)+1, func(i int) bool {
+		if err != nil {
+			return true
+		}
+		header, e := bactor.GetHeaderByHeight(uint32(i))
+		if e != nil {
+			err = fmt.Errorf("GetHeaderByHeight height:%d error:%s", i, e)
+			return true
+		}
+		return int64(header.Timestamp) > timestamp
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if n == 0 {
+		return 0, false, nil
+	}
+	return uint32(n - 1), true, nil
+}
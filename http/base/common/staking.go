@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+type AuthorizeInfoRsp struct {
+	PeerPubkey           string
+	ConsensusPos         uint64
+	CandidatePos         uint64
+	NewPos               uint64
+	WithdrawConsensusPos uint64
+	WithdrawCandidatePos uint64
+	WithdrawUnfreezePos  uint64
+}
+
+type PendingUnlockRsp struct {
+	View uint32
+	Pos  uint64
+}
+
+type StakingInfoRsp struct {
+	Address      string
+	View         uint32
+	TotalStake   uint64
+	ClaimablePos uint64
+	ClaimableFee uint64
+	Authorizes   []AuthorizeInfoRsp
+	Unlocks      []PendingUnlockRsp
+}
+
+type RewardsEstimateRsp struct {
+	Address     string
+	PeerPubkey  string
+	View        uint32
+	PeerReward  int64
+	TotalReward uint64
+}
+
+//GetStakingInfo return the pos of address authorized to each peer, the pending unlocks by view and the claimable
+//pos and fee
+func GetStakingInfo(addr common.Address) (*StakingInfoRsp, error) {
+	data, err := preExecuteNative(utils.GovernanceContractAddress, governance.GET_STAKING_INFO, addr[:])
+	if err != nil {
+		return nil, err
+	}
+	info := new(governance.StakingInfo)
+	if err := info.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, err
+	}
+	rsp := &StakingInfoRsp{
+		Address:      info.Address.ToBase58(),
+		View:         info.View,
+		TotalStake:   info.TotalStake,
+		ClaimablePos: info.ClaimablePos,
+		ClaimableFee: info.ClaimableFee,
+		Authorizes:   make([]AuthorizeInfoRsp, 0, len(info.Authorizes)),
+		Unlocks:      make([]PendingUnlockRsp, 0, len(info.Unlocks)),
+	}
+	for _, authorize := range info.Authorizes {
+		rsp.Authorizes = append(rsp.Authorizes, AuthorizeInfoRsp{
+			PeerPubkey:           authorize.PeerPubkey,
+			ConsensusPos:         authorize.ConsensusPos,
+			CandidatePos:         authorize.CandidatePos,
+			NewPos:               authorize.NewPos,
+			WithdrawConsensusPos: authorize.WithdrawConsensusPos,
+			WithdrawCandidatePos: authorize.WithdrawCandidatePos,
+			WithdrawUnfreezePos:  authorize.WithdrawUnfreezePos,
+		})
+	}
+	for _, unlock := range info.Unlocks {
+		rsp.Unlocks = append(rsp.Unlocks, PendingUnlockRsp{View: unlock.View, Pos: unlock.Pos})
+	}
+	return rsp, nil
+}
+
+//EstimateRewards return the ong split to address from the peer and from all the peers if current governance view
+//is committed now
+func EstimateRewards(addr common.Address, peerPubkey string) (*RewardsEstimateRsp, error) {
+	data, err := preExecuteNative(utils.GovernanceContractAddress, governance.ESTIMATE_REWARDS, &governance.EstimateRewardsParam{
+		Address:    addr,
+		PeerPubkey: peerPubkey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	estimate := new(governance.RewardsEstimate)
+	if err := estimate.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, err
+	}
+	return &RewardsEstimateRsp{
+		Address:     estimate.Address.ToBase58(),
+		PeerPubkey:  estimate.PeerPubkey,
+		View:        estimate.View,
+		PeerReward:  estimate.PeerReward,
+		TotalReward: estimate.TotalReward,
+	}, nil
+}
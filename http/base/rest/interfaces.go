@@ -409,6 +409,80 @@ func GetStorage(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//get storage changes of contract between two heights
+func GetStorageDiff(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	param, ok := cmd["From"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	fromHeight, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	param, ok = cmd["To"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	toHeight, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	diff, err := bactor.GetStorageDiff(address, uint32(fromHeight), uint32(toHeight))
+	if err != nil {
+		resp = ResponsePack(berr.INVALID_PARAMS)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = diff
+	return resp
+}
+
+//get proof of contract storage key at height
+func GetStorageProof(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	str, ok = cmd["Key"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	key, err := common.HexToBytes(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	str, ok = cmd["Height"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	height, err := strconv.ParseUint(str, 10, 32)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	proof, err := bactor.GetStorageProof(address, key, uint32(height))
+	if err != nil {
+		resp = ResponsePack(berr.INVALID_PARAMS)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = proof
+	return resp
+}
+
 //get balance of address
 func GetBalance(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -552,6 +626,111 @@ func GetGrantOng(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//get the stake of address in governance contract
+func GetStakingInfo(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	addrStr, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	addr, err := bcomn.GetAddress(addrStr)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetStakingInfo(addr)
+	if err != nil {
+		resp = ResponsePack(berr.INTERNAL_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//estimate the ong split to address from peer in current governance view
+func EstimateRewards(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	addrStr, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	addr, err := bcomn.GetAddress(addrStr)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	peerPubkey, ok := cmd["Peer"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.EstimateRewards(addr, peerPubkey)
+	if err != nil {
+		resp = ResponsePack(berr.INTERNAL_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//get the admin and the functions assigned to each role of contract in auth contract
+func GetRoleFuncs(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetRoleFuncs(contract)
+	if err != nil {
+		resp = ResponsePack(berr.INTERNAL_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//get the roles held by each ONT ID of contract in auth contract
+func GetOntIDTokens(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetOntIDTokens(contract)
+	if err != nil {
+		resp = ResponsePack(berr.INTERNAL_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//verify the JWT or JSON-LD credential issued by ONT ID
+func VerifyCredential(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Credential"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.VerifyCredential(str)
+	if err != nil {
+		resp = ResponsePack(berr.INTERNAL_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
 //get memory pool transaction count
 func GetMemPoolTxCount(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -254,6 +254,121 @@ func GetStorage(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(common.ToHexString(value))
 }
 
+//get storage changes of contract between two heights
+func GetStorageDiff(params []interface{}) map[string]interface{} {
+	if len(params) < 3 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	fromHeight, ok := params[1].(float64)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	toHeight, ok := params[2].(float64)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	diff, err := bactor.GetStorageDiff(address, uint32(fromHeight), uint32(toHeight))
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	return rpc.ResponseSuccess(diff)
+}
+
+//get proof of contract storage key at height
+func GetStorageProof(params []interface{}) map[string]interface{} {
+	if len(params) < 3 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok = params[1].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	key, err := hex.DecodeString(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	height, ok := params[2].(float64)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	proof, err := bactor.GetStorageProof(address, key, uint32(height))
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	return rpc.ResponseSuccess(proof)
+}
+
+//get raw value of contract state key at height
+func GetStateAtHeight(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	key, err := hex.DecodeString(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	height, ok := params[1].(float64)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	value, err := bactor.GetStateAtHeight(key, uint32(height))
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	if len(value) == 0 {
+		return rpc.ResponseSuccess(nil)
+	}
+	return rpc.ResponseSuccess(common.ToHexString(value))
+}
+
+//get raw contract states with key prefix at height
+func FindStatesAtHeight(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	prefix, err := hex.DecodeString(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	height, ok := params[1].(float64)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	items, err := bactor.FindStatesAtHeight(prefix, uint32(height))
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	result := make([]bcomn.StateItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, bcomn.StateItem{Key: common.ToHexString(item.Key), Value: common.ToHexString(item.Value)})
+	}
+	return rpc.ResponseSuccess(result)
+}
+
 //send raw transaction
 // A JSON example for sendrawtransaction method as following:
 //   {"jsonrpc": "2.0", "method": "sendrawtransaction", "params": ["raw transactioin in hex"], "id": 0}
@@ -620,6 +735,106 @@ func GetGrantOng(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(rsp)
 }
 
+//get the stake of address in governance contract
+func GetStakingInfo(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	addr, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetStakingInfo(addr)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
+//estimate the ong split to address from peer in current governance view
+func EstimateRewards(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	addr, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	peerPubkey, ok := params[1].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.EstimateRewards(addr, peerPubkey)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
+//get the admin and the functions assigned to each role of contract in auth contract
+func GetRoleFuncs(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetRoleFuncs(contract)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
+//get the roles held by each ONT ID of contract in auth contract
+func GetOntIDTokens(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetOntIDTokens(contract)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
+//verify the JWT or JSON-LD credential issued by ONT ID
+func VerifyCredential(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.VerifyCredential(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
 //get cross chain message by height
 func GetCrossChainMsg(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
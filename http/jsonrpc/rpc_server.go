@@ -43,6 +43,10 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getrawtransaction", GetRawTransaction)
 	rpc.HandleFunc("sendrawtransaction", SendRawTransaction)
 	rpc.HandleFunc("getstorage", GetStorage)
+	rpc.HandleFunc("getstoragediff", GetStorageDiff)
+	rpc.HandleFunc("getstorageproof", GetStorageProof)
+	rpc.HandleFunc("getstateatheight", GetStateAtHeight)
+	rpc.HandleFunc("findstatesatheight", FindStatesAtHeight)
 	rpc.HandleFunc("getversion", GetNodeVersion)
 	rpc.HandleFunc("getnetworkid", GetNetworkId)
 
@@ -61,6 +65,11 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getgasprice", GetGasPrice)
 	rpc.HandleFunc("getunboundong", GetUnboundOng)
 	rpc.HandleFunc("getgrantong", GetGrantOng)
+	rpc.HandleFunc("getstakinginfo", GetStakingInfo)
+	rpc.HandleFunc("estimaterewards", EstimateRewards)
+	rpc.HandleFunc("getrolefuncs", GetRoleFuncs)
+	rpc.HandleFunc("getontidtokens", GetOntIDTokens)
+	rpc.HandleFunc("verifycredential", VerifyCredential)
 
 	rpc.HandleFunc("getcrosschainmsg", GetCrossChainMsg)
 	rpc.HandleFunc("getcrossstatesproof", GetCrossStatesProof)
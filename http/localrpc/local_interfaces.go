@@ -19,9 +19,13 @@
 package localrpc
 
 import (
+	"math"
 	"time"
 
+	ontcom "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
 	bactor "github.com/ontio/ontology/http/base/actor"
 	"github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
@@ -84,3 +88,77 @@ func SetDebugInfo(params []interface{}) map[string]interface{} {
 	}
 	return rpc.ResponsePack(berr.SUCCESS, true)
 }
+
+//mine blocks in solo dev mode, params: [count]
+func MineBlocks(params []interface{}) map[string]interface{} {
+	count, ok := parseUint32Param(params)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bactor.ConsensusSrvMineBlocks(count)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
+//advance the timestamp of following blocks in solo dev mode, params: [seconds]
+func AdvanceTime(params []interface{}) map[string]interface{} {
+	seconds, ok := parseUint32Param(params)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bactor.ConsensusSrvAdvanceTime(seconds)
+	if err != nil {
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
+//skip the signature check of transactions paid by the address in solo test mode, params: [address]
+func Impersonate(params []interface{}) map[string]interface{} {
+	addr, ok := parseImpersonateParam(params)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	types.Impersonate(addr)
+	log.Infof("Impersonate payer %s", addr.ToBase58())
+	return rpc.ResponseSuccess(true)
+}
+
+//restore the signature check of transactions paid by the address, params: [address]
+func StopImpersonating(params []interface{}) map[string]interface{} {
+	addr, ok := parseImpersonateParam(params)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	types.StopImpersonating(addr)
+	log.Infof("Stop impersonating payer %s", addr.ToBase58())
+	return rpc.ResponseSuccess(true)
+}
+
+func parseImpersonateParam(params []interface{}) (ontcom.Address, bool) {
+	if config.DefConfig.Genesis.ConsensusType != config.CONSENSUS_TYPE_SOLO || len(params) < 1 {
+		return ontcom.ADDRESS_EMPTY, false
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return ontcom.ADDRESS_EMPTY, false
+	}
+	addr, err := ontcom.AddressFromBase58(str)
+	if err != nil {
+		return ontcom.ADDRESS_EMPTY, false
+	}
+	return addr, true
+}
+
+func parseUint32Param(params []interface{}) (uint32, bool) {
+	if len(params) < 1 {
+		return 0, false
+	}
+	val, ok := params[0].(float64)
+	if !ok || val < 0 || val > math.MaxUint32 || val != float64(uint32(val)) {
+		return 0, false
+	}
+	return uint32(val), true
+}
@@ -43,6 +43,10 @@ func StartLocalServer() error {
 	rpc.HandleFunc("startconsensus", StartConsensus)
 	rpc.HandleFunc("stopconsensus", StopConsensus)
 	rpc.HandleFunc("setdebuginfo", SetDebugInfo)
+	rpc.HandleFunc("mineblocks", MineBlocks)
+	rpc.HandleFunc("advancetime", AdvanceTime)
+	rpc.HandleFunc("impersonate", Impersonate)
+	rpc.HandleFunc("stopimpersonate", StopImpersonating)
 
 	// TODO: only listen to local host
 	err := http.ListenAndServe(LOCAL_HOST+":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), nil)
@@ -59,11 +59,16 @@ var (
 		Name: "ontology_p2p_reconnect_count",
 		Help: "ontology p2p reconnect count",
 	})
+
+	diskUsageMetric = prom.NewGaugeVec(prom.GaugeOpts{
+		Name: "ontology_disk_usage_bytes",
+		Help: "ontology disk usage of ledger stores",
+	}, []string{"store"})
 )
 
 var (
 	metrics = []prom.Collector{nodePortMetric, blockHeightMetric, inboundsCountMetric,
-		outboundsCountMetric, peerStatusMetric, reconnectCountMetric}
+		outboundsCountMetric, peerStatusMetric, reconnectCountMetric, diskUsageMetric}
 )
 
 func initMetric() error {
@@ -81,6 +86,12 @@ func metricUpdate(n p2p.P2P) {
 
 	blockHeightMetric.Set(float64(ledger.DefLedger.GetCurrentBlockHeight()))
 
+	if usage, err := ledger.DefLedger.GetDiskUsage(); err == nil {
+		for store, size := range usage {
+			diskUsageMetric.WithLabelValues(store).Set(float64(size))
+		}
+	}
+
 	ns, ok := n.(*netserver.NetServer)
 	if !ok {
 		return
@@ -44,6 +44,13 @@ type Info struct {
 	NodePort      uint16
 	NodeId        string
 	NodeType      string
+	DiskUsage     []StoreDiskUsage
+	TotalDisk     string
+}
+
+type StoreDiskUsage struct {
+	Store string
+	Size  string
 }
 
 const (
@@ -72,6 +79,36 @@ func initPageInfo(blockHeight uint32, curNodeType string, ngbrCnt int, ngbrsInfo
 		NodeId:        id, NodeType: curNodeType}, nil
 }
 
+func initDiskUsage(info *Info) {
+	usage, err := ledger.DefLedger.GetDiskUsage()
+	if err != nil {
+		info.TotalDisk = err.Error()
+		return
+	}
+	var total uint64
+	for store, size := range usage {
+		info.DiskUsage = append(info.DiskUsage, StoreDiskUsage{Store: store, Size: formatBytes(size)})
+		total += size
+	}
+	sort.Slice(info.DiskUsage, func(i, j int) bool {
+		return info.DiskUsage[i].Store < info.DiskUsage[j].Store
+	})
+	info.TotalDisk = formatBytes(total)
+}
+
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
 func viewHandler(w http.ResponseWriter, r *http.Request) {
 	var ngbrNodersInfo []NgbNodeInfo
 	var ngbId string
@@ -104,6 +141,7 @@ func viewHandler(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/info", http.StatusFound)
 		return
 	}
+	initDiskUsage(pageInfo)
 
 	err = templates.ExecuteTemplate(w, "info", pageInfo)
 	if err != nil {
@@ -67,6 +67,31 @@ const TEMPLATE_PAGE = `
 </table>
 <br><br><br><br>
 
+<table class="bt" width="80%">
+	<tr><th>Disk Usage</th></tr>
+</table>
+<br>
+
+<table class="bd" width="80%">
+<tr>
+<td width="20%" >
+	<table class="font" width="100%">
+	<tr><th>Total</th></tr>
+	<tr><td align="center"><b><font size="40px">{{.TotalDisk}}</font></b></td></tr>
+	</table>
+</td>
+<td width="80%">
+	<table class="font" width="100%">
+	<tr><th>Store</th><th>Size</th></tr>
+	{{range .DiskUsage}}
+	<tr><td align="center">{{.Store}}</td><td align="center">{{.Size}}</td></tr>
+	{{end}}
+	</table>
+</td>
+</tr>
+</table>
+<br><br><br><br>
+
 <table class="bt" width="80%">
 	<tr><th>Neighbors Information</th></tr>
 </table>
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package restful
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology/common/log"
+	bcomn "github.com/ontio/ontology/http/base/common"
+)
+
+//DID_RESOLVE is the path of W3C DID resolution of ONT ID, /1.0/identifiers/<did>?versionTime=<RFC3339 time>
+const DID_RESOLVE = "/1.0/identifiers/"
+
+var didErrorStatus = map[string]int{
+	bcomn.DID_ERR_INVALID_DID:                  http.StatusBadRequest,
+	bcomn.DID_ERR_INVALID_OPTIONS:              http.StatusBadRequest,
+	bcomn.DID_ERR_NOT_FOUND:                    http.StatusNotFound,
+	bcomn.DID_ERR_REPRESENTATION_NOT_SUPPORTED: http.StatusNotAcceptable,
+	bcomn.DID_ERR_METHOD_NOT_SUPPORTED:         http.StatusNotImplemented,
+	bcomn.DID_ERR_INTERNAL:                     http.StatusInternalServerError,
+}
+
+//init the handler of DID resolution, which is not wrapped as the other apis
+func (this *restServer) initDIDHandler() {
+	this.router.Get(DID_RESOLVE+".+", func(w http.ResponseWriter, r *http.Request) {
+		did := strings.TrimPrefix(r.URL.Path, DID_RESOLVE)
+		documentType, ok := didRepresentation(r.Header.Get("Accept"))
+		var result *bcomn.DIDResolutionResult
+		if ok {
+			result = bcomn.ResolveDID(did, r.URL.Query().Get("versionTime"))
+		} else {
+			result = &bcomn.DIDResolutionResult{
+				Context: bcomn.DID_RESOLUTION_CONTEXT,
+				DIDResolutionMetadata: &bcomn.DIDResolutionMetadata{
+					Retrieved:    time.Now().UTC().Format(time.RFC3339),
+					Error:        bcomn.DID_ERR_REPRESENTATION_NOT_SUPPORTED,
+					ErrorMessage: "unsupported accept " + r.Header.Get("Accept"),
+				},
+				DIDDocumentMetadata: &bcomn.DIDDocumentMetadata{},
+			}
+		}
+
+		status := http.StatusOK
+		if result.DIDResolutionMetadata.Error != "" {
+			status = didErrorStatus[result.DIDResolutionMetadata.Error]
+		} else if result.DIDDocumentMetadata.Deactivated {
+			status = http.StatusGone
+		}
+		contentType, data := bcomn.DID_RESOLUTION_CONTENT_TYPE, []byte(nil)
+		if documentType != "" && status == http.StatusOK {
+			contentType, data = documentType, result.DIDDocument
+		} else {
+			var err error
+			if data, err = json.Marshal(result); err != nil {
+				log.Errorf("HTTP Handle - json.Marshal: %v", err)
+				status, data = http.StatusInternalServerError, nil
+			}
+		}
+		w.Header().Set("content-type", contentType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(status)
+		w.Write(data)
+	})
+}
+
+//didRepresentation return the media type of DID document if only the document is accepted, empty if the resolution
+//result is accepted, and false if neither is accepted
+func didRepresentation(accept string) (string, bool) {
+	if accept == "" {
+		return "", true
+	}
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/did+ld+json", "application/did+json":
+			return mediaType, true
+		case "application/ld+json":
+			if profile, ok := params["profile"]; ok && profile != "https://w3id.org/did-resolution" {
+				continue
+			}
+			return "", true
+		case "application/json", "application/*", "*/*":
+			return "", true
+		}
+	}
+	return "", false
+}
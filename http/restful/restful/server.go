@@ -64,6 +64,8 @@ const (
 	GET_BLK_HASH          = "/api/v1/block/hash/:height"
 	GET_TX                = "/api/v1/transaction/:hash"
 	GET_STORAGE           = "/api/v1/storage/:hash/:key"
+	GET_STORAGE_DIFF      = "/api/v1/storagediff/:hash/:from/:to"
+	GET_STORAGE_PROOF     = "/api/v1/storageproof/:hash/:key/:height"
 	GET_BALANCE           = "/api/v1/balance/:addr"
 	GET_CONTRACT_STATE    = "/api/v1/contract/:hash"
 	GET_SMTCOCE_EVT_TXS   = "/api/v1/smartcode/event/transactions/:height"
@@ -74,13 +76,18 @@ const (
 	GET_ALLOWANCE         = "/api/v1/allowance/:asset/:from/:to"
 	GET_UNBOUNDONG        = "/api/v1/unboundong/:addr"
 	GET_GRANTONG          = "/api/v1/grantong/:addr"
+	GET_STAKING_INFO      = "/api/v1/stakinginfo/:addr"
+	GET_ESTIMATE_REWARDS  = "/api/v1/estimaterewards/:addr/:peer"
+	GET_ROLE_FUNCS        = "/api/v1/auth/rolefuncs/:addr"
+	GET_ONTID_TOKENS      = "/api/v1/auth/ontidtokens/:addr"
 	GET_MEMPOOL_TXCOUNT   = "/api/v1/mempool/txcount"
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
 	GET_MEMPOOL_TXHASHS   = "/api/v1/mempool/txhashlist"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
 
-	POST_RAW_TX = "/api/v1/transaction"
+	POST_RAW_TX            = "/api/v1/transaction"
+	POST_VERIFY_CREDENTIAL = "/api/v1/credential/verify"
 )
 
 //init restful server
@@ -91,6 +98,7 @@ func InitRestServer() rest.ApiServer {
 	rt.registryMethod()
 	rt.initGetHandler()
 	rt.initPostHandler()
+	rt.initDIDHandler()
 	return rt
 }
 
@@ -163,12 +171,18 @@ func (this *restServer) registryMethod() {
 		GET_SMTCOCE_EVTS:      {name: "getsmartcodeeventbyhash", handler: rest.GetSmartCodeEventByTxHash},
 		GET_BLK_HGT_BY_TXHASH: {name: "getblockheightbytxhash", handler: rest.GetBlockHeightByTxHash},
 		GET_STORAGE:           {name: "getstorage", handler: rest.GetStorage},
+		GET_STORAGE_DIFF:      {name: "getstoragediff", handler: rest.GetStorageDiff},
+		GET_STORAGE_PROOF:     {name: "getstorageproof", handler: rest.GetStorageProof},
 		GET_BALANCE:           {name: "getbalance", handler: rest.GetBalance},
 		GET_ALLOWANCE:         {name: "getallowance", handler: rest.GetAllowance},
 		GET_MERKLE_PROOF:      {name: "getmerkleproof", handler: rest.GetMerkleProof},
 		GET_GAS_PRICE:         {name: "getgasprice", handler: rest.GetGasPrice},
 		GET_UNBOUNDONG:        {name: "getunboundong", handler: rest.GetUnboundOng},
 		GET_GRANTONG:          {name: "getgrantong", handler: rest.GetGrantOng},
+		GET_STAKING_INFO:      {name: "getstakinginfo", handler: rest.GetStakingInfo},
+		GET_ESTIMATE_REWARDS:  {name: "estimaterewards", handler: rest.EstimateRewards},
+		GET_ROLE_FUNCS:        {name: "getrolefuncs", handler: rest.GetRoleFuncs},
+		GET_ONTID_TOKENS:      {name: "getontidtokens", handler: rest.GetOntIDTokens},
 		GET_MEMPOOL_TXCOUNT:   {name: "getmempooltxcount", handler: rest.GetMemPoolTxCount},
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
 		GET_MEMPOOL_TXHASHS:   {name: "getmempooltxhashlist", handler: rest.GetMemPoolTxHashList},
@@ -177,7 +191,8 @@ func (this *restServer) registryMethod() {
 	}
 
 	postMethodMap := map[string]Action{
-		POST_RAW_TX: {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_RAW_TX:            {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_VERIFY_CREDENTIAL: {name: "verifycredential", handler: rest.VerifyCredential},
 	}
 	this.postMap = postMethodMap
 	this.getMap = getMethodMap
@@ -203,6 +218,10 @@ func (this *restServer) getPath(url string) string {
 		return GET_SMTCOCE_EVTS
 	} else if strings.Contains(url, strings.TrimRight(GET_BLK_HGT_BY_TXHASH, ":hash")) {
 		return GET_BLK_HGT_BY_TXHASH
+	} else if strings.Contains(url, strings.TrimRight(GET_STORAGE_DIFF, ":hash/:from/:to")) {
+		return GET_STORAGE_DIFF
+	} else if strings.Contains(url, strings.TrimRight(GET_STORAGE_PROOF, ":hash/:key/:height")) {
+		return GET_STORAGE_PROOF
 	} else if strings.Contains(url, strings.TrimRight(GET_STORAGE, ":hash/:key")) {
 		return GET_STORAGE
 	} else if strings.Contains(url, strings.TrimRight(GET_BALANCE, ":addr")) {
@@ -215,6 +234,14 @@ func (this *restServer) getPath(url string) string {
 		return GET_UNBOUNDONG
 	} else if strings.Contains(url, strings.TrimRight(GET_GRANTONG, ":addr")) {
 		return GET_GRANTONG
+	} else if strings.Contains(url, strings.TrimRight(GET_STAKING_INFO, ":addr")) {
+		return GET_STAKING_INFO
+	} else if strings.Contains(url, strings.TrimRight(GET_ESTIMATE_REWARDS, ":addr/:peer")) {
+		return GET_ESTIMATE_REWARDS
+	} else if strings.Contains(url, strings.TrimRight(GET_ROLE_FUNCS, ":addr")) {
+		return GET_ROLE_FUNCS
+	} else if strings.Contains(url, strings.TrimRight(GET_ONTID_TOKENS, ":addr")) {
+		return GET_ONTID_TOKENS
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
 	}
@@ -242,6 +269,12 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["PreExec"] = r.FormValue("preExec")
 	case GET_STORAGE:
 		req["Hash"], req["Key"] = getParam(r, "hash"), getParam(r, "key")
+	case GET_STORAGE_DIFF:
+		req["Hash"] = getParam(r, "hash")
+		req["From"], req["To"] = getParam(r, "from"), getParam(r, "to")
+	case GET_STORAGE_PROOF:
+		req["Hash"], req["Key"] = getParam(r, "hash"), getParam(r, "key")
+		req["Height"] = getParam(r, "height")
 	case GET_SMTCOCE_EVT_TXS:
 		req["Height"] = getParam(r, "height")
 	case GET_SMTCOCE_EVTS:
@@ -259,6 +292,14 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_GRANTONG:
 		req["Addr"] = getParam(r, "addr")
+	case GET_STAKING_INFO:
+		req["Addr"] = getParam(r, "addr")
+	case GET_ESTIMATE_REWARDS:
+		req["Addr"], req["Peer"] = getParam(r, "addr"), getParam(r, "peer")
+	case GET_ROLE_FUNCS:
+		req["Addr"] = getParam(r, "addr")
+	case GET_ONTID_TOKENS:
+		req["Addr"] = getParam(r, "addr")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
 	default:
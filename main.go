@@ -19,9 +19,11 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"github.com/ontio/ontology/core/types"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -43,6 +45,8 @@ import (
 	"github.com/ontio/ontology/consensus"
 	"github.com/ontio/ontology/core/genesis"
 	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/store/forkstore"
+	"github.com/ontio/ontology/core/store/ledgerstore"
 	"github.com/ontio/ontology/events"
 	bactor "github.com/ontio/ontology/http/base/actor"
 	"github.com/ontio/ontology/http/ethrpc"
@@ -73,12 +77,18 @@ func setupAPP() *cli.App {
 		cmd.ContractCommand,
 		cmd.ImportCommand,
 		cmd.ExportCommand,
+		cmd.RollbackCommand,
+		cmd.DbCommand,
 		cmd.TxCommond,
 		cmd.SigTxCommand,
 		cmd.MultiSigAddrCommand,
 		cmd.MultiSigTxCommand,
 		cmd.SendTxCommand,
 		cmd.ShowTxCommand,
+		cmd.DevnetCommand,
+		cmd.GovernanceCommand,
+		cmd.OntIDCommand,
+		cmd.RelayerCommand,
 	}
 	app.Flags = []cli.Flag{
 		//common setting
@@ -88,8 +98,16 @@ func setupAPP() *cli.App {
 		utils.DisableLogFileFlag,
 		utils.DisableEventLogFlag,
 		utils.DataDirFlag,
+		utils.DbBackendFlag,
 		utils.ETHTxGasLimitFlag,
 		utils.WasmVerifyMethodFlag,
+		utils.TxExecModeFlag,
+		utils.PruneHistoryFlag,
+		utils.StateGCFlag,
+		utils.SaveWriteSetsFlag,
+		utils.SaveReverseWriteSetsFlag,
+		utils.StorageProofFlag,
+		utils.LightFlag,
 		//account setting
 		utils.WalletFileFlag,
 		utils.AccountAddressFlag,
@@ -97,6 +115,8 @@ func setupAPP() *cli.App {
 		//consensus setting
 		utils.EnableConsensusFlag,
 		utils.MaxTxInBlockFlag,
+		utils.RemoteSignerFlag,
+		utils.RemoteSignerSecretFlag,
 		//txpool setting
 		utils.GasPriceFlag,
 		utils.GasLimitFlag,
@@ -115,6 +135,10 @@ func setupAPP() *cli.App {
 		//test mode setting
 		utils.EnableTestModeFlag,
 		utils.TestModeGenBlockTimeFlag,
+		utils.TestModeInstantSealFlag,
+		utils.TestModePrefundFileFlag,
+		utils.TestModeForkRpcFlag,
+		utils.TestModeImpersonateFlag,
 		//rpc setting
 		utils.RPCDisabledFlag,
 		utils.RPCPortFlag,
@@ -237,10 +261,13 @@ func initConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 	return cfg, nil
 }
 
-func initAccount(ctx *cli.Context) (*account.Account, error) {
+func initAccount(ctx *cli.Context) (account.Signer, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
 	}
+	if address := ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerFlag)); address != "" {
+		return initRemoteSigner(ctx, address)
+	}
 	walletFile := ctx.GlobalString(utils.GetFlagName(utils.WalletFileFlag))
 	if walletFile == "" {
 		return nil, fmt.Errorf("please config wallet file using --wallet flag")
@@ -264,6 +291,30 @@ func initAccount(ctx *cli.Context) (*account.Account, error) {
 	return acc, nil
 }
 
+func initRemoteSigner(ctx *cli.Context, address string) (account.Signer, error) {
+	secretFile := ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerSecretFlag))
+	if secretFile == "" {
+		return nil, fmt.Errorf("please config the secret of remote signer using --%s flag",
+			utils.GetFlagName(utils.RemoteSignerSecretFlag))
+	}
+	secret, err := ioutil.ReadFile(secretFile)
+	if err != nil {
+		return nil, fmt.Errorf("read secret file error: %s", err)
+	}
+	signer, err := account.NewRemoteSigner(address, bytes.TrimSpace(secret))
+	if err != nil {
+		return nil, err
+	}
+	pubKey := hex.EncodeToString(keypair.SerializePublicKey(signer.PubKey()))
+	addr := types.AddressFromPubKey(signer.PubKey())
+	log.Infof("Using remote signer: %s, account: %s, pubkey: %s", address, addr.ToBase58(), pubKey)
+
+	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
+		config.DefConfig.Genesis.SOLO.Bookkeepers = []string{pubKey}
+	}
+	return signer, nil
+}
+
 func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error) {
 	events.Init() //Init event hub
 
@@ -278,9 +329,73 @@ func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error
 	if err != nil {
 		return nil, fmt.Errorf("genesisBlock error %s", err)
 	}
-	ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
-	if err != nil {
-		return nil, fmt.Errorf("NewLedger error: %s", err)
+	solo := genesisConfig.SOLO
+	if light := ctx.GlobalString(utils.GetFlagName(utils.LightFlag)); light != "" {
+		if strings.ToLower(genesisConfig.ConsensusType) != config.CONSENSUS_TYPE_VBFT {
+			return nil, fmt.Errorf("--%s works with vbft network only", utils.GetFlagName(utils.LightFlag))
+		}
+		if config.DefConfig.Consensus.EnableConsensus {
+			return nil, fmt.Errorf("--%s can not work with --%s", utils.GetFlagName(utils.LightFlag),
+				utils.GetFlagName(utils.EnableConsensusFlag))
+		}
+		var remotes []ledgerstore.LightRemote
+		for _, address := range strings.Split(light, ",") {
+			remotes = append(remotes, forkstore.NewRpcClient(strings.TrimSpace(address)))
+		}
+		ledger.DefLedger, err = ledger.InitLightLedger(dbDir, bookKeepers, genesisBlock, remotes)
+		if err != nil {
+			return nil, fmt.Errorf("NewLightLedger error: %s", err)
+		}
+		log.Infof("Light ledger fetches blocks from %s", light)
+	} else if genesisConfig.ConsensusType == config.CONSENSUS_TYPE_SOLO && solo != nil && solo.ForkRpc != "" {
+		ledger.DefLedger, err = ledger.InitForkLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock,
+			forkstore.NewRpcClient(solo.ForkRpc))
+		if err != nil {
+			return nil, fmt.Errorf("NewForkLedger error: %s", err)
+		}
+		log.Infof("Ledger states forked from %s", solo.ForkRpc)
+	} else {
+		ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
+		if err != nil {
+			return nil, fmt.Errorf("NewLedger error: %s", err)
+		}
+	}
+	if genesisConfig.ConsensusType == config.CONSENSUS_TYPE_SOLO && solo != nil {
+		for _, addr := range solo.Impersonated {
+			address, err := common.AddressFromBase58(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid impersonated address %s: %s", addr, err)
+			}
+			types.Impersonate(address)
+			log.Infof("Impersonate payer %s", addr)
+		}
+	}
+	stateGC := ctx.GlobalBool(utils.GetFlagName(utils.StateGCFlag))
+	if pruneHistory := ctx.GlobalUint(utils.GetFlagName(utils.PruneHistoryFlag)); pruneHistory != 0 {
+		ledger.DefLedger.EnableBlockPrune(uint32(pruneHistory))
+		log.Infof("Enable block prune, history length: %d", pruneHistory)
+		if stateGC {
+			ledger.DefLedger.EnableStateGC()
+			log.Infof("Enable state gc")
+		}
+	} else if stateGC {
+		return nil, fmt.Errorf("--%s works with --%s only", utils.GetFlagName(utils.StateGCFlag),
+			utils.GetFlagName(utils.PruneHistoryFlag))
+	}
+
+	if ctx.GlobalBool(utils.GetFlagName(utils.SaveWriteSetsFlag)) {
+		ledger.DefLedger.EnableWriteSetSaving()
+		log.Infof("Enable write set saving")
+	}
+	if ctx.GlobalBool(utils.GetFlagName(utils.SaveReverseWriteSetsFlag)) {
+		ledger.DefLedger.EnableReverseWriteSetSaving()
+		log.Infof("Enable reverse write set saving")
+	}
+	if ctx.GlobalBool(utils.GetFlagName(utils.StorageProofFlag)) {
+		if err := ledger.DefLedger.EnableStorageProof(); err != nil {
+			return nil, fmt.Errorf("EnableStorageProof error: %s", err)
+		}
+		log.Infof("Enable storage proof")
 	}
 
 	log.Infof("Ledger init success")
@@ -303,7 +418,7 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	return txPoolServer, nil
 }
 
-func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acct *account.Account) (*p2pserver.P2PServer, p2p.P2P, error) {
+func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acct account.Signer) (*p2pserver.P2PServer, p2p.P2P, error) {
 	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		return nil, nil, nil
 	}
@@ -323,7 +438,7 @@ func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acct *account.A
 	return p2p, p2p.GetNetwork(), nil
 }
 
-func initConsensus(ctx *cli.Context, net p2p.P2P, txpoolSvr *proc.TXPoolServer, acc *account.Account) (consensus.ConsensusService, error) {
+func initConsensus(ctx *cli.Context, net p2p.P2P, txpoolSvr *proc.TXPoolServer, acc account.Signer) (consensus.ConsensusService, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
 	}
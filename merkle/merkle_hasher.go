@@ -140,12 +140,28 @@ func MerkleLeafPath(data []byte, hashes []common.Uint256) ([]byte, error) {
 	if size > MAX_SIZE {
 		return nil, fmt.Errorf("data length over max value:%d", MAX_SIZE)
 	}
-	index := getIndex(HashLeaf(data), hashes)
-	if index < 0 {
-		return nil, fmt.Errorf("%s", "values doesn't exist!")
-	}
 	sink := common.NewZeroCopySink(make([]byte, 0, size))
 	sink.WriteVarBytes(data)
+	if err := writeLeafPath(sink, HashLeaf(data), hashes); err != nil {
+		return nil, err
+	}
+	return sink.Bytes(), nil
+}
+
+// MerkleLeafHashPath return the path of leaf hash, which is the sibling hashes from the leaf to root without data
+func MerkleLeafHashPath(leaf common.Uint256, hashes []common.Uint256) ([]byte, error) {
+	sink := common.NewZeroCopySink(make([]byte, 0, len(hashes)*(common.UINT256_SIZE+1)))
+	if err := writeLeafPath(sink, leaf, hashes); err != nil {
+		return nil, err
+	}
+	return sink.Bytes(), nil
+}
+
+func writeLeafPath(sink *common.ZeroCopySink, leaf common.Uint256, hashes []common.Uint256) error {
+	index := getIndex(leaf, hashes)
+	if index < 0 {
+		return fmt.Errorf("%s", "values doesn't exist!")
+	}
 	d := depth(len(hashes))
 	merkleTree := MerkleHashes(hashes, d)
 	for i := d; i > 0; i-- {
@@ -165,7 +181,7 @@ func MerkleLeafPath(data []byte, hashes []common.Uint256) ([]byte, error) {
 		}
 		index = nIndex
 	}
-	return sink.Bytes(), nil
+	return nil
 }
 
 func MerkleHashes(preLeaves []common.Uint256, depth int) [][]common.Uint256 {
@@ -198,16 +214,30 @@ func MerkleProve(path []byte, root common.Uint256) ([]byte, error) {
 	if eof || irr {
 		return nil, errors.New("read bytes error")
 	}
-	hash := HashLeaf(value)
+	if err := proveLeaf(source, HashLeaf(value), root); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// MerkleProveLeafHash check the path of leaf hash returned by MerkleLeafHashPath with root
+func MerkleProveLeafHash(leaf common.Uint256, path []byte, root common.Uint256) error {
+	if len(path)%(common.UINT256_SIZE+1) != 0 {
+		return errors.New("invalid path length")
+	}
+	return proveLeaf(common.NewZeroCopySource(path), leaf, root)
+}
+
+func proveLeaf(source *common.ZeroCopySource, hash common.Uint256, root common.Uint256) error {
 	size := int((source.Size() - source.Pos()) / common.UINT256_SIZE)
 	for i := 0; i < size; i++ {
 		f, eof := source.NextByte()
 		if eof {
-			return nil, errors.New("read byte error")
+			return errors.New("read byte error")
 		}
 		v, eof := source.NextHash()
 		if eof {
-			return nil, errors.New("read hash error")
+			return errors.New("read hash error")
 		}
 		if f == LEFT {
 			hash = HashChildren(v, hash)
@@ -217,9 +247,9 @@ func MerkleProve(path []byte, root common.Uint256) ([]byte, error) {
 	}
 
 	if hash != root {
-		return nil, fmt.Errorf("excepted root is not equal actual root, excepted:%x, actual:%x", hash, root)
+		return fmt.Errorf("excepted root is not equal actual root, excepted:%x, actual:%x", hash, root)
 	}
-	return value, nil
+	return nil
 }
 
 func depth(n int) int {
@@ -69,15 +69,15 @@ func (self *SubnetMembersRequest) Role() string {
 	return "gov"
 }
 
-func NewMembersRequest(from, to common.PeerId, acc *account.Account) (*SubnetMembersRequest, error) {
+func NewMembersRequest(from, to common.PeerId, acc account.Signer) (*SubnetMembersRequest, error) {
 	request := &SubnetMembersRequest{
 		From:      from,
 		To:        to,
 		Timestamp: uint32(time.Now().Unix()),
-		PubKey:    acc.PublicKey,
+		PubKey:    acc.PubKey(),
 	}
 
-	sig, err := signature.Sign(acc, request.sigdata())
+	sig, err := acc.Sign(request.sigdata())
 	if err != nil {
 		return nil, err
 	}
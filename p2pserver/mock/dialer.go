@@ -43,15 +43,19 @@ func (d *dialer) Dial(nodeAddr string) (net.Conn, error) {
 		return nil, errors.New("can not be reached")
 	}
 
-	if _, allow := d.network.canEstablish[combineKey(d.id, l.id)]; !allow {
+	key := combineKey(d.id, l.id)
+	if _, allow := d.network.canEstablish[key]; !allow {
 		return nil, errors.New("can not be reached")
 	}
 
 	c, s := net.Pipe()
 
-	cw := connWraper{c, d.address, d.network, l.address}
-	sw := connWraper{s, l.address, d.network, d.address}
-	l.PushToAccept(sw)
+	cw := connWraper{c, d.address, d.network, l.address, key}
+	sw := connWraper{s, l.address, d.network, d.address, ""}
+	if !l.PushToAccept(sw) {
+		return nil, errors.New("can not be reached")
+	}
+	d.network.conns[key] = append(d.network.conns[key], c)
 
 	return cw, nil
 }
@@ -39,6 +39,8 @@ type Network interface {
 	NewDialer(id common.PeerId) connect_controller.Dialer
 	NewDialerWithHost(id common.PeerId, host string) connect_controller.Dialer
 	AllowConnect(id1, id2 common.PeerId)
+	// DisallowConnect forbid the connection and close the established ones
+	DisallowConnect(id1, id2 common.PeerId)
 	DeliverRate(percent uint)
 }
 
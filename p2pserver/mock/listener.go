@@ -21,6 +21,7 @@ package mock
 import (
 	"errors"
 	"net"
+	"sync"
 
 	"github.com/ontio/ontology/p2pserver/common"
 )
@@ -29,6 +30,9 @@ type Listener struct {
 	id      common.PeerId
 	conn    chan net.Conn
 	address string
+	network *network
+	closed  chan struct{}
+	once    sync.Once
 }
 
 var _ net.Listener = &Listener{}
@@ -45,6 +49,8 @@ func (n *network) NewListenerWithAddr(id common.PeerId, hostport string) net.Lis
 		id:      id,
 		address: hostport,
 		conn:    make(chan net.Conn),
+		network: n,
+		closed:  make(chan struct{}),
 	}
 
 	n.Lock()
@@ -56,16 +62,23 @@ func (n *network) NewListenerWithAddr(id common.PeerId, hostport string) net.Lis
 
 func (l *Listener) Accept() (net.Conn, error) {
 	select {
-	case conn, ok := <-l.conn:
-		if ok {
-			return conn, nil
-		}
+	case conn := <-l.conn:
+		return conn, nil
+	case <-l.closed:
 		return nil, errors.New("closed channel")
 	}
 }
 
+// Close stop accepting and remove the listener from network, so its address can be listened again
 func (l *Listener) Close() error {
-	close(l.conn)
+	l.once.Do(func() {
+		l.network.Lock()
+		if l.network.listeners[l.address] == l {
+			delete(l.network.listeners, l.address)
+		}
+		l.network.Unlock()
+		close(l.closed)
+	})
 	return nil
 }
 
@@ -86,8 +99,19 @@ func (l *Listener) ID() string {
 	return l.id.ToHexString()
 }
 
-func (l *Listener) PushToAccept(conn net.Conn) {
+// PushToAccept queue conn to be accepted, return false if the listener is closed
+func (l *Listener) PushToAccept(conn net.Conn) bool {
+	select {
+	case <-l.closed:
+		return false
+	default:
+	}
 	go func() {
-		l.conn <- conn
+		select {
+		case l.conn <- conn:
+		case <-l.closed:
+			conn.Close()
+		}
 	}()
+	return true
 }
@@ -29,14 +29,11 @@ import (
 	"github.com/ontio/ontology/p2pserver/common"
 )
 
-func init() {
-	common.Difficulty = 1
-}
-
 type network struct {
 	sync.RWMutex
 	canEstablish map[string]struct{}
 	listeners    map[string]*Listener
+	conns        map[string][]net.Conn
 	startID      uint32
 }
 
@@ -48,7 +45,9 @@ func NewNetwork() Network {
 		canEstablish: make(map[string]struct{}),
 		// host:port -> Listener
 		listeners: make(map[string]*Listener),
-		startID:   0,
+		// id|id -> dialed connections
+		conns:   make(map[string][]net.Conn),
+		startID: 0,
 	}
 
 	return ret
@@ -95,6 +94,36 @@ func (n *network) AllowConnect(id1, id2 common.PeerId) {
 	n.canEstablish[combineKey(id1, id2)] = struct{}{}
 }
 
+// DisallowConnect forbid the connection between the two peers and close the established ones, so a network
+// partition can be simulated
+func (n *network) DisallowConnect(id1, id2 common.PeerId) {
+	n.Lock()
+	defer n.Unlock()
+
+	key := combineKey(id1, id2)
+	delete(n.canEstablish, key)
+	for _, conn := range n.conns[key] {
+		conn.Close()
+	}
+	delete(n.conns, key)
+}
+
+func (n *network) removeConn(key string, conn net.Conn) {
+	n.Lock()
+	defer n.Unlock()
+
+	conns := n.conns[key]
+	for i, c := range conns {
+		if c == conn {
+			n.conns[key] = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(n.conns[key]) == 0 {
+		delete(n.conns, key)
+	}
+}
+
 // DeliverRate TODO
 func (n *network) DeliverRate(percent uint) {
 
@@ -105,6 +134,14 @@ type connWraper struct {
 	address string
 	network *network
 	remote  string
+	key     string // key of the peers in network if it is a dialed connection
+}
+
+func (cw connWraper) Close() error {
+	if cw.key != "" {
+		cw.network.removeConn(cw.key, cw.Conn)
+	}
+	return cw.Conn.Close()
 }
 
 var _ net.Addr = &connWraper{}
@@ -83,6 +83,45 @@ func TestNetwork(t *testing.T) {
 	a.Nil(lconn2, "should be nil")
 }
 
+func TestDisallowConnect(t *testing.T) {
+	a := require.New(t)
+	dp := genPeerID()
+	lp := genPeerID()
+
+	n := NewNetwork()
+	d := n.NewDialer(dp)
+	laddr, l := n.NewListener(lp)
+
+	n.AllowConnect(dp, lp)
+	dconn, err := d.Dial(laddr)
+	a.Nil(err, "should be nil")
+	lconn, err := l.Accept()
+	a.Nil(err, "accept should get one conn")
+
+	// established connection is closed after disallow
+	n.DisallowConnect(dp, lp)
+	_, err = dconn.Write([]byte{1})
+	a.NotNil(err, "write to closed conn should fail")
+	_, err = lconn.Read(make([]byte, 1))
+	a.NotNil(err, "read from closed conn should fail")
+
+	dconn, err = d.Dial(laddr)
+	a.Nil(dconn, "connection should be nil")
+	a.NotNil(err, "err shuld not be nil")
+
+	// connect again after allow
+	n.AllowConnect(dp, lp)
+	dconn, err = d.Dial(laddr)
+	a.Nil(err, "should be nil")
+	a.NotNil(dconn, "should be a real conn")
+
+	// closed listener can not be dialed
+	l.Close()
+	dconn, err = d.Dial(laddr)
+	a.Nil(dconn, "connection should be nil")
+	a.NotNil(err, "err shuld not be nil")
+}
+
 func TestNetIP(t *testing.T) {
 	a := require.New(t)
 	ip := net.ParseIP("0.0.0.1")
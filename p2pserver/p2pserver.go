@@ -43,7 +43,7 @@ type P2PServer struct {
 }
 
 //NewServer return a new p2pserver according to the pubkey
-func NewServer(acct *account.Account, txpool common2.TxPoolService) (*P2PServer, error) {
+func NewServer(acct account.Signer, txpool common2.TxPoolService) (*P2PServer, error) {
 	db := ledger.DefLedger
 	var rsv []string
 	var recRsv []string
@@ -63,12 +63,12 @@ type MsgHandler struct {
 	persistRecentPeerService *recent_peers.PersistRecentPeerService
 	subnet                   *subnet.SubNet
 	ledger                   *ledger.Ledger
-	acct                     *account.Account // nil if conenesus is not enabled
+	acct                     account.Signer // nil if conenesus is not enabled
 	staticReserveFilter      p2p.AddressFilter
 	txPoolService            common2.TxPoolService
 }
 
-func NewMsgHandler(acct *account.Account, staticReserveFilter p2p.AddressFilter, ld *ledger.Ledger,
+func NewMsgHandler(acct account.Signer, staticReserveFilter p2p.AddressFilter, ld *ledger.Ledger,
 	txPool common2.TxPoolService, logger msgCommon.Logger) *MsgHandler {
 	gov := utils.NewGovNodeResolver(ld)
 	seedsList := config.DefConfig.Genesis.SeedList
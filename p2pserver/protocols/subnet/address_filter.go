@@ -37,7 +37,7 @@ func (self *SubNetReservedAddrFilter) Contains(addr string) bool {
 	}
 
 	// gov node
-	if self.subnet.acct != nil && self.subnet.gov.IsGovNodePubKey(self.subnet.acct.PublicKey) {
+	if self.subnet.acct != nil && self.subnet.gov.IsGovNodePubKey(self.subnet.acct.PubKey()) {
 		return self.subnet.isSeedIp(ip) || self.subnet.IpInMembers(ip)
 	}
 
@@ -40,7 +40,7 @@ func (self *SubNet) ProposeOffline(nodes []string) error {
 	if self.acct == nil {
 		return errors.New("only consensus node can propose offline witness")
 	}
-	key := vconfig.PubkeyID(self.acct.PublicKey)
+	key := vconfig.PubkeyID(self.acct.PubKey())
 	role, view := self.gov.GetNodeRoleAndView(key)
 	if role != utils.ConsensusNode {
 		return errors.New("only consensus node can propose offline witness")
@@ -169,7 +169,7 @@ func (self *SubNet) processOfflineWitnessMsg(ctx *p2p.Context, msg *types.Offlin
 	defer self.lock.Unlock()
 	offline := self.offlineWitness[msg.Hash()]
 	if offline == nil {
-		govNode := self.acct != nil && self.gov.IsGovNodePubKey(self.acct.PublicKey)
+		govNode := self.acct != nil && self.gov.IsGovNodePubKey(self.acct.PubKey())
 		if govNode {
 			err := msg.VoteFor(self.acct, self.collectOfflineIndexLocked(msg.NodePubKeys))
 			if err != nil {
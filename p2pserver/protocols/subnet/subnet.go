@@ -52,7 +52,7 @@ type Offline struct {
 }
 
 type SubNet struct {
-	acct     *account.Account // nil if conenesus is not enabled
+	acct     account.Signer // nil if conenesus is not enabled
 	seeds    *utils.HostsResolver
 	gov      utils.GovNodeResolver
 	unparker *utils.Parker
@@ -68,7 +68,7 @@ type SubNet struct {
 	logger         common.Logger
 }
 
-func NewSubNet(acc *account.Account, seeds *utils.HostsResolver,
+func NewSubNet(acc account.Signer, seeds *utils.HostsResolver,
 	gov utils.GovNodeResolver, logger common.Logger) *SubNet {
 	return &SubNet{
 		acct:     acc,
@@ -268,7 +268,7 @@ func (self *SubNet) newMembersRequest(from, to common.PeerId) *types.SubnetMembe
 	var request *types.SubnetMembersRequest
 	// need first check is gov node, since gov node may also be seed node
 	// so the remote peer can known this node is gov node.
-	if self.acct != nil && self.gov.IsGovNodePubKey(self.acct.PublicKey) {
+	if self.acct != nil && self.gov.IsGovNodePubKey(self.acct.PubKey()) {
 		var err error
 		request, err = types.NewMembersRequest(from, to, self.acct)
 		if err != nil {
@@ -356,7 +356,7 @@ func (self *SubNet) maintainLoop(net p2p.P2P) {
 				}
 			}
 		}
-		seedOrGov := self.IsSeedNode() || (self.acct != nil && self.gov.IsGovNodePubKey(self.acct.PublicKey))
+		seedOrGov := self.IsSeedNode() || (self.acct != nil && self.gov.IsGovNodePubKey(self.acct.PubKey()))
 		selfAddr := self.selfAddr
 		self.lock.Unlock()
 
@@ -30,6 +30,7 @@ import (
 // when need to check authorization, use CheckWitness
 // when smart contract execute trigger event, use PushNotifications push it to smart contract notifications
 // when need to invoke a smart contract, use AppCall to invoke it
+// when need to invoke an evm contract from other vm types, use CallEVMContract
 type ContextRef interface {
 	PushContext(context *Context)
 	CurrentContext() *Context
@@ -46,6 +47,7 @@ type ContextRef interface {
 	SetInternalErr()
 	IsInternalErr() bool
 	PutCrossStateHashes(hashes []common.Uint256)
+	CallEVMContract(caller, target common.Address, input []byte) ([]byte, error)
 }
 
 type Engine interface {
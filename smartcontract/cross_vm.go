@@ -0,0 +1,206 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package smartcontract
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	ethcomm "github.com/ethereum/go-ethereum/common"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	ctypes "github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/event"
+	evm2 "github.com/ontio/ontology/smartcontract/service/evm"
+	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/service/util"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/vm/crossvm_codec"
+	"github.com/ontio/ontology/vm/evm"
+	"github.com/ontio/ontology/vm/evm/errors"
+	"github.com/ontio/ontology/vm/evm/params"
+	neotypes "github.com/ontio/ontology/vm/neovm/types"
+)
+
+// CallEVMContract invoke evm contract from neovm or wasmvm contract
+// the evm call consumes the gas left in this smart contract, and its logs are pushed as notifies
+func (this *SmartContract) CallEVMContract(caller, target common.Address, input []byte) ([]byte, error) {
+	if this.Config.Height < config.GetCrossVMCallHeight() {
+		return nil, fmt.Errorf("[CallEVMContract] cross vm call is not enabled at height %d", this.Config.Height)
+	}
+	if !this.checkContexts() {
+		return nil, fmt.Errorf("%s", "engine over max limit!")
+	}
+	this.PushContext(&context.Context{ContractAddress: target})
+	defer this.PopContext()
+
+	var txHash common.Uint256
+	if this.Config.Tx != nil {
+		txHash = this.Config.Tx.Hash()
+	}
+	statedb := storage.NewStateDB(this.CacheDB, ethcomm.Hash(txHash), ethcomm.Hash(this.Config.BlockHash), ong.OngBalanceHandle{})
+	vmenv := this.newEVM(statedb)
+
+	ret, leftGas, err := vmenv.Call(evm.AccountRef(caller), ethcomm.Address(target), input, this.Gas, big.NewInt(0))
+	this.Gas = leftGas
+	if err != nil {
+		return nil, fmt.Errorf("[CallEVMContract] call evm contract %s failed: %s", target.ToHexString(), err)
+	}
+	if err = statedb.DbErr(); err != nil {
+		return nil, err
+	}
+	if err = statedb.CommitToCacheDB(); err != nil {
+		return nil, err
+	}
+
+	for _, log := range statedb.GetLogs() {
+		this.PushNotifications([]*event.NotifyEventInfo{event.NotifyEventInfoFromEvmLog(log)})
+	}
+	this.PushNotifications(statedb.GetNotifications())
+	this.PutCrossStateHashes(statedb.GetCrossStateHashes())
+
+	return ret, nil
+}
+
+// NewCrossVMCaller return the caller evm uses to reach neovm and wasmvm contracts through this smart contract
+func (this *SmartContract) NewCrossVMCaller(statedb *storage.StateDB) evm.CrossVMCaller {
+	return &crossVMCaller{sc: this, statedb: statedb}
+}
+
+func (this *SmartContract) newEVM(statedb *storage.StateDB) *evm.EVM {
+	chainConfig := params.GetChainConfig(config.DefConfig.P2PNode.EVMChainId)
+	blockContext := evm2.NewEVMBlockContext(this.Config.Height, this.Config.Time, this.Store)
+	txContext := evm.TxContext{GasPrice: big.NewInt(0)}
+	if this.Config.Tx != nil {
+		txContext.Origin = ethcomm.Address(this.Config.Tx.Payer)
+		txContext.GasPrice = new(big.Int).SetUint64(this.Config.Tx.GasPrice)
+	}
+
+	return evm.NewEVM(blockContext, txContext, statedb, chainConfig, evm.Config{CrossVM: this.NewCrossVMCaller(statedb)})
+}
+
+// crossVMCaller shares the gas budget of the smart contract with the evm,
+// notifies and cross chain state hashes of the callee are kept in the statedb so that they are dropped when the evm reverts
+type crossVMCaller struct {
+	sc      *SmartContract
+	statedb *storage.StateDB
+}
+
+func (self *crossVMCaller) CallContract(caller ethcomm.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	sc := self.sc
+	if sc.Config.Height < config.GetCrossVMCallHeight() {
+		return nil, gas, fmt.Errorf("cross vm call is not enabled at height %d", sc.Config.Height)
+	}
+	target, param, err := crossvm_codec.DeserializeCrossVMCall(input)
+	if err != nil {
+		return nil, gas, err
+	}
+
+	outerGas := sc.Gas
+	sc.Gas = gas
+	notifyIndex := len(sc.Notifications)
+	crossHashIndex := len(sc.CrossHashes)
+	sc.PushContext(&context.Context{ContractAddress: common.Address(caller)})
+	defer func() {
+		sc.PopContext()
+		sc.Gas = outerGas
+	}()
+
+	if !sc.CheckUseGas(neovm.APPCALL_GAS) {
+		return nil, 0, errors.ErrOutOfGas
+	}
+	ret, err := sc.callNonEVMContract(target, param)
+	if err != nil {
+		sc.Notifications = sc.Notifications[:notifyIndex]
+		sc.CrossHashes = sc.CrossHashes[:crossHashIndex]
+		return nil, sc.Gas, err
+	}
+
+	self.statedb.AddNotifications(sc.Notifications[notifyIndex:])
+	sc.Notifications = sc.Notifications[:notifyIndex]
+	self.statedb.AddCrossStateHashes(sc.CrossHashes[crossHashIndex:])
+	sc.CrossHashes = sc.CrossHashes[:crossHashIndex]
+
+	return ret, sc.Gas, nil
+}
+
+func (this *SmartContract) callNonEVMContract(target common.Address, param []byte) ([]byte, error) {
+	dep, _, err := this.CacheDB.GetContract(target)
+	if err != nil {
+		return nil, err
+	}
+	if dep == nil {
+		return nil, fmt.Errorf("contract %s is not exist", target.ToHexString())
+	}
+
+	if dep.VmType() == payload.WASMVM_TYPE {
+		list, err := crossvm_codec.DeserializeCallParam(param)
+		if err != nil {
+			return nil, err
+		}
+		params, ok := list.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("wasm invoke error: wrong param type:%s", reflect.TypeOf(list).String())
+		}
+		inputs, err := utils.BuildWasmVMInvokeCode(target, params)
+		if err != nil {
+			return nil, err
+		}
+		service, err := this.NewExecuteEngine(inputs, ctypes.InvokeWasm)
+		if err != nil {
+			return nil, err
+		}
+		res, err := service.Invoke()
+		if err != nil {
+			return nil, err
+		}
+		return res.([]byte), nil
+	}
+
+	evalstack, err := util.GenerateNeoVMParamEvalStack(param)
+	if err != nil {
+		return nil, err
+	}
+	service, err := this.NewExecuteEngine([]byte{}, ctypes.InvokeNeo)
+	if err != nil {
+		return nil, err
+	}
+	err = util.SetNeoServiceParamAndEngine(target, service, evalstack)
+	if err != nil {
+		return nil, err
+	}
+	res, err := service.Invoke()
+	if err != nil {
+		return nil, err
+	}
+
+	sink := common.NewZeroCopySink([]byte{crossvm_codec.VERSION})
+	if res != nil {
+		val := res.(*neotypes.VmValue)
+		err = neotypes.BuildResultFromNeo(*val, sink)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sink.Bytes(), nil
+}
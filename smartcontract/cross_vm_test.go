@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package smartcontract
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+
+	ethcomm "github.com/ethereum/go-ethereum/common"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/vm/crossvm_codec"
+	"github.com/ontio/ontology/vm/evm"
+	"github.com/stretchr/testify/assert"
+)
+
+var crossVMNativeAddress = common.AddressFromVmCode([]byte("cross vm test native"))
+
+func init() {
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	//native contract pushing a notify and a cross chain state hash, used as the final callee
+	native.Contracts[crossVMNativeAddress] = func(ns *native.NativeService) {
+		ns.Register("push", func(ns *native.NativeService) ([]byte, error) {
+			ns.PushCrossState([]byte("cross vm"))
+			ns.Notifications = append(ns.Notifications, &event.NotifyEventInfo{
+				ContractAddress: crossVMNativeAddress,
+				States:          "push",
+			})
+			return nutils.BYTE_TRUE, nil
+		})
+	}
+}
+
+// evm runtime code forwarding its call data to CrossVMCallAddress, it returns the success flag of the call as
+// a word, or reverts after the call if revert is set
+func forwardCode(revert bool) []byte {
+	code := "3660006000376000600036600060006" + "10c20" + "5af1"
+	if revert {
+		code += "5060006000fd"
+	} else {
+		code += "60005260206000f3"
+	}
+	buf, _ := hex.DecodeString(code)
+	return buf
+}
+
+// minimal wasm module exporting invoke, which traps if trap is set
+func wasmCode(trap bool) []byte {
+	code := "0061736d01000000" + "01040160000003020100070a0106696e766f6b650000"
+	if trap {
+		code += "0a0501030000" + "0b"
+	} else {
+		code += "0a040102000b"
+	}
+	buf, _ := hex.DecodeString(code)
+	return buf
+}
+
+func newCrossVMTestContract(t *testing.T) *SmartContract {
+	cache := storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore()))
+	statedb := storage.NewStateDB(cache, ethcomm.Hash{}, ethcomm.Hash{}, ong.OngBalanceHandle{})
+	statedb.SetCode(ethcomm.Address(forwardAddress(false)), forwardCode(false))
+	statedb.SetCode(ethcomm.Address(forwardAddress(true)), forwardCode(true))
+
+	deploy, err := payload.NewDeployCode(neoVMCode(t), payload.NEOVM_TYPE, "", "", "", "", "")
+	assert.Nil(t, err)
+	cache.PutContract(deploy)
+	for _, trap := range []bool{false, true} {
+		deploy, err = payload.NewDeployCode(wasmCode(trap), payload.WASMVM_TYPE, "", "", "", "", "")
+		assert.Nil(t, err)
+		cache.PutContract(deploy)
+	}
+
+	gasTable := make(map[string]uint64)
+	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		gasTable[k.(string)] = value.(uint64)
+		return true
+	})
+	return &SmartContract{
+		Config:       &Config{Height: 1, Tx: &types.Transaction{}},
+		CacheDB:      cache,
+		GasTable:     gasTable,
+		Gas:          100000000,
+		WasmExecStep: config.DEFAULT_WASM_MAX_STEPCOUNT,
+	}
+}
+
+func forwardAddress(revert bool) common.Address {
+	return common.AddressFromVmCode([]byte(fmt.Sprintf("forward %v", revert)))
+}
+
+func crossVMCallInput(target common.Address) []byte {
+	sink := common.NewZeroCopySink([]byte{crossvm_codec.VERSION})
+	_ = crossvm_codec.EncodeList(sink, []interface{}{})
+	return append(target[:], sink.Bytes()...)
+}
+
+func TestCrossVMCallNeoVM(t *testing.T) {
+	sc := newCrossVMTestContract(t)
+	neoAddress := common.AddressFromVmCode(neoVMCode(t))
+	caller := common.AddressFromVmCode([]byte("caller"))
+
+	gas := sc.Gas
+	ret, err := sc.CallEVMContract(caller, forwardAddress(false), crossVMCallInput(neoAddress))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), new(big.Int).SetBytes(ret).Uint64())
+	assert.True(t, gas-sc.Gas >= neovm.APPCALL_GAS)
+	assert.Equal(t, 1, len(sc.CrossHashes))
+	assert.Equal(t, 1, len(sc.Notifications))
+	assert.Equal(t, crossVMNativeAddress, sc.Notifications[0].ContractAddress)
+
+	//the callee succeeds but the evm frame reverts, so its notify and cross chain state are dropped
+	gas = sc.Gas
+	_, err = sc.CallEVMContract(caller, forwardAddress(true), crossVMCallInput(neoAddress))
+	assert.NotNil(t, err)
+	assert.True(t, gas-sc.Gas >= neovm.APPCALL_GAS)
+	assert.Equal(t, 1, len(sc.CrossHashes))
+	assert.Equal(t, 1, len(sc.Notifications))
+}
+
+func TestCrossVMCallWasm(t *testing.T) {
+	sc := newCrossVMTestContract(t)
+	caller := common.AddressFromVmCode([]byte("caller"))
+
+	ret, err := sc.CallEVMContract(caller, forwardAddress(false), crossVMCallInput(common.AddressFromVmCode(wasmCode(false))))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), new(big.Int).SetBytes(ret).Uint64())
+
+	//a failed callee only fails the evm CALL, which consumes all the gas given to it
+	gas := sc.Gas
+	ret, err = sc.CallEVMContract(caller, forwardAddress(false), crossVMCallInput(common.AddressFromVmCode(wasmCode(true))))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), new(big.Int).SetBytes(ret).Uint64())
+	assert.True(t, gas-sc.Gas > gas/2)
+
+	//the cross vm call address is only reachable by CALL
+	_, leftGas, err := sc.newEVM(storage.NewStateDB(sc.CacheDB, ethcomm.Hash{}, ethcomm.Hash{}, ong.OngBalanceHandle{})).
+		StaticCall(evm.AccountRef(caller), evm.CrossVMCallAddress, crossVMCallInput(common.AddressFromVmCode(wasmCode(false))), 100000)
+	assert.NotNil(t, err)
+	assert.Equal(t, uint64(0), leftGas)
+}
+
+// neovm contract invoking the test native contract
+func neoVMCode(t *testing.T) []byte {
+	code, err := utils.BuildNativeInvokeCode(crossVMNativeAddress, 0, "push", []interface{}{[]byte{1}})
+	assert.Nil(t, err)
+	return code
+}
@@ -660,4 +660,6 @@ func RegisterAuthContract(native *native.NativeService) {
 	native.Register("assignOntIDsToRole", AssignOntIDsToRole)
 	native.Register("verifyToken", VerifyToken)
 	native.Register("transfer", Transfer)
+	native.Register(GET_ROLE_FUNCS, GetRoleFuncs)
+	native.Register(GET_ONTID_TOKENS, GetOntIDTokens)
 }
@@ -0,0 +1,257 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	cstates "github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	GET_ROLE_FUNCS   = "getRoleFuncs"
+	GET_ONTID_TOKENS = "getOntIDTokens"
+)
+
+/* **********************************************   */
+type RoleFuncs struct {
+	Role      []byte
+	FuncNames []string
+}
+
+//ContractRoleFuncs is the admin of contract and the functions assigned to each role
+type ContractRoleFuncs struct {
+	ContractAddr common.Address
+	AdminOntID   []byte
+	Roles        []*RoleFuncs
+}
+
+func (this *ContractRoleFuncs) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.ContractAddr)
+	sink.WriteVarBytes(this.AdminOntID)
+	utils.EncodeVarUint(sink, uint64(len(this.Roles)))
+	for _, role := range this.Roles {
+		sink.WriteVarBytes(role.Role)
+		utils.EncodeVarUint(sink, uint64(len(role.FuncNames)))
+		for _, fn := range role.FuncNames {
+			sink.WriteString(fn)
+		}
+	}
+}
+
+func (this *ContractRoleFuncs) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.ContractAddr, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.AdminOntID, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("AdminOntID Deserialization error: %s", err)
+	}
+	roleLen, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	this.Roles = nil
+	for i := uint64(0); i < roleLen; i++ {
+		role := new(RoleFuncs)
+		if role.Role, err = utils.DecodeVarBytes(source); err != nil {
+			return fmt.Errorf("Role Deserialization error: %s", err)
+		}
+		fnLen, err := utils.DecodeVarUint(source)
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < fnLen; j++ {
+			fn, err := utils.DecodeString(source)
+			if err != nil {
+				return err
+			}
+			role.FuncNames = append(role.FuncNames, fn)
+		}
+		this.Roles = append(this.Roles, role)
+	}
+	return nil
+}
+
+/* **********************************************   */
+type OntIDToken struct {
+	Role       []byte
+	ExpireTime uint32
+	Level      uint8
+}
+
+type OntIDTokens struct {
+	OntID  []byte
+	Tokens []*OntIDToken
+}
+
+//ContractOntIDTokens is the roles held by each ONT ID of contract, with the expire time and the delegation level
+type ContractOntIDTokens struct {
+	ContractAddr common.Address
+	OntIDs       []*OntIDTokens
+}
+
+func (this *ContractOntIDTokens) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.ContractAddr)
+	utils.EncodeVarUint(sink, uint64(len(this.OntIDs)))
+	for _, ontID := range this.OntIDs {
+		sink.WriteVarBytes(ontID.OntID)
+		utils.EncodeVarUint(sink, uint64(len(ontID.Tokens)))
+		for _, token := range ontID.Tokens {
+			sink.WriteVarBytes(token.Role)
+			utils.EncodeVarUint(sink, uint64(token.ExpireTime))
+			utils.EncodeVarUint(sink, uint64(token.Level))
+		}
+	}
+}
+
+func (this *ContractOntIDTokens) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.ContractAddr, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	ontIDLen, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	this.OntIDs = nil
+	for i := uint64(0); i < ontIDLen; i++ {
+		ontID := new(OntIDTokens)
+		if ontID.OntID, err = utils.DecodeVarBytes(source); err != nil {
+			return fmt.Errorf("OntID Deserialization error: %s", err)
+		}
+		tokenLen, err := utils.DecodeVarUint(source)
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < tokenLen; j++ {
+			token := new(OntIDToken)
+			if token.Role, err = utils.DecodeVarBytes(source); err != nil {
+				return fmt.Errorf("Role Deserialization error: %s", err)
+			}
+			expireTime, err := utils.DecodeVarUint(source)
+			if err != nil || expireTime > uint64(^uint32(0)) {
+				return fmt.Errorf("ExpireTime Deserialization error: %v", err)
+			}
+			level, err := utils.DecodeVarUint(source)
+			if err != nil || level > uint64(^uint8(0)) {
+				return fmt.Errorf("Level Deserialization error: %v", err)
+			}
+			token.ExpireTime, token.Level = uint32(expireTime), uint8(level)
+			ontID.Tokens = append(ontID.Tokens, token)
+		}
+		this.OntIDs = append(this.OntIDs, ontID)
+	}
+	return nil
+}
+
+/*
+ * read only methods, which are only available in pre-execution
+ */
+func getRoleFuncs(native *native.NativeService, contractAddr common.Address) (*ContractRoleFuncs, error) {
+	admin, err := getContractAdmin(native, contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("getContractAdmin failed: %v", err)
+	}
+	ret := &ContractRoleFuncs{ContractAddr: contractAddr, AdminOntID: admin}
+
+	prefix := concatRoleFuncKey(native, contractAddr, nil)
+	iter := native.CacheDB.NewIterator(prefix)
+	defer iter.Release()
+	for has := iter.First(); has; has = iter.Next() {
+		value, err := cstates.GetValueFromRawStorageItem(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("get roleFuncs storage item failed: %v", err)
+		}
+		funcs := new(roleFuncs)
+		if err := funcs.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return nil, fmt.Errorf("deserialize roleFuncs object failed. data: %x", value)
+		}
+		role := append([]byte{}, iter.Key()[len(prefix):]...)
+		ret.Roles = append(ret.Roles, &RoleFuncs{Role: role, FuncNames: funcs.funcNames})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func GetRoleFuncs(native *native.NativeService) ([]byte, error) {
+	if !native.PreExec {
+		return nil, fmt.Errorf("[getRoleFuncs] only available in pre-execution")
+	}
+	contractAddr, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return nil, fmt.Errorf("[getRoleFuncs] deserialize param failed: %v", err)
+	}
+	ret, err := getRoleFuncs(native, contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("[getRoleFuncs] %v", err)
+	}
+	return common.SerializeToBytes(ret), nil
+}
+
+func getOntIDTokens(native *native.NativeService, contractAddr common.Address) (*ContractOntIDTokens, error) {
+	ret := &ContractOntIDTokens{ContractAddr: contractAddr}
+
+	prefix := concatOntIDTokenKey(native, contractAddr, nil)
+	iter := native.CacheDB.NewIterator(prefix)
+	defer iter.Release()
+	for has := iter.First(); has; has = iter.Next() {
+		value, err := cstates.GetValueFromRawStorageItem(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("get roleTokens storage item failed: %v", err)
+		}
+		tokens := new(roleTokens)
+		if err := tokens.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return nil, fmt.Errorf("deserialize roleTokens object failed. data: %x", value)
+		}
+		ontID := &OntIDTokens{OntID: append([]byte{}, iter.Key()[len(prefix):]...)}
+		for _, token := range tokens.tokens {
+			ontID.Tokens = append(ontID.Tokens, &OntIDToken{
+				Role:       token.role,
+				ExpireTime: token.expireTime,
+				Level:      token.level,
+			})
+		}
+		ret.OntIDs = append(ret.OntIDs, ontID)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func GetOntIDTokens(native *native.NativeService) ([]byte, error) {
+	if !native.PreExec {
+		return nil, fmt.Errorf("[getOntIDTokens] only available in pre-execution")
+	}
+	contractAddr, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return nil, fmt.Errorf("[getOntIDTokens] deserialize param failed: %v", err)
+	}
+	ret, err := getOntIDTokens(native, contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("[getOntIDTokens] %v", err)
+	}
+	return common.SerializeToBytes(ret), nil
+}
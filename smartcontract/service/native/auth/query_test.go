@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/testsuite"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryRolesAndTokens(t *testing.T) {
+	contract := testsuite.RandomAddress()
+	other := testsuite.RandomAddress()
+	testsuite.InvokeNativeContract(t, utils.AuthContractAddress, func(native *native.NativeService) ([]byte, error) {
+		assert.Nil(t, putContractAdmin(native, contract, []byte("did:ont:admin")))
+		assert.Nil(t, putRoleFunc(native, contract, []byte("role1"), &roleFuncs{[]string{"foo", "bar"}}))
+		assert.Nil(t, putRoleFunc(native, contract, []byte("role2"), &roleFuncs{[]string{"baz"}}))
+		assert.Nil(t, putRoleFunc(native, other, []byte("role3"), &roleFuncs{[]string{"qux"}}))
+		assert.Nil(t, putOntIDToken(native, contract, []byte("did:ont:user1"), &roleTokens{[]*AuthToken{
+			{role: []byte("role1"), expireTime: 100, level: 2},
+			{role: []byte("role2"), expireTime: 200, level: 1},
+		}}))
+		assert.Nil(t, putOntIDToken(native, other, []byte("did:ont:user2"), &roleTokens{[]*AuthToken{
+			{role: []byte("role3"), expireTime: 300, level: 1},
+		}}))
+
+		roles, err := getRoleFuncs(native, contract)
+		assert.Nil(t, err)
+		assert.Equal(t, &ContractRoleFuncs{
+			ContractAddr: contract,
+			AdminOntID:   []byte("did:ont:admin"),
+			Roles: []*RoleFuncs{
+				{Role: []byte("role1"), FuncNames: []string{"bar", "foo"}},
+				{Role: []byte("role2"), FuncNames: []string{"baz"}},
+			},
+		}, roles)
+		decodedRoles := new(ContractRoleFuncs)
+		assert.Nil(t, decodedRoles.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(roles))))
+		assert.Equal(t, roles, decodedRoles)
+
+		tokens, err := getOntIDTokens(native, contract)
+		assert.Nil(t, err)
+		assert.Equal(t, &ContractOntIDTokens{
+			ContractAddr: contract,
+			OntIDs: []*OntIDTokens{{OntID: []byte("did:ont:user1"), Tokens: []*OntIDToken{
+				{Role: []byte("role1"), ExpireTime: 100, Level: 2},
+				{Role: []byte("role2"), ExpireTime: 200, Level: 1},
+			}}},
+		}, tokens)
+		decodedTokens := new(ContractOntIDTokens)
+		assert.Nil(t, decodedTokens.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(tokens))))
+		assert.Equal(t, tokens, decodedTokens)
+
+		native.PreExec = false
+		_, err = GetRoleFuncs(native)
+		assert.NotNil(t, err)
+		return nil, nil
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/ontio/ontology/common"
@@ -50,6 +51,15 @@ func RegisterLockProxyContract(native *native.NativeService) {
 	native.Register(GET_ASSET_HASH_NAME, GetAssetHash)
 	native.Register(GET_CROSSED_AMOUNT_NAME, GetCrossedAmount)
 	native.Register(GET_CROSSED_LIMIT_NAME, GetCrossedLimit)
+	if native.Height < config.GetLockProxyQuotaHeight() {
+		return
+	}
+	native.Register(PAUSE_NAME, Pause)
+	native.Register(UNPAUSE_NAME, Unpause)
+	native.Register(IS_PAUSED_NAME, IsPaused)
+	native.Register(SET_QUOTA_NAME, SetQuota)
+	native.Register(GET_QUOTA_NAME, GetQuota)
+	native.Register(GET_REMAINING_QUOTA_NAME, GetRemainingQuota)
 }
 
 func BindProxyHash(native *native.NativeService) ([]byte, error) {
@@ -148,6 +158,9 @@ func Lock(native *native.NativeService) ([]byte, error) {
 	if lockParam.Value == 0 {
 		return utils.BYTE_FALSE, nil
 	}
+	if err := checkPaused(native, contract); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Lock] %s", err)
+	}
 	// currently, only support ont and ong lock operation
 	if lockParam.SourceAssetHash != ontContract && lockParam.SourceAssetHash != ongContract {
 		return utils.BYTE_FALSE, fmt.Errorf("[Lock] only support ont/ong lock, expect:%s or %s, but got:%s", hex.EncodeToString(ontContract[:]), hex.EncodeToString(ongContract[:]), hex.EncodeToString(lockParam.SourceAssetHash[:]))
@@ -179,6 +192,9 @@ func Lock(native *native.NativeService) ([]byte, error) {
 	}
 	// increase the new crossed amount by Value
 	native.CacheDB.Put(GenCrossedAmountKey(contract, lockParam.SourceAssetHash, lockParam.ToChainID), utils.GenVarBytesStorageItem(newCrossedAmount.Bytes()).ToArray())
+	if err := consumeQuota(native, contract, lockParam.SourceAssetHash, lockParam.ToChainID, lockParam.Value, false); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Lock] %s", err)
+	}
 
 	// get target chain proxy hash from storage
 	targetProxyHashBs, err := utils.GetStorageVarBytes(native, GenBindProxyKey(contract, lockParam.ToChainID))
@@ -215,6 +231,9 @@ func Unlock(native *native.NativeService) ([]byte, error) {
 	if !native.ContextRef.CheckWitness(utils.CrossChainContractAddress) {
 		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] can ONLY be invoked by CrossChainContractAddress:%s Contract, checkwitness failed!", hex.EncodeToString(utils.CrossChainContractAddress[:]))
 	}
+	if err := checkPaused(native, contract); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] %s", err)
+	}
 	ontContract := utils.OntContractAddress
 	ongContract := utils.OngContractAddress
 
@@ -269,6 +288,9 @@ func Unlock(native *native.NativeService) ([]byte, error) {
 	}
 	// decrease the new crossed amount by Value
 	native.CacheDB.Put(GenCrossedAmountKey(contract, assetAddress, unlockParam.FromChainId), utils.GenVarBytesStorageItem(newCrossedAmount.Bytes()).ToArray())
+	if err := consumeQuota(native, contract, assetAddress, unlockParam.FromChainId, args.Value, true); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] %s", err)
+	}
 
 	AddUnLockNotifications(native, contract, unlockParam.FromChainId, unlockParam.FromContractHashBs, assetAddress, toAddress, args.Value)
 
@@ -380,3 +402,118 @@ func GetCrossedLimit(native *native.NativeService) ([]byte, error) {
 	}
 	return common.BigIntToNeoBytes(big.NewInt(0).SetBytes(crossedLimitBs)), nil
 }
+
+// Pause stop lock and unlock in emergency, such as an exploit of header sync
+func Pause(native *native.NativeService) ([]byte, error) {
+	return setPaused(native, PAUSE_NAME, true)
+}
+
+func Unpause(native *native.NativeService) ([]byte, error) {
+	return setPaused(native, UNPAUSE_NAME, false)
+}
+
+func setPaused(native *native.NativeService, method string, paused bool) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	if err := validateOperator(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[%s] %s", method, err)
+	}
+	value := utils.BYTE_FALSE
+	if paused {
+		value = utils.BYTE_TRUE
+	}
+	native.CacheDB.Put(GenPausedKey(contract), utils.GenVarBytesStorageItem(value).ToArray())
+	if config.DefConfig.Common.EnableEventLog {
+		native.Notifications = append(native.Notifications,
+			&event.NotifyEventInfo{
+				ContractAddress: contract,
+				States:          []interface{}{method},
+			})
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+func IsPaused(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	paused, err := isPaused(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[IsPaused] %s", err)
+	}
+	if paused {
+		return utils.BYTE_TRUE, nil
+	}
+	return utils.BYTE_FALSE, nil
+}
+
+// SetQuota set the max amount of asset locked to the chain and the max amount unlocked from it in the rolling window,
+// the lock and unlock are counted separately
+func SetQuota(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param SetQuotaParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetQuota] Deserialization SetQuotaParam error:%s", err)
+	}
+	if err := validateOperator(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetQuota] %s", err)
+	}
+	if param.Quota == 0 {
+		native.CacheDB.Delete(GenQuotaKey(contract, param.SourceAssetHash, param.ChainId))
+		native.CacheDB.Delete(GenQuotaUsageKey(contract, param.SourceAssetHash, param.ChainId, false))
+		native.CacheDB.Delete(GenQuotaUsageKey(contract, param.SourceAssetHash, param.ChainId, true))
+	} else {
+		if param.Window == 0 || param.Window > math.MaxUint32 {
+			return utils.BYTE_FALSE, fmt.Errorf("[SetQuota] window:%d should be from 1 to %d seconds", param.Window, uint32(math.MaxUint32))
+		}
+		quota := &Quota{Quota: param.Quota, Window: param.Window}
+		sink := common.NewZeroCopySink(nil)
+		quota.Serialization(sink)
+		native.CacheDB.Put(GenQuotaKey(contract, param.SourceAssetHash, param.ChainId), utils.GenVarBytesStorageItem(sink.Bytes()).ToArray())
+	}
+	if config.DefConfig.Common.EnableEventLog {
+		native.Notifications = append(native.Notifications,
+			&event.NotifyEventInfo{
+				ContractAddress: contract,
+				States:          []interface{}{SET_QUOTA_NAME, hex.EncodeToString(param.SourceAssetHash[:]), param.ChainId, param.Quota, param.Window},
+			})
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// GetQuota return the serialized quota of asset and chain, empty if the quota is not set
+func GetQuota(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param QuotaParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetQuota] Deserialization QuotaParam error:%s", err)
+	}
+	quota, err := getQuota(native, contract, param.SourceAssetHash, param.ChainId)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetQuota] %s", err)
+	}
+	if quota == nil {
+		return []byte{}, nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	quota.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+// GetRemainingQuota return the amount of asset can be locked to or unlocked from the chain in current window
+func GetRemainingQuota(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param RemainingQuotaParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRemainingQuota] Deserialization RemainingQuotaParam error:%s", err)
+	}
+	quota, err := getQuota(native, contract, param.SourceAssetHash, param.ChainId)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRemainingQuota] %s", err)
+	}
+	if quota == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRemainingQuota] no quota of asset:%s with chainId:%d", hex.EncodeToString(param.SourceAssetHash[:]), param.ChainId)
+	}
+	_, used, err := getQuotaUsage(native, contract, param.SourceAssetHash, param.ChainId, param.Unlock, quota)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRemainingQuota] %s", err)
+	}
+	return common.BigIntToNeoBytes(big.NewInt(0).SetUint64(remainingQuota(quota, used))), nil
+}
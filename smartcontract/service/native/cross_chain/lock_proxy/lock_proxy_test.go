@@ -0,0 +1,189 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package lock_proxy
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/global_params"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNative(operator common.Address, signer common.Address) *native.NativeService {
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore()))
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeAddress(sink, operator)
+	db.Put(global_params.GenerateOperatorKey(utils.ParamContractAddress), (&states.StorageItem{Value: sink.Bytes()}).ToArray())
+	sc := &smartcontract.SmartContract{
+		Config: &smartcontract.Config{
+			Tx: &types.Transaction{SignedAddr: []common.Address{signer}},
+		},
+	}
+	sc.PushContext(&context.Context{ContractAddress: utils.LockProxyContractAddress})
+	return &native.NativeService{CacheDB: db, ContextRef: sc}
+}
+
+func setQuota(ns *native.NativeService, quota, window uint64) error {
+	param := SetQuotaParam{SourceAssetHash: utils.OntContractAddress, ChainId: 2, Quota: quota, Window: window}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns.Input = sink.Bytes()
+	_, err := SetQuota(ns)
+	return err
+}
+
+func remaining(t *testing.T, ns *native.NativeService, unlock bool) uint64 {
+	param := RemainingQuotaParam{SourceAssetHash: utils.OntContractAddress, ChainId: 2, Unlock: unlock}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns.Input = sink.Bytes()
+	res, err := GetRemainingQuota(ns)
+	assert.Nil(t, err)
+	return common.BigIntFromNeoBytes(res).Uint64()
+}
+
+func TestPause(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	operator := account.NewAccount("").Address
+	ns := newTestNative(operator, account.NewAccount("").Address)
+	_, err := Pause(ns)
+	assert.NotNil(t, err)
+
+	ns = newTestNative(operator, operator)
+	res, err := IsPaused(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_FALSE, res)
+
+	_, err = Pause(ns)
+	assert.Nil(t, err)
+	res, _ = IsPaused(ns)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	lockParam := LockParam{SourceAssetHash: utils.OntContractAddress, FromAddress: operator, ToChainID: 2, ToAddress: operator[:], Value: 1}
+	sink := common.NewZeroCopySink(nil)
+	lockParam.Serialization(sink)
+	ns.Input = sink.Bytes()
+	_, err = Lock(ns)
+	assert.EqualError(t, err, "[Lock] lock proxy is paused")
+
+	_, err = Unpause(ns)
+	assert.Nil(t, err)
+	res, _ = IsPaused(ns)
+	assert.Equal(t, utils.BYTE_FALSE, res)
+}
+
+func TestQuota(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	operator := account.NewAccount("").Address
+	ns := newTestNative(operator, account.NewAccount("").Address)
+	assert.NotNil(t, setQuota(ns, 100, 2400))
+
+	ns = newTestNative(operator, operator)
+	contract := utils.LockProxyContractAddress
+	asset := utils.OntContractAddress
+	// no quota
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 1000, false))
+	assert.NotNil(t, setQuota(ns, 100, 0))
+	assert.Nil(t, setQuota(ns, 100, 2400))
+	assert.Equal(t, uint64(100), remaining(t, ns, false))
+
+	ns.Time = 1000
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 60, false))
+	ns.Time = 1050
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 30, false))
+	assert.NotNil(t, consumeQuota(ns, contract, asset, 2, 20, false))
+	// quota of other chain is not affected
+	assert.Nil(t, consumeQuota(ns, contract, asset, 3, 20, false))
+	assert.Equal(t, uint64(10), remaining(t, ns, false))
+
+	// the unlock is counted separately, so the locks do not use up the quota of unlocks and vice versa
+	assert.Equal(t, uint64(100), remaining(t, ns, true))
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 70, true))
+	assert.NotNil(t, consumeQuota(ns, contract, asset, 2, 40, true))
+	assert.Equal(t, uint64(30), remaining(t, ns, true))
+	assert.Equal(t, uint64(10), remaining(t, ns, false))
+
+	// bucket size is 100s, the amount crossed in bucket 1000 expires at 3400
+	ns.Time = 3399
+	assert.Equal(t, uint64(10), remaining(t, ns, false))
+	ns.Time = 3400
+	assert.Equal(t, uint64(100), remaining(t, ns, false))
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 100, false))
+	assert.Equal(t, uint64(0), remaining(t, ns, false))
+	assert.Equal(t, uint64(100), remaining(t, ns, true))
+
+	// remove quota
+	assert.Nil(t, setQuota(ns, 0, 0))
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 1000, false))
+	assert.Nil(t, consumeQuota(ns, contract, asset, 2, 1000, true))
+	param := RemainingQuotaParam{SourceAssetHash: asset, ChainId: 2}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns.Input = sink.Bytes()
+	_, err := GetRemainingQuota(ns)
+	assert.NotNil(t, err)
+	param2 := QuotaParam{SourceAssetHash: asset, ChainId: 2}
+	sink = common.NewZeroCopySink(nil)
+	param2.Serialization(sink)
+	ns.Input = sink.Bytes()
+	res, err := GetQuota(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
+func TestQuotaHeight(t *testing.T) {
+	operator := account.NewAccount("").Address
+	ns := newTestNative(operator, operator)
+	ns.ServiceMap = make(map[string]native.Handler)
+	ns.Height = config.GetLockProxyQuotaHeight() - 1
+	RegisterLockProxyContract(ns)
+	assert.NotNil(t, ns.ServiceMap[LOCK_NAME])
+	for _, method := range []string{PAUSE_NAME, UNPAUSE_NAME, IS_PAUSED_NAME, SET_QUOTA_NAME, GET_QUOTA_NAME, GET_REMAINING_QUOTA_NAME} {
+		assert.Nil(t, ns.ServiceMap[method], method)
+	}
+
+	// the pause and quota in state are not checked before the height
+	ns.CacheDB.Put(GenPausedKey(utils.LockProxyContractAddress), utils.GenVarBytesStorageItem(utils.BYTE_TRUE).ToArray())
+	assert.Nil(t, checkPaused(ns, utils.LockProxyContractAddress))
+	assert.Nil(t, setQuota(ns, 100, 2400))
+	assert.Nil(t, consumeQuota(ns, utils.LockProxyContractAddress, utils.OntContractAddress, 2, 1000, false))
+
+	ns.Height = config.GetLockProxyQuotaHeight()
+	RegisterLockProxyContract(ns)
+	assert.NotNil(t, ns.ServiceMap[PAUSE_NAME])
+	assert.NotNil(t, checkPaused(ns, utils.LockProxyContractAddress))
+	assert.NotNil(t, consumeQuota(ns, utils.LockProxyContractAddress, utils.OntContractAddress, 2, 1000, false))
+}
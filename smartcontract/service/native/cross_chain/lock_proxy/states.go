@@ -190,3 +190,144 @@ func (this *BindAssetParam) Deserialization(source *common.ZeroCopySource) error
 	}
 	return nil
 }
+
+type SetQuotaParam struct {
+	SourceAssetHash common.Address
+	ChainId         uint64
+	Quota           uint64 // max amount locked to the chain and max amount unlocked from it in window, 0 removes the quota
+	Window          uint64 // length of rolling window in seconds
+}
+
+func (this *SetQuotaParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.SourceAssetHash)
+	utils.EncodeVarUint(sink, this.ChainId)
+	utils.EncodeVarUint(sink, this.Quota)
+	utils.EncodeVarUint(sink, this.Window)
+}
+
+func (this *SetQuotaParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.SourceAssetHash, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("SetQuotaParam.Deserialization DecodeAddress SourceAssetHash error:%s", err)
+	}
+	if this.ChainId, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("SetQuotaParam.Deserialization DecodeVarUint ChainId error:%s", err)
+	}
+	if this.Quota, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("SetQuotaParam.Deserialization DecodeVarUint Quota error:%s", err)
+	}
+	if this.Window, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("SetQuotaParam.Deserialization DecodeVarUint Window error:%s", err)
+	}
+	return nil
+}
+
+type QuotaParam struct {
+	SourceAssetHash common.Address
+	ChainId         uint64
+}
+
+func (this *QuotaParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.SourceAssetHash)
+	utils.EncodeVarUint(sink, this.ChainId)
+}
+
+func (this *QuotaParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.SourceAssetHash, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("QuotaParam.Deserialization DecodeAddress SourceAssetHash error:%s", err)
+	}
+	if this.ChainId, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("QuotaParam.Deserialization DecodeVarUint ChainId error:%s", err)
+	}
+	return nil
+}
+
+// RemainingQuotaParam query the remaining lock quota, or the unlock quota if Unlock is set
+type RemainingQuotaParam struct {
+	SourceAssetHash common.Address
+	ChainId         uint64
+	Unlock          bool
+}
+
+func (this *RemainingQuotaParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.SourceAssetHash)
+	utils.EncodeVarUint(sink, this.ChainId)
+	utils.EncodeBool(sink, this.Unlock)
+}
+
+func (this *RemainingQuotaParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.SourceAssetHash, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("RemainingQuotaParam.Deserialization DecodeAddress SourceAssetHash error:%s", err)
+	}
+	if this.ChainId, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("RemainingQuotaParam.Deserialization DecodeVarUint ChainId error:%s", err)
+	}
+	if this.Unlock, err = utils.DecodeBool(source); err != nil {
+		return fmt.Errorf("RemainingQuotaParam.Deserialization DecodeBool Unlock error:%s", err)
+	}
+	return nil
+}
+
+// Quota of an asset and a chain
+type Quota struct {
+	Quota  uint64
+	Window uint64
+}
+
+func (this *Quota) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.Quota)
+	utils.EncodeVarUint(sink, this.Window)
+}
+
+func (this *Quota) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Quota, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Quota.Deserialization DecodeVarUint Quota error:%s", err)
+	}
+	if this.Window, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Quota.Deserialization DecodeVarUint Window error:%s", err)
+	}
+	return nil
+}
+
+// QuotaRecord is the amount crossed in the bucket starting from Time
+type QuotaRecord struct {
+	Time   uint32
+	Amount uint64
+}
+
+// QuotaUsage is the amount crossed in the buckets of rolling window, the oldest first
+type QuotaUsage struct {
+	Records []QuotaRecord
+}
+
+func (this *QuotaUsage) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.Records)))
+	for _, record := range this.Records {
+		utils.EncodeVarUint(sink, uint64(record.Time))
+		utils.EncodeVarUint(sink, record.Amount)
+	}
+}
+
+func (this *QuotaUsage) Deserialization(source *common.ZeroCopySource) error {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("QuotaUsage.Deserialization DecodeVarUint length error:%s", err)
+	}
+	records := make([]QuotaRecord, 0, n)
+	for i := uint64(0); i < n; i++ {
+		t, err := utils.DecodeVarUint(source)
+		if err != nil {
+			return fmt.Errorf("QuotaUsage.Deserialization DecodeVarUint Time error:%s", err)
+		}
+		amount, err := utils.DecodeVarUint(source)
+		if err != nil {
+			return fmt.Errorf("QuotaUsage.Deserialization DecodeVarUint Amount error:%s", err)
+		}
+		records = append(records, QuotaRecord{Time: uint32(t), Amount: amount})
+	}
+	this.Records = records
+	return nil
+}
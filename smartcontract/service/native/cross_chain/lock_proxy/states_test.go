@@ -103,3 +103,46 @@ func TestBindAssetParam_Serialize(t *testing.T) {
 	}
 	assert.Equal(t, bindAssetParam, bindAssetParam2)
 }
+
+func TestSetQuotaParam_Serialize(t *testing.T) {
+	param := SetQuotaParam{
+		SourceAssetHash: utils.OngContractAddress,
+		ChainId:         2,
+		Quota:           constants.ONG_TOTAL_SUPPLY,
+		Window:          86400,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	param2 := SetQuotaParam{}
+	if err := param2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("SetQuotaParam deserialize fail!")
+	}
+	assert.Equal(t, param, param2)
+}
+
+func TestRemainingQuotaParam_Serialize(t *testing.T) {
+	param := RemainingQuotaParam{SourceAssetHash: utils.OngContractAddress, ChainId: 2, Unlock: true}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	param2 := RemainingQuotaParam{}
+	if err := param2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("RemainingQuotaParam deserialize fail!")
+	}
+	assert.Equal(t, param, param2)
+}
+
+func TestQuotaUsage_Serialize(t *testing.T) {
+	usage := QuotaUsage{
+		Records: []QuotaRecord{{Time: 3600, Amount: 100}, {Time: 7200, Amount: 0}},
+	}
+	sink := common.NewZeroCopySink(nil)
+	usage.Serialization(sink)
+
+	usage2 := QuotaUsage{}
+	if err := usage2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("QuotaUsage deserialize fail!")
+	}
+	assert.Equal(t, usage, usage2)
+}
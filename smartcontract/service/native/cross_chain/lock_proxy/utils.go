@@ -28,6 +28,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/event"
 	"github.com/ontio/ontology/smartcontract/service/native"
 	"github.com/ontio/ontology/smartcontract/service/native/cross_chain/cross_chain_manager"
+	"github.com/ontio/ontology/smartcontract/service/native/global_params"
 	"github.com/ontio/ontology/smartcontract/service/native/ont"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
 )
@@ -43,9 +44,23 @@ const (
 	GET_CROSSED_LIMIT_NAME  = "getCrossedLimit"
 	GET_CROSSED_AMOUNT_NAME = "getCrossedAmount"
 
-	TARGET_ASSET_HASH_PEFIX = "TargetAssetHash"
-	CROSS_LIMIT_PREFIX      = "AssetCrossLimit"
-	CROSS_AMOUNT_PREFIX     = "AssetCrossedAmount"
+	PAUSE_NAME               = "pause"
+	UNPAUSE_NAME             = "unpause"
+	IS_PAUSED_NAME           = "isPaused"
+	SET_QUOTA_NAME           = "setQuota"
+	GET_QUOTA_NAME           = "getQuota"
+	GET_REMAINING_QUOTA_NAME = "getRemainingQuota"
+
+	TARGET_ASSET_HASH_PEFIX   = "TargetAssetHash"
+	CROSS_LIMIT_PREFIX        = "AssetCrossLimit"
+	CROSS_AMOUNT_PREFIX       = "AssetCrossedAmount"
+	PAUSED_PREFIX             = "Paused"
+	QUOTA_PREFIX              = "AssetQuota"
+	LOCK_QUOTA_USAGE_PREFIX   = "AssetLockQuotaUsage"
+	UNLOCK_QUOTA_USAGE_PREFIX = "AssetUnlockQuotaUsage"
+
+	// the rolling window of quota is divided into buckets, the amount crossed in a bucket expires together
+	QUOTA_BUCKETS = 24
 )
 
 func AddLockNotifications(native *native.NativeService, contract, sourceAssetAddress common.Address, toChainId uint64, toContract []byte, targetAssetHash []byte, fromAddress common.Address, toAddress []byte, amount uint64) {
@@ -155,3 +170,155 @@ func getTransferFromInput(toAddress common.Address, value uint64) []byte {
 	transferFromState.Serialization(sink)
 	return sink.Bytes()
 }
+
+func GenPausedKey(contract common.Address) []byte {
+	return append(contract[:], []byte(PAUSED_PREFIX)...)
+}
+
+func GenQuotaKey(contract, assetContract common.Address, chainId uint64) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(chainId)
+	chainIdBytes := sink.Bytes()
+	temp := append(contract[:], []byte(QUOTA_PREFIX)...)
+	temp = append(temp, assetContract[:]...)
+	return append(temp, chainIdBytes...)
+}
+
+func GenQuotaUsageKey(contract, assetContract common.Address, chainId uint64, unlock bool) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(chainId)
+	chainIdBytes := sink.Bytes()
+	prefix := LOCK_QUOTA_USAGE_PREFIX
+	if unlock {
+		prefix = UNLOCK_QUOTA_USAGE_PREFIX
+	}
+	temp := append(contract[:], []byte(prefix)...)
+	temp = append(temp, assetContract[:]...)
+	return append(temp, chainIdBytes...)
+}
+
+// validateOperator check the witness of the operator of global param contract, which may be a multisig address
+func validateOperator(native *native.NativeService) error {
+	operatorAddress, err := global_params.GetStorageRole(native,
+		global_params.GenerateOperatorKey(utils.ParamContractAddress))
+	if err != nil {
+		return fmt.Errorf("get operator error:%s", err)
+	}
+	if err = utils.ValidateOwner(native, operatorAddress); err != nil {
+		return fmt.Errorf("checkWitness error:%s", err)
+	}
+	return nil
+}
+
+// checkPaused return error if lock proxy is paused, it can not be paused before the lock proxy quota height
+func checkPaused(native *native.NativeService, contract common.Address) error {
+	if native.Height < config.GetLockProxyQuotaHeight() {
+		return nil
+	}
+	paused, err := isPaused(native, contract)
+	if err != nil {
+		return err
+	}
+	if paused {
+		return fmt.Errorf("lock proxy is paused")
+	}
+	return nil
+}
+
+func isPaused(native *native.NativeService, contract common.Address) (bool, error) {
+	value, err := utils.GetStorageVarBytes(native, GenPausedKey(contract))
+	if err != nil {
+		return false, fmt.Errorf("isPaused, error:%s", err)
+	}
+	return len(value) != 0 && value[0] != 0, nil
+}
+
+// getQuota return the quota of asset and chain, nil is returned if the quota is not set
+func getQuota(native *native.NativeService, contract, assetContract common.Address, chainId uint64) (*Quota, error) {
+	value, err := utils.GetStorageVarBytes(native, GenQuotaKey(contract, assetContract, chainId))
+	if err != nil {
+		return nil, fmt.Errorf("getQuota, error:%s", err)
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	quota := new(Quota)
+	if err := quota.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, fmt.Errorf("getQuota, deserialize quota error:%s", err)
+	}
+	return quota, nil
+}
+
+// getQuotaUsage return the amount locked or unlocked in the rolling window ending at current block time
+func getQuotaUsage(native *native.NativeService, contract, assetContract common.Address, chainId uint64, unlock bool,
+	quota *Quota) (*QuotaUsage, uint64, error) {
+	value, err := utils.GetStorageVarBytes(native, GenQuotaUsageKey(contract, assetContract, chainId, unlock))
+	if err != nil {
+		return nil, 0, fmt.Errorf("getQuotaUsage, error:%s", err)
+	}
+	usage := new(QuotaUsage)
+	if len(value) != 0 {
+		if err := usage.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return nil, 0, fmt.Errorf("getQuotaUsage, deserialize usage error:%s", err)
+		}
+	}
+	records := usage.Records[:0]
+	used := uint64(0)
+	for _, record := range usage.Records {
+		if uint64(record.Time)+quota.Window > uint64(native.Time) {
+			records = append(records, record)
+			used += record.Amount
+		}
+	}
+	usage.Records = records
+	return usage, used, nil
+}
+
+func remainingQuota(quota *Quota, used uint64) uint64 {
+	if used >= quota.Quota {
+		return 0
+	}
+	return quota.Quota - used
+}
+
+// consumeQuota add amount to the lock or unlock quota usage of asset and chain, error is returned if it exceeds the
+// quota. The quota is not checked before the lock proxy quota height
+func consumeQuota(native *native.NativeService, contract, assetContract common.Address, chainId uint64, amount uint64,
+	unlock bool) error {
+	if native.Height < config.GetLockProxyQuotaHeight() {
+		return nil
+	}
+	quota, err := getQuota(native, contract, assetContract, chainId)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return nil
+	}
+	usage, used, err := getQuotaUsage(native, contract, assetContract, chainId, unlock, quota)
+	if err != nil {
+		return err
+	}
+	if remaining := remainingQuota(quota, used); amount > remaining {
+		direction := "lock"
+		if unlock {
+			direction = "unlock"
+		}
+		return fmt.Errorf("amount:%d exceeds remaining %s quota:%d of asset:%s with chainId:%d", amount, direction,
+			remaining, hex.EncodeToString(assetContract[:]), chainId)
+	}
+	bucketSize := quota.Window / QUOTA_BUCKETS
+	if bucketSize == 0 {
+		bucketSize = 1
+	}
+	bucket := native.Time - uint32(uint64(native.Time)%bucketSize)
+	if n := len(usage.Records); n > 0 && usage.Records[n-1].Time == bucket {
+		usage.Records[n-1].Amount += amount
+	} else {
+		usage.Records = append(usage.Records, QuotaRecord{Time: bucket, Amount: amount})
+	}
+	sink := common.NewZeroCopySink(nil)
+	usage.Serialization(sink)
+	native.CacheDB.Put(GenQuotaUsageKey(contract, assetContract, chainId, unlock), utils.GenVarBytesStorageItem(sink.Bytes()).ToArray())
+	return nil
+}
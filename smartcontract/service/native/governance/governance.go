@@ -81,6 +81,8 @@ const (
 	GET_PEER_POOL                    = "getPeerPool"
 	GET_PEER_INFO                    = "getPeerInfo"
 	GET_PEER_POOL_BY_ADDRESS         = "getPeerPoolByAddress"
+	GET_STAKING_INFO                 = "getStakingInfo"
+	ESTIMATE_REWARDS                 = "estimateRewards"
 
 	//key prefix
 	GLOBAL_PARAM      = "globalParam"
@@ -162,6 +164,8 @@ func RegisterGovernanceContract(native *native.NativeService) {
 	native.Register(GET_PEER_POOL, GetPeerPool)
 	native.Register(GET_PEER_INFO, GetPeerInfo)
 	native.Register(GET_PEER_POOL_BY_ADDRESS, GetPeerPoolByAddress)
+	native.Register(GET_STAKING_INFO, GetStakingInfo)
+	native.Register(ESTIMATE_REWARDS, EstimateRewards)
 }
 
 //Init governance contract, include vbft config, global param and ontid admin.
@@ -0,0 +1,349 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+type EstimateRewardsParam struct {
+	Address    common.Address
+	PeerPubkey string
+}
+
+func (this *EstimateRewardsParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Address[:])
+	sink.WriteString(this.PeerPubkey)
+}
+
+func (this *EstimateRewardsParam) Deserialization(source *common.ZeroCopySource) error {
+	address, err := utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeAddress, deserialize address error: %v", err)
+	}
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeString, deserialize peerPubkey error: %v", err)
+	}
+	this.Address = address
+	this.PeerPubkey = peerPubkey
+	return nil
+}
+
+//PendingUnlock is the unAuthorized pos which can be withdrawn when governance view reaches View
+type PendingUnlock struct {
+	View uint32
+	Pos  uint64
+}
+
+//StakingInfo is the stake of address in governance contract at View
+type StakingInfo struct {
+	Address      common.Address
+	View         uint32
+	TotalStake   uint64
+	ClaimablePos uint64 //unfrozen pos which can be withdrawn now
+	ClaimableFee uint64 //ong split from governance fee which can be withdrawn now
+	Authorizes   []*AuthorizeInfo
+	Unlocks      []*PendingUnlock
+}
+
+func (this *StakingInfo) Serialization(sink *common.ZeroCopySink) {
+	this.Address.Serialization(sink)
+	sink.WriteUint32(this.View)
+	sink.WriteUint64(this.TotalStake)
+	sink.WriteUint64(this.ClaimablePos)
+	sink.WriteUint64(this.ClaimableFee)
+	utils.EncodeVarUint(sink, uint64(len(this.Authorizes)))
+	for _, authorize := range this.Authorizes {
+		authorize.Serialization(sink)
+	}
+	utils.EncodeVarUint(sink, uint64(len(this.Unlocks)))
+	for _, unlock := range this.Unlocks {
+		sink.WriteUint32(unlock.View)
+		sink.WriteUint64(unlock.Pos)
+	}
+}
+
+func (this *StakingInfo) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.Address.Deserialization(source); err != nil {
+		return fmt.Errorf("address.Deserialization, deserialize address error: %v", err)
+	}
+	var err error
+	if this.View, err = utils.DecodeUint32(source); err != nil {
+		return fmt.Errorf("utils.DecodeUint32, deserialize view error: %v", err)
+	}
+	if this.TotalStake, err = utils.DecodeUint64(source); err != nil {
+		return fmt.Errorf("utils.DecodeUint64, deserialize totalStake error: %v", err)
+	}
+	if this.ClaimablePos, err = utils.DecodeUint64(source); err != nil {
+		return fmt.Errorf("utils.DecodeUint64, deserialize claimablePos error: %v", err)
+	}
+	if this.ClaimableFee, err = utils.DecodeUint64(source); err != nil {
+		return fmt.Errorf("utils.DecodeUint64, deserialize claimableFee error: %v", err)
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize authorizes length error: %v", err)
+	}
+	this.Authorizes = nil
+	for i := uint64(0); i < n; i++ {
+		authorize := new(AuthorizeInfo)
+		if err := authorize.Deserialization(source); err != nil {
+			return fmt.Errorf("deserialize authorizeInfo error: %v", err)
+		}
+		this.Authorizes = append(this.Authorizes, authorize)
+	}
+	n, err = utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize unlocks length error: %v", err)
+	}
+	this.Unlocks = nil
+	for i := uint64(0); i < n; i++ {
+		unlock := new(PendingUnlock)
+		if unlock.View, err = utils.DecodeUint32(source); err != nil {
+			return fmt.Errorf("utils.DecodeUint32, deserialize unlock view error: %v", err)
+		}
+		if unlock.Pos, err = utils.DecodeUint64(source); err != nil {
+			return fmt.Errorf("utils.DecodeUint64, deserialize unlock pos error: %v", err)
+		}
+		this.Unlocks = append(this.Unlocks, unlock)
+	}
+	return nil
+}
+
+//RewardsEstimate is the ong split to address if the current view is committed now
+type RewardsEstimate struct {
+	Address     common.Address
+	PeerPubkey  string
+	View        uint32
+	PeerReward  int64  //ong split from the peer, negative if the split from other peers is more without the peer
+	TotalReward uint64 //ong split from all the peers
+}
+
+func (this *RewardsEstimate) Serialization(sink *common.ZeroCopySink) {
+	this.Address.Serialization(sink)
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteUint32(this.View)
+	sink.WriteUint64(uint64(this.PeerReward))
+	sink.WriteUint64(this.TotalReward)
+}
+
+func (this *RewardsEstimate) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.Address.Deserialization(source); err != nil {
+		return fmt.Errorf("address.Deserialization, deserialize address error: %v", err)
+	}
+	var err error
+	if this.PeerPubkey, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("utils.DecodeString, deserialize peerPubkey error: %v", err)
+	}
+	if this.View, err = utils.DecodeUint32(source); err != nil {
+		return fmt.Errorf("utils.DecodeUint32, deserialize view error: %v", err)
+	}
+	peerReward, err := utils.DecodeUint64(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeUint64, deserialize peerReward error: %v", err)
+	}
+	this.PeerReward = int64(peerReward)
+	if this.TotalReward, err = utils.DecodeUint64(source); err != nil {
+		return fmt.Errorf("utils.DecodeUint64, deserialize totalReward error: %v", err)
+	}
+	return nil
+}
+
+//GetStakingInfo return the pos of address authorized to the peers of current and previous view, the pending
+//unlocks and the claimable pos and fee. It is only available in pre-execution.
+func GetStakingInfo(native *native.NativeService) ([]byte, error) {
+	if !native.PreExec {
+		return utils.BYTE_FALSE, fmt.Errorf("getStakingInfo, only available in pre-execution")
+	}
+	address, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getStakingInfo, deserialize address error: %v", err)
+	}
+	info, err := getStakingInfo(native, utils.GovernanceContractAddress, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getStakingInfo error: %v", err)
+	}
+	return common.SerializeToBytes(info), nil
+}
+
+func getStakingInfo(native *native.NativeService, contract common.Address, address common.Address) (*StakingInfo, error) {
+	view, err := GetView(native, contract)
+	if err != nil {
+		return nil, fmt.Errorf("getView error: %v", err)
+	}
+	//the quited peers are still in peer pool of previous view
+	peers := make(map[string]bool)
+	for _, v := range []uint32{view, view - 1} {
+		peerPoolMap, err := GetPeerPoolMap(native, contract, v)
+		if err != nil {
+			continue
+		}
+		for peerPubkey := range peerPoolMap.PeerPoolMap {
+			peers[peerPubkey] = true
+		}
+	}
+	peerPubkeys := make([]string, 0, len(peers))
+	for peerPubkey := range peers {
+		peerPubkeys = append(peerPubkeys, peerPubkey)
+	}
+	sort.Strings(peerPubkeys)
+
+	info := &StakingInfo{Address: address, View: view}
+	unlocks := make(map[uint32]uint64)
+	for _, peerPubkey := range peerPubkeys {
+		authorizeInfo, err := getAuthorizeInfo(native, contract, peerPubkey, address)
+		if err != nil {
+			return nil, fmt.Errorf("getAuthorizeInfo error: %v", err)
+		}
+		if authorizeInfo.ConsensusPos+authorizeInfo.CandidatePos+authorizeInfo.NewPos+
+			authorizeInfo.WithdrawConsensusPos+authorizeInfo.WithdrawCandidatePos+authorizeInfo.WithdrawUnfreezePos == 0 {
+			continue
+		}
+		info.Authorizes = append(info.Authorizes, authorizeInfo)
+		info.ClaimablePos += authorizeInfo.WithdrawUnfreezePos
+		//withdraw candidate pos is unfrozen in next view, withdraw consensus pos in next next view
+		unlocks[view+1] += authorizeInfo.WithdrawCandidatePos
+		unlocks[view+2] += authorizeInfo.WithdrawConsensusPos
+	}
+	for _, v := range []uint32{view + 1, view + 2} {
+		if unlocks[v] != 0 {
+			info.Unlocks = append(info.Unlocks, &PendingUnlock{View: v, Pos: unlocks[v]})
+		}
+	}
+	totalStake, err := getTotalStake(native, contract, address)
+	if err != nil {
+		return nil, fmt.Errorf("getTotalStake error: %v", err)
+	}
+	info.TotalStake = totalStake.Stake
+	splitFeeAddress, err := getSplitFeeAddress(native, contract, address)
+	if err != nil {
+		return nil, fmt.Errorf("getSplitFeeAddress error: %v", err)
+	}
+	info.ClaimableFee = splitFeeAddress.Amount
+	return info, nil
+}
+
+//EstimateRewards return the ong split to address from the peer and from all the peers if current view is committed
+//now. The split of governance contract is executed on current state and reverted. It is only available in
+//pre-execution.
+func EstimateRewards(native *native.NativeService) ([]byte, error) {
+	if !native.PreExec {
+		return utils.BYTE_FALSE, fmt.Errorf("estimateRewards, only available in pre-execution")
+	}
+	params := new(EstimateRewardsParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("estimateRewards, deserialize params error: %v", err)
+	}
+	estimate, err := estimateRewards(native, utils.GovernanceContractAddress, params.Address, params.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("estimateRewards error: %v", err)
+	}
+	return common.SerializeToBytes(estimate), nil
+}
+
+func estimateRewards(native *native.NativeService, contract common.Address, address common.Address,
+	peerPubkey string) (*RewardsEstimate, error) {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	view, err := GetView(native, contract)
+	if err != nil {
+		return nil, fmt.Errorf("getView error: %v", err)
+	}
+	snapshot := native.CacheDB.Snapshot()
+	defer native.CacheDB.RevertToSnapshot(snapshot)
+
+	totalReward, err := splitRewards(native, contract, address, view)
+	if err != nil {
+		return nil, err
+	}
+
+	//split again without the pos authorized to the peer and the peer fee, the difference is split from the peer
+	native.CacheDB.RevertToSnapshot(snapshot.DeepClone())
+	native.CacheDB.Delete(utils.ConcatKey(contract, AUTHORIZE_INFO_POOL, peerPubkeyPrefix, address[:]))
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view-1)
+	if err != nil {
+		return nil, fmt.Errorf("getPeerPoolMap error: %v", err)
+	}
+	if peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]; ok && peerPoolItem.Address == address {
+		peerPoolItem.Address = common.ADDRESS_EMPTY
+		if err := putPeerPoolMap(native, contract, view-1, peerPoolMap); err != nil {
+			return nil, fmt.Errorf("putPeerPoolMap error: %v", err)
+		}
+	}
+	otherReward, err := splitRewards(native, contract, address, view)
+	if err != nil {
+		return nil, err
+	}
+	peerReward, err := signedDiff(totalReward, otherReward)
+	if err != nil {
+		return nil, fmt.Errorf("peer reward error: %v", err)
+	}
+	return &RewardsEstimate{
+		Address:     address,
+		PeerPubkey:  peerPubkey,
+		View:        view,
+		PeerReward:  peerReward,
+		TotalReward: totalReward,
+	}, nil
+}
+
+//signedDiff return a - b, which is negative if b is larger
+func signedDiff(a, b uint64) (int64, error) {
+	if a >= b {
+		if a-b > math.MaxInt64 {
+			return 0, fmt.Errorf("difference of %d and %d overflows", a, b)
+		}
+		return int64(a - b), nil
+	}
+	if b-a > math.MaxInt64 {
+		return 0, fmt.Errorf("difference of %d and %d overflows", a, b)
+	}
+	return -int64(b - a), nil
+}
+
+//splitRewards execute the split of view and return the ong split to address
+func splitRewards(native *native.NativeService, contract common.Address, address common.Address, view uint32) (uint64, error) {
+	before, err := getSplitFeeAddress(native, contract, address)
+	if err != nil {
+		return 0, fmt.Errorf("getSplitFeeAddress error: %v", err)
+	}
+	if view <= NEW_VERSION_VIEW {
+		err = executeSplit(native, contract, view)
+	} else {
+		_, err = executeSplit2(native, contract, view)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("split of view %d error: %v", view, err)
+	}
+	after, err := getSplitFeeAddress(native, contract, address)
+	if err != nil {
+		return 0, fmt.Errorf("getSplitFeeAddress error: %v", err)
+	}
+	return after.Amount - before.Amount, nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/testsuite"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPeers = []string{"02aa", "02bb", "02cc"}
+
+//initStakingState put the governance state of view 8 with 2 consensus peers and 1 candidate peer
+func initStakingState(t *testing.T, native *native.NativeService, staker common.Address) {
+	contract := utils.GovernanceContractAddress
+	assert.Nil(t, putGovernanceView(native, contract, &GovernanceView{View: 8}))
+	assert.Nil(t, putConfig(native, contract, &Configuration{N: 3, C: 1, K: 2, L: 16}))
+	assert.Nil(t, putGlobalParam(native, contract, &GlobalParam{A: 50, B: 50, Yita: 5}))
+	assert.Nil(t, putGlobalParam2(native, contract, &GlobalParam2{MinAuthorizePos: 1, CandidateFeeSplitNum: 3}))
+	yi := make([]uint32, 101)
+	for i := range yi {
+		yi[i] = uint32(i * 10000)
+	}
+	assert.Nil(t, putSplitCurve(native, contract, &SplitCurve{Yi: yi}))
+
+	peerPoolMap := &PeerPoolMap{PeerPoolMap: make(map[string]*PeerPoolItem)}
+	for i, peerPubkey := range testPeers {
+		status := ConsensusStatus
+		if i == 2 {
+			status = CandidateStatus
+		}
+		peerPoolMap.PeerPoolMap[peerPubkey] = &PeerPoolItem{Index: uint32(i + 1), PeerPubkey: peerPubkey,
+			Address: testsuite.RandomAddress(), Status: status, InitPos: 10000, TotalPos: 5000}
+		assert.Nil(t, putPeerAttributes(native, contract, &PeerAttributes{PeerPubkey: peerPubkey, TPeerCost: 20}))
+	}
+	assert.Nil(t, putPeerPoolMap(native, contract, 7, peerPoolMap))
+	assert.Nil(t, putPeerPoolMap(native, contract, 8, peerPoolMap))
+
+	assert.Nil(t, putAuthorizeInfo(native, contract, &AuthorizeInfo{PeerPubkey: testPeers[0], Address: staker,
+		ConsensusPos: 1000, WithdrawConsensusPos: 200, WithdrawUnfreezePos: 50}))
+	assert.Nil(t, putAuthorizeInfo(native, contract, &AuthorizeInfo{PeerPubkey: testPeers[2], Address: staker,
+		CandidatePos: 3000, WithdrawCandidatePos: 100}))
+	assert.Nil(t, putSplitFeeAddress(native, contract, staker, &SplitFeeAddress{Address: staker, Amount: 7}))
+
+	native.CacheDB.Put(ont.GenBalanceKey(utils.OngContractAddress, contract),
+		utils.GenUInt64StorageItem(1000000000000).ToArray())
+}
+
+func TestStakingInfo(t *testing.T) {
+	ont.InitOnt()
+	ong.InitOng()
+	staker := testsuite.RandomAddress()
+	testsuite.InvokeNativeContract(t, utils.GovernanceContractAddress, func(native *native.NativeService) ([]byte, error) {
+		initStakingState(t, native, staker)
+		contract := utils.GovernanceContractAddress
+
+		info, err := getStakingInfo(native, contract, staker)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(8), info.View)
+		assert.Equal(t, 2, len(info.Authorizes))
+		assert.Equal(t, uint64(50), info.ClaimablePos)
+		assert.Equal(t, uint64(7), info.ClaimableFee)
+		assert.Equal(t, []*PendingUnlock{{View: 9, Pos: 100}, {View: 10, Pos: 200}}, info.Unlocks)
+		decoded := new(StakingInfo)
+		assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(info))))
+		assert.Equal(t, info, decoded)
+
+		consensus, err := estimateRewards(native, contract, staker, testPeers[0])
+		assert.Nil(t, err)
+		assert.True(t, consensus.PeerReward > 0)
+		candidate, err := estimateRewards(native, contract, staker, testPeers[2])
+		assert.Nil(t, err)
+		assert.True(t, candidate.PeerReward > 0)
+		other, err := estimateRewards(native, contract, staker, testPeers[1])
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), other.PeerReward)
+		assert.Equal(t, int64(consensus.TotalReward), consensus.PeerReward+candidate.PeerReward)
+
+		//the split is reverted
+		splitFee, err := getSplitFeeAddress(native, contract, staker)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(7), splitFee.Amount)
+		return nil, nil
+	})
+}
+
+func TestRewardsEstimateNegative(t *testing.T) {
+	diff, err := signedDiff(100, 300)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-200), diff)
+	diff, err = signedDiff(300, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), diff)
+	_, err = signedDiff(math.MaxUint64, 0)
+	assert.NotNil(t, err)
+	_, err = signedDiff(0, math.MaxUint64)
+	assert.NotNil(t, err)
+
+	estimate := &RewardsEstimate{Address: testsuite.RandomAddress(), PeerPubkey: testPeers[0], View: 8,
+		PeerReward: -200, TotalReward: 100}
+	decoded := new(RewardsEstimate)
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(estimate))))
+	assert.Equal(t, estimate, decoded)
+}
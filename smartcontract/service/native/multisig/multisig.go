@@ -0,0 +1,372 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package multisig implements the on chain multisig wallet contract.
+//Owners of a wallet create proposals to invoke native, neovm, wasmvm or evm contracts,
+//approve them in separate transactions, and execute a proposal once the approvals reach
+//the threshold and the timelock of wallet has passed. The call is executed with the wallet
+//as the calling context, so the callee accepts the wallet address as witness.
+//Owners, threshold and timelock are updated by a proposal calling updateWallet of the wallet itself.
+package multisig
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/service/util"
+	"github.com/ontio/ontology/smartcontract/states"
+	"github.com/ontio/ontology/vm/crossvm_codec"
+	neotypes "github.com/ontio/ontology/vm/neovm/types"
+)
+
+func InitMultisig() {
+	native.Contracts[utils.MultisigContractAddress] = RegisterMultisigContract
+}
+
+func RegisterMultisigContract(native *native.NativeService) {
+	native.Register(CREATE_WALLET_NAME, CreateWallet)
+	native.Register(UPDATE_WALLET_NAME, UpdateWallet)
+	native.Register(PROPOSE_NAME, Propose)
+	native.Register(APPROVE_NAME, Approve)
+	native.Register(REVOKE_NAME, Revoke)
+	native.Register(EXECUTE_NAME, Execute)
+	native.Register(CANCEL_NAME, Cancel)
+	native.Register(GET_WALLET_NAME, GetWallet)
+	native.Register(GET_PROPOSAL_NAME, GetProposal)
+}
+
+func CreateWallet(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param CreateWalletParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] Deserialize CreateWalletParam error:%s", err)
+	}
+	if err := utils.ValidateOwner(native, param.Creator); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] checkWitness error:%s", err)
+	}
+	if err := validateOwners(param.Owners, param.Threshold); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] %s", err)
+	}
+	if err := validateTimelock(param.Timelock); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] %s", err)
+	}
+
+	index, err := utils.GetStorageUInt64(native.CacheDB, GenWalletCountKey(contract))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] get wallet count error:%s", err)
+	}
+	address := WalletAddress(contract, param.Creator, index)
+	native.CacheDB.Put(GenWalletCountKey(contract), utils.GenUInt64StorageItem(index+1).ToArray())
+	putWallet(native, contract, address, &Wallet{
+		Owners:    param.Owners,
+		Threshold: param.Threshold,
+		Timelock:  param.Timelock,
+	})
+	addNotify(native, contract, CREATE_WALLET_NAME, address.ToBase58(), param.Creator.ToBase58())
+	return address[:], nil
+}
+
+func UpdateWallet(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[UpdateWallet] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param UpdateWalletParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[UpdateWallet] Deserialize UpdateWalletParam error:%s", err)
+	}
+	//only the wallet itself can witness, i.e. an executed proposal of the wallet
+	if err := utils.ValidateOwner(native, param.Wallet); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[UpdateWallet] checkWitness error:%s", err)
+	}
+	wallet, err := getWallet(native, contract, param.Wallet)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[UpdateWallet] %s", err)
+	}
+	if err := validateOwners(param.Owners, param.Threshold); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[UpdateWallet] %s", err)
+	}
+	if err := validateTimelock(param.Timelock); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[UpdateWallet] %s", err)
+	}
+	wallet.Owners = param.Owners
+	wallet.Threshold = param.Threshold
+	wallet.Timelock = param.Timelock
+	wallet.Version++
+	putWallet(native, contract, param.Wallet, wallet)
+	addNotify(native, contract, UPDATE_WALLET_NAME, param.Wallet.ToBase58(), param.Threshold, param.Timelock)
+	return utils.BYTE_TRUE, nil
+}
+
+func Propose(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Propose] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param ProposeParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Propose] Deserialize ProposeParam error:%s", err)
+	}
+	if err := utils.ValidateOwner(native, param.Proposer); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Propose] checkWitness error:%s", err)
+	}
+	wallet, err := getWallet(native, contract, param.Wallet)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Propose] %s", err)
+	}
+	if !wallet.isOwner(param.Proposer) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Propose] %s is not owner of wallet", param.Proposer.ToBase58())
+	}
+	if err := validateCall(&param.Call); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Propose] invalid call:%s", err)
+	}
+
+	proposal := &Proposal{
+		Id:            wallet.ProposalCount,
+		Proposer:      param.Proposer,
+		Call:          param.Call,
+		WalletVersion: wallet.Version,
+		Status:        PROPOSAL_PENDING,
+	}
+	addApproval(native, wallet, proposal, param.Proposer)
+	wallet.ProposalCount++
+	putWallet(native, contract, param.Wallet, wallet)
+	putProposal(native, contract, param.Wallet, proposal)
+	addNotify(native, contract, PROPOSE_NAME, param.Wallet.ToBase58(), proposal.Id, param.Proposer.ToBase58())
+	return common.BigIntToNeoBytes(new(big.Int).SetUint64(proposal.Id)), nil
+}
+
+func Approve(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Approve] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param ProposalParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Approve] Deserialize ProposalParam error:%s", err)
+	}
+	wallet, proposal, err := getPendingProposal(native, contract, &param)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Approve] %s", err)
+	}
+	if proposal.approvedBy(param.Owner) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Approve] proposal %d has been approved by %s", proposal.Id, param.Owner.ToBase58())
+	}
+	addApproval(native, wallet, proposal, param.Owner)
+	putProposal(native, contract, param.Wallet, proposal)
+	addNotify(native, contract, APPROVE_NAME, param.Wallet.ToBase58(), proposal.Id, param.Owner.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+func Revoke(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param ProposalParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] Deserialize ProposalParam error:%s", err)
+	}
+	wallet, proposal, err := getPendingProposal(native, contract, &param)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] %s", err)
+	}
+	if !proposal.approvedBy(param.Owner) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] proposal %d has not been approved by %s", proposal.Id, param.Owner.ToBase58())
+	}
+	approvals := make([]common.Address, 0, len(proposal.Approvals)-1)
+	for _, approver := range proposal.Approvals {
+		if approver != param.Owner {
+			approvals = append(approvals, approver)
+		}
+	}
+	proposal.Approvals = approvals
+	if uint64(len(proposal.Approvals)) < wallet.Threshold {
+		proposal.ExecutableTime = 0
+	}
+	putProposal(native, contract, param.Wallet, proposal)
+	addNotify(native, contract, REVOKE_NAME, param.Wallet.ToBase58(), proposal.Id, param.Owner.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+func Execute(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Execute] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param ProposalParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Execute] Deserialize ProposalParam error:%s", err)
+	}
+	wallet, proposal, err := getPendingProposal(native, contract, &param)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Execute] %s", err)
+	}
+	if uint64(len(proposal.Approvals)) < wallet.Threshold {
+		return utils.BYTE_FALSE, fmt.Errorf("[Execute] proposal %d has %d approvals, threshold is %d",
+			proposal.Id, len(proposal.Approvals), wallet.Threshold)
+	}
+	if uint64(native.Time) < proposal.ExecutableTime {
+		return utils.BYTE_FALSE, fmt.Errorf("[Execute] proposal %d is locked until %d", proposal.Id, proposal.ExecutableTime)
+	}
+	//mark executed before the call, so the call can not execute the proposal again
+	proposal.Status = PROPOSAL_EXECUTED
+	putProposal(native, contract, param.Wallet, proposal)
+
+	result, err := invokeCall(native, param.Wallet, &proposal.Call)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Execute] invoke proposal %d error:%s", proposal.Id, err)
+	}
+	addNotify(native, contract, EXECUTE_NAME, param.Wallet.ToBase58(), proposal.Id, hex.EncodeToString(result))
+	return result, nil
+}
+
+func Cancel(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param ProposalParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] Deserialize ProposalParam error:%s", err)
+	}
+	_, proposal, err := getPendingProposal(native, contract, &param)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s", err)
+	}
+	if proposal.Proposer != param.Owner {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] only proposer can cancel proposal %d", proposal.Id)
+	}
+	proposal.Status = PROPOSAL_CANCELLED
+	putProposal(native, contract, param.Wallet, proposal)
+	addNotify(native, contract, CANCEL_NAME, param.Wallet.ToBase58(), proposal.Id, param.Owner.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+func GetWallet(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetWallet] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	address, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetWallet] decode wallet address error:%s", err)
+	}
+	wallet, err := getWallet(native, contract, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetWallet] %s", err)
+	}
+	return common.SerializeToBytes(wallet), nil
+}
+
+func GetProposal(native *native.NativeService) ([]byte, error) {
+	if err := CheckMultisigAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetProposal] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param GetProposalParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetProposal] Deserialize GetProposalParam error:%s", err)
+	}
+	proposal, err := getProposal(native, contract, param.Wallet, param.Id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetProposal] %s", err)
+	}
+	return common.SerializeToBytes(proposal), nil
+}
+
+//invokeCall invoke the contract of call with wallet as the calling context
+func invokeCall(service *native.NativeService, wallet common.Address, call *Call) ([]byte, error) {
+	service.ContextRef.PushContext(&context.Context{ContractAddress: wallet})
+	defer service.ContextRef.PopContext()
+
+	switch call.VmType {
+	case NATIVE_CALL:
+		nat := &native.NativeService{
+			Store:   service.Store,
+			CacheDB: service.CacheDB,
+			InvokeParam: states.ContractInvokeParam{
+				Address: call.Contract,
+				Method:  call.Method,
+				Args:    call.Args,
+			},
+			Tx:         service.Tx,
+			Height:     service.Height,
+			Time:       service.Time,
+			BlockHash:  service.BlockHash,
+			ContextRef: service.ContextRef,
+			ServiceMap: make(map[string]native.Handler),
+			PreExec:    service.PreExec,
+		}
+		return nat.Invoke()
+	case NEOVM_CALL:
+		if !service.ContextRef.CheckUseGas(neovm.NATIVE_INVOKE_GAS) {
+			return nil, fmt.Errorf("check use gaslimit insufficient")
+		}
+		evalStack, err := util.GenerateNeoVMParamEvalStack(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		engine, err := service.ContextRef.NewExecuteEngine([]byte{}, types.InvokeNeo)
+		if err != nil {
+			return nil, err
+		}
+		if err := util.SetNeoServiceParamAndEngine(call.Contract, engine, evalStack); err != nil {
+			return nil, err
+		}
+		res, err := engine.Invoke()
+		if err != nil {
+			return nil, err
+		}
+		sink := common.NewZeroCopySink([]byte{crossvm_codec.VERSION})
+		if res != nil {
+			if err := neotypes.BuildResultFromNeo(*res.(*neotypes.VmValue), sink); err != nil {
+				return nil, err
+			}
+		}
+		return sink.Bytes(), nil
+	case WASMVM_CALL:
+		if !service.ContextRef.CheckUseGas(neovm.NATIVE_INVOKE_GAS) {
+			return nil, fmt.Errorf("check use gaslimit insufficient")
+		}
+		param := common.SerializeToBytes(&states.WasmContractParam{Address: call.Contract, Args: call.Args})
+		engine, err := service.ContextRef.NewExecuteEngine(param, types.InvokeWasm)
+		if err != nil {
+			return nil, err
+		}
+		res, err := engine.Invoke()
+		if err != nil {
+			return nil, err
+		}
+		return res.([]byte), nil
+	case EVM_CALL:
+		return service.ContextRef.CallEVMContract(wallet, call.Contract, call.Args)
+	default:
+		return nil, fmt.Errorf("unsupported vm type %d", call.VmType)
+	}
+}
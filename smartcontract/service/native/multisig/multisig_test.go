@@ -0,0 +1,220 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/testsuite"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	ont.InitOnt()
+	ong.InitOng()
+	InitMultisig()
+}
+
+type testEnv struct {
+	*testsuite.NativeEnv
+}
+
+func newTestEnv() *testEnv {
+	return &testEnv{testsuite.NewNativeEnv()}
+}
+
+func (this *testEnv) invoke(signer common.Address, handler native.Handler, param common.Serializable) ([]byte, error) {
+	return this.Invoke(utils.MultisigContractAddress, signer, 0, handler, common.SerializeToBytes(param))
+}
+
+func (this *testEnv) createWallet(t *testing.T, owners []common.Address, threshold, timelock uint64) common.Address {
+	param := &CreateWalletParam{Creator: owners[0], Owners: owners, Threshold: threshold, Timelock: timelock}
+	res, err := this.invoke(owners[0], CreateWallet, param)
+	assert.Nil(t, err)
+	wallet, err := common.AddressParseFromBytes(res)
+	assert.Nil(t, err)
+	return wallet
+}
+
+func (this *testEnv) propose(t *testing.T, wallet, proposer common.Address, call Call) uint64 {
+	res, err := this.invoke(proposer, Propose, &ProposeParam{Wallet: wallet, Proposer: proposer, Call: call})
+	assert.Nil(t, err)
+	return common.BigIntFromNeoBytes(res).Uint64()
+}
+
+func (this *testEnv) proposal(t *testing.T, wallet common.Address, id uint64) *Proposal {
+	res, err := this.invoke(common.ADDRESS_EMPTY, GetProposal, &GetProposalParam{Wallet: wallet, Id: id})
+	assert.Nil(t, err)
+	proposal := new(Proposal)
+	assert.Nil(t, proposal.Deserialization(common.NewZeroCopySource(res)))
+	return proposal
+}
+
+func (this *testEnv) wallet(t *testing.T, address common.Address) *Wallet {
+	sc := &smartcontract.SmartContract{Config: &smartcontract.Config{}}
+	sc.PushContext(&context.Context{ContractAddress: utils.MultisigContractAddress})
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeAddress(sink, address)
+	res, err := GetWallet(&native.NativeService{CacheDB: this.DB, ContextRef: sc, Input: sink.Bytes()})
+	assert.Nil(t, err)
+	wallet := new(Wallet)
+	assert.Nil(t, wallet.Deserialization(common.NewZeroCopySource(res)))
+	return wallet
+}
+
+func (this *testEnv) balance(address common.Address) uint64 {
+	balance, _ := utils.GetStorageUInt64(this.DB, ont.GenBalanceKey(utils.OntContractAddress, address))
+	return balance
+}
+
+func newOwners(n int) []common.Address {
+	owners := make([]common.Address, 0, n)
+	for i := 0; i < n; i++ {
+		owners = append(owners, account.NewAccount("").Address)
+	}
+	return owners
+}
+
+func transferCall(from, to common.Address, value uint64) Call {
+	transfers := &ont.Transfers{States: []ont.State{{From: from, To: to, Value: value}}}
+	return Call{VmType: NATIVE_CALL, Contract: utils.OntContractAddress, Method: ont.TRANSFER_NAME, Args: common.SerializeToBytes(transfers)}
+}
+
+func TestCreateWallet(t *testing.T) {
+	env := newTestEnv()
+	owners := newOwners(3)
+
+	_, err := env.invoke(owners[1], CreateWallet, &CreateWalletParam{Creator: owners[0], Owners: owners, Threshold: 2})
+	assert.NotNil(t, err)
+	_, err = env.invoke(owners[0], CreateWallet, &CreateWalletParam{Creator: owners[0], Owners: owners, Threshold: 4})
+	assert.NotNil(t, err)
+	_, err = env.invoke(owners[0], CreateWallet, &CreateWalletParam{Creator: owners[0], Owners: append(owners, owners[0]), Threshold: 2})
+	assert.NotNil(t, err)
+
+	wallet1 := env.createWallet(t, owners, 2, 0)
+	wallet2 := env.createWallet(t, owners, 2, 0)
+	assert.NotEqual(t, wallet1, wallet2)
+	assert.Equal(t, WalletAddress(utils.MultisigContractAddress, owners[0], 1), wallet2)
+
+	assert.Equal(t, &Wallet{Owners: owners, Threshold: 2}, env.wallet(t, wallet1))
+}
+
+func TestExecute(t *testing.T) {
+	env := newTestEnv()
+	owners := newOwners(3)
+	wallet := env.createWallet(t, owners, 2, 100)
+	env.DB.Put(ont.GenBalanceKey(utils.OntContractAddress, wallet), utils.GenUInt64StorageItem(10).ToArray())
+	to := account.NewAccount("").Address
+
+	_, err := env.invoke(to, Propose, &ProposeParam{Wallet: wallet, Proposer: to, Call: transferCall(wallet, to, 3)})
+	assert.NotNil(t, err)
+	id := env.propose(t, wallet, owners[0], transferCall(wallet, to, 3))
+	execute := &ProposalParam{Wallet: wallet, Id: id, Owner: owners[0]}
+	_, err = env.invoke(owners[0], Execute, execute)
+	assert.NotNil(t, err)
+
+	//approvals start the timelock
+	env.Time = 1000
+	_, err = env.invoke(owners[0], Approve, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[0]})
+	assert.NotNil(t, err)
+	_, err = env.invoke(owners[1], Approve, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[1]})
+	assert.Nil(t, err)
+	_, err = env.invoke(owners[2], Approve, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[2]})
+	assert.Nil(t, err)
+	_, err = env.invoke(owners[2], Revoke, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[2]})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1100), env.proposal(t, wallet, id).ExecutableTime)
+
+	env.Time = 1099
+	_, err = env.invoke(owners[0], Execute, execute)
+	assert.NotNil(t, err)
+	env.Time = 1100
+	_, err = env.invoke(owners[0], Execute, execute)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), env.balance(wallet))
+	assert.Equal(t, uint64(3), env.balance(to))
+	assert.Equal(t, PROPOSAL_EXECUTED, env.proposal(t, wallet, id).Status)
+
+	_, err = env.invoke(owners[0], Execute, execute)
+	assert.NotNil(t, err)
+}
+
+func TestRevokeAndCancel(t *testing.T) {
+	env := newTestEnv()
+	owners := newOwners(3)
+	wallet := env.createWallet(t, owners, 2, 0)
+	id := env.propose(t, wallet, owners[0], transferCall(wallet, owners[1], 1))
+
+	_, err := env.invoke(owners[1], Approve, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[1]})
+	assert.Nil(t, err)
+	_, err = env.invoke(owners[1], Revoke, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[1]})
+	assert.Nil(t, err)
+	_, err = env.invoke(owners[0], Execute, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[0]})
+	assert.NotNil(t, err)
+
+	_, err = env.invoke(owners[1], Cancel, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[1]})
+	assert.NotNil(t, err)
+	_, err = env.invoke(owners[0], Cancel, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[0]})
+	assert.Nil(t, err)
+	assert.Equal(t, PROPOSAL_CANCELLED, env.proposal(t, wallet, id).Status)
+	_, err = env.invoke(owners[1], Approve, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[1]})
+	assert.NotNil(t, err)
+}
+
+func TestUpdateWallet(t *testing.T) {
+	env := newTestEnv()
+	owners := newOwners(3)
+	wallet := env.createWallet(t, owners, 1, 0)
+	rotated := []common.Address{owners[2], account.NewAccount("").Address}
+	update := &UpdateWalletParam{Wallet: wallet, Owners: rotated, Threshold: 2, Timelock: 10}
+
+	//owners can not update wallet without a proposal
+	_, err := env.invoke(owners[0], UpdateWallet, update)
+	assert.NotNil(t, err)
+
+	pending := env.propose(t, wallet, owners[1], transferCall(wallet, owners[1], 1))
+	id := env.propose(t, wallet, owners[0], Call{
+		VmType:   NATIVE_CALL,
+		Contract: utils.MultisigContractAddress,
+		Method:   UPDATE_WALLET_NAME,
+		Args:     common.SerializeToBytes(update),
+	})
+	_, err = env.invoke(owners[0], Execute, &ProposalParam{Wallet: wallet, Id: id, Owner: owners[0]})
+	assert.Nil(t, err)
+
+	assert.Equal(t, &Wallet{Owners: rotated, Threshold: 2, Timelock: 10, Version: 1, ProposalCount: 2}, env.wallet(t, wallet))
+
+	//removed owner can not propose, and proposals before update become stale
+	_, err = env.invoke(owners[0], Propose, &ProposeParam{Wallet: wallet, Proposer: owners[0], Call: transferCall(wallet, owners[0], 1)})
+	assert.NotNil(t, err)
+	_, err = env.invoke(owners[1], Execute, &ProposalParam{Wallet: wallet, Id: pending, Owner: owners[1]})
+	assert.NotNil(t, err)
+	_, err = env.invoke(owners[2], Approve, &ProposalParam{Wallet: wallet, Id: pending, Owner: owners[2]})
+	assert.NotNil(t, err)
+}
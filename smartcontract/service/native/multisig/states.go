@@ -0,0 +1,336 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+type Wallet struct {
+	Owners    []common.Address
+	Threshold uint64
+	//seconds to wait after a proposal gets enough approvals
+	Timelock uint64
+	//increased by every update of wallet, pending proposals of old version become stale
+	Version       uint64
+	ProposalCount uint64
+}
+
+func (this *Wallet) Serialization(sink *common.ZeroCopySink) {
+	encodeAddresses(sink, this.Owners)
+	utils.EncodeVarUint(sink, this.Threshold)
+	utils.EncodeVarUint(sink, this.Timelock)
+	utils.EncodeVarUint(sink, this.Version)
+	utils.EncodeVarUint(sink, this.ProposalCount)
+}
+
+func (this *Wallet) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Owners, err = decodeAddresses(source); err != nil {
+		return fmt.Errorf("Wallet.Deserialization decodeAddresses Owners error:%s", err)
+	}
+	if this.Threshold, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Wallet.Deserialization DecodeVarUint Threshold error:%s", err)
+	}
+	if this.Timelock, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Wallet.Deserialization DecodeVarUint Timelock error:%s", err)
+	}
+	if this.Version, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Wallet.Deserialization DecodeVarUint Version error:%s", err)
+	}
+	if this.ProposalCount, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Wallet.Deserialization DecodeVarUint ProposalCount error:%s", err)
+	}
+	return nil
+}
+
+func (this *Wallet) isOwner(address common.Address) bool {
+	for _, owner := range this.Owners {
+		if owner == address {
+			return true
+		}
+	}
+	return false
+}
+
+//Call is the contract invocation executed by a proposal.
+//Method is only used by native contracts, Args is the input of the contract:
+//native args for native contracts, cross vm codec params for neovm contracts,
+//wasm input for wasmvm contracts and call data for evm contracts
+type Call struct {
+	VmType   byte
+	Contract common.Address
+	Method   string
+	Args     []byte
+}
+
+func (this *Call) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(this.VmType))
+	utils.EncodeAddress(sink, this.Contract)
+	utils.EncodeString(sink, this.Method)
+	utils.EncodeVarBytes(sink, this.Args)
+}
+
+func (this *Call) Deserialization(source *common.ZeroCopySource) error {
+	vmType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("Call.Deserialization DecodeVarUint VmType error:%s", err)
+	}
+	if vmType > math.MaxUint8 {
+		return fmt.Errorf("Call.Deserialization invalid VmType:%d", vmType)
+	}
+	this.VmType = byte(vmType)
+	if this.Contract, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("Call.Deserialization DecodeAddress Contract error:%s", err)
+	}
+	if this.Method, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("Call.Deserialization DecodeString Method error:%s", err)
+	}
+	if this.Args, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("Call.Deserialization DecodeVarBytes Args error:%s", err)
+	}
+	return nil
+}
+
+type Proposal struct {
+	Id            uint64
+	Proposer      common.Address
+	Call          Call
+	WalletVersion uint64
+	Approvals     []common.Address
+	//block time after which the proposal can be executed, only valid when approvals reach threshold
+	ExecutableTime uint64
+	Status         byte
+}
+
+func (this *Proposal) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.Id)
+	utils.EncodeAddress(sink, this.Proposer)
+	this.Call.Serialization(sink)
+	utils.EncodeVarUint(sink, this.WalletVersion)
+	encodeAddresses(sink, this.Approvals)
+	utils.EncodeVarUint(sink, this.ExecutableTime)
+	sink.WriteByte(this.Status)
+}
+
+func (this *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Proposal.Deserialization DecodeVarUint Id error:%s", err)
+	}
+	if this.Proposer, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("Proposal.Deserialization DecodeAddress Proposer error:%s", err)
+	}
+	if err = this.Call.Deserialization(source); err != nil {
+		return fmt.Errorf("Proposal.Deserialization Call error:%s", err)
+	}
+	if this.WalletVersion, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Proposal.Deserialization DecodeVarUint WalletVersion error:%s", err)
+	}
+	if this.Approvals, err = decodeAddresses(source); err != nil {
+		return fmt.Errorf("Proposal.Deserialization decodeAddresses Approvals error:%s", err)
+	}
+	if this.ExecutableTime, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("Proposal.Deserialization DecodeVarUint ExecutableTime error:%s", err)
+	}
+	status, eof := source.NextByte()
+	if eof {
+		return fmt.Errorf("Proposal.Deserialization NextByte Status error:%s", io.ErrUnexpectedEOF)
+	}
+	this.Status = status
+	return nil
+}
+
+func (this *Proposal) approvedBy(address common.Address) bool {
+	for _, approver := range this.Approvals {
+		if approver == address {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateWalletParam struct {
+	Creator   common.Address
+	Owners    []common.Address
+	Threshold uint64
+	Timelock  uint64
+}
+
+func (this *CreateWalletParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Creator)
+	encodeAddresses(sink, this.Owners)
+	utils.EncodeVarUint(sink, this.Threshold)
+	utils.EncodeVarUint(sink, this.Timelock)
+}
+
+func (this *CreateWalletParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Creator, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("CreateWalletParam.Deserialization DecodeAddress Creator error:%s", err)
+	}
+	if this.Owners, err = decodeAddresses(source); err != nil {
+		return fmt.Errorf("CreateWalletParam.Deserialization decodeAddresses Owners error:%s", err)
+	}
+	if this.Threshold, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("CreateWalletParam.Deserialization DecodeVarUint Threshold error:%s", err)
+	}
+	if this.Timelock, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("CreateWalletParam.Deserialization DecodeVarUint Timelock error:%s", err)
+	}
+	return nil
+}
+
+//UpdateWalletParam replaces the owners, threshold and timelock of wallet,
+//it can only be invoked by the wallet itself through an executed proposal
+type UpdateWalletParam struct {
+	Wallet    common.Address
+	Owners    []common.Address
+	Threshold uint64
+	Timelock  uint64
+}
+
+func (this *UpdateWalletParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	encodeAddresses(sink, this.Owners)
+	utils.EncodeVarUint(sink, this.Threshold)
+	utils.EncodeVarUint(sink, this.Timelock)
+}
+
+func (this *UpdateWalletParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("UpdateWalletParam.Deserialization DecodeAddress Wallet error:%s", err)
+	}
+	if this.Owners, err = decodeAddresses(source); err != nil {
+		return fmt.Errorf("UpdateWalletParam.Deserialization decodeAddresses Owners error:%s", err)
+	}
+	if this.Threshold, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("UpdateWalletParam.Deserialization DecodeVarUint Threshold error:%s", err)
+	}
+	if this.Timelock, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("UpdateWalletParam.Deserialization DecodeVarUint Timelock error:%s", err)
+	}
+	return nil
+}
+
+type ProposeParam struct {
+	Wallet   common.Address
+	Proposer common.Address
+	Call     Call
+}
+
+func (this *ProposeParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	utils.EncodeAddress(sink, this.Proposer)
+	this.Call.Serialization(sink)
+}
+
+func (this *ProposeParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("ProposeParam.Deserialization DecodeAddress Wallet error:%s", err)
+	}
+	if this.Proposer, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("ProposeParam.Deserialization DecodeAddress Proposer error:%s", err)
+	}
+	if err = this.Call.Deserialization(source); err != nil {
+		return fmt.Errorf("ProposeParam.Deserialization Call error:%s", err)
+	}
+	return nil
+}
+
+//ProposalParam is the param of approve, revoke, execute and cancel, Owner is the invoker
+type ProposalParam struct {
+	Wallet common.Address
+	Id     uint64
+	Owner  common.Address
+}
+
+func (this *ProposalParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	utils.EncodeVarUint(sink, this.Id)
+	utils.EncodeAddress(sink, this.Owner)
+}
+
+func (this *ProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("ProposalParam.Deserialization DecodeAddress Wallet error:%s", err)
+	}
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("ProposalParam.Deserialization DecodeVarUint Id error:%s", err)
+	}
+	if this.Owner, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("ProposalParam.Deserialization DecodeAddress Owner error:%s", err)
+	}
+	return nil
+}
+
+type GetProposalParam struct {
+	Wallet common.Address
+	Id     uint64
+}
+
+func (this *GetProposalParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	utils.EncodeVarUint(sink, this.Id)
+}
+
+func (this *GetProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("GetProposalParam.Deserialization DecodeAddress Wallet error:%s", err)
+	}
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("GetProposalParam.Deserialization DecodeVarUint Id error:%s", err)
+	}
+	return nil
+}
+
+func encodeAddresses(sink *common.ZeroCopySink, addresses []common.Address) {
+	utils.EncodeVarUint(sink, uint64(len(addresses)))
+	for _, address := range addresses {
+		utils.EncodeAddress(sink, address)
+	}
+}
+
+func decodeAddresses(source *common.ZeroCopySource) ([]common.Address, error) {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return nil, err
+	}
+	if n > source.Len() {
+		return nil, io.ErrUnexpectedEOF
+	}
+	addresses := make([]common.Address, 0, n)
+	for i := uint64(0); i < n; i++ {
+		address, err := utils.DecodeAddress(source)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
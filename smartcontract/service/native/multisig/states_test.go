@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposal_Serialize(t *testing.T) {
+	proposal := Proposal{
+		Id:       3,
+		Proposer: utils.OntContractAddress,
+		Call: Call{
+			VmType:   EVM_CALL,
+			Contract: utils.OngContractAddress,
+			Args:     []byte{1, 2, 3},
+		},
+		WalletVersion:  1,
+		Approvals:      []common.Address{utils.OntContractAddress, utils.OngContractAddress},
+		ExecutableTime: 1000,
+		Status:         PROPOSAL_EXECUTED,
+	}
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+
+	proposal2 := Proposal{}
+	assert.Nil(t, proposal2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, proposal, proposal2)
+
+	_, err := decodeAddresses(common.NewZeroCopySource(common.NewZeroCopySink(nil).Bytes()))
+	assert.NotNil(t, err)
+}
+
+func TestProposeParam_Serialize(t *testing.T) {
+	param := ProposeParam{
+		Wallet:   utils.OntContractAddress,
+		Proposer: utils.OngContractAddress,
+		Call: Call{
+			VmType:   NATIVE_CALL,
+			Contract: utils.OntContractAddress,
+			Method:   "transfer",
+			Args:     []byte{1},
+		},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	param2 := ProposeParam{}
+	assert.Nil(t, param2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, param, param2)
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	CREATE_WALLET_NAME = "createWallet"
+	UPDATE_WALLET_NAME = "updateWallet"
+	PROPOSE_NAME       = "propose"
+	APPROVE_NAME       = "approve"
+	REVOKE_NAME        = "revoke"
+	EXECUTE_NAME       = "execute"
+	CANCEL_NAME        = "cancel"
+	GET_WALLET_NAME    = "getWallet"
+	GET_PROPOSAL_NAME  = "getProposal"
+)
+
+const (
+	WALLET_COUNT    = "WalletCount"
+	WALLET_PREFIX   = "Wallet"
+	PROPOSAL_PREFIX = "Proposal"
+
+	MAX_OWNERS = 64
+	//max length of call args, keeps proposal storage bounded
+	MAX_CALL_ARGS_LEN = 1024 * 1024
+)
+
+//vm type of proposal call, same as the contract types of wasm runtime
+const (
+	NATIVE_CALL byte = iota
+	NEOVM_CALL
+	WASMVM_CALL
+	EVM_CALL
+)
+
+const (
+	PROPOSAL_PENDING byte = iota
+	PROPOSAL_EXECUTED
+	PROPOSAL_CANCELLED
+)
+
+func CheckMultisigAvailability(native *native.NativeService) error {
+	if native.Height < config.GetMultisigHeight() {
+		return fmt.Errorf("multisig contract is not available")
+	}
+	return nil
+}
+
+func GenWalletCountKey(contract common.Address) []byte {
+	return utils.ConcatKey(contract, []byte(WALLET_COUNT))
+}
+
+func GenWalletKey(contract, wallet common.Address) []byte {
+	return utils.ConcatKey(contract, []byte(WALLET_PREFIX), wallet[:])
+}
+
+func GenProposalKey(contract, wallet common.Address, id uint64) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(id)
+	return utils.ConcatKey(contract, []byte(PROPOSAL_PREFIX), wallet[:], sink.Bytes())
+}
+
+//WalletAddress derive the address of the wallet created by creator with the global wallet index.
+//The address is the double sha256 hash rather than the hash160 of contract addresses,
+//so no contract deployed on any vm can be located at a wallet address
+func WalletAddress(contract, creator common.Address, index uint64) common.Address {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBytes(contract[:])
+	sink.WriteBytes([]byte(WALLET_PREFIX))
+	sink.WriteBytes(creator[:])
+	sink.WriteUint64(index)
+	temp := sha256.Sum256(sink.Bytes())
+	hash := sha256.Sum256(temp[:])
+	var address common.Address
+	copy(address[:], hash[:])
+	return address
+}
+
+func validateOwners(owners []common.Address, threshold uint64) error {
+	if len(owners) == 0 || len(owners) > MAX_OWNERS {
+		return fmt.Errorf("owners count should be between 1 and %d", MAX_OWNERS)
+	}
+	exist := make(map[common.Address]bool)
+	for _, owner := range owners {
+		if exist[owner] {
+			return fmt.Errorf("duplicated owner %s", owner.ToBase58())
+		}
+		exist[owner] = true
+	}
+	if threshold == 0 || threshold > uint64(len(owners)) {
+		return fmt.Errorf("threshold should be between 1 and owners count %d", len(owners))
+	}
+	return nil
+}
+
+func validateTimelock(timelock uint64) error {
+	if timelock > math.MaxUint32 {
+		return fmt.Errorf("timelock %d is too large", timelock)
+	}
+	return nil
+}
+
+func validateCall(call *Call) error {
+	switch call.VmType {
+	case NATIVE_CALL:
+		if !utils.IsNativeContract(call.Contract) {
+			return fmt.Errorf("%s is not a native contract", call.Contract.ToHexString())
+		}
+		if call.Method == "" {
+			return fmt.Errorf("method of native call is empty")
+		}
+	case NEOVM_CALL, WASMVM_CALL, EVM_CALL:
+		if call.Method != "" {
+			return fmt.Errorf("method is only used by native call")
+		}
+	default:
+		return fmt.Errorf("unsupported vm type %d", call.VmType)
+	}
+	if len(call.Args) > MAX_CALL_ARGS_LEN {
+		return fmt.Errorf("call args is too long")
+	}
+	return nil
+}
+
+func getWallet(native *native.NativeService, contract, address common.Address) (*Wallet, error) {
+	data, err := utils.GetStorageVarBytes(native, GenWalletKey(contract, address))
+	if err != nil {
+		return nil, fmt.Errorf("get wallet error:%s", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("wallet %s does not exist", address.ToBase58())
+	}
+	wallet := new(Wallet)
+	if err := wallet.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize wallet error:%s", err)
+	}
+	return wallet, nil
+}
+
+func putWallet(native *native.NativeService, contract, address common.Address, wallet *Wallet) {
+	native.CacheDB.Put(GenWalletKey(contract, address), utils.GenVarBytesStorageItem(common.SerializeToBytes(wallet)).ToArray())
+}
+
+func getProposal(native *native.NativeService, contract, wallet common.Address, id uint64) (*Proposal, error) {
+	data, err := utils.GetStorageVarBytes(native, GenProposalKey(contract, wallet, id))
+	if err != nil {
+		return nil, fmt.Errorf("get proposal error:%s", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("proposal %d of wallet %s does not exist", id, wallet.ToBase58())
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize proposal error:%s", err)
+	}
+	return proposal, nil
+}
+
+func putProposal(native *native.NativeService, contract, wallet common.Address, proposal *Proposal) {
+	native.CacheDB.Put(GenProposalKey(contract, wallet, proposal.Id), utils.GenVarBytesStorageItem(common.SerializeToBytes(proposal)).ToArray())
+}
+
+//getPendingProposal load the wallet and the proposal which can still be approved, executed or cancelled by owner
+func getPendingProposal(native *native.NativeService, contract common.Address, param *ProposalParam) (*Wallet, *Proposal, error) {
+	if err := utils.ValidateOwner(native, param.Owner); err != nil {
+		return nil, nil, fmt.Errorf("checkWitness error:%s", err)
+	}
+	wallet, err := getWallet(native, contract, param.Wallet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !wallet.isOwner(param.Owner) {
+		return nil, nil, fmt.Errorf("%s is not owner of wallet", param.Owner.ToBase58())
+	}
+	proposal, err := getProposal(native, contract, param.Wallet, param.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proposal.Status != PROPOSAL_PENDING {
+		return nil, nil, fmt.Errorf("proposal %d is not pending", proposal.Id)
+	}
+	if proposal.WalletVersion != wallet.Version {
+		return nil, nil, fmt.Errorf("proposal %d is stale, wallet has been updated", proposal.Id)
+	}
+	return wallet, proposal, nil
+}
+
+//addApproval approve the proposal by owner, the timelock starts when approvals reach threshold
+func addApproval(native *native.NativeService, wallet *Wallet, proposal *Proposal, owner common.Address) {
+	proposal.Approvals = append(proposal.Approvals, owner)
+	if uint64(len(proposal.Approvals)) == wallet.Threshold {
+		proposal.ExecutableTime = uint64(native.Time) + wallet.Timelock
+	}
+}
+
+func addNotify(native *native.NativeService, contract common.Address, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          states,
+		})
+}
@@ -63,16 +63,30 @@ func OngInit(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, errors.NewErr("Init ong has been completed!")
 	}
 	addr := common.Address{}
+	remain := constants.ONG_TOTAL_SUPPLY
 	if config.DefConfig.P2PNode.NetworkId == config.NETWORK_ID_SOLO_NET {
 		bookkeepers, _ := config.DefConfig.GetBookkeepers()
 		addr = types.AddressFromPubKey(bookkeepers[0])
+
+		prefundAddrs, prefunded, err := config.DefConfig.GetPrefunded()
+		if err != nil {
+			return utils.BYTE_FALSE, err
+		}
+		for i, acct := range prefunded {
+			if acct.ONG == 0 || prefundAddrs[i] == addr {
+				continue
+			}
+			balance := utils.GenUInt64StorageItem(acct.ONG)
+			native.CacheDB.Put(append(contract[:], prefundAddrs[i][:]...), balance.ToArray())
+			remain -= acct.ONG
+		}
 	} else {
 		addr = utils.OntContractAddress
 	}
 
 	item := utils.GenUInt64StorageItem(constants.ONG_TOTAL_SUPPLY)
 	native.CacheDB.Put(ont.GenTotalSupplyKey(contract), item.ToArray())
-	native.CacheDB.Put(append(contract[:], addr[:]...), item.ToArray())
+	native.CacheDB.Put(append(contract[:], addr[:]...), utils.GenUInt64StorageItem(remain).ToArray())
 	ont.AddNotifications(native, contract, &ont.State{To: utils.OntContractAddress, Value: constants.ONG_TOTAL_SUPPLY})
 	return utils.BYTE_TRUE, nil
 }
@@ -83,4 +83,6 @@ func RegisterIDContract(srvc *native.NativeService) {
 	srvc.Register("getServiceJson", GetServiceJson)
 	srvc.Register("getControllerJson", GetControllerJson)
 	srvc.Register("getDocumentJson", GetDocumentJson)
+	srvc.Register("getIDState", GetIDState)
+	srvc.Register("getPublicKeyByIndex", GetPublicKeyByIndex)
 }
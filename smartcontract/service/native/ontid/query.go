@@ -314,6 +314,78 @@ func GetKeyState(srvc *native.NativeService) ([]byte, error) {
 	}
 }
 
+//the states of ONT ID returned by getIDState
+const (
+	ID_STATE_NOT_EXIST = "not exist"
+	ID_STATE_VALID     = "valid"
+	ID_STATE_REVOKED   = "revoked"
+)
+
+//GetIDState return whether the ID is registered and revoked, which is only available in pre-execution
+func GetIDState(srvc *native.NativeService) ([]byte, error) {
+	log.Debug("GetIDState")
+	if !srvc.PreExec {
+		return nil, fmt.Errorf("get ID state failed: only available in pre-execution")
+	}
+	source := common.NewZeroCopySource(srvc.Input)
+	// arg0: ID
+	arg0, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("get ID state failed: argument 0 error")
+	}
+	encId, err := encodeID(arg0)
+	if err != nil {
+		return nil, fmt.Errorf("encodeID failed: %s", err)
+	}
+	switch checkIDState(srvc, encId) {
+	case flag_valid:
+		return []byte(ID_STATE_VALID), nil
+	case flag_revoke:
+		return []byte(ID_STATE_REVOKED), nil
+	default:
+		return []byte(ID_STATE_NOT_EXIST), nil
+	}
+}
+
+//GetPublicKeyByIndex return the serialized public key by index and whether it is revoked, empty if the ID is not valid
+//or the key is not exist, which is only available in pre-execution
+func GetPublicKeyByIndex(srvc *native.NativeService) ([]byte, error) {
+	log.Debug("GetPublicKeyByIndex")
+	if !srvc.PreExec {
+		return nil, fmt.Errorf("get public key failed: only available in pre-execution")
+	}
+	source := common.NewZeroCopySource(srvc.Input)
+	// arg0: ID
+	arg0, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("get public key failed: argument 0 error")
+	}
+	// arg1: public key index
+	arg1, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return nil, fmt.Errorf("get public key failed: argument 1 error, %s", err)
+	}
+	encId, err := encodeID(arg0)
+	if err != nil {
+		return nil, fmt.Errorf("encodeID failed: %s", err)
+	}
+	if !isValid(srvc, encId) || arg1 == 0 || arg1 > uint64(^uint32(0)) {
+		return nil, nil
+	}
+	publicKeys, err := getAllPk_Version1(srvc, encId, append(encId, FIELD_PK))
+	if err != nil {
+		return nil, fmt.Errorf("get public key failed: %s", err)
+	}
+	if arg1 > uint64(len(publicKeys)) {
+		return nil, nil
+	}
+	pk := publicKeys[arg1-1]
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(pk.key)
+	sink.WriteBool(pk.revoked)
+	return sink.Bytes(), nil
+}
+
 func GetServiceJson(srvc *native.NativeService) ([]byte, error) {
 	log.Debug("GetServiceJson")
 	params := new(SearchServiceParam)
@@ -18,9 +18,14 @@
 package ontid
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
 )
 
 func TestQueryDocument(t *testing.T) {
@@ -30,3 +35,122 @@ func TestQueryDocument(t *testing.T) {
 func CaseQueryDocument(t *testing.T, n *native.NativeService) {
 
 }
+
+func TestGetIDState(t *testing.T) {
+	testcase(t, CaseGetIDState)
+}
+
+func CaseGetIDState(t *testing.T, n *native.NativeService) {
+	id, err := account.GenerateID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := account.NewAccount("")
+	getState := func() string {
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteVarBytes([]byte(id))
+		n.Input = sink.Bytes()
+		state, err := GetIDState(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(state)
+	}
+
+	if state := getState(); state != ID_STATE_NOT_EXIST {
+		t.Fatalf("state of unregistered id is %s", state)
+	}
+	if err := regID(n, id, a); err != nil {
+		t.Fatal(err)
+	}
+	if state := getState(); state != ID_STATE_VALID {
+		t.Fatalf("state of registered id is %s", state)
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(id)
+	utils.EncodeVarUint(sink, 1)
+	n.Input = sink.Bytes()
+	if _, err := revokeID(n); err != nil {
+		t.Fatal(err)
+	}
+	if state := getState(); state != ID_STATE_REVOKED {
+		t.Fatalf("state of revoked id is %s", state)
+	}
+
+	n.PreExec = false
+	if _, err := GetIDState(n); err == nil {
+		t.Error("get id state out of pre-execution")
+	}
+}
+
+func TestGetPublicKeyByIndex(t *testing.T) {
+	testcase(t, CaseGetPublicKeyByIndex)
+}
+
+func CaseGetPublicKeyByIndex(t *testing.T, n *native.NativeService) {
+	id, err := account.GenerateID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a0 := account.NewAccount("")
+	a1 := account.NewAccount("")
+	getKey := func(index uint64) []byte {
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteVarBytes([]byte(id))
+		utils.EncodeVarUint(sink, index)
+		n.Input = sink.Bytes()
+		res, err := GetPublicKeyByIndex(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	keyState := func(a *account.Account, revoked bool) []byte {
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteVarBytes(keypair.SerializePublicKey(a.PubKey()))
+		sink.WriteBool(revoked)
+		return sink.Bytes()
+	}
+
+	if res := getKey(1); len(res) != 0 {
+		t.Fatalf("get key of unregistered id: %x", res)
+	}
+	if err := regID(n, id, a0); err != nil {
+		t.Fatal(err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(id)
+	sink.WriteVarBytes(keypair.SerializePublicKey(a1.PubKey()))
+	sink.WriteVarBytes(keypair.SerializePublicKey(a0.PubKey()))
+	n.Input = sink.Bytes()
+	n.Tx.SignedAddr = []common.Address{a0.Address}
+	if _, err := addKey(n); err != nil {
+		t.Fatal(err)
+	}
+	if res := getKey(2); !bytes.Equal(res, keyState(a1, false)) {
+		t.Fatalf("key 2 is %x", res)
+	}
+
+	sink.Reset()
+	sink.WriteString(id)
+	sink.WriteVarBytes(keypair.SerializePublicKey(a1.PubKey()))
+	sink.WriteVarBytes(keypair.SerializePublicKey(a0.PubKey()))
+	n.Input = sink.Bytes()
+	if _, err := removeKey(n); err != nil {
+		t.Fatal(err)
+	}
+	if res := getKey(1); !bytes.Equal(res, keyState(a0, false)) {
+		t.Fatalf("key 1 is %x", res)
+	}
+	if res := getKey(2); !bytes.Equal(res, keyState(a1, true)) {
+		t.Fatalf("revoked key 2 is %x", res)
+	}
+	if res := getKey(3); len(res) != 0 {
+		t.Fatalf("get key not exist: %x", res)
+	}
+
+	n.PreExec = false
+	if _, err := GetPublicKeyByIndex(n); err == nil {
+		t.Error("get public key out of pre-execution")
+	}
+}
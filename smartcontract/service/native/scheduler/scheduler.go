@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package scheduler implements the scheduler contract for deferred contract calls.
+//A user registers neovm or wasmvm invoke code with a future block height and a gas limit,
+//prepaying gas limit * gas price ONG to the contract. At the start of that block, before any
+//transaction, the ledger executes the due calls in the order they were scheduled as system
+//transactions signed by the owner, charges the gas used and refunds the rest of the prepayment.
+//Calls can be cancelled before their height with a full refund.
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+func InitScheduler() {
+	native.Contracts[utils.SchedulerContractAddress] = RegisterSchedulerContract
+}
+
+func RegisterSchedulerContract(native *native.NativeService) {
+	native.Register(SCHEDULE_NAME, Schedule)
+	native.Register(CANCEL_NAME, Cancel)
+	native.Register(GET_SCHEDULED_CALL_NAME, GetScheduledCall)
+	native.Register(GET_SCHEDULED_CALLS_NAME, GetScheduledCalls)
+}
+
+func Schedule(native *native.NativeService) ([]byte, error) {
+	if err := CheckSchedulerAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param ScheduleParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] Deserialize ScheduleParam error:%s", err)
+	}
+	if err := utils.ValidateOwner(native, param.Owner); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] checkWitness error:%s", err)
+	}
+	if err := validateSchedule(native, &param); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] %s", err)
+	}
+	gasPrice := native.Tx.GasPrice
+	if gasPrice > math.MaxUint64/param.GasLimit {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] gas fee overflow, gas limit %d gas price %d", param.GasLimit, gasPrice)
+	}
+	ids, err := getCallIds(native.CacheDB, contract, param.Height)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] %s", err)
+	}
+	if len(ids.Ids) >= MAX_CALLS_PER_HEIGHT {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] height %d already has %d scheduled calls", param.Height, MAX_CALLS_PER_HEIGHT)
+	}
+	count, err := utils.GetStorageUInt64(native.CacheDB, GenCallCountKey(contract))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] get call count error:%s", err)
+	}
+
+	call := &ScheduledCall{
+		Id:       count,
+		Owner:    param.Owner,
+		Height:   param.Height,
+		TxType:   param.TxType,
+		Code:     param.Code,
+		GasLimit: param.GasLimit,
+		GasPrice: gasPrice,
+	}
+	if err := appCallTransferOng(native, param.Owner, contract, call.GasLimit*call.GasPrice); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] prepay gas error:%s", err)
+	}
+	native.CacheDB.Put(GenCallCountKey(contract), utils.GenUInt64StorageItem(count+1).ToArray())
+	putCall(native.CacheDB, contract, call)
+	ids.Ids = append(ids.Ids, call.Id)
+	putCallIds(native.CacheDB, contract, call.Height, ids)
+	addNotify(native, contract, SCHEDULE_NAME, call.Id, call.Owner.ToBase58(), call.Height, call.GasLimit, call.GasPrice)
+	return common.BigIntToNeoBytes(new(big.Int).SetUint64(call.Id)), nil
+}
+
+func Cancel(native *native.NativeService) ([]byte, error) {
+	if err := CheckSchedulerAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param CancelParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] Deserialize CancelParam error:%s", err)
+	}
+	if err := utils.ValidateOwner(native, param.Owner); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] checkWitness error:%s", err)
+	}
+	call, err := getCall(native.CacheDB, contract, param.Id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s", err)
+	}
+	if call.Owner != param.Owner {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s is not owner of scheduled call %d", param.Owner.ToBase58(), call.Id)
+	}
+	if call.Height <= native.Height {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] scheduled call %d at height %d can not be cancelled at height %d",
+			call.Id, call.Height, native.Height)
+	}
+	ids, err := getCallIds(native.CacheDB, contract, call.Height)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s", err)
+	}
+	remain := make([]uint64, 0, len(ids.Ids))
+	for _, id := range ids.Ids {
+		if id != call.Id {
+			remain = append(remain, id)
+		}
+	}
+	ids.Ids = remain
+	putCallIds(native.CacheDB, contract, call.Height, ids)
+	native.CacheDB.Delete(GenCallKey(contract, call.Id))
+	if err := appCallTransferOng(native, contract, call.Owner, call.GasLimit*call.GasPrice); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] refund gas error:%s", err)
+	}
+	addNotify(native, contract, CANCEL_NAME, call.Id, call.Owner.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+func GetScheduledCall(native *native.NativeService) ([]byte, error) {
+	if err := CheckSchedulerAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCall] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCall] decode id error:%s", err)
+	}
+	call, err := getCall(native.CacheDB, contract, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCall] %s", err)
+	}
+	return common.SerializeToBytes(call), nil
+}
+
+func GetScheduledCalls(native *native.NativeService) ([]byte, error) {
+	if err := CheckSchedulerAvailability(native); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCalls] %s", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	height, err := decodeHeight(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCalls] decode height error:%s", err)
+	}
+	ids, err := getCallIds(native.CacheDB, contract, height)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCalls] %s", err)
+	}
+	return common.SerializeToBytes(ids), nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/testsuite"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	ont.InitOnt()
+	ong.InitOng()
+	InitScheduler()
+}
+
+const testGasPrice = 2500
+
+type testEnv struct {
+	*testsuite.NativeEnv
+}
+
+func newTestEnv() *testEnv {
+	env := &testEnv{testsuite.NewNativeEnv()}
+	env.Height = 10
+	return env
+}
+
+func (this *testEnv) invoke(signer common.Address, handler native.Handler, input []byte) ([]byte, error) {
+	return this.Invoke(utils.SchedulerContractAddress, signer, testGasPrice, handler, input)
+}
+
+func (this *testEnv) schedule(owner common.Address, height uint32, gasLimit uint64) (uint64, error) {
+	param := &ScheduleParam{Owner: owner, Height: height, TxType: types.InvokeNeo, Code: []byte{1}, GasLimit: gasLimit}
+	res, err := this.invoke(owner, Schedule, common.SerializeToBytes(param))
+	if err != nil {
+		return 0, err
+	}
+	return common.BigIntFromNeoBytes(res).Uint64(), nil
+}
+
+func (this *testEnv) ids(t *testing.T, height uint32) []uint64 {
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, uint64(height))
+	res, err := this.invoke(common.ADDRESS_EMPTY, GetScheduledCalls, sink.Bytes())
+	assert.Nil(t, err)
+	ids := new(CallIds)
+	assert.Nil(t, ids.Deserialization(common.NewZeroCopySource(res)))
+	return ids.Ids
+}
+
+func (this *testEnv) ongBalance(address common.Address) uint64 {
+	balance, _ := utils.GetStorageUInt64(this.DB, ont.GenBalanceKey(utils.OngContractAddress, address))
+	return balance
+}
+
+func TestSchedule(t *testing.T) {
+	env := newTestEnv()
+	owner := account.NewAccount("").Address
+	env.DB.Put(ont.GenBalanceKey(utils.OngContractAddress, owner), utils.GenUInt64StorageItem(100000*testGasPrice).ToArray())
+
+	_, err := env.schedule(owner, env.Height, 20000)
+	assert.NotNil(t, err)
+	_, err = env.schedule(owner, env.Height+MAX_SCHEDULE_DELAY+1, 20000)
+	assert.NotNil(t, err)
+	_, err = env.schedule(owner, env.Height+1, 100)
+	assert.NotNil(t, err)
+	_, err = env.schedule(owner, env.Height+1, 200000)
+	assert.NotNil(t, err, "prepayment exceeds balance")
+	param := &ScheduleParam{Owner: owner, Height: env.Height + 1, TxType: types.InvokeNeo, Code: []byte{1}, GasLimit: 20000}
+	_, err = env.invoke(account.NewAccount("").Address, Schedule, common.SerializeToBytes(param))
+	assert.NotNil(t, err)
+	param.TxType = types.Deploy
+	_, err = env.invoke(owner, Schedule, common.SerializeToBytes(param))
+	assert.NotNil(t, err)
+
+	id0, err := env.schedule(owner, env.Height+2, 20000)
+	assert.Nil(t, err)
+	id1, err := env.schedule(owner, env.Height+1, 30000)
+	assert.Nil(t, err)
+	id2, err := env.schedule(owner, env.Height+2, 40000)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{0, 1, 2}, []uint64{id0, id1, id2})
+	assert.Equal(t, uint64(90000*testGasPrice), env.ongBalance(utils.SchedulerContractAddress))
+	assert.Equal(t, uint64(10000*testGasPrice), env.ongBalance(owner))
+
+	assert.Equal(t, []uint64{id0, id2}, env.ids(t, env.Height+2))
+	calls, err := GetDueCalls(env.DB, env.Height+2)
+	assert.Nil(t, err)
+	assert.Equal(t, &ScheduledCall{Id: id2, Owner: owner, Height: env.Height + 2, TxType: types.InvokeNeo, Code: []byte{1},
+		GasLimit: 40000, GasPrice: testGasPrice}, calls[1])
+
+	RemoveDueCalls(env.DB, env.Height+2, calls)
+	assert.Empty(t, env.ids(t, env.Height+2))
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, id0)
+	_, err = env.invoke(owner, GetScheduledCall, sink.Bytes())
+	assert.NotNil(t, err)
+}
+
+func TestCancel(t *testing.T) {
+	env := newTestEnv()
+	owner := account.NewAccount("").Address
+	env.DB.Put(ont.GenBalanceKey(utils.OngContractAddress, owner), utils.GenUInt64StorageItem(100000*testGasPrice).ToArray())
+	id0, err := env.schedule(owner, env.Height+1, 20000)
+	assert.Nil(t, err)
+	id1, err := env.schedule(owner, env.Height+1, 30000)
+	assert.Nil(t, err)
+
+	other := account.NewAccount("").Address
+	_, err = env.invoke(other, Cancel, common.SerializeToBytes(&CancelParam{Owner: other, Id: id0}))
+	assert.NotNil(t, err)
+	_, err = env.invoke(owner, Cancel, common.SerializeToBytes(&CancelParam{Owner: owner, Id: id0}))
+	assert.Nil(t, err)
+	_, err = env.invoke(owner, Cancel, common.SerializeToBytes(&CancelParam{Owner: owner, Id: id0}))
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []uint64{id1}, env.ids(t, env.Height+1))
+	assert.Equal(t, uint64(30000*testGasPrice), env.ongBalance(utils.SchedulerContractAddress))
+	assert.Equal(t, uint64(70000*testGasPrice), env.ongBalance(owner))
+
+	// a call can not be cancelled once it is due
+	env.Height++
+	_, err = env.invoke(owner, Cancel, common.SerializeToBytes(&CancelParam{Owner: owner, Id: id1}))
+	assert.NotNil(t, err)
+	assert.Equal(t, []uint64{id1}, env.ids(t, env.Height))
+	assert.Equal(t, uint64(30000*testGasPrice), env.ongBalance(utils.SchedulerContractAddress))
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+//ScheduledCall is the invoke code registered by owner, executed at the start of block Height
+//as a system transaction signed by owner and paid by the gas prepaid when scheduling
+type ScheduledCall struct {
+	Id       uint64
+	Owner    common.Address
+	Height   uint32
+	TxType   types.TransactionType
+	Code     []byte
+	GasLimit uint64
+	GasPrice uint64
+}
+
+func (this *ScheduledCall) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.Id)
+	utils.EncodeAddress(sink, this.Owner)
+	utils.EncodeVarUint(sink, uint64(this.Height))
+	utils.EncodeVarUint(sink, uint64(this.TxType))
+	utils.EncodeVarBytes(sink, this.Code)
+	utils.EncodeVarUint(sink, this.GasLimit)
+	utils.EncodeVarUint(sink, this.GasPrice)
+}
+
+func (this *ScheduledCall) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization DecodeVarUint Id error:%s", err)
+	}
+	if this.Owner, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization DecodeAddress Owner error:%s", err)
+	}
+	if this.Height, err = decodeHeight(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization decodeHeight error:%s", err)
+	}
+	if this.TxType, err = decodeTxType(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization decodeTxType error:%s", err)
+	}
+	if this.Code, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization DecodeVarBytes Code error:%s", err)
+	}
+	if this.GasLimit, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization DecodeVarUint GasLimit error:%s", err)
+	}
+	if this.GasPrice, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("ScheduledCall.Deserialization DecodeVarUint GasPrice error:%s", err)
+	}
+	return nil
+}
+
+//ScheduleParam register Code to be invoked at Height, the gas price is the one of the scheduling transaction
+type ScheduleParam struct {
+	Owner    common.Address
+	Height   uint32
+	TxType   types.TransactionType
+	Code     []byte
+	GasLimit uint64
+}
+
+func (this *ScheduleParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Owner)
+	utils.EncodeVarUint(sink, uint64(this.Height))
+	utils.EncodeVarUint(sink, uint64(this.TxType))
+	utils.EncodeVarBytes(sink, this.Code)
+	utils.EncodeVarUint(sink, this.GasLimit)
+}
+
+func (this *ScheduleParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Owner, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("ScheduleParam.Deserialization DecodeAddress Owner error:%s", err)
+	}
+	if this.Height, err = decodeHeight(source); err != nil {
+		return fmt.Errorf("ScheduleParam.Deserialization decodeHeight error:%s", err)
+	}
+	if this.TxType, err = decodeTxType(source); err != nil {
+		return fmt.Errorf("ScheduleParam.Deserialization decodeTxType error:%s", err)
+	}
+	if this.Code, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("ScheduleParam.Deserialization DecodeVarBytes Code error:%s", err)
+	}
+	if this.GasLimit, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("ScheduleParam.Deserialization DecodeVarUint GasLimit error:%s", err)
+	}
+	return nil
+}
+
+type CancelParam struct {
+	Owner common.Address
+	Id    uint64
+}
+
+func (this *CancelParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Owner)
+	utils.EncodeVarUint(sink, this.Id)
+}
+
+func (this *CancelParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Owner, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("CancelParam.Deserialization DecodeAddress Owner error:%s", err)
+	}
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("CancelParam.Deserialization DecodeVarUint Id error:%s", err)
+	}
+	return nil
+}
+
+//CallIds is the ids of calls scheduled at a height, in the order of scheduling
+type CallIds struct {
+	Ids []uint64
+}
+
+func (this *CallIds) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.Ids)))
+	for _, id := range this.Ids {
+		utils.EncodeVarUint(sink, id)
+	}
+}
+
+func (this *CallIds) Deserialization(source *common.ZeroCopySource) error {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("CallIds.Deserialization DecodeVarUint count error:%s", err)
+	}
+	if n > MAX_CALLS_PER_HEIGHT {
+		return fmt.Errorf("CallIds.Deserialization too many ids:%d", n)
+	}
+	ids := make([]uint64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		id, err := utils.DecodeVarUint(source)
+		if err != nil {
+			return fmt.Errorf("CallIds.Deserialization DecodeVarUint id error:%s", err)
+		}
+		ids = append(ids, id)
+	}
+	this.Ids = ids
+	return nil
+}
+
+func decodeHeight(source *common.ZeroCopySource) (uint32, error) {
+	height, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return 0, err
+	}
+	if height > math.MaxUint32 {
+		return 0, fmt.Errorf("invalid height:%d", height)
+	}
+	return uint32(height), nil
+}
+
+func decodeTxType(source *common.ZeroCopySource) (types.TransactionType, error) {
+	txType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return 0, err
+	}
+	if txType > math.MaxUint8 {
+		return 0, fmt.Errorf("invalid tx type:%d", txType)
+	}
+	return types.TransactionType(txType), nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledCall_Serialize(t *testing.T) {
+	call := ScheduledCall{
+		Id:       3,
+		Owner:    utils.OntContractAddress,
+		Height:   100,
+		TxType:   types.InvokeWasm,
+		Code:     []byte{1, 2, 3},
+		GasLimit: 20000,
+		GasPrice: 2500,
+	}
+	sink := common.NewZeroCopySink(nil)
+	call.Serialization(sink)
+
+	call2 := ScheduledCall{}
+	assert.Nil(t, call2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, call, call2)
+}
+
+func TestCallIds_Serialize(t *testing.T) {
+	ids := CallIds{Ids: []uint64{0, 5, 300}}
+	ids2 := CallIds{}
+	assert.Nil(t, ids2.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(&ids))))
+	assert.Equal(t, ids, ids2)
+
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, MAX_CALLS_PER_HEIGHT+1)
+	assert.NotNil(t, ids2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+)
+
+const (
+	SCHEDULE_NAME            = "schedule"
+	CANCEL_NAME              = "cancel"
+	EXECUTE_NAME             = "execute"
+	GET_SCHEDULED_CALL_NAME  = "getScheduledCall"
+	GET_SCHEDULED_CALLS_NAME = "getScheduledCalls"
+)
+
+const (
+	CALL_COUNT  = "CallCount"
+	CALL_PREFIX = "Call"
+	IDS_PREFIX  = "Ids"
+
+	//max blocks between scheduling and execution
+	MAX_SCHEDULE_DELAY = 1000000
+	//max calls executed at the start of one block, together with MAX_CALL_GAS_LIMIT bounds the block start work
+	MAX_CALLS_PER_HEIGHT = 64
+	MAX_CALL_GAS_LIMIT   = 20000000
+	MAX_CODE_LEN         = 1024 * 1024
+)
+
+func CheckSchedulerAvailability(native *native.NativeService) error {
+	if native.Height < config.GetSchedulerHeight() {
+		return fmt.Errorf("scheduler contract is not available")
+	}
+	return nil
+}
+
+func GenCallCountKey(contract common.Address) []byte {
+	return utils.ConcatKey(contract, []byte(CALL_COUNT))
+}
+
+func GenCallKey(contract common.Address, id uint64) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(id)
+	return utils.ConcatKey(contract, []byte(CALL_PREFIX), sink.Bytes())
+}
+
+func GenCallIdsKey(contract common.Address, height uint32) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(height)
+	return utils.ConcatKey(contract, []byte(IDS_PREFIX), sink.Bytes())
+}
+
+//CallHash is the hash under which the execute event of a scheduled call is recorded,
+//it never collides with transaction hashes since no transaction is serialized as the preimage
+func CallHash(id uint64) common.Uint256 {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBytes(utils.SchedulerContractAddress[:])
+	sink.WriteBytes([]byte(CALL_PREFIX))
+	sink.WriteUint64(id)
+	temp := sha256.Sum256(sink.Bytes())
+	return common.Uint256(sha256.Sum256(temp[:]))
+}
+
+func validateSchedule(native *native.NativeService, param *ScheduleParam) error {
+	if param.Height <= native.Height {
+		return fmt.Errorf("height %d is not after current height %d", param.Height, native.Height)
+	}
+	if param.Height-native.Height > MAX_SCHEDULE_DELAY {
+		return fmt.Errorf("height %d is more than %d blocks later", param.Height, MAX_SCHEDULE_DELAY)
+	}
+	if param.TxType != types.InvokeNeo && param.TxType != types.InvokeWasm {
+		return fmt.Errorf("unsupported tx type %d", param.TxType)
+	}
+	if len(param.Code) == 0 || len(param.Code) > MAX_CODE_LEN {
+		return fmt.Errorf("code length should be between 1 and %d", MAX_CODE_LEN)
+	}
+	if param.GasLimit < neovm.MIN_TRANSACTION_GAS || param.GasLimit > MAX_CALL_GAS_LIMIT {
+		return fmt.Errorf("gas limit should be between %d and %d", neovm.MIN_TRANSACTION_GAS, MAX_CALL_GAS_LIMIT)
+	}
+	return nil
+}
+
+func getStorageVarBytes(cache *storage.CacheDB, key []byte) ([]byte, error) {
+	item, err := utils.GetStorageItem(cache, key)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return utils.DecodeVarBytes(common.NewZeroCopySource(item.Value))
+}
+
+func getCall(cache *storage.CacheDB, contract common.Address, id uint64) (*ScheduledCall, error) {
+	data, err := getStorageVarBytes(cache, GenCallKey(contract, id))
+	if err != nil {
+		return nil, fmt.Errorf("get scheduled call error:%s", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("scheduled call %d does not exist", id)
+	}
+	call := new(ScheduledCall)
+	if err := call.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize scheduled call error:%s", err)
+	}
+	return call, nil
+}
+
+func putCall(cache *storage.CacheDB, contract common.Address, call *ScheduledCall) {
+	cache.Put(GenCallKey(contract, call.Id), utils.GenVarBytesStorageItem(common.SerializeToBytes(call)).ToArray())
+}
+
+func getCallIds(cache *storage.CacheDB, contract common.Address, height uint32) (*CallIds, error) {
+	data, err := getStorageVarBytes(cache, GenCallIdsKey(contract, height))
+	if err != nil {
+		return nil, fmt.Errorf("get call ids error:%s", err)
+	}
+	ids := new(CallIds)
+	if len(data) == 0 {
+		return ids, nil
+	}
+	if err := ids.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize call ids error:%s", err)
+	}
+	return ids, nil
+}
+
+func putCallIds(cache *storage.CacheDB, contract common.Address, height uint32, ids *CallIds) {
+	key := GenCallIdsKey(contract, height)
+	if len(ids.Ids) == 0 {
+		cache.Delete(key)
+		return
+	}
+	cache.Put(key, utils.GenVarBytesStorageItem(common.SerializeToBytes(ids)).ToArray())
+}
+
+//GetDueCalls return the calls scheduled at height, in the order of scheduling
+func GetDueCalls(cache *storage.CacheDB, height uint32) ([]*ScheduledCall, error) {
+	ids, err := getCallIds(cache, utils.SchedulerContractAddress, height)
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]*ScheduledCall, 0, len(ids.Ids))
+	for _, id := range ids.Ids {
+		call, err := getCall(cache, utils.SchedulerContractAddress, id)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+//RemoveDueCalls delete the calls scheduled at height before they are executed, their prepayments stay in the
+//contract until each call is settled
+func RemoveDueCalls(cache *storage.CacheDB, height uint32, calls []*ScheduledCall) {
+	for _, call := range calls {
+		cache.Delete(GenCallKey(utils.SchedulerContractAddress, call.Id))
+	}
+	cache.Delete(GenCallIdsKey(utils.SchedulerContractAddress, height))
+}
+
+func appCallTransferOng(native *native.NativeService, from, to common.Address, amount uint64) error {
+	transfers := &ont.Transfers{States: []ont.State{{From: from, To: to, Value: amount}}}
+	if _, err := native.NativeCall(utils.OngContractAddress, ont.TRANSFER_NAME, common.SerializeToBytes(transfers)); err != nil {
+		return fmt.Errorf("appCallTransferOng error:%s", err)
+	}
+	return nil
+}
+
+func addNotify(native *native.NativeService, contract common.Address, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          states,
+		})
+}
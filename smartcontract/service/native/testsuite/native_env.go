@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package testsuite
+
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/storage"
+)
+
+//NativeEnv is the storage and block that the handlers of a native contract run against in tests
+type NativeEnv struct {
+	DB     *storage.CacheDB
+	Height uint32
+	Time   uint32
+}
+
+func NewNativeEnv() *NativeEnv {
+	return &NativeEnv{DB: storage.NewCacheDB(NewOverlayDB())}
+}
+
+//Invoke runs handler as a method of contract, called with input by a transaction signed by signer
+func (this *NativeEnv) Invoke(contract, signer common.Address, gasPrice uint64, handler native.Handler,
+	input []byte) ([]byte, error) {
+	tx := &types.Transaction{GasPrice: gasPrice, SignedAddr: []common.Address{signer}}
+	sc := &smartcontract.SmartContract{
+		Config: &smartcontract.Config{Tx: tx, Height: this.Height, Time: this.Time},
+	}
+	sc.PushContext(&context.Context{ContractAddress: contract})
+	ns := &native.NativeService{
+		CacheDB:    this.DB,
+		ContextRef: sc,
+		Tx:         tx,
+		Height:     this.Height,
+		Time:       this.Time,
+		Input:      input,
+		ServiceMap: make(map[string]native.Handler),
+	}
+	return handler(ns)
+}
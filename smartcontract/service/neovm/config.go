@@ -113,6 +113,7 @@ var (
 
 	NATIVE_INVOKE_NAME = "Ontology.Native.Invoke"
 	WASM_INVOKE_NAME   = "Ontology.Wasm.InvokeWasm"
+	EVM_INVOKE_NAME    = "Ontology.Evm.InvokeEvm"
 
 	GETSCRIPTCONTAINER_NAME     = "System.ExecutionEngine.GetScriptContainer"
 	GETEXECUTINGSCRIPTHASH_NAME = "System.ExecutionEngine.GetExecutingScriptHash"
@@ -205,6 +206,7 @@ func initGAS_TABLE() *sync.Map {
 
 	m.Store(RUNTIME_VERIFYMUTISIG_NAME, RUNTIME_VERIFYMUTISIG_GAS)
 	m.Store(WASM_INVOKE_NAME, APPCALL_GAS)
+	m.Store(EVM_INVOKE_NAME, APPCALL_GAS)
 
 	m.Store(config.WASM_GAS_FACTOR, config.DEFAULT_WASM_GAS_FACTOR)
 
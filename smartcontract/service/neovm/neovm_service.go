@@ -93,6 +93,7 @@ var (
 		RUNTIME_VERIFYMUTISIG_NAME:      RuntimeVerifyMutiSig,
 		NATIVE_INVOKE_NAME:              NativeInvoke,
 		WASM_INVOKE_NAME:                WASMInvoke,
+		EVM_INVOKE_NAME:                 EVMInvoke,
 		STORAGE_GET_NAME:                StorageGet,
 		STORAGE_PUT_NAME:                StoragePut,
 		STORAGE_DELETE_NAME:             StorageDelete,
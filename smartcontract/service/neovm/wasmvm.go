@@ -84,3 +84,33 @@ func WASMInvoke(service *NeoVmService, engine *vm.Executor) error {
 
 	return engine.EvalStack.PushBytes(tmpRes.([]byte))
 }
+
+// neovm contract call evm contract
+func EVMInvoke(service *NeoVmService, engine *vm.Executor) error {
+	address, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+
+	contractAddress, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("invoke evm contract:%s, address invalid", address)
+	}
+
+	parambytes, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	calldata, err := crossvm_codec.DeserializeEVMCallParam(parambytes)
+	if err != nil {
+		return err
+	}
+
+	self := service.ContextRef.CurrentContext().ContractAddress
+	res, err := service.ContextRef.CallEVMContract(self, contractAddress, calldata)
+	if err != nil {
+		return err
+	}
+
+	return engine.EvalStack.PushBytes(res)
+}
@@ -24,7 +24,9 @@ import (
 	"io"
 	"reflect"
 
+	ethcomm "github.com/ethereum/go-ethereum/common"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/types"
@@ -46,6 +48,7 @@ const (
 	NATIVE_CONTRACT ContractType = iota
 	NEOVM_CONTRACT
 	WASMVM_CONTRACT
+	EVM_CONTRACT
 	UNKOWN_CONTRACT
 )
 
@@ -625,6 +628,15 @@ func getContractTypeInner(service *WasmVmService, addr common.Address) (Contract
 		return UNKOWN_CONTRACT, err
 	}
 	if dep == nil {
+		if service.Height >= config.GetCrossVMCallHeight() {
+			acct, err := service.CacheDB.GetEthAccount(ethcomm.Address(addr))
+			if err != nil {
+				return UNKOWN_CONTRACT, err
+			}
+			if acct.CodeHash != (ethcomm.Hash{}) {
+				return EVM_CONTRACT, nil
+			}
+		}
 		return UNKOWN_CONTRACT, fmt.Errorf("contract %s is not exist", addr.ToHexString())
 	}
 	if dep.VmType() == payload.WASMVM_TYPE {
@@ -777,6 +789,20 @@ func callContractInner(service *WasmVmService, contractAddress common.Address, i
 			result = source.Bytes()
 		}
 
+	case EVM_CONTRACT:
+		calldata, err := crossvm_codec.DeserializeEVMCallParam(inputs)
+		if err != nil {
+			return []byte{}, err
+		}
+
+		self := service.ContextRef.CurrentContext().ContractAddress
+		tmpRes, err := service.ContextRef.CallEVMContract(self, contractAddress, calldata)
+		if err != nil {
+			return []byte{}, err
+		}
+
+		result = crossvm_codec.SerializeEVMCallResult(tmpRes)
+
 	default:
 		return []byte{}, errors.NewErr("Not a supported contract type")
 	}
@@ -68,6 +68,16 @@ func makePrefixedKey(dst []byte, prefix byte, key []byte) []byte {
 	return dst
 }
 
+// Snapshot return a copy of current transaction cache, which can be restored by RevertToSnapshot
+func (self *CacheDB) Snapshot() *overlaydb.MemDB {
+	return self.memdb.DeepClone()
+}
+
+// RevertToSnapshot restore the transaction cache to snapshot
+func (self *CacheDB) RevertToSnapshot(snapshot *overlaydb.MemDB) {
+	self.memdb = snapshot
+}
+
 // Commit current transaction cache to block cache
 func (self *CacheDB) Commit() {
 	self.memdb.ForEach(func(key, val []byte) {
@@ -29,6 +29,7 @@ import (
 	common2 "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/store/overlaydb"
 	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
 )
 
 type OngBalanceHandle interface {
@@ -42,6 +43,8 @@ type StateDB struct {
 	cacheDB          *CacheDB
 	Suicided         map[common.Address]bool
 	logs             []*types.StorageLog
+	notifies         []*event.NotifyEventInfo // notifies of contracts called through cross vm call
+	crossHashes      []comm.Uint256           // cross chain state hashes of contracts called through cross vm call
 	thash, bhash     common.Hash
 	txIndex          int
 	refund           uint64
@@ -80,6 +83,26 @@ func (self *StateDB) GetLogs() []*types.StorageLog {
 	return self.logs
 }
 
+// AddNotifications records notifies raised by other vm types during a cross vm call,
+// they are discarded together with logs when the call is reverted.
+func (self *StateDB) AddNotifications(notifies []*event.NotifyEventInfo) {
+	self.notifies = append(self.notifies, notifies...)
+}
+
+func (self *StateDB) GetNotifications() []*event.NotifyEventInfo {
+	return self.notifies
+}
+
+// AddCrossStateHashes records cross chain state hashes pushed by other vm types during a cross vm call,
+// they are discarded together with notifies when the call is reverted.
+func (self *StateDB) AddCrossStateHashes(hashes []comm.Uint256) {
+	self.crossHashes = append(self.crossHashes, hashes...)
+}
+
+func (self *StateDB) GetCrossStateHashes() []comm.Uint256 {
+	return self.crossHashes
+}
+
 func (self *StateDB) Commit() error {
 	err := self.CommitToCacheDB()
 	if err != nil {
@@ -105,10 +128,12 @@ func (self *StateDB) CommitToCacheDB() error {
 }
 
 type snapshot struct {
-	changes  *overlaydb.MemDB
-	suicided map[common.Address]bool
-	logsSize int
-	refund   uint64
+	changes       *overlaydb.MemDB
+	suicided      map[common.Address]bool
+	logsSize      int
+	notifySize    int
+	crossHashSize int
+	refund        uint64
 }
 
 func (self *StateDB) AddRefund(gas uint64) {
@@ -340,10 +365,12 @@ func (self *StateDB) Snapshot() int {
 	}
 
 	sn := &snapshot{
-		changes:  changes,
-		suicided: suicided,
-		logsSize: len(self.logs),
-		refund:   self.refund,
+		changes:       changes,
+		suicided:      suicided,
+		logsSize:      len(self.logs),
+		notifySize:    len(self.notifies),
+		crossHashSize: len(self.crossHashes),
+		refund:        self.refund,
 	}
 
 	self.snapshots = append(self.snapshots, sn)
@@ -363,6 +390,8 @@ func (self *StateDB) RevertToSnapshot(idx int) {
 	self.Suicided = sn.suicided
 	self.refund = sn.refund
 	self.logs = self.logs[:sn.logsSize]
+	self.notifies = self.notifies[:sn.notifySize]
+	self.crossHashes = self.crossHashes[:sn.crossHashSize]
 }
 
 func (self *StateDB) SubBalance(addr common.Address, val *big.Int) {
@@ -50,3 +50,33 @@ func TestDeserializeNotify(t *testing.T) {
 
 	assert.Equal(t, DeserializeNotify(EncodeNotify(t, value)), interface{}(expected))
 }
+
+func TestEVMCallParam(t *testing.T) {
+	calldata := []byte{0xa9, 0x05, 0x9c, 0xbb, 0x01}
+	param := SerializeEVMCallParam(calldata)
+	decoded, err := DeserializeEVMCallParam(param)
+	assert.Nil(t, err)
+	assert.Equal(t, calldata, decoded)
+
+	list, err := EncodeValue([]interface{}{"transfer"})
+	assert.Nil(t, err)
+	_, err = DeserializeEVMCallParam(append([]byte{VERSION}, list...))
+	assert.Equal(t, ERROR_PARAM_NOT_SUPPORTED_TYPE, err)
+}
+
+func TestDeserializeCrossVMCall(t *testing.T) {
+	addr := common.AddressFromVmCode([]byte("123"))
+	list, err := EncodeValue([]interface{}{"transfer", big.NewInt(100)})
+	assert.Nil(t, err)
+	param := append([]byte{VERSION}, list...)
+
+	target, decoded, err := DeserializeCrossVMCall(append(addr[:], param...))
+	assert.Nil(t, err)
+	assert.Equal(t, addr, target)
+	assert.Equal(t, param, decoded)
+
+	_, _, err = DeserializeCrossVMCall(addr[:10])
+	assert.Equal(t, ERROR_PARAM_FORMAT, err)
+	_, _, err = DeserializeCrossVMCall(append(addr[:], 0x01))
+	assert.Equal(t, ERROR_PARAM_FORMAT, err)
+}
@@ -34,3 +34,43 @@ func DeserializeCallParam(input []byte) (interface{}, error) {
 	source := common.NewZeroCopySource(input[1:])
 	return DecodeValue(source)
 }
+
+// evm contracts are called with raw abi encoded call data wrapped as a byte array param
+// version(1byte) + ByteArrayType(1byte) + len(4bytes) + calldata
+func DeserializeEVMCallParam(input []byte) ([]byte, error) {
+	val, err := DeserializeCallParam(input)
+	if err != nil {
+		return nil, err
+	}
+	calldata, ok := val.([]byte)
+	if !ok {
+		return nil, ERROR_PARAM_NOT_SUPPORTED_TYPE
+	}
+	return calldata, nil
+}
+
+func SerializeEVMCallParam(calldata []byte) []byte {
+	sink := common.NewZeroCopySink([]byte{VERSION})
+	EncodeBytes(sink, calldata)
+	return sink.Bytes()
+}
+
+// evm return data is wrapped the same way as the call data
+func SerializeEVMCallResult(ret []byte) []byte {
+	return SerializeEVMCallParam(ret)
+}
+
+// input byte array of a call made by evm contract should be the following format
+// target address(20bytes) + version(1byte) + type(1byte) + data...
+func DeserializeCrossVMCall(input []byte) (common.Address, []byte, error) {
+	source := common.NewZeroCopySource(input)
+	addr, eof := source.NextAddress()
+	if eof {
+		return common.Address{}, nil, ERROR_PARAM_FORMAT
+	}
+	param, _ := source.NextBytes(source.Len())
+	if !bytes.HasPrefix(param, []byte{VERSION}) {
+		return common.Address{}, nil, ERROR_PARAM_FORMAT
+	}
+	return addr, param, nil
+}
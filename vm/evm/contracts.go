@@ -44,6 +44,9 @@ type PrecompiledContract interface {
 	Run(input []byte) ([]byte, error) // Run runs the precompiled contract
 }
 
+// CrossVMCallAddress is the reserved address evm contracts call to reach neovm and wasm contracts.
+var CrossVMCallAddress = common.BytesToAddress([]byte{0x0c, 0x20})
+
 // PrecompiledContractsHomestead contains the default set of pre-compiled Ethereum
 // contracts used in the Frontier and Homestead releases.
 var PrecompiledContractsHomestead = map[common.Address]PrecompiledContract{
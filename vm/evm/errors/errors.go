@@ -37,4 +37,6 @@ var (
 	ErrGasUintOverflow          = errors.New("gas uint64 overflow")
 	ErrInvalidRetsub            = errors.New("invalid retsub")
 	ErrReturnStackExceeded      = errors.New("return stack limit reached")
+	ErrCrossVMValueTransfer     = errors.New("cross vm call can not transfer value")
+	ErrCrossVMCallRestricted    = errors.New("cross vm call only allowed by CALL")
 )
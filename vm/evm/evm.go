@@ -75,6 +75,11 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	return p, ok
 }
 
+// isCrossVMCall reports whether a call to addr should be dispatched to another vm type.
+func (evm *EVM) isCrossVMCall(addr common.Address) bool {
+	return evm.vmConfig.CrossVM != nil && addr == CrossVMCallAddress
+}
+
 // BlockContext provides the EVM with auxiliary information. Once provided
 // it shouldn't be modified.
 type BlockContext struct {
@@ -196,6 +201,17 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		return nil, gas, errors.ErrInsufficientBalance
 	}
 	snapshot := evm.StateDB.Snapshot()
+	if evm.isCrossVMCall(addr) {
+		if value.Sign() != 0 {
+			return nil, gas, errors.ErrCrossVMValueTransfer
+		}
+		ret, gas, err = evm.vmConfig.CrossVM.CallContract(caller.Address(), input, gas)
+		if err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+			gas = 0
+		}
+		return ret, gas, err
+	}
 	p, isPrecompile := evm.precompile(addr)
 
 	if !evm.StateDB.Exist(addr) {
@@ -264,6 +280,9 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, errors.ErrDepth
 	}
+	if evm.isCrossVMCall(addr) {
+		return nil, 0, errors.ErrCrossVMCallRestricted
+	}
 	// Fail if we're trying to transfer more than the available balance
 	// Note although it's noop to transfer X ether to caller itself. But
 	// if caller doesn't have enough balance, it would be an error to allow
@@ -307,6 +326,9 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, errors.ErrDepth
 	}
+	if evm.isCrossVMCall(addr) {
+		return nil, 0, errors.ErrCrossVMCallRestricted
+	}
 	var snapshot = evm.StateDB.Snapshot()
 
 	// It is allowed to call precompiles, even via delegatecall
@@ -341,6 +363,9 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, errors.ErrDepth
 	}
+	if evm.isCrossVMCall(addr) {
+		return nil, 0, errors.ErrCrossVMCallRestricted
+	}
 	// We take a snapshot here. This is a bit counter-intuitive, and could probably be skipped.
 	// However, even a staticcall is considered a 'touch'. On mainnet, static calls were introduced
 	// after all empty accounts were deleted, so this is not required. However, if we omit this,
@@ -79,3 +79,11 @@ type CallContext interface {
 	// Create a new contract
 	Create(env *EVM, me ContractRef, data []byte, gas, value *big.Int) ([]byte, common.Address, error)
 }
+
+// CrossVMCaller dispatches calls made to CrossVMCallAddress into the other vm types of the chain.
+// The input is the 20-byte target contract address followed by crossvm_codec encoded call params.
+type CrossVMCaller interface {
+	// CallContract runs the call on behalf of caller with the given gas and returns the encoded
+	// result and the gas left over.
+	CallContract(caller common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error)
+}
@@ -37,6 +37,8 @@ type Config struct {
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
 	ExtraEips []int // Additional EIPS that are to be enabled
+
+	CrossVM CrossVMCaller // Dispatches calls to CrossVMCallAddress into other vm types, disabled if nil
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the